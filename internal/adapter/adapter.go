@@ -1,6 +1,23 @@
 package adapter
 
-import "io"
+import (
+	"bufio"
+	"io"
+)
+
+// MaxLineSize is the longest line of agent output an adapter will read, in
+// bytes; gumloop run sets it from max_line_size. A single stream-json event
+// (e.g. a tool_use carrying a whole file) can be far larger than
+// bufio.Scanner's 64KB default.
+var MaxLineSize = 16 * 1024 * 1024
+
+// newScanner returns a line scanner over reader that accepts lines up to
+// MaxLineSize
+func newScanner(reader io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, MaxLineSize)
+	return scanner
+}
 
 // Adapter processes agent output and emits normalized events.
 //
@@ -1,7 +1,6 @@
 package adapter
 
 import (
-	"bufio"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -57,7 +56,7 @@ type ClaudeDelta struct {
 //
 // Malformed JSON lines are logged as warnings and skipped.
 func (a *ClaudeAdapter) Process(reader io.Reader, events chan<- Event) error {
-	scanner := bufio.NewScanner(reader)
+	scanner := newScanner(reader)
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -321,3 +321,40 @@ func TestClaudeAdapter_Process_MultipleContentBlocks(t *testing.T) {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
+
+func TestClaudeAdapter_Process_LargeEvent(t *testing.T) {
+	adapter := &ClaudeAdapter{}
+	text := strings.Repeat("x", 200*1024)
+	input := `{"type":"assistant","message":{"content":[{"type":"text","text":"` + text + `"}]}}` + "\n" +
+		`{"type":"tool_use","name":"Read"}`
+
+	events := make(chan Event, 10)
+	if err := adapter.Process(strings.NewReader(input), events); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	close(events)
+
+	msg, ok := (<-events).(AssistantMessage)
+	if !ok || msg.Text != text {
+		t.Fatalf("expected the %d-byte assistant message, got %d bytes", len(text), len(msg.Text))
+	}
+	// The rest of the stream is still read
+	if tool, ok := (<-events).(ToolUse); !ok || tool.Name != "Read" {
+		t.Errorf("expected ToolUse Read after the large event, got %+v", tool)
+	}
+}
+
+func TestClaudeAdapter_Process_LineTooLong(t *testing.T) {
+	saved := MaxLineSize
+	MaxLineSize = 1024
+	defer func() { MaxLineSize = saved }()
+
+	adapter := &ClaudeAdapter{}
+	input := `{"type":"assistant","message":{"content":[{"type":"text","text":"` + strings.Repeat("x", 2048) + `"}]}}`
+
+	events := make(chan Event, 10)
+	err := adapter.Process(strings.NewReader(input), events)
+	if err == nil || !strings.Contains(err.Error(), "token too long") {
+		t.Errorf("expected a token too long error, got %v", err)
+	}
+}
@@ -1,7 +1,6 @@
 package adapter
 
 import (
-	"bufio"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -44,7 +43,7 @@ type CodexEvent struct {
 // If JSON parsing fails for a line, it treats the line as plain text and
 // emits it as an AssistantMessage to ensure output is never lost.
 func (a *CodexAdapter) Process(reader io.Reader, events chan<- Event) error {
-	scanner := bufio.NewScanner(reader)
+	scanner := newScanner(reader)
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -461,3 +461,20 @@ func TestCodexAdapter_Process_EmptyInput(t *testing.T) {
 		// OK
 	}
 }
+
+func TestCodexAdapter_Process_LargeEvent(t *testing.T) {
+	adapter := &CodexAdapter{}
+	content := strings.Repeat("y", 100*1024)
+	input := `{"type":"message","content":"` + content + `"}`
+
+	events := make(chan Event, 10)
+	if err := adapter.Process(strings.NewReader(input), events); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	close(events)
+
+	msg, ok := (<-events).(AssistantMessage)
+	if !ok || msg.Text != content {
+		t.Errorf("expected the %d-byte message, got %d bytes", len(content), len(msg.Text))
+	}
+}
@@ -1,9 +1,6 @@
 package adapter
 
-import (
-	"bufio"
-	"io"
-)
+import "io"
 
 // PassThroughAdapter forwards lines as AssistantMessage events.
 // Used for agents that output plain text: Gemini, OpenCode, Cursor, Ollama.
@@ -17,7 +14,7 @@ func NewPassThroughAdapter() *PassThroughAdapter {
 // Process reads lines from the reader and emits them as AssistantMessage events.
 // This adapter does not parse structured output - it simply forwards all text.
 func (a *PassThroughAdapter) Process(reader io.Reader, events chan<- Event) error {
-	scanner := bufio.NewScanner(reader)
+	scanner := newScanner(reader)
 
 	// Read line by line
 	for scanner.Scan() {
@@ -0,0 +1,90 @@
+package adapter
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ToolPattern maps lines matching a regular expression to a tool name.
+// The first capture group, if any, becomes the tool input.
+type ToolPattern struct {
+	Name   string
+	Regexp *regexp.Regexp
+}
+
+// DefaultToolPatterns recognizes the "Running/Editing/Reading X" lines
+// that plain-text agents commonly print.
+var DefaultToolPatterns = []ToolPattern{
+	{Name: "Bash", Regexp: regexp.MustCompile(`^\s*Running:?\s+(.+)$`)},
+	{Name: "Edit", Regexp: regexp.MustCompile(`^\s*Editing:?\s+(.+)$`)},
+	{Name: "Read", Regexp: regexp.MustCompile(`^\s*Reading:?\s+(.+)$`)},
+}
+
+// ParseToolPatterns parses "Name=regex" specs into tool patterns.
+func ParseToolPatterns(specs []string) ([]ToolPattern, error) {
+	var patterns []ToolPattern
+	for _, spec := range specs {
+		name, expr, ok := strings.Cut(spec, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || expr == "" {
+			return nil, fmt.Errorf("invalid tool pattern %q (expected Name=regex)", spec)
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tool pattern %q: %w", spec, err)
+		}
+		patterns = append(patterns, ToolPattern{Name: name, Regexp: re})
+	}
+	return patterns, nil
+}
+
+// PlainTextAdapter forwards lines as AssistantMessage events, like
+// PassThroughAdapter, and also emits a ToolUse event before any line that
+// matches one of its patterns.
+type PlainTextAdapter struct {
+	Patterns []ToolPattern
+}
+
+// NewPlainTextAdapter creates a plain-text adapter.
+// A nil patterns slice uses DefaultToolPatterns.
+func NewPlainTextAdapter(patterns []ToolPattern) *PlainTextAdapter {
+	if patterns == nil {
+		patterns = DefaultToolPatterns
+	}
+	return &PlainTextAdapter{Patterns: patterns}
+}
+
+// Process reads lines from the reader and emits them as AssistantMessage
+// events, preceded by a ToolUse event for lines that look like tool usage.
+func (a *PlainTextAdapter) Process(reader io.Reader, events chan<- Event) error {
+	scanner := newScanner(reader)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if tool, ok := a.detectTool(line); ok {
+			events <- tool
+		}
+		events <- AssistantMessage{Text: line}
+	}
+
+	return scanner.Err()
+}
+
+// detectTool returns the ToolUse for the first pattern matching line
+func (a *PlainTextAdapter) detectTool(line string) (ToolUse, bool) {
+	for _, p := range a.Patterns {
+		match := p.Regexp.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		tool := ToolUse{Name: p.Name}
+		if len(match) > 1 {
+			tool.Input = strings.TrimSpace(match[1])
+		}
+		return tool, true
+	}
+	return ToolUse{}, false
+}
@@ -0,0 +1,87 @@
+package adapter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collectEvents runs the adapter over input and returns every event it emitted
+func collectEvents(t *testing.T, a Adapter, input string) []Event {
+	t.Helper()
+	events := make(chan Event, 100)
+	require.NoError(t, a.Process(strings.NewReader(input), events))
+	close(events)
+
+	var received []Event
+	for event := range events {
+		received = append(received, event)
+	}
+	return received
+}
+
+func TestPlainTextAdapter_DetectsTools(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		tool ToolUse
+	}{
+		{"running with colon", "Running: go test ./...", ToolUse{Name: "Bash", Input: "go test ./..."}},
+		{"running without colon", "Running make build", ToolUse{Name: "Bash", Input: "make build"}},
+		{"editing", "Editing internal/git/git.go", ToolUse{Name: "Edit", Input: "internal/git/git.go"}},
+		{"reading indented", "  Reading: README.md", ToolUse{Name: "Read", Input: "README.md"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received := collectEvents(t, NewPlainTextAdapter(nil), tt.line+"\n")
+			assert.Equal(t, []Event{tt.tool, AssistantMessage{Text: tt.line}}, received)
+		})
+	}
+}
+
+func TestPlainTextAdapter_IgnoresOrdinaryLines(t *testing.T) {
+	lines := []string{
+		"I'll start by looking at the tests.",
+		"The tests are running fine now.",
+		"Reading:",
+		"",
+	}
+
+	for _, line := range lines {
+		received := collectEvents(t, NewPlainTextAdapter(nil), line+"\n")
+		assert.Equal(t, []Event{AssistantMessage{Text: line}}, received, "line %q", line)
+	}
+}
+
+func TestPlainTextAdapter_CustomPatterns(t *testing.T) {
+	patterns, err := ParseToolPatterns([]string{`Bash=^\$ (.+)$`, "Write=^Writing"})
+	require.NoError(t, err)
+
+	received := collectEvents(t, NewPlainTextAdapter(patterns), "$ ls -la\nWriting main.go\nRunning: go test\n")
+	assert.Equal(t, []Event{
+		ToolUse{Name: "Bash", Input: "ls -la"},
+		AssistantMessage{Text: "$ ls -la"},
+		ToolUse{Name: "Write"},
+		AssistantMessage{Text: "Writing main.go"},
+		// Custom patterns replace the defaults
+		AssistantMessage{Text: "Running: go test"},
+	}, received)
+}
+
+func TestParseToolPatterns(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		patterns, err := ParseToolPatterns(nil)
+		require.NoError(t, err)
+		assert.Nil(t, patterns)
+	})
+
+	t.Run("invalid specs", func(t *testing.T) {
+		for _, spec := range []string{"Bash", "=^Running", "Bash=", "Bash=(unclosed"} {
+			_, err := ParseToolPatterns([]string{spec})
+			assert.Error(t, err, "spec %q", spec)
+		}
+	})
+}
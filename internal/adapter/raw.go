@@ -0,0 +1,22 @@
+package adapter
+
+import "io"
+
+// RawAdapter copies agent output to a writer byte for byte and emits no
+// events. Used by gumloop run --no-adapter, for agents whose output format
+// an adapter can't handle.
+type RawAdapter struct {
+	w io.Writer
+}
+
+// NewRawAdapter creates a raw adapter writing to w.
+func NewRawAdapter(w io.Writer) *RawAdapter {
+	return &RawAdapter{w: w}
+}
+
+// Process copies everything from the reader to the adapter's writer.
+// The events channel is left untouched.
+func (a *RawAdapter) Process(reader io.Reader, events chan<- Event) error {
+	_, err := io.Copy(a.w, reader)
+	return err
+}
@@ -0,0 +1,21 @@
+package adapter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawAdapter_Process(t *testing.T) {
+	// Stream JSON, partial lines and ANSI codes all pass through unchanged
+	input := "{\"type\":\"assistant\",\"message\":{}}\n\x1b[32mok\x1b[0m\nno newline at end"
+	var out bytes.Buffer
+	events := make(chan Event, 10)
+
+	err := NewRawAdapter(&out).Process(strings.NewReader(input), events)
+	assert.NoError(t, err)
+	assert.Equal(t, input, out.String())
+	assert.Empty(t, events)
+}
@@ -36,17 +36,85 @@ type Agent struct {
 	// CheckCommand is the command to verify agent is installed (e.g., "claude", "codex")
 	CheckCommand string
 
+	// AuthCheck is a command run before a session to check the agent is
+	// logged in (e.g., ["codex", "login", "status"]); empty to skip
+	AuthCheck []string
+
 	// AutonomousFlags are flags used in --choo-choo mode for autonomous operation
 	AutonomousFlags []string
 
 	// InteractiveFlags are flags used in single-run mode
 	InteractiveFlags []string
 
+	// TerminalCommand is the command for run --interactive, where the agent's
+	// own UI takes over the terminal (e.g., "codex" rather than "codex exec");
+	// empty uses Command
+	TerminalCommand string
+
+	// TerminalFlags are flags used with run --interactive. Unlike
+	// InteractiveFlags they leave out print mode and structured output,
+	// which only make sense when an adapter reads the agent's output.
+	TerminalFlags []string
+
+	// TerminalPromptFlag is the flag the prompt follows with run
+	// --interactive (e.g., "-i"); empty passes it like BuildCommand does
+	TerminalPromptFlag string
+
+	// NoTerminalPrompt is set for agents whose interactive UI can't be
+	// started with a prompt, so run --interactive isn't supported
+	NoTerminalPrompt bool
+
 	// ModelFlag is how to pass model (e.g., "--model", "-m", "" for none/positional)
 	ModelFlag string
 
 	// PromptStyle defines how to pass the prompt to the agent
 	PromptStyle PromptStyle
+
+	// SystemPromptFlag is how to pass a system prompt (e.g., "--append-system-prompt",
+	// "" when unsupported, in which case the system prompt is prepended to the prompt)
+	SystemPromptFlag string
+
+	// SystemPrompt is extra instruction text sent with every prompt (empty for none)
+	SystemPrompt string
+
+	// BaseURL overrides the agent's API endpoint (e.g., a proxy), passed via
+	// the agent's base URL environment variable (see BaseURLEnvVar)
+	BaseURL string
+
+	// ToolPatterns are "Name=regex" specs used to spot tool usage in plain-text
+	// output (empty uses the adapter defaults)
+	ToolPatterns []string
+}
+
+// promptStyles are the prompt styles BuildCommand knows how to handle
+var promptStyles = []PromptStyle{PromptStyleArg, PromptStylePipe, PromptStyleStream, PromptStyleOllama}
+
+// Validate checks that the agent is complete enough to run: it needs an ID
+// without spaces, a command and a known prompt style.
+func (a *Agent) Validate() error {
+	if a.ID == "" {
+		return fmt.Errorf("agent has no id")
+	}
+	if strings.ContainsAny(a.ID, " \t\n") {
+		return fmt.Errorf("agent id '%s' must not contain spaces", a.ID)
+	}
+	if strings.TrimSpace(a.Command) == "" {
+		return fmt.Errorf("agent '%s' has no command", a.ID)
+	}
+
+	for _, style := range promptStyles {
+		if a.PromptStyle == style {
+			return nil
+		}
+	}
+	valid := make([]string, len(promptStyles))
+	for i, style := range promptStyles {
+		valid[i] = string(style)
+	}
+	if a.PromptStyle == "" {
+		return fmt.Errorf("agent '%s' has no prompt style (valid: %s)", a.ID, strings.Join(valid, ", "))
+	}
+	return fmt.Errorf("agent '%s' has unknown prompt style '%s' (valid: %s)", a.ID, a.PromptStyle, strings.Join(valid, ", "))
 }
 
 // Registry stores all registered agents.
@@ -57,6 +125,24 @@ func RegisterAgent(agent *Agent) {
 	Registry[agent.ID] = agent
 }
 
+// customAgents tracks the IDs registered by RegisterCustomAgent
+var customAgents = make(map[string]bool)
+
+// RegisterCustomAgent validates a user-defined agent and adds it to the
+// registry. Custom agents extend the built-ins and can't replace one, but
+// may be registered again (e.g. when config is reloaded).
+func RegisterCustomAgent(agent *Agent) error {
+	if _, exists := Registry[agent.ID]; exists && !customAgents[agent.ID] {
+		return fmt.Errorf("agent '%s' is built in and can't be redefined", agent.ID)
+	}
+	if err := agent.Validate(); err != nil {
+		return err
+	}
+	RegisterAgent(agent)
+	customAgents[agent.ID] = true
+	return nil
+}
+
 // GetAgent retrieves an agent by ID.
 // Returns an error with helpful suggestions if the agent is not found.
 func GetAgent(id string) (*Agent, error) {
@@ -169,11 +255,6 @@ func min(a, b, c int) int {
 //
 // Returns a command array suitable for exec.Command(args[0], args[1:]...)
 func (a *Agent) BuildCommand(prompt string, model string, autonomous bool) []string {
-	// Start with the base command
-	cmdParts := strings.Fields(a.Command)
-	args := make([]string, 0, len(cmdParts)+10)
-	args = append(args, cmdParts...)
-
 	// Add mode-specific flags
 	var flags []string
 	if autonomous {
@@ -181,6 +262,29 @@ func (a *Agent) BuildCommand(prompt string, model string, autonomous bool) []str
 	} else {
 		flags = a.InteractiveFlags
 	}
+	return a.buildArgs(a.Command, flags, "", prompt, model)
+}
+
+// BuildTerminalCommand constructs the command array for run --interactive,
+// which starts the agent's own interactive UI with the prompt: the
+// TerminalCommand (or Command) with TerminalFlags instead of the print-mode
+// flags BuildCommand uses.
+func (a *Agent) BuildTerminalCommand(prompt string, model string) []string {
+	command := a.TerminalCommand
+	if command == "" {
+		command = a.Command
+	}
+	return a.buildArgs(command, a.TerminalFlags, a.TerminalPromptFlag, prompt, model)
+}
+
+// buildArgs constructs the command array for command with flags, adding the
+// model, system prompt and prompt (after promptFlag, if set) as the agent
+// expects them
+func (a *Agent) buildArgs(command string, flags []string, promptFlag string, prompt string, model string) []string {
+	// Start with the base command
+	cmdParts := strings.Fields(command)
+	args := make([]string, 0, len(cmdParts)+10)
+	args = append(args, cmdParts...)
 	args = append(args, flags...)
 
 	// Add model flag if specified and agent supports it
@@ -188,6 +292,14 @@ func (a *Agent) BuildCommand(prompt string, model string, autonomous bool) []str
 		args = append(args, a.ModelFlag, model)
 	}
 
+	// Add system prompt flag if the agent supports it (otherwise it is
+	// folded into the prompt by FramePrompt)
+	if a.SystemPrompt != "" && a.SystemPromptFlag != "" {
+		args = append(args, a.SystemPromptFlag, a.SystemPrompt)
+	}
+
+	prompt = a.FramePrompt(prompt)
+
 	// Handle prompt based on style
 	switch a.PromptStyle {
 	case PromptStyleOllama:
@@ -200,6 +312,9 @@ func (a *Agent) BuildCommand(prompt string, model string, autonomous bool) []str
 
 	case PromptStyleArg, PromptStyleStream:
 		// Argument style: prompt is passed as final argument
+		if promptFlag != "" {
+			args = append(args, promptFlag)
+		}
 		args = append(args, prompt)
 
 	case PromptStylePipe:
@@ -209,3 +324,13 @@ func (a *Agent) BuildCommand(prompt string, model string, autonomous bool) []str
 
 	return args
 }
+
+// FramePrompt returns the prompt text the agent should receive.
+// When the agent has a system prompt but no flag to pass it, the system
+// prompt is prepended to the user prompt; otherwise the prompt is unchanged.
+func (a *Agent) FramePrompt(prompt string) string {
+	if a.SystemPrompt == "" || a.SystemPromptFlag != "" {
+		return prompt
+	}
+	return a.SystemPrompt + "\n\n" + prompt
+}
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -220,6 +221,127 @@ func TestBuildCommand(t *testing.T) {
 	}
 }
 
+func TestBuildTerminalCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		agent    *Agent
+		expected []string
+	}{
+		{
+			name: "terminal command replaces command, print flags dropped",
+			agent: &Agent{
+				Command:          "codex exec",
+				InteractiveFlags: []string{"--json"},
+				TerminalCommand:  "codex",
+				ModelFlag:        "--model",
+				PromptStyle:      PromptStyleArg,
+			},
+			expected: []string{"codex", "--model", "gpt-5", "Fix bugs"},
+		},
+		{
+			name: "prompt flag goes right before the prompt",
+			agent: &Agent{
+				Command:            "gemini",
+				InteractiveFlags:   []string{"-p", "--output-format", "text"},
+				TerminalPromptFlag: "-i",
+				ModelFlag:          "--model",
+				PromptStyle:        PromptStyleArg,
+			},
+			expected: []string{"gemini", "--model", "gpt-5", "-i", "Fix bugs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.agent.BuildTerminalCommand("Fix bugs", "gpt-5")
+			if strings.Join(result, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestBuildCommand_SystemPrompt(t *testing.T) {
+	tests := []struct {
+		name     string
+		agent    *Agent
+		expected []string
+	}{
+		{
+			name: "agent with system prompt flag",
+			agent: &Agent{
+				ID:               "claude",
+				Command:          "claude",
+				AutonomousFlags:  []string{"-p"},
+				ModelFlag:        "--model",
+				PromptStyle:      PromptStyleStream,
+				SystemPromptFlag: "--append-system-prompt",
+				SystemPrompt:     "Be terse",
+			},
+			expected: []string{"claude", "-p", "--model", "sonnet", "--append-system-prompt", "Be terse", "Fix bugs"},
+		},
+		{
+			name: "agent without system prompt flag",
+			agent: &Agent{
+				ID:              "codex",
+				Command:         "codex exec",
+				AutonomousFlags: []string{"--full-auto"},
+				ModelFlag:       "--model",
+				PromptStyle:     PromptStyleArg,
+				SystemPrompt:    "Be terse",
+			},
+			expected: []string{"codex", "exec", "--full-auto", "--model", "sonnet", "Be terse\n\nFix bugs"},
+		},
+		{
+			name: "no system prompt set",
+			agent: &Agent{
+				ID:               "claude",
+				Command:          "claude",
+				AutonomousFlags:  []string{"-p"},
+				ModelFlag:        "--model",
+				PromptStyle:      PromptStyleStream,
+				SystemPromptFlag: "--append-system-prompt",
+			},
+			expected: []string{"claude", "-p", "--model", "sonnet", "Fix bugs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.agent.BuildCommand("Fix bugs", "sonnet", true)
+
+			if len(result) != len(tt.expected) {
+				t.Errorf("expected %d args, got %d\nExpected: %v\nGot: %v",
+					len(tt.expected), len(result), tt.expected, result)
+				return
+			}
+
+			for i, arg := range tt.expected {
+				if result[i] != arg {
+					t.Errorf("arg[%d]: expected '%s', got '%s'", i, arg, result[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFramePrompt(t *testing.T) {
+	withFlag := &Agent{SystemPromptFlag: "--append-system-prompt", SystemPrompt: "Be terse"}
+	if got := withFlag.FramePrompt("Fix bugs"); got != "Fix bugs" {
+		t.Errorf("expected prompt unchanged when flag is supported, got '%s'", got)
+	}
+
+	piped := &Agent{PromptStyle: PromptStylePipe, SystemPrompt: "Be terse"}
+	if got := piped.FramePrompt("Fix bugs"); got != "Be terse\n\nFix bugs" {
+		t.Errorf("expected system prompt prepended, got '%s'", got)
+	}
+
+	none := &Agent{PromptStyle: PromptStylePipe}
+	if got := none.FramePrompt("Fix bugs"); got != "Fix bugs" {
+		t.Errorf("expected prompt unchanged without system prompt, got '%s'", got)
+	}
+}
+
 func TestLevenshteinDistance(t *testing.T) {
 	tests := []struct {
 		a        string
@@ -260,3 +382,42 @@ func hasSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestAgentValidate(t *testing.T) {
+	valid := Agent{ID: "custom", Name: "Custom", Command: "custom-agent run", PromptStyle: PromptStyleArg}
+
+	tests := []struct {
+		name    string
+		modify  func(a *Agent)
+		wantErr string
+	}{
+		{name: "valid", modify: func(a *Agent) {}},
+		{name: "pipe style", modify: func(a *Agent) { a.PromptStyle = PromptStylePipe }},
+		{name: "no id", modify: func(a *Agent) { a.ID = "" }, wantErr: "agent has no id"},
+		{name: "id with spaces", modify: func(a *Agent) { a.ID = "my agent" }, wantErr: "must not contain spaces"},
+		{name: "no command", modify: func(a *Agent) { a.Command = "" }, wantErr: "agent 'custom' has no command"},
+		{name: "blank command", modify: func(a *Agent) { a.Command = "   " }, wantErr: "agent 'custom' has no command"},
+		{name: "no prompt style", modify: func(a *Agent) { a.PromptStyle = "" }, wantErr: "agent 'custom' has no prompt style (valid: arg, pipe, stream, ollama)"},
+		{name: "unknown prompt style", modify: func(a *Agent) { a.PromptStyle = "stdin" }, wantErr: "unknown prompt style 'stdin'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := valid
+			tt.modify(&a)
+			err := a.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error containing %q, got nil", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %q", tt.wantErr, err.Error())
+			}
+		})
+	}
+}
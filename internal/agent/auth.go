@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// authCheckTimeout bounds how long CheckAuth waits for the auth command
+var authCheckTimeout = 10 * time.Second
+
+// CheckAuth runs the agent's AuthCheck command and returns an error if it
+// fails, which usually means the agent isn't logged in. Agents without an
+// AuthCheck always pass.
+func (a *Agent) CheckAuth() error {
+	if len(a.AuthCheck) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), authCheckTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, a.AuthCheck[0], a.AuthCheck[1:]...)
+	cmd.Env = a.BuildEnv(os.Environ())
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return fmt.Errorf("%s auth check (%s) timed out after %s", a.Name, strings.Join(a.AuthCheck, " "), authCheckTimeout)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("%s auth check (%s) failed: %v", a.Name, strings.Join(a.AuthCheck, " "), err)
+		if out := strings.TrimSpace(output.String()); out != "" {
+			msg += "\n" + out
+		}
+		return fmt.Errorf("%s", msg)
+	}
+	return nil
+}
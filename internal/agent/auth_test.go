@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckAuth_Succeeds(t *testing.T) {
+	a := &Agent{Name: "Fake", AuthCheck: []string{"sh", "-c", "echo 'Logged in as dev@example.com'"}}
+	if err := a.CheckAuth(); err != nil {
+		t.Errorf("expected auth check to pass, got: %v", err)
+	}
+}
+
+func TestCheckAuth_Fails(t *testing.T) {
+	a := &Agent{Name: "Fake", AuthCheck: []string{"sh", "-c", "echo 'Not logged in' >&2; exit 1"}}
+	err := a.CheckAuth()
+	if err == nil {
+		t.Fatal("expected auth check to fail")
+	}
+	if !strings.Contains(err.Error(), "Fake auth check (sh -c") {
+		t.Errorf("expected error to name the agent and command, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "Not logged in") {
+		t.Errorf("expected error to include the command's output, got: %v", err)
+	}
+}
+
+func TestCheckAuth_NoCheck(t *testing.T) {
+	a := &Agent{Name: "Fake"}
+	if err := a.CheckAuth(); err != nil {
+		t.Errorf("expected agents without AuthCheck to pass, got: %v", err)
+	}
+}
+
+func TestCheckAuth_Timeout(t *testing.T) {
+	orig := authCheckTimeout
+	authCheckTimeout = 50 * time.Millisecond
+	defer func() { authCheckTimeout = orig }()
+
+	a := &Agent{Name: "Fake", AuthCheck: []string{"sleep", "5"}}
+	err := a.CheckAuth()
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout error, got: %v", err)
+	}
+}
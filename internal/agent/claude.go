@@ -6,6 +6,7 @@ func init() {
 		Name:         "Claude Code",
 		Command:      "claude",
 		CheckCommand: "claude",
+		AuthCheck:    []string{"claude", "auth", "status"},
 		AutonomousFlags: []string{
 			"-p",
 			"--dangerously-skip-permissions",
@@ -19,7 +20,10 @@ func init() {
 			"--output-format",
 			"stream-json",
 		},
-		ModelFlag:   "--model",
-		PromptStyle: PromptStyleStream,
+		// Interactive UI: the prompt starts the session, no flags needed
+		TerminalFlags:    []string{},
+		ModelFlag:        "--model",
+		PromptStyle:      PromptStyleStream,
+		SystemPromptFlag: "--append-system-prompt",
 	})
 }
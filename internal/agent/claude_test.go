@@ -26,8 +26,9 @@ func setupClaudeTests() {
 			"--output-format",
 			"stream-json",
 		},
-		ModelFlag:   "--model",
-		PromptStyle: PromptStyleStream,
+		ModelFlag:        "--model",
+		PromptStyle:      PromptStyleStream,
+		SystemPromptFlag: "--append-system-prompt",
 	})
 }
 
@@ -6,6 +6,7 @@ func init() {
 		Name:         "OpenAI Codex",
 		Command:      "codex exec",
 		CheckCommand: "codex",
+		AuthCheck:    []string{"codex", "login", "status"},
 		AutonomousFlags: []string{
 			"--full-auto",
 			"--json",
@@ -13,7 +14,9 @@ func init() {
 		InteractiveFlags: []string{
 			"--json",
 		},
-		ModelFlag:   "--model",
-		PromptStyle: PromptStyleArg,
+		// Interactive UI is plain "codex", not "codex exec"
+		TerminalCommand: "codex",
+		ModelFlag:       "--model",
+		PromptStyle:     PromptStyleArg,
 	})
 }
@@ -0,0 +1,40 @@
+package agent
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Auto is the cli setting that picks the first installed agent
+const Auto = "auto"
+
+// autoOrder is the order agents are tried in for cli: auto
+var autoOrder = []string{"claude", "codex", "gemini", "opencode", "cursor", "ollama"}
+
+// Installed reports whether the agent's CheckCommand is on the PATH
+func (a *Agent) Installed() bool {
+	if a.CheckCommand == "" {
+		return false
+	}
+	_, err := exec.LookPath(a.CheckCommand)
+	return err == nil
+}
+
+// DetectInstalled returns the first agent in autoOrder whose CheckCommand
+// is on the PATH. Returns an error naming the commands it looked for if
+// none is installed.
+func DetectInstalled() (*Agent, error) {
+	var tried []string
+	for _, id := range autoOrder {
+		ag, ok := Registry[id]
+		if !ok {
+			continue
+		}
+		if ag.Installed() {
+			return ag, nil
+		}
+		tried = append(tried, ag.CheckCommand)
+	}
+	return nil, fmt.Errorf("cli is 'auto' but no supported agent is installed (looked for %s on PATH)", strings.Join(tried, ", "))
+}
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// autoRegistry replaces the registry with agents for each autoOrder ID,
+// since other tests clear it
+func autoRegistry(t *testing.T) {
+	t.Helper()
+	saved := Registry
+	t.Cleanup(func() { Registry = saved })
+
+	Registry = make(map[string]*Agent)
+	for _, id := range autoOrder {
+		command := id
+		if id == "cursor" {
+			command = "cursor-agent"
+		}
+		RegisterAgent(&Agent{ID: id, Name: id, Command: command, CheckCommand: command})
+	}
+}
+
+// fakePath points PATH at a directory holding an executable for each command
+func fakePath(t *testing.T, commands ...string) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range commands {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestDetectInstalled(t *testing.T) {
+	autoRegistry(t)
+	fakePath(t, "gemini")
+	ag, err := DetectInstalled()
+	if err != nil {
+		t.Fatalf("DetectInstalled() error: %v", err)
+	}
+	if ag.ID != "gemini" {
+		t.Errorf("Expected the only installed agent (gemini), got %s", ag.ID)
+	}
+}
+
+func TestDetectInstalled_PreferenceOrder(t *testing.T) {
+	autoRegistry(t)
+	fakePath(t, "ollama", "codex", "cursor-agent")
+	ag, err := DetectInstalled()
+	if err != nil {
+		t.Fatalf("DetectInstalled() error: %v", err)
+	}
+	if ag.ID != "codex" {
+		t.Errorf("Expected codex to be preferred, got %s", ag.ID)
+	}
+}
+
+func TestDetectInstalled_NoneInstalled(t *testing.T) {
+	autoRegistry(t)
+	fakePath(t)
+	_, err := DetectInstalled()
+	if err == nil {
+		t.Fatal("Expected an error with no agents on PATH")
+	}
+	if !strings.Contains(err.Error(), "no supported agent is installed") || !strings.Contains(err.Error(), "cursor-agent") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package agent
+
+// baseURLEnvVars maps agent IDs to the environment variable each agent's CLI
+// reads its API base URL from. Agents not listed don't support base_url.
+var baseURLEnvVars = map[string]string{
+	"claude": "ANTHROPIC_BASE_URL",
+	"codex":  "OPENAI_BASE_URL",
+	"gemini": "GOOGLE_GEMINI_BASE_URL",
+	"ollama": "OLLAMA_HOST",
+}
+
+// BaseURLEnvVar returns the environment variable used to pass a base URL to
+// the given agent, or "" if the agent doesn't support one.
+func BaseURLEnvVar(agentID string) string {
+	return baseURLEnvVars[agentID]
+}
+
+// BuildEnv returns the environment for the agent process: base plus the
+// agent's base URL variable when BaseURL is set and the agent supports it.
+func (a *Agent) BuildEnv(base []string) []string {
+	envVar := BaseURLEnvVar(a.ID)
+	if a.BaseURL == "" || envVar == "" {
+		return base
+	}
+
+	env := make([]string, 0, len(base)+1)
+	env = append(env, base...)
+	return append(env, envVar+"="+a.BaseURL)
+}
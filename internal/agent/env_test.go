@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"testing"
+)
+
+func TestBuildEnv_BaseURL(t *testing.T) {
+	tests := []struct {
+		id       string
+		expected string
+	}{
+		{"claude", "ANTHROPIC_BASE_URL=https://proxy.internal"},
+		{"codex", "OPENAI_BASE_URL=https://proxy.internal"},
+		{"gemini", "GOOGLE_GEMINI_BASE_URL=https://proxy.internal"},
+		{"ollama", "OLLAMA_HOST=https://proxy.internal"},
+	}
+
+	base := []string{"PATH=/usr/bin", "HOME=/home/test"}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			a := &Agent{ID: tt.id, BaseURL: "https://proxy.internal"}
+			env := a.BuildEnv(base)
+
+			if len(env) != len(base)+1 {
+				t.Fatalf("expected %d env entries, got %d: %v", len(base)+1, len(env), env)
+			}
+			if env[len(env)-1] != tt.expected {
+				t.Errorf("expected '%s', got '%s'", tt.expected, env[len(env)-1])
+			}
+		})
+	}
+}
+
+func TestBuildEnv_Unchanged(t *testing.T) {
+	base := []string{"PATH=/usr/bin"}
+
+	tests := []struct {
+		name  string
+		agent *Agent
+	}{
+		{"no base URL", &Agent{ID: "claude"}},
+		{"agent without base URL support", &Agent{ID: "cursor", BaseURL: "https://proxy.internal"}},
+		{"opencode", &Agent{ID: "opencode", BaseURL: "https://proxy.internal"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := tt.agent.BuildEnv(base)
+			if len(env) != 1 || env[0] != "PATH=/usr/bin" {
+				t.Errorf("expected env unchanged, got %v", env)
+			}
+		})
+	}
+}
+
+func TestBaseURLEnvVar(t *testing.T) {
+	if got := BaseURLEnvVar("claude"); got != "ANTHROPIC_BASE_URL" {
+		t.Errorf("expected ANTHROPIC_BASE_URL, got '%s'", got)
+	}
+	if got := BaseURLEnvVar("cursor"); got != "" {
+		t.Errorf("expected no env var for cursor, got '%s'", got)
+	}
+}
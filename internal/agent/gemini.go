@@ -6,6 +6,8 @@ func init() {
 		Name:         "Google Gemini",
 		Command:      "gemini",
 		CheckCommand: "gemini",
+		// No AuthCheck: the Gemini CLI has no non-interactive way to report
+		// whether it's logged in, and its auth can come from several env vars
 		AutonomousFlags: []string{
 			"-p",
 			"--yolo",
@@ -17,7 +19,9 @@ func init() {
 			"--output-format",
 			"text",
 		},
-		ModelFlag:   "--model",
-		PromptStyle: PromptStyleArg,
+		// Interactive UI: -i runs the prompt and stays interactive
+		TerminalPromptFlag: "-i",
+		ModelFlag:          "--model",
+		PromptStyle:        PromptStyleArg,
 	})
 }
@@ -10,6 +10,8 @@ func init() {
 		AutonomousFlags: []string{"-p", "-q", "-f", "text"},
 		// Interactive: -p for prompt, -f for format (no -q to show spinner)
 		InteractiveFlags: []string{"-p", "-f", "text"},
+		// The TUI has no way to take a prompt (-p always runs non-interactively)
+		NoTerminalPrompt: true,
 		// OpenCode uses config file (~/.opencode.json), not CLI flag
 		ModelFlag: "",
 		// Prompt is passed via -p flag as argument
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/adriancodes/gumloop/internal/agent"
+	"github.com/adriancodes/gumloop/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// agentsCmd lists the agents gumloop can run
+var agentsCmd = &cobra.Command{
+	Use:   "agents",
+	Short: "List supported agents",
+	Long: `List the agents gumloop can run: the built-in ones and any defined under
+agents in config. An agent is installed when its check command is on the PATH.
+
+With --json, print the list as a JSON array for tools and editor integrations.`,
+	Args: cobra.NoArgs,
+	RunE: runAgents,
+}
+
+var agentsJSON bool
+
+func init() {
+	rootCmd.AddCommand(agentsCmd)
+	agentsCmd.Flags().BoolVar(&agentsJSON, "json", false, "Print the agents as JSON")
+}
+
+// agentInfo is one agent in the output of gumloop agents
+type agentInfo struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Command      string `json:"command"`
+	CheckCommand string `json:"check_command"`
+	Installed    bool   `json:"installed"`
+	PromptStyle  string `json:"prompt_style"`
+}
+
+// listAgents describes every agent in agent.Registry, sorted by ID
+func listAgents() []agentInfo {
+	infos := []agentInfo{}
+	for _, id := range agent.ListAgents() {
+		ag := agent.Registry[id]
+		infos = append(infos, agentInfo{
+			ID:           ag.ID,
+			Name:         ag.Name,
+			Command:      ag.Command,
+			CheckCommand: ag.CheckCommand,
+			Installed:    ag.Installed(),
+			PromptStyle:  string(ag.PromptStyle),
+		})
+	}
+	return infos
+}
+
+func runAgents(cmd *cobra.Command, args []string) error {
+	// Custom agents from config are listed alongside the built-ins
+	var defs []config.CustomAgent
+	if err := viper.UnmarshalKey("agents", &defs); err != nil {
+		return fmt.Errorf("invalid agents config: %w", err)
+	}
+	if err := registerCustomAgents(defs); err != nil {
+		return err
+	}
+
+	infos := listAgents()
+	if agentsJSON {
+		data, err := json.MarshalIndent(infos, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode agents: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	idWidth, nameWidth := 0, 0
+	for _, info := range infos {
+		idWidth = max(idWidth, len(info.ID))
+		nameWidth = max(nameWidth, len(info.Name))
+	}
+	for _, info := range infos {
+		status := "✅ installed"
+		if !info.Installed {
+			status = fmt.Sprintf("❌ not installed (%s not on PATH)", info.CheckCommand)
+		}
+		fmt.Printf("  %-*s  %-*s  %s\n", idWidth, info.ID, nameWidth, info.Name, status)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adriancodes/gumloop/internal/agent"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// onlyOnPath replaces PATH with a directory holding a fake executable for
+// each of names
+func onlyOnPath(t *testing.T, names ...string) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range names {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0755))
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestAgents_JSON(t *testing.T) {
+	onlyOnPath(t, "claude", "my-agent")
+	viper.Reset()
+	viper.SetConfigType("yaml")
+	require.NoError(t, viper.ReadConfig(strings.NewReader(`agents:
+  - id: my-agent
+    name: My Agent
+    command: my-agent run
+    prompt_style: pipe
+`)))
+	agentsJSON = true
+	defer func() { agentsJSON = false; delete(agent.Registry, "my-agent"); viper.Reset() }()
+
+	output := captureStdout(t, func() {
+		require.NoError(t, runAgents(nil, nil))
+	})
+
+	var infos []agentInfo
+	require.NoError(t, json.Unmarshal([]byte(output), &infos), output)
+	byID := map[string]agentInfo{}
+	for _, info := range infos {
+		byID[info.ID] = info
+	}
+	assert.Len(t, infos, len(agent.Registry))
+
+	assert.Equal(t, agentInfo{
+		ID:           "claude",
+		Name:         "Claude Code",
+		Command:      "claude",
+		CheckCommand: "claude",
+		Installed:    true,
+		PromptStyle:  "stream",
+	}, byID["claude"])
+
+	codex := byID["codex"]
+	assert.Equal(t, "OpenAI Codex", codex.Name)
+	assert.False(t, codex.Installed)
+	assert.NotEmpty(t, codex.CheckCommand)
+
+	assert.Equal(t, agentInfo{
+		ID:           "my-agent",
+		Name:         "My Agent",
+		Command:      "my-agent run",
+		CheckCommand: "my-agent",
+		Installed:    true,
+		PromptStyle:  "pipe",
+	}, byID["my-agent"])
+
+	// Keys are snake_case for tools reading the output
+	assert.Contains(t, output, `"check_command": "claude"`)
+	assert.Contains(t, output, `"prompt_style": "stream"`)
+}
+
+func TestAgents_Human(t *testing.T) {
+	onlyOnPath(t, "claude")
+	viper.Reset()
+
+	output := captureStdout(t, func() {
+		require.NoError(t, runAgents(nil, nil))
+	})
+	assert.Contains(t, output, "Claude Code")
+	assert.Contains(t, output, "✅ installed")
+	assert.Contains(t, output, "❌ not installed (codex not on PATH)")
+}
@@ -38,7 +38,7 @@ var configSetCmd = &cobra.Command{
 By default, sets the value in the project config (./.gumloop.yaml).
 Use --global to set in the global config (~/.config/gumloop/config.yaml).
 
-Valid keys: cli, model, prompt_file, auto_push, stuck_threshold, verify`,
+Valid keys: ` + strings.Join(configKeys, ", "),
 	Args: cobra.ExactArgs(2),
 	RunE: runConfigSet,
 }
@@ -52,7 +52,7 @@ var configGetCmd = &cobra.Command{
 By default, gets the effective value (merged from all sources).
 Use --global to get only from the global config.
 
-Valid keys: cli, model, prompt_file, auto_push, stuck_threshold, verify`,
+Valid keys: ` + strings.Join(configKeys, ", "),
 	Args: cobra.ExactArgs(1),
 	RunE: runConfigGet,
 }
@@ -105,9 +105,9 @@ func runConfigSet(cmd *cobra.Command, args []string) error {
 	value := args[1]
 
 	// Validate key
-	validKeys := []string{"cli", "model", "prompt_file", "auto_push", "stuck_threshold", "verify", "memory"}
-	if !contains(validKeys, key) {
-		return fmt.Errorf("unknown config key '%s' (valid keys: %s)", key, strings.Join(validKeys, ", "))
+	k, err := lookupConfigKey(key)
+	if err != nil {
+		return err
 	}
 
 	// Determine which file to write to
@@ -141,15 +141,24 @@ func runConfigSet(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Update the value
-	if err := setConfigValue(&cfg, key, value); err != nil {
+	// The agent a project cli selects may be defined in the global config
+	agents := cfg.Agents
+	if key == "cli" && !globalFlag {
+		if global, err := config.LoadGlobal(); err == nil {
+			cfg.Agents = append(append([]config.CustomAgent(nil), global.Agents...), agents...)
+		}
+	}
+
+	// Update the value (validates it and normalizes its type)
+	if err := k.set(&cfg, value); err != nil {
 		return err
 	}
+	cfg.Agents = agents
 
-	// Write back to file
-	data, err = yaml.Marshal(&cfg)
+	// Rewrite only the target key so comments, ordering and unknown keys survive
+	data, err = setYAMLKey(data, key, &cfg)
 	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+		return fmt.Errorf("failed to update config: %w", err)
 	}
 
 	if err := os.WriteFile(configPath, data, 0644); err != nil {
@@ -165,18 +174,114 @@ func runConfigSet(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// setYAMLKey sets key in the YAML document data to the value it has in cfg,
+// leaving the rest of the document (comments, key order, unknown keys) intact.
+// Missing keys are appended to the end of the top-level mapping.
+func setYAMLKey(data []byte, key string, cfg *config.Config) ([]byte, error) {
+	// Encode the config to pick up the typed node for the key
+	var encoded yaml.Node
+	if err := encoded.Encode(cfg); err != nil {
+		return nil, err
+	}
+	value := mappingValue(&encoded, key)
+	if value == nil {
+		// omitempty fields are dropped when empty; store an explicit null,
+		// which loads as the zero value for strings and lists alike
+		value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: ""}
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	// The encoder never writes a document start marker, and drops files
+	// with nothing but comments, so carry those over by hand
+	var header string
+	switch {
+	case doc.Kind == 0:
+		// Empty, missing or comment-only file: keep the comments above the new key
+		header = string(data)
+		if strings.TrimSpace(header) == "" {
+			header = ""
+		} else if !strings.HasSuffix(header, "\n") {
+			header += "\n"
+		}
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	case hasDocumentStart(data):
+		header = "---\n"
+	}
+	if root := doc.Content[0]; root.Kind == yaml.ScalarNode && root.Tag == "!!null" {
+		// A bare "---" document has no mapping yet
+		doc.Content[0] = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config file is not a YAML mapping")
+	}
+
+	replaced := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == key {
+			old := root.Content[i+1]
+			value.HeadComment = old.HeadComment
+			value.LineComment = old.LineComment
+			value.FootComment = old.FootComment
+			root.Content[i+1] = value
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		root.Content = append(root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+			value,
+		)
+	}
+
+	var buf strings.Builder
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(header + buf.String()), nil
+}
+
+// hasDocumentStart reports whether the YAML starts with a "---" marker
+func hasDocumentStart(data []byte) bool {
+	first, _, _ := strings.Cut(string(data), "\n")
+	first = strings.TrimSpace(first)
+	return first == "---" || strings.HasPrefix(first, "--- ")
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
 // runConfigGet gets a configuration value
 func runConfigGet(cmd *cobra.Command, args []string) error {
 	key := args[0]
 
 	// Validate key
-	validKeys := []string{"cli", "model", "prompt_file", "auto_push", "stuck_threshold", "verify", "memory"}
-	if !contains(validKeys, key) {
-		return fmt.Errorf("unknown config key '%s' (valid keys: %s)", key, strings.Join(validKeys, ", "))
+	k, err := lookupConfigKey(key)
+	if err != nil {
+		return err
 	}
 
 	var cfg config.Config
-	var err error
 
 	if globalFlag {
 		// Get from global only
@@ -196,15 +301,12 @@ func runConfigGet(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("failed to load project config: %w", err)
 		}
 		cfg = config.Merge(defaults, global, project)
+		if err := config.ValidateCLI(cfg); err != nil {
+			return err
+		}
 	}
 
-	// Get the value
-	value, err := getConfigValue(&cfg, key)
-	if err != nil {
-		return err
-	}
-
-	fmt.Println(value)
+	fmt.Println(k.get(&cfg))
 	return nil
 }
 
@@ -246,158 +348,44 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 
 	// Merge to get effective config
 	effective := config.Merge(defaults, global, project)
+	if err := config.ValidateCLI(effective); err != nil {
+		return err
+	}
 
+	if len(configFiles) > 0 {
+		fmt.Println("Config files:")
+		fmt.Print(describeConfigFiles(configFiles, "  "))
+		fmt.Println()
+	}
 	fmt.Println("Effective configuration:")
 	fmt.Println()
 
 	// Print each value with its source
-	printValueWithSource("cli", effective.CLI, defaults, global, project)
-	printValueWithSource("model", effective.Model, defaults, global, project)
-	printValueWithSource("prompt_file", effective.PromptFile, defaults, global, project)
-	printValueWithSource("auto_push", fmt.Sprintf("%t", effective.AutoPush), defaults, global, project)
-	printValueWithSource("stuck_threshold", fmt.Sprintf("%d", effective.StuckThreshold), defaults, global, project)
-	printValueWithSource("verify", effective.Verify, defaults, global, project)
-	printValueWithSource("memory", fmt.Sprintf("%t", effective.Memory), defaults, global, project)
-
-	return nil
-}
-
-// setConfigValue sets a value in the config struct based on the key
-func setConfigValue(cfg *config.Config, key, value string) error {
-	switch key {
-	case "cli":
-		// Validate agent name
-		validAgents := []string{"claude", "codex", "gemini", "opencode", "cursor", "ollama"}
-		if !contains(validAgents, value) {
-			return fmt.Errorf("invalid agent '%s' (valid: %s)", value, strings.Join(validAgents, ", "))
-		}
-		cfg.CLI = value
-	case "model":
-		cfg.Model = value
-	case "prompt_file":
-		cfg.PromptFile = value
-	case "auto_push":
-		// Parse boolean
-		if value == "true" {
-			cfg.AutoPush = true
-		} else if value == "false" {
-			cfg.AutoPush = false
-		} else {
-			return fmt.Errorf("auto_push must be 'true' or 'false', got '%s'", value)
-		}
-	case "stuck_threshold":
-		// Parse integer
-		var threshold int
-		if _, err := fmt.Sscanf(value, "%d", &threshold); err != nil {
-			return fmt.Errorf("stuck_threshold must be an integer, got '%s'", value)
-		}
-		if threshold < 0 {
-			return fmt.Errorf("stuck_threshold must be positive, got %d", threshold)
-		}
-		cfg.StuckThreshold = threshold
-	case "verify":
-		cfg.Verify = value
-	case "memory":
-		if value == "true" {
-			cfg.Memory = true
-		} else if value == "false" {
-			cfg.Memory = false
-		} else {
-			return fmt.Errorf("memory must be 'true' or 'false', got '%s'", value)
-		}
-	default:
-		return fmt.Errorf("unknown config key: %s", key)
+	for _, k := range configKeyTable {
+		fmt.Printf("  %-17s %-15s (from: %s)\n", k.name+":", formatValue(k.get(&effective)), configSource(k, &effective, &defaults, &global, &project))
 	}
-	return nil
-}
 
-// getConfigValue gets a value from the config struct based on the key
-func getConfigValue(cfg *config.Config, key string) (string, error) {
-	switch key {
-	case "cli":
-		return cfg.CLI, nil
-	case "model":
-		return cfg.Model, nil
-	case "prompt_file":
-		return cfg.PromptFile, nil
-	case "auto_push":
-		return fmt.Sprintf("%t", cfg.AutoPush), nil
-	case "stuck_threshold":
-		return fmt.Sprintf("%d", cfg.StuckThreshold), nil
-	case "verify":
-		return cfg.Verify, nil
-	case "memory":
-		return fmt.Sprintf("%t", cfg.Memory), nil
-	default:
-		return "", fmt.Errorf("unknown config key: %s", key)
-	}
+	return nil
 }
 
 // printConfig prints a config struct in a readable format
 func printConfig(cfg config.Config) {
-	fmt.Printf("  cli:             %s\n", formatValue(cfg.CLI))
-	fmt.Printf("  model:           %s\n", formatValue(cfg.Model))
-	fmt.Printf("  prompt_file:     %s\n", formatValue(cfg.PromptFile))
-	fmt.Printf("  auto_push:       %t\n", cfg.AutoPush)
-	fmt.Printf("  stuck_threshold: %d\n", cfg.StuckThreshold)
-	fmt.Printf("  verify:          %s\n", formatValue(cfg.Verify))
-	fmt.Printf("  memory:          %t\n", cfg.Memory)
+	for _, k := range configKeyTable {
+		fmt.Printf("  %-16s %s\n", k.name+":", formatValue(k.get(&cfg)))
+	}
 }
 
-// printValueWithSource prints a value with its source
-func printValueWithSource(key, effectiveValue string, defaults, global, project config.Config) {
-	source := "default"
-
-	// Determine source by comparing with each layer
-	switch key {
-	case "cli":
-		if project.CLI != "" && project.CLI == effectiveValue {
-			source = "project"
-		} else if global.CLI != "" && global.CLI == effectiveValue {
-			source = "global"
-		}
-	case "model":
-		if project.Model != "" && project.Model == effectiveValue {
-			source = "project"
-		} else if global.Model != "" && global.Model == effectiveValue {
-			source = "global"
-		}
-	case "prompt_file":
-		if project.PromptFile != "" && project.PromptFile == effectiveValue {
-			source = "project"
-		} else if global.PromptFile != "" && global.PromptFile == effectiveValue {
-			source = "global"
-		}
-	case "auto_push":
-		// For bool, we can't easily determine the source, so check if it differs from default
-		defaultValue := defaults.AutoPush
-		if project.AutoPush != defaultValue {
-			source = "project"
-		} else if global.AutoPush != defaultValue {
-			source = "global"
-		}
-	case "stuck_threshold":
-		if project.StuckThreshold != 0 && fmt.Sprintf("%d", project.StuckThreshold) == effectiveValue {
-			source = "project"
-		} else if global.StuckThreshold != 0 && fmt.Sprintf("%d", global.StuckThreshold) == effectiveValue {
-			source = "global"
-		}
-	case "verify":
-		if project.Verify != "" && project.Verify == effectiveValue {
-			source = "project"
-		} else if global.Verify != "" && global.Verify == effectiveValue {
-			source = "global"
-		}
-	case "memory":
-		defaultValue := defaults.Memory
-		if project.Memory != defaultValue {
-			source = "project"
-		} else if global.Memory != defaultValue {
-			source = "global"
-		}
+// configSource returns which layer the effective value of a key comes from:
+// project, global or default
+func configSource(k configKey, effective, defaults, global, project *config.Config) string {
+	value := k.get(effective)
+	switch {
+	case k.isSet(project, defaults) && k.get(project) == value:
+		return "project"
+	case k.isSet(global, defaults) && k.get(global) == value:
+		return "global"
 	}
-
-	fmt.Printf("  %-17s %-15s (from: %s)\n", key+":", formatValue(effectiveValue), source)
+	return "default"
 }
 
 // formatValue formats a value for display (empty string becomes "(not set)")
@@ -0,0 +1,304 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adriancodes/gumloop/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const commentedConfig = `# Project settings for gumloop
+cli: claude # the agent to use
+
+# Verification runs after every iteration
+verify: npm test
+stuck_threshold: 3
+custom_note: keep me
+`
+
+func TestRunConfigSet_PreservesComments(t *testing.T) {
+	withTempDir(t)
+	globalFlag = false
+	require.NoError(t, os.WriteFile(".gumloop.yaml", []byte(commentedConfig), 0644))
+
+	captureStdout(t, func() {
+		require.NoError(t, runConfigSet(nil, []string{"verify", "go test ./..."}))
+	})
+
+	data, err := os.ReadFile(".gumloop.yaml")
+	require.NoError(t, err)
+	out := string(data)
+
+	assert.Contains(t, out, "# Project settings for gumloop")
+	assert.Contains(t, out, "cli: claude # the agent to use")
+	assert.Contains(t, out, "# Verification runs after every iteration")
+	assert.Contains(t, out, "verify: go test ./...")
+	assert.Contains(t, out, "custom_note: keep me")
+	assert.NotContains(t, out, "npm test")
+
+	// Key order is unchanged
+	assert.Less(t, strings.Index(out, "cli:"), strings.Index(out, "verify:"))
+	assert.Less(t, strings.Index(out, "verify:"), strings.Index(out, "stuck_threshold:"))
+}
+
+func TestRunConfigSet_AppendsMissingKey(t *testing.T) {
+	withTempDir(t)
+	globalFlag = false
+	require.NoError(t, os.WriteFile(".gumloop.yaml", []byte(commentedConfig), 0644))
+
+	captureStdout(t, func() {
+		require.NoError(t, runConfigSet(nil, []string{"auto_push", "false"}))
+	})
+
+	data, err := os.ReadFile(".gumloop.yaml")
+	require.NoError(t, err)
+	out := string(data)
+
+	assert.Contains(t, out, "# Project settings for gumloop")
+	assert.Contains(t, out, "auto_push: false")
+	assert.Greater(t, strings.Index(out, "auto_push:"), strings.Index(out, "custom_note:"))
+}
+
+func TestRunConfigSet_NewFile(t *testing.T) {
+	withTempDir(t)
+	globalFlag = false
+
+	captureStdout(t, func() {
+		require.NoError(t, runConfigSet(nil, []string{"stuck_threshold", "5"}))
+	})
+
+	data, err := os.ReadFile(".gumloop.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "stuck_threshold: 5\n", string(data))
+}
+
+func TestRunConfigSet_DocumentStartAndCommentOnly(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		want     string
+	}{
+		{
+			name:     "document start marker",
+			existing: "---\ncli: claude # the agent\n",
+			want:     "---\ncli: claude # the agent\nstuck_threshold: 5\n",
+		},
+		{
+			name:     "bare document start marker",
+			existing: "---\n",
+			want:     "---\nstuck_threshold: 5\n",
+		},
+		{
+			name:     "comments only",
+			existing: "# Project settings for gumloop\n\n# Fill these in later",
+			want:     "# Project settings for gumloop\n\n# Fill these in later\nstuck_threshold: 5\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withTempDir(t)
+			globalFlag = false
+			require.NoError(t, os.WriteFile(".gumloop.yaml", []byte(tt.existing), 0644))
+
+			captureStdout(t, func() {
+				require.NoError(t, runConfigSet(nil, []string{"stuck_threshold", "5"}))
+			})
+
+			data, err := os.ReadFile(".gumloop.yaml")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(data))
+		})
+	}
+}
+
+func TestRunConfigSet_InvalidValueLeavesFile(t *testing.T) {
+	withTempDir(t)
+	globalFlag = false
+	require.NoError(t, os.WriteFile(".gumloop.yaml", []byte(commentedConfig), 0644))
+
+	err := runConfigSet(nil, []string{"auto_push", "maybe"})
+	assert.Error(t, err)
+
+	data, err := os.ReadFile(".gumloop.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, commentedConfig, string(data))
+}
+
+func TestRunConfigSet_GlobalCustomAgent(t *testing.T) {
+	withTempDir(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	globalFlag = false
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".config", "gumloop"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".config", "gumloop", "config.yaml"),
+		[]byte("agents:\n  - id: mine\n    command: mine run\n"), 0644))
+
+	// A project cli can select an agent defined globally...
+	captureStdout(t, func() {
+		require.NoError(t, runConfigSet(nil, []string{"cli", "mine"}))
+	})
+	data, err := os.ReadFile(".gumloop.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "cli: mine\n", string(data))
+
+	// ...and the merged config accepts it
+	captureStdout(t, func() {
+		require.NoError(t, runConfigGet(nil, []string{"cli"}))
+	})
+
+	// An agent defined nowhere is still rejected
+	assert.Error(t, runConfigSet(nil, []string{"cli", "theirs"}))
+}
+
+func TestRunConfigSet_HideTools(t *testing.T) {
+	withTempDir(t)
+	globalFlag = false
+
+	captureStdout(t, func() {
+		require.NoError(t, runConfigSet(nil, []string{"hide_tools", "Read, TodoWrite"}))
+	})
+
+	cfg, err := config.LoadProject()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Read", "TodoWrite"}, cfg.HideTools)
+
+	// Setting an empty value clears the list
+	captureStdout(t, func() {
+		require.NoError(t, runConfigSet(nil, []string{"hide_tools", ""}))
+	})
+
+	cfg, err = config.LoadProject()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.HideTools)
+}
+
+func TestRunConfigSet_ToolPatterns(t *testing.T) {
+	withTempDir(t)
+	globalFlag = false
+
+	captureStdout(t, func() {
+		require.NoError(t, runConfigSet(nil, []string{"tool_patterns", `Bash=^\$ (.{1,80})$; Write=^Writing (.+)$`}))
+	})
+
+	cfg, err := config.LoadProject()
+	require.NoError(t, err)
+	assert.Equal(t, []string{`Bash=^\$ (.{1,80})$`, `Write=^Writing (.+)$`}, cfg.ToolPatterns)
+
+	// Invalid regexes are rejected
+	err = runConfigSet(nil, []string{"tool_patterns", "Bash=(unclosed"})
+	assert.Error(t, err)
+}
+
+func TestConfigKeys_SetGetRoundTrip(t *testing.T) {
+	// A valid value for each key that differs from its default
+	samples := map[string]string{
+		"cli":                     "codex",
+		"model":                   "gpt-5",
+		"prompt_file":             "TASKS.md",
+		"prompt_search":           "true",
+		"auto_push":               "false",
+		"stuck_threshold":         "5",
+		"stuck_disabled":          "true",
+		"max_no_change":           "2",
+		"rate_limit_wait":         "30",
+		"commit_grace_iterations": "2",
+		"verify":                  "go test ./...",
+		"verify_parallel":         "true",
+		"verify_shell":            "bash -c",
+		"memory":                  "true",
+		"commit_if_dirty":         "true",
+		"commit_on_interrupt":     "true",
+		"require_clean_tree":      "true",
+		"commit_trailer":          "true",
+		"workdir":                 "packages/app",
+		"models_file":             "models.json",
+		"system_prompt":           "You are careful.",
+		"prompt_prefix":           "Always run gofmt.",
+		"stuck_hint":              "Commit what you have.",
+		"loop_prompt":             "Keep going",
+		"done_signal":             "^DONE$",
+		"commit_message_pattern":  "^feat: ",
+		"show_banner":             "false",
+		"banner_style":            "compact",
+		"max_line_length":         "200",
+		"max_line_size":           "64",
+		"commit_count_source":     "branch",
+		"theme":                   "rocket",
+		"hide_tools":              "Read,TodoWrite",
+		"tool_patterns":           "Bash=^\\$ (.+)$",
+		"push_args":               "--no-verify --push-option=ci.skip",
+		"base_url":                "http://localhost:11434",
+	}
+	defaults := config.Defaults()
+
+	for _, k := range configKeyTable {
+		t.Run(k.name, func(t *testing.T) {
+			value, ok := samples[k.name]
+			require.True(t, ok, "add a sample value for %s", k.name)
+
+			var cfg config.Config
+			require.NoError(t, k.set(&cfg, value))
+			assert.Equal(t, value, k.get(&cfg))
+			assert.True(t, k.isSet(&cfg, &defaults))
+
+			// The key name matches the field's YAML name
+			data, err := setYAMLKey(nil, k.name, &cfg)
+			require.NoError(t, err)
+			var loaded config.Config
+			require.NoError(t, yaml.Unmarshal(data, &loaded))
+			assert.Equal(t, value, k.get(&loaded))
+		})
+	}
+}
+
+func TestConfigKeys_InvalidValues(t *testing.T) {
+	tests := []struct {
+		key, value, want string
+	}{
+		{"cli", "vim", "invalid agent 'vim'"},
+		{"auto_push", "maybe", "auto_push must be 'true' or 'false', got 'maybe'"},
+		{"stuck_threshold", "three", "stuck_threshold must be an integer, got 'three'"},
+		{"stuck_threshold", "-1", "stuck_threshold must be positive, got -1"},
+		{"max_no_change", "0", "max_no_change must be at least 1, got 0"},
+		{"rate_limit_wait", "soon", "rate_limit_wait must be an integer (seconds), got 'soon'"},
+		{"rate_limit_wait", "0", "rate_limit_wait must be at least 1, got 0"},
+		{"done_signal", "(", "invalid value for done_signal"},
+		{"banner_style", "huge", "invalid banner_style 'huge'"},
+		{"tool_patterns", "Bash", "Bash"},
+		{"push_args", "upstream", "'upstream'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			k, err := lookupConfigKey(tt.key)
+			require.NoError(t, err)
+			var cfg config.Config
+			assert.ErrorContains(t, k.set(&cfg, tt.value), tt.want)
+		})
+	}
+
+	_, err := lookupConfigKey("colour")
+	assert.ErrorContains(t, err, "unknown config key 'colour'")
+}
+
+func TestConfigSource(t *testing.T) {
+	defaults := config.Defaults()
+	global := config.Config{Verify: "npm test", Model: "gpt-5"}
+	project := config.Config{Verify: "go test ./..."}
+	effective := config.Merge(defaults, global, project)
+
+	source := func(name string) string {
+		k, err := lookupConfigKey(name)
+		require.NoError(t, err)
+		return configSource(k, &effective, &defaults, &global, &project)
+	}
+	assert.Equal(t, "project", source("verify"))
+	assert.Equal(t, "global", source("model"))
+	assert.Equal(t, "default", source("stuck_threshold"))
+}
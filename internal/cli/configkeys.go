@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/adriancodes/gumloop/internal/adapter"
+	"github.com/adriancodes/gumloop/internal/agent"
+	"github.com/adriancodes/gumloop/internal/config"
+	"github.com/adriancodes/gumloop/internal/ui"
+)
+
+// configKey is one key accepted by config set/get, and shown by config
+// list/show. Adding a config field only needs an entry in configKeyTable.
+type configKey struct {
+	name string
+
+	// get formats the key's value in cfg
+	get func(cfg *config.Config) string
+
+	// set parses and validates value and stores it in cfg
+	set func(cfg *config.Config, value string) error
+
+	// isSet reports whether a config layer sets the key, for config show's
+	// source column. Booleans count as set when they differ from the default.
+	isSet func(layer, defaults *config.Config) bool
+}
+
+// configKeyTable lists the config keys in the order they're shown
+var configKeyTable = []configKey{
+	stringKey("cli", func(c *config.Config) *string { return &c.CLI }, func(cfg *config.Config, value string) error {
+		validAgents := []string{"claude", "codex", "gemini", "opencode", "cursor", "ollama", agent.Auto}
+		for _, custom := range cfg.Agents {
+			validAgents = append(validAgents, custom.ID)
+		}
+		if !contains(validAgents, value) {
+			return fmt.Errorf("invalid agent '%s' (valid: %s)", value, strings.Join(validAgents, ", "))
+		}
+		return nil
+	}),
+	stringKey("model", func(c *config.Config) *string { return &c.Model }, nil),
+	stringKey("prompt_file", func(c *config.Config) *string { return &c.PromptFile }, nil),
+	boolKey("prompt_search", func(c *config.Config) *bool { return &c.PromptSearch }),
+	boolKey("auto_push", func(c *config.Config) *bool { return &c.AutoPush }),
+	intKey("stuck_threshold", func(c *config.Config) *int { return &c.StuckThreshold }, 0, ""),
+	boolKey("stuck_disabled", func(c *config.Config) *bool { return &c.StuckDisabled }),
+	intKey("max_no_change", func(c *config.Config) *int { return &c.MaxNoChange }, 1, ""),
+	intKey("rate_limit_wait", func(c *config.Config) *int { return &c.RateLimitWait }, 1, "seconds"),
+	intKey("commit_grace_iterations", func(c *config.Config) *int { return &c.CommitGraceIterations }, 0, ""),
+	stringKey("verify", func(c *config.Config) *string { return &c.Verify }, nil),
+	boolKey("verify_parallel", func(c *config.Config) *bool { return &c.VerifyParallel }),
+	stringKey("verify_shell", func(c *config.Config) *string { return &c.VerifyShell }, nil),
+	boolKey("memory", func(c *config.Config) *bool { return &c.Memory }),
+	boolKey("commit_if_dirty", func(c *config.Config) *bool { return &c.CommitIfDirty }),
+	boolKey("commit_on_interrupt", func(c *config.Config) *bool { return &c.CommitOnInterrupt }),
+	boolKey("require_clean_tree", func(c *config.Config) *bool { return &c.RequireCleanTree }),
+	boolKey("commit_trailer", func(c *config.Config) *bool { return &c.CommitTrailer }),
+	stringKey("workdir", func(c *config.Config) *string { return &c.WorkDir }, nil),
+	stringKey("models_file", func(c *config.Config) *string { return &c.ModelsFile }, nil),
+	stringKey("system_prompt", func(c *config.Config) *string { return &c.SystemPrompt }, nil),
+	stringKey("prompt_prefix", func(c *config.Config) *string { return &c.PromptPrefix }, nil),
+	stringKey("stuck_hint", func(c *config.Config) *string { return &c.StuckHint }, nil),
+	stringKey("loop_prompt", func(c *config.Config) *string { return &c.LoopPrompt }, nil),
+	stringKey("done_signal", func(c *config.Config) *string { return &c.DoneSignal }, validRegexp("done_signal")),
+	stringKey("commit_message_pattern", func(c *config.Config) *string { return &c.CommitMessagePattern }, validRegexp("commit_message_pattern")),
+	boolKey("show_banner", func(c *config.Config) *bool { return &c.ShowBanner }),
+	stringKey("banner_style", func(c *config.Config) *string { return &c.BannerStyle }, oneOf("banner_style", "styles", ui.BannerStyles())),
+	intKey("max_line_length", func(c *config.Config) *int { return &c.MaxLineLength }, 0, "characters"),
+	intKey("max_line_size", func(c *config.Config) *int { return &c.MaxLineSize }, 1, "MiB"),
+	stringKey("commit_count_source", func(c *config.Config) *string { return &c.CommitCountSource }, oneOf("commit_count_source", "sources", config.CommitCountSources())),
+	stringKey("theme", func(c *config.Config) *string { return &c.Theme }, oneOf("theme", "themes", ui.ThemeNames())),
+	// Comma-separated list of tool names
+	listKey("hide_tools", ",", func(c *config.Config) *[]string { return &c.HideTools }, nil),
+	// Semicolon-separated Name=regex specs (regexes often contain commas)
+	listKey("tool_patterns", ";", func(c *config.Config) *[]string { return &c.ToolPatterns }, func(specs []string) error {
+		_, err := adapter.ParseToolPatterns(specs)
+		return err
+	}),
+	// Space-separated git push options
+	listKey("push_args", " ", func(c *config.Config) *[]string { return &c.PushArgs }, config.ValidatePushArgs),
+	stringKey("base_url", func(c *config.Config) *string { return &c.BaseURL }, nil),
+}
+
+// configKeys lists the keys accepted by config set/get
+var configKeys = configKeyNames()
+
+// configKeyNames returns the names in configKeyTable
+func configKeyNames() []string {
+	names := make([]string, len(configKeyTable))
+	for i, k := range configKeyTable {
+		names[i] = k.name
+	}
+	return names
+}
+
+// lookupConfigKey returns the table entry for name
+func lookupConfigKey(name string) (configKey, error) {
+	for _, k := range configKeyTable {
+		if k.name == name {
+			return k, nil
+		}
+	}
+	return configKey{}, fmt.Errorf("unknown config key '%s' (valid keys: %s)", name, strings.Join(configKeys, ", "))
+}
+
+// stringKey is a string field; validate (if set) checks new values
+func stringKey(name string, field func(*config.Config) *string, validate func(cfg *config.Config, value string) error) configKey {
+	return configKey{
+		name: name,
+		get:  func(cfg *config.Config) string { return *field(cfg) },
+		set: func(cfg *config.Config, value string) error {
+			if validate != nil {
+				if err := validate(cfg, value); err != nil {
+					return err
+				}
+			}
+			*field(cfg) = value
+			return nil
+		},
+		isSet: func(layer, _ *config.Config) bool { return *field(layer) != "" },
+	}
+}
+
+// boolKey is a true/false field
+func boolKey(name string, field func(*config.Config) *bool) configKey {
+	return configKey{
+		name: name,
+		get:  func(cfg *config.Config) string { return strconv.FormatBool(*field(cfg)) },
+		set: func(cfg *config.Config, value string) error {
+			switch value {
+			case "true":
+				*field(cfg) = true
+			case "false":
+				*field(cfg) = false
+			default:
+				return fmt.Errorf("%s must be 'true' or 'false', got '%s'", name, value)
+			}
+			return nil
+		},
+		isSet: func(layer, defaults *config.Config) bool { return *field(layer) != *field(defaults) },
+	}
+}
+
+// intKey is an integer field of at least min; unit (if any) is shown when
+// a value isn't a number
+func intKey(name string, field func(*config.Config) *int, min int, unit string) configKey {
+	return configKey{
+		name: name,
+		get:  func(cfg *config.Config) string { return strconv.Itoa(*field(cfg)) },
+		set: func(cfg *config.Config, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				if unit != "" {
+					return fmt.Errorf("%s must be an integer (%s), got '%s'", name, unit, value)
+				}
+				return fmt.Errorf("%s must be an integer, got '%s'", name, value)
+			}
+			if n < min {
+				if min == 0 {
+					return fmt.Errorf("%s must be positive, got %d", name, n)
+				}
+				return fmt.Errorf("%s must be at least %d, got %d", name, min, n)
+			}
+			*field(cfg) = n
+			return nil
+		},
+		isSet: func(layer, _ *config.Config) bool { return *field(layer) != 0 },
+	}
+}
+
+// listKey is a list field written as one sep-separated value; validate (if
+// set) checks new lists
+func listKey(name, sep string, field func(*config.Config) *[]string, validate func([]string) error) configKey {
+	return configKey{
+		name: name,
+		get:  func(cfg *config.Config) string { return strings.Join(*field(cfg), sep) },
+		set: func(cfg *config.Config, value string) error {
+			var items []string
+			if sep == " " {
+				items = strings.Fields(value)
+			} else {
+				for _, item := range strings.Split(value, sep) {
+					if item = strings.TrimSpace(item); item != "" {
+						items = append(items, item)
+					}
+				}
+			}
+			if validate != nil {
+				if err := validate(items); err != nil {
+					return err
+				}
+			}
+			*field(cfg) = items
+			return nil
+		},
+		isSet: func(layer, _ *config.Config) bool { return len(*field(layer)) > 0 },
+	}
+}
+
+// validRegexp checks that a value compiles as a regular expression
+func validRegexp(name string) func(*config.Config, string) error {
+	return func(_ *config.Config, value string) error {
+		if _, err := regexp.Compile(value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		return nil
+	}
+}
+
+// oneOf checks that a value is one of valid
+func oneOf(name, plural string, valid []string) func(*config.Config, string) error {
+	return func(_ *config.Config, value string) error {
+		if !contains(valid, value) {
+			return fmt.Errorf("invalid %s '%s'. Valid %s: %s", name, value, plural, strings.Join(valid, ", "))
+		}
+		return nil
+	}
+}
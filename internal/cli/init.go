@@ -1,17 +1,18 @@
 package cli
 
 import (
-	"bufio"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/adriancodes/gumloop/internal/config"
 	"github.com/adriancodes/gumloop/internal/ui"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 )
 
@@ -20,6 +21,10 @@ var (
 	nonInteractive bool
 	// initGlobal is set by the --global flag
 	initGlobal bool
+	// initModelsTimeout is set by the --models-timeout flag
+	initModelsTimeout time.Duration
+	// initMinimal is set by the --minimal flag
+	initMinimal bool
 )
 
 // initCmd represents the init command
@@ -34,7 +39,9 @@ and optionally creates a PROMPT.md template.
 Use --global to create global config (~/.config/gumloop/config.yaml) instead,
 which applies to all projects that don't have their own .gumloop.yaml.
 
-Use --non-interactive to skip the wizard and use defaults.`,
+Use --non-interactive to skip the wizard and use defaults.
+
+Use --minimal to write only the settings that differ from the defaults.`,
 	RunE: runInit,
 }
 
@@ -42,6 +49,8 @@ func init() {
 	rootCmd.AddCommand(initCmd)
 	initCmd.Flags().BoolVar(&nonInteractive, "non-interactive", false, "Skip wizard, use defaults")
 	initCmd.Flags().BoolVar(&initGlobal, "global", false, "Create global config instead of project config")
+	initCmd.Flags().BoolVar(&initMinimal, "minimal", false, "Only write settings that differ from the defaults")
+	initCmd.Flags().DurationVar(&initModelsTimeout, "models-timeout", ui.DefaultModelsTimeout, "How long to wait for the models.dev model list")
 }
 
 // runInit executes the init command logic
@@ -68,7 +77,7 @@ func runInit(cmd *cobra.Command, args []string) error {
 		} else {
 			fmt.Println(warnStyle.Render("Config file already exists: .gumloop.yaml"))
 		}
-		if !confirmOverwrite() {
+		if !ui.Confirm(promptInput, os.Stdout, "Overwrite?", false) {
 			fmt.Println()
 			fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("No changes made."))
 			fmt.Println()
@@ -83,14 +92,20 @@ func runInit(cmd *cobra.Command, args []string) error {
 		// Use defaults in non-interactive mode
 		defaults := config.Defaults()
 		wizardConfig = &ui.WizardConfig{
-			CLI:          defaults.CLI,
-			Model:        defaults.Model,
-			Verify:       defaults.Verify,
-			CreatePrompt: !initGlobal, // Don't create PROMPT.md for global config
+			CLI:            defaults.CLI,
+			Model:          defaults.Model,
+			Verify:         defaults.Verify,
+			AutoPush:       defaults.AutoPush,
+			StuckThreshold: defaults.StuckThreshold,
+			CreatePrompt:   !initGlobal, // Don't create PROMPT.md for global config
 		}
 	} else {
-		// Launch interactive wizard
-		wizardConfig, err = ui.RunWizard()
+		// Launch interactive wizard (reading models from a local file if configured)
+		defaults := config.Defaults()
+		wizardConfig, err = ui.RunWizard(
+			ui.WizardConfig{AutoPush: defaults.AutoPush, StuckThreshold: defaults.StuckThreshold},
+			ui.ModelSource{File: viper.GetString("models_file"), Timeout: initModelsTimeout},
+		)
 		if err != nil {
 			// Check if user cancelled
 			if errors.Is(err, ui.ErrWizardCancelled) {
@@ -109,15 +124,14 @@ func runInit(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Create config struct from wizard values
-	cfg := config.Config{
-		CLI:            wizardConfig.CLI,
-		Model:          wizardConfig.Model,
-		PromptFile:     "PROMPT.md",           // Always use PROMPT.md
-		AutoPush:       true,                  // Default to auto-push
-		StuckThreshold: 3,                     // Default stuck threshold
-		Verify:         wizardConfig.Verify,
-	}
+	// Create config struct from wizard values, on top of the defaults
+	cfg := config.Defaults()
+	cfg.CLI = wizardConfig.CLI
+	cfg.Model = wizardConfig.Model
+	cfg.PromptFile = "PROMPT.md" // Always use PROMPT.md
+	cfg.Verify = wizardConfig.Verify
+	cfg.AutoPush = wizardConfig.AutoPush
+	cfg.StuckThreshold = wizardConfig.StuckThreshold
 
 	// Write config file
 	if err := writeConfigFileToPath(cfg, configPath); err != nil {
@@ -126,7 +140,7 @@ func runInit(cmd *cobra.Command, args []string) error {
 
 	// Create PROMPT.md if requested (only for project config)
 	if wizardConfig.CreatePrompt && !initGlobal {
-		if err := writePromptTemplate(); err != nil {
+		if err := writePromptTemplate(wizardConfig.Task); err != nil {
 			return fmt.Errorf("failed to write PROMPT.md: %w", err)
 		}
 	}
@@ -182,19 +196,121 @@ func writeConfigFileToPath(cfg config.Config, configPath string) error {
 	}
 
 	// Marshal config to YAML
+	var doc yaml.Node
+	if err := doc.Encode(&cfg); err != nil {
+		return err
+	}
+	if initMinimal {
+		if err := dropDefaultKeys(&doc, config.Defaults()); err != nil {
+			return err
+		}
+		if len(doc.Content) == 0 {
+			_, err := f.WriteString("# Every setting is at its default (see 'gumloop config show')\n")
+			return err
+		}
+	}
+
 	encoder := yaml.NewEncoder(f)
 	encoder.SetIndent(2)
 
-	if err := encoder.Encode(&cfg); err != nil {
+	if err := encoder.Encode(&doc); err != nil {
 		return err
 	}
 
 	return encoder.Close()
 }
 
-// writePromptTemplate writes the default PROMPT.md template
-func writePromptTemplate() error {
-	template := `# Task
+// dropDefaultKeys removes the keys of an encoded config mapping whose values
+// are the same as in defaults, for init --minimal
+func dropDefaultKeys(doc *yaml.Node, defaults config.Config) error {
+	var defaultDoc yaml.Node
+	if err := defaultDoc.Encode(&defaults); err != nil {
+		return err
+	}
+	defaultValues := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(defaultDoc.Content); i += 2 {
+		defaultValues[defaultDoc.Content[i].Value] = defaultDoc.Content[i+1]
+	}
+
+	var kept []*yaml.Node
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key, value := doc.Content[i], doc.Content[i+1]
+		if def, ok := defaultValues[key.Value]; ok && sameYAML(def, value) {
+			continue
+		}
+		kept = append(kept, key, value)
+	}
+	doc.Content = kept
+	return nil
+}
+
+// sameYAML reports whether two nodes encode to the same YAML
+func sameYAML(a, b *yaml.Node) bool {
+	aOut, aErr := yaml.Marshal(a)
+	bOut, bErr := yaml.Marshal(b)
+	return aErr == nil && bErr == nil && string(aOut) == string(bOut)
+}
+
+// promptTemplateFile is the name of the custom PROMPT.md template in the global config dir
+const promptTemplateFile = "prompt-template.md"
+
+// writePromptTemplate writes PROMPT.md from the custom template
+// (~/.config/gumloop/prompt-template.md) if present, otherwise the built-in one.
+// A non-empty task replaces the template's Task section.
+func writePromptTemplate(task string) error {
+	content := loadPromptTemplate()
+	if task != "" {
+		content = replaceTaskSection(content, task)
+	}
+	return os.WriteFile("PROMPT.md", []byte(content), 0644)
+}
+
+// replaceTaskSection replaces the body of the first Task heading with task,
+// adding a Task section at the top if the template has none
+func replaceTaskSection(template, task string) string {
+	lines := strings.Split(template, "\n")
+	start := -1
+	for i, line := range lines {
+		if match := headingPattern.FindStringSubmatch(line); match != nil && strings.EqualFold(match[1], "task") {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return "# Task\n\n" + task + "\n\n" + template
+	}
+
+	// The section runs until the next heading
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		if headingPattern.MatchString(lines[i]) {
+			end = i
+			break
+		}
+	}
+
+	section := []string{lines[start], "", task, ""}
+	result := append(append(lines[:start:start], section...), lines[end:]...)
+	return strings.Join(result, "\n")
+}
+
+// loadPromptTemplate returns the custom prompt template, falling back to the
+// built-in template when it is missing, unreadable or empty
+func loadPromptTemplate() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return defaultPromptTemplate
+	}
+
+	data, err := os.ReadFile(filepath.Join(homeDir, ".config", "gumloop", promptTemplateFile))
+	if err != nil || strings.TrimSpace(string(data)) == "" {
+		return defaultPromptTemplate
+	}
+	return string(data)
+}
+
+// defaultPromptTemplate is the built-in PROMPT.md template
+const defaultPromptTemplate = `# Task
 
 [Describe what you want the agent to do here]
 
@@ -229,9 +345,6 @@ Add new guardrails here when you observe failure patterns.
 Use high numbers for critical rules (e.g., 99999).
 `
 
-	return os.WriteFile("PROMPT.md", []byte(template), 0644)
-}
-
 // printInitSuccessMessage displays the success message with next steps
 func printInitSuccessMessage(configPath string, createdPrompt bool) {
 	successStyle := lipgloss.NewStyle().
@@ -267,17 +380,3 @@ func printInitSuccessMessage(configPath string, createdPrompt bool) {
 	}
 	fmt.Println()
 }
-
-// confirmOverwrite prompts the user to confirm overwriting existing config
-func confirmOverwrite() bool {
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Print("Overwrite? (y/N): ")
-
-	response, err := reader.ReadString('\n')
-	if err != nil {
-		return false
-	}
-
-	response = strings.TrimSpace(strings.ToLower(response))
-	return response == "y" || response == "yes"
-}
@@ -3,6 +3,7 @@ package cli
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/adriancodes/gumloop/internal/config"
@@ -116,7 +117,7 @@ func TestWritePromptTemplate(t *testing.T) {
 	require.NoError(t, os.Chdir(tmpDir))
 
 	// Write template
-	err = writePromptTemplate()
+	err = writePromptTemplate("")
 	require.NoError(t, err)
 
 	// Verify file exists
@@ -137,6 +138,75 @@ func TestWritePromptTemplate(t *testing.T) {
 	assert.Contains(t, contentStr, "Run tests before committing")
 }
 
+func TestWritePromptTemplate_CustomTemplate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".config", "gumloop")
+	require.NoError(t, os.MkdirAll(configDir, 0755))
+
+	custom := "# Aufgabe\n\n[Beschreibe die Aufgabe]\n"
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "prompt-template.md"), []byte(custom), 0644))
+
+	withTempDir(t)
+	require.NoError(t, writePromptTemplate(""))
+
+	content, err := os.ReadFile("PROMPT.md")
+	require.NoError(t, err)
+	assert.Equal(t, custom, string(content))
+}
+
+func TestWritePromptTemplate_BuiltinFallback(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, configDir string)
+	}{
+		{
+			name:  "no custom template",
+			setup: func(t *testing.T, configDir string) {},
+		},
+		{
+			name: "empty custom template",
+			setup: func(t *testing.T, configDir string) {
+				require.NoError(t, os.MkdirAll(configDir, 0755))
+				require.NoError(t, os.WriteFile(filepath.Join(configDir, "prompt-template.md"), []byte("  \n"), 0644))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			tt.setup(t, filepath.Join(home, ".config", "gumloop"))
+
+			withTempDir(t)
+			require.NoError(t, writePromptTemplate(""))
+
+			content, err := os.ReadFile("PROMPT.md")
+			require.NoError(t, err)
+			assert.Equal(t, defaultPromptTemplate, string(content))
+		})
+	}
+}
+
+func TestWritePromptTemplate_Task(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	withTempDir(t)
+
+	require.NoError(t, writePromptTemplate("Fix the login form\n\n- validate email"))
+
+	content, err := os.ReadFile("PROMPT.md")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(content), "# Task\n\nFix the login form\n\n- validate email\n\n# Plan\n"))
+	assert.NotContains(t, string(content), "[Describe what you want the agent to do here]")
+	assert.Contains(t, string(content), "# Rules")
+}
+
+func TestReplaceTaskSection_NoTaskHeading(t *testing.T) {
+	got := replaceTaskSection("# Rules\n\nBe careful.\n", "Fix the build")
+	assert.Equal(t, "# Task\n\nFix the build\n\n# Rules\n\nBe careful.\n", got)
+}
+
 func TestInitCmdNonInteractive(t *testing.T) {
 	// Create temp directory for test
 	tmpDir, err := os.MkdirTemp("", "gumloop-init-test-*")
@@ -325,3 +395,63 @@ func TestWriteConfigFileCreatesValidYAML(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteConfigFileMinimal(t *testing.T) {
+	withTempDir(t)
+	initGlobal = false
+	initMinimal = true
+	defer func() { initMinimal = false }()
+
+	cfg := config.Defaults()
+	cfg.CLI = "codex"
+	cfg.Verify = "go test ./..."
+	cfg.StuckThreshold = 5
+	require.NoError(t, writeConfigFileToPath(cfg, ".gumloop.yaml"))
+
+	data, err := os.ReadFile(".gumloop.yaml")
+	require.NoError(t, err)
+	content := string(data)
+
+	// Overridden keys are written...
+	assert.Contains(t, content, "cli: codex\n")
+	assert.Contains(t, content, "stuck_threshold: 5\n")
+	assert.Contains(t, content, "verify: go test ./...\n")
+
+	// ...and default-valued ones aren't
+	for _, key := range []string{"model:", "prompt_file:", "auto_push:", "max_no_change:", "rate_limit_wait:", "memory:", "show_banner:", "theme:"} {
+		assert.NotContains(t, content, key)
+	}
+
+	var parsed config.Config
+	require.NoError(t, yaml.Unmarshal(data, &parsed))
+	assert.Equal(t, "codex", parsed.CLI)
+	assert.Equal(t, 5, parsed.StuckThreshold)
+}
+
+func TestWriteConfigFileMinimal_AllDefaults(t *testing.T) {
+	withTempDir(t)
+	initGlobal = false
+	initMinimal = true
+	defer func() { initMinimal = false }()
+
+	require.NoError(t, writeConfigFileToPath(config.Defaults(), ".gumloop.yaml"))
+
+	data, err := os.ReadFile(".gumloop.yaml")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# Every setting is at its default")
+
+	var parsed config.Config
+	assert.NoError(t, yaml.Unmarshal(data, &parsed))
+}
+
+func TestInitCmdKeepsBannerDefault(t *testing.T) {
+	withTempDir(t)
+	nonInteractive = true
+	defer func() { nonInteractive = false }()
+
+	require.NoError(t, runInit(nil, []string{}))
+
+	data, err := os.ReadFile(".gumloop.yaml")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "show_banner: true")
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/adriancodes/gumloop/internal/agent"
+)
+
+// interactiveCommand builds the command that starts the agent's own
+// interactive UI, wired straight to the terminal. No adapter sits between
+// the agent and the user, so none of the print-mode or structured output
+// flags of BuildCommand are used.
+func interactiveCommand(ag *agent.Agent, prompt, model, workDir string) (*exec.Cmd, error) {
+	if ag.PromptStyle == agent.PromptStylePipe {
+		return nil, fmt.Errorf("--interactive is not supported for %s: it reads the prompt from stdin", ag.Name)
+	}
+	if ag.NoTerminalPrompt {
+		return nil, fmt.Errorf("--interactive is not supported for %s: its interactive mode can't be started with a prompt", ag.Name)
+	}
+
+	args := ag.BuildTerminalCommand(prompt, model)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("agent BuildTerminalCommand returned empty command")
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = workDir
+	cmd.Env = ag.BuildEnv(os.Environ())
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd, nil
+}
+
+// execInteractive runs the agent once in interactive mode and returns its
+// exit code
+func execInteractive(ag *agent.Agent, prompt, model, workDir string) (int, error) {
+	cmd, err := interactiveCommand(ag, prompt, model, workDir)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), nil
+		}
+		return 0, fmt.Errorf("failed to run %s: %w", ag.Name, err)
+	}
+	return 0, nil
+}
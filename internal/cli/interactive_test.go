@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/adriancodes/gumloop/internal/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInteractiveCommand(t *testing.T) {
+	ag, err := agent.GetAgent("gemini")
+	require.NoError(t, err)
+
+	cmd, err := interactiveCommand(ag, "Fix the tests", "pro", "/tmp")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"gemini", "--model", "pro", "-i", "Fix the tests"}, cmd.Args)
+	assert.Equal(t, "/tmp", cmd.Dir)
+
+	// The agent talks to the terminal directly, not through an adapter pipe
+	assert.Equal(t, os.Stdin, cmd.Stdin)
+	assert.Equal(t, os.Stdout, cmd.Stdout)
+	assert.Equal(t, os.Stderr, cmd.Stderr)
+}
+
+func TestInteractiveCommand_NoPrintModeFlags(t *testing.T) {
+	tests := []struct {
+		id       string
+		expected []string
+	}{
+		{"claude", []string{"claude", "--model", "sonnet", "Fix the tests"}},
+		{"codex", []string{"codex", "--model", "sonnet", "Fix the tests"}},
+		{"cursor", []string{"cursor-agent", "--model", "sonnet", "Fix the tests"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			ag, err := agent.GetAgent(tt.id)
+			require.NoError(t, err)
+
+			cmd, err := interactiveCommand(ag, "Fix the tests", "sonnet", "")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cmd.Args)
+		})
+	}
+}
+
+func TestInteractiveCommand_NoTerminalPrompt(t *testing.T) {
+	ag, err := agent.GetAgent("opencode")
+	require.NoError(t, err)
+
+	_, err = interactiveCommand(ag, "prompt", "", "")
+	assert.Error(t, err)
+}
+
+func TestInteractiveCommand_PipeStyle(t *testing.T) {
+	ag := &agent.Agent{Name: "Piped", Command: "cat", PromptStyle: agent.PromptStylePipe}
+
+	_, err := interactiveCommand(ag, "prompt", "", "")
+	assert.Error(t, err)
+}
+
+func TestExecInteractive_ExitCode(t *testing.T) {
+	ag := &agent.Agent{Name: "Shell", Command: "sh", TerminalFlags: []string{"-c"}, PromptStyle: agent.PromptStyleArg}
+
+	code, err := execInteractive(ag, "exit 4", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, 4, code)
+}
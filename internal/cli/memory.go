@@ -3,7 +3,10 @@ package cli
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/adriancodes/gumloop/internal/git"
 	"github.com/adriancodes/gumloop/internal/memory"
 	"github.com/spf13/cobra"
 )
@@ -37,10 +40,58 @@ var memoryClearCmd = &cobra.Command{
 	RunE:  runMemoryClear,
 }
 
+// memoryNoteCmd sets the remaining-work note for the next session
+var memoryNoteCmd = &cobra.Command{
+	Use:   "note <text>",
+	Short: "Leave a note for the next session",
+	Long: `Set the "remaining" field of the session memory file without running the agent.
+
+The note is injected into the next run's prompt when memory is enabled.
+Creates the memory file if it doesn't exist yet.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runMemoryNote,
+}
+
+// memoryDiffCmd compares the current session memory with the previous one
+var memoryDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare session memory with the previous session",
+	Long: `Show how the current session memory differs from the previous session.
+
+The previous session's memory is kept in .gumloop-memory.prev.yaml, which
+gumloop run writes at the start of each session with memory enabled.`,
+	Args: cobra.NoArgs,
+	RunE: runMemoryDiff,
+}
+
+// memoryPruneCmd trims the commit log
+var memoryPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Trim old commits from session memory",
+	Long: `Keep only the most recent commits in the session memory's commit log.
+
+The iteration and commit counts are kept unless --reset-counters is given,
+which sets both to zero. Useful before sharing the memory file. The commits
+and note that remain are still passed to the next session.`,
+	Args: cobra.NoArgs,
+	RunE: runMemoryPrune,
+}
+
+var (
+	memoryPruneKeep  int
+	memoryPruneReset bool
+)
+
 func init() {
 	rootCmd.AddCommand(memoryCmd)
 	memoryCmd.AddCommand(memoryShowCmd)
 	memoryCmd.AddCommand(memoryClearCmd)
+	memoryCmd.AddCommand(memoryNoteCmd)
+	memoryCmd.AddCommand(memoryDiffCmd)
+	memoryCmd.AddCommand(memoryPruneCmd)
+
+	memoryPruneCmd.Flags().IntVar(&memoryPruneKeep, "keep", 5, "Number of most recent commits to keep")
+	memoryPruneCmd.Flags().BoolVar(&memoryPruneReset, "reset-counters", false, "Also set the iteration and commit counts to zero")
 }
 
 func runMemoryShow(cmd *cobra.Command, args []string) error {
@@ -60,10 +111,17 @@ func runMemoryShow(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  Started:    %s\n", mem.StartedAt.Format("2006-01-02 15:04:05 MST"))
 	fmt.Printf("  Branch:     %s\n", mem.Branch)
 	fmt.Printf("  Agent:      %s\n", mem.AgentName)
+	if mem.Model != "" {
+		fmt.Printf("  Model:      %s\n", mem.Model)
+	}
 	fmt.Printf("  Iterations: %d\n", mem.Iterations)
 	fmt.Printf("  Commits:    %d\n", mem.Commits)
 	if mem.ExitReason != "" {
-		fmt.Printf("  Exit:       %s\n", mem.ExitReason)
+		if mem.ExitCode != nil {
+			fmt.Printf("  Exit:       %s (code %d)\n", mem.ExitReason, *mem.ExitCode)
+		} else {
+			fmt.Printf("  Exit:       %s\n", mem.ExitReason)
+		}
 	}
 
 	if len(mem.CommitLog) > 0 {
@@ -96,3 +154,101 @@ func runMemoryClear(cmd *cobra.Command, args []string) error {
 	fmt.Println("Session memory cleared.")
 	return nil
 }
+
+func runMemoryNote(cmd *cobra.Command, args []string) error {
+	note := strings.TrimSpace(strings.Join(args, " "))
+	if note == "" {
+		return fmt.Errorf("note cannot be empty")
+	}
+
+	mem, err := memory.Load(memory.DefaultFileName)
+	if err != nil {
+		return fmt.Errorf("failed to load session memory: %w", err)
+	}
+	if mem == nil {
+		mem = &memory.SessionMemory{StartedAt: time.Now()}
+	}
+
+	mem.Remaining = note
+	if err := mem.Save(memory.DefaultFileName); err != nil {
+		return fmt.Errorf("failed to save session memory: %w", err)
+	}
+
+	fmt.Println("Session memory note saved.")
+	return nil
+}
+
+func runMemoryDiff(cmd *cobra.Command, args []string) error {
+	cur, err := memory.Load(memory.DefaultFileName)
+	if err != nil {
+		return fmt.Errorf("failed to load session memory: %w", err)
+	}
+	if cur == nil {
+		fmt.Println("No session memory found.")
+		return nil
+	}
+
+	// The previous session's memory is kept in the git directory, so
+	// outside a repository there is none
+	var prev *memory.SessionMemory
+	if prevPath, err := git.GitPath(memory.PrevFileName); err == nil {
+		prev, err = memory.Load(prevPath)
+		if err != nil {
+			return fmt.Errorf("failed to load previous session memory: %w", err)
+		}
+	}
+	if prev == nil {
+		fmt.Println("No previous session to compare with.")
+		return nil
+	}
+
+	d := memory.Compare(prev, cur)
+
+	fmt.Println("Session Memory Diff (previous → current)")
+	fmt.Println()
+	if len(d.Changed) == 0 {
+		fmt.Println("  No changes.")
+	}
+	for _, c := range d.Changed {
+		fmt.Printf("  %-11s %s → %s\n", c.Field+":", formatValue(c.Prev), formatValue(c.Cur))
+	}
+
+	if len(d.NewCommits) > 0 {
+		fmt.Println()
+		fmt.Println("New commits:")
+		for _, c := range d.NewCommits {
+			fmt.Printf("  + %s  %s\n", c.Hash, c.Message)
+		}
+	}
+
+	return nil
+}
+
+func runMemoryPrune(cmd *cobra.Command, args []string) error {
+	if memoryPruneKeep < 0 {
+		return fmt.Errorf("--keep must be non-negative, got %d", memoryPruneKeep)
+	}
+
+	mem, err := memory.Load(memory.DefaultFileName)
+	if err != nil {
+		return fmt.Errorf("failed to load session memory: %w", err)
+	}
+	if mem == nil {
+		fmt.Println("No session memory found.")
+		return nil
+	}
+
+	removed := mem.Prune(memoryPruneKeep)
+	if memoryPruneReset {
+		mem.ResetCounters()
+	}
+	if err := mem.Save(memory.DefaultFileName); err != nil {
+		return fmt.Errorf("failed to save session memory: %w", err)
+	}
+
+	fmt.Printf("Removed %d commit(s) from session memory, kept %d.\n", removed, len(mem.CommitLog))
+	if memoryPruneReset {
+		fmt.Println("Iteration and commit counts reset.")
+	}
+	return nil
+}
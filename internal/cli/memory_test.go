@@ -3,6 +3,7 @@ package cli
 import (
 	"bytes"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 	"time"
@@ -23,6 +24,16 @@ func withTempDir(t *testing.T) string {
 	return dir
 }
 
+// withTempRepo changes into a new git repository for the rest of the test
+func withTempRepo(t *testing.T) string {
+	t.Helper()
+	dir := withTempDir(t)
+	for _, args := range [][]string{{"init", "-q"}, {"config", "user.email", "test@example.com"}, {"config", "user.name", "Test User"}} {
+		require.NoError(t, exec.Command("git", args...).Run())
+	}
+	return dir
+}
+
 // captureStdout runs fn while capturing os.Stdout and returns the output.
 func captureStdout(t *testing.T, fn func()) string {
 	t.Helper()
@@ -65,13 +76,13 @@ func TestMemoryShow_WithFullMemory(t *testing.T) {
 		AgentName:  "Claude Code",
 		Iterations: 7,
 		Commits:    5,
-		ExitReason: "Max iterations reached",
 		CommitLog: []memory.CommitRecord{
 			{Hash: "a1b2c3d", Message: "Add JWT middleware and token validation"},
 			{Hash: "d4e5f6g", Message: "Add auth routes and login handler"},
 		},
 		Remaining: "Refresh token rotation has not been implemented yet.",
 	}
+	mem.SetExit("Max iterations reached", 3)
 	require.NoError(t, mem.Save(filepath.Join(dir, memory.DefaultFileName)))
 
 	output := captureStdout(t, func() {
@@ -88,7 +99,7 @@ func TestMemoryShow_WithFullMemory(t *testing.T) {
 	assert.Contains(t, output, "Agent:      Claude Code")
 	assert.Contains(t, output, "Iterations: 7")
 	assert.Contains(t, output, "Commits:    5")
-	assert.Contains(t, output, "Exit:       Max iterations reached")
+	assert.Contains(t, output, "Exit:       Max iterations reached (code 3)")
 
 	// Commit log section — use "\nCommits:\n" to match the section header specifically
 	assert.Contains(t, output, "\nCommits:\n")
@@ -100,6 +111,21 @@ func TestMemoryShow_WithFullMemory(t *testing.T) {
 	assert.Contains(t, output, "Refresh token rotation has not been implemented yet.")
 }
 
+func TestMemoryShow_WithoutExitCode(t *testing.T) {
+	dir := withTempDir(t)
+
+	// Written before exit codes were recorded
+	content := "branch: main\nagent: Claude Code\niterations: 2\nexit_reason: Interrupted\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, memory.DefaultFileName), []byte(content), 0644))
+
+	output := captureStdout(t, func() {
+		assert.NoError(t, runMemoryShow(nil, nil))
+	})
+
+	assert.Contains(t, output, "Exit:       Interrupted\n")
+	assert.NotContains(t, output, "(code")
+}
+
 func TestMemoryShow_MinimalMemory(t *testing.T) {
 	// Memory with only required fields — no ExitReason, no CommitLog, no Remaining.
 	// Exercises the 3 negative conditional branches in runMemoryShow.
@@ -215,6 +241,58 @@ func TestMemoryClear_Idempotent(t *testing.T) {
 	assert.Contains(t, output2, "No session memory to clear.")
 }
 
+// --- memory note ---
+
+func TestMemoryNote_ExistingMemory(t *testing.T) {
+	dir := withTempDir(t)
+
+	mem := &memory.SessionMemory{Branch: "main", AgentName: "Claude Code", Iterations: 4, Commits: 2}
+	path := filepath.Join(dir, memory.DefaultFileName)
+	require.NoError(t, mem.Save(path))
+
+	output := captureStdout(t, func() {
+		err := runMemoryNote(nil, []string{"Finish", "the OAuth callback"})
+		assert.NoError(t, err)
+	})
+	assert.Contains(t, output, "Session memory note saved.")
+
+	// Note persisted, other fields untouched
+	loaded, err := memory.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Finish the OAuth callback", loaded.Remaining)
+	assert.Equal(t, "main", loaded.Branch)
+	assert.Equal(t, 4, loaded.Iterations)
+
+	// Visible in memory show
+	output = captureStdout(t, func() {
+		err := runMemoryShow(nil, nil)
+		assert.NoError(t, err)
+	})
+	assert.Contains(t, output, "Remaining:")
+	assert.Contains(t, output, "Finish the OAuth callback")
+}
+
+func TestMemoryNote_NoFile(t *testing.T) {
+	dir := withTempDir(t)
+
+	captureStdout(t, func() {
+		err := runMemoryNote(nil, []string{"Start with the parser"})
+		assert.NoError(t, err)
+	})
+
+	loaded, err := memory.Load(filepath.Join(dir, memory.DefaultFileName))
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+	assert.Equal(t, "Start with the parser", loaded.Remaining)
+}
+
+func TestMemoryNote_Empty(t *testing.T) {
+	withTempDir(t)
+
+	err := runMemoryNote(nil, []string{"  "})
+	assert.Error(t, err)
+}
+
 // --- command registration ---
 
 func TestMemoryCommandRegistration(t *testing.T) {
@@ -231,8 +309,114 @@ func TestMemoryCommandRegistration(t *testing.T) {
 			}
 			assert.True(t, subNames["show"], "memory should have 'show' subcommand")
 			assert.True(t, subNames["clear"], "memory should have 'clear' subcommand")
+			assert.True(t, subNames["note"], "memory should have 'note' subcommand")
 			break
 		}
 	}
 	assert.True(t, found, "'memory' command should be registered on rootCmd")
 }
+
+// --- memory diff ---
+
+func TestMemoryDiff(t *testing.T) {
+	dir := withTempDir(t)
+
+	prev := &memory.SessionMemory{
+		Iterations: 3,
+		Commits:    1,
+		ExitReason: "Max iterations reached",
+		CommitLog:  []memory.CommitRecord{{Hash: "aaa1111", Message: "Add JWT middleware"}},
+	}
+	require.NoError(t, exec.Command("git", "init", "-q").Run())
+	require.NoError(t, prev.Save(filepath.Join(dir, ".git", memory.PrevFileName)))
+
+	cur := &memory.SessionMemory{
+		Iterations: 5,
+		Commits:    3,
+		ExitReason: "Complete (no changes)",
+		CommitLog: []memory.CommitRecord{
+			{Hash: "ccc3333", Message: "Add logout"},
+			{Hash: "bbb2222", Message: "Add login handler"},
+			{Hash: "aaa1111", Message: "Add JWT middleware"},
+		},
+	}
+	require.NoError(t, cur.Save(filepath.Join(dir, memory.DefaultFileName)))
+
+	output := captureStdout(t, func() {
+		assert.NoError(t, runMemoryDiff(nil, nil))
+	})
+
+	assert.Contains(t, output, "Iterations: 3 → 5\n")
+	assert.Contains(t, output, "Commits:    1 → 3\n")
+	assert.Contains(t, output, "Exit:       Max iterations reached → Complete (no changes)")
+	assert.NotContains(t, output, "Branch:", "unchanged fields aren't shown")
+	assert.Contains(t, output, "+ ccc3333  Add logout")
+	assert.Contains(t, output, "+ bbb2222  Add login handler")
+	assert.NotContains(t, output, "aaa1111")
+}
+
+func TestMemoryDiff_NoPrevious(t *testing.T) {
+	dir := withTempDir(t)
+	require.NoError(t, (&memory.SessionMemory{Iterations: 1}).Save(filepath.Join(dir, memory.DefaultFileName)))
+
+	output := captureStdout(t, func() {
+		assert.NoError(t, runMemoryDiff(nil, nil))
+	})
+	assert.Contains(t, output, "No previous session to compare with.")
+}
+
+// --- memory prune ---
+
+func TestMemoryPrune(t *testing.T) {
+	dir := withTempDir(t)
+	path := filepath.Join(dir, memory.DefaultFileName)
+
+	mem := &memory.SessionMemory{
+		Iterations: 9,
+		Commits:    4,
+		CommitLog: []memory.CommitRecord{
+			{Hash: "d4", Message: "Fourth"},
+			{Hash: "c3", Message: "Third"},
+			{Hash: "b2", Message: "Second"},
+			{Hash: "a1", Message: "First"},
+		},
+	}
+	require.NoError(t, mem.Save(path))
+
+	memoryPruneKeep, memoryPruneReset = 2, false
+	defer func() { memoryPruneKeep, memoryPruneReset = 5, false }()
+	output := captureStdout(t, func() {
+		assert.NoError(t, runMemoryPrune(nil, nil))
+	})
+	assert.Contains(t, output, "Removed 2 commit(s) from session memory, kept 2.")
+
+	loaded, err := memory.Load(path)
+	require.NoError(t, err)
+	require.Len(t, loaded.CommitLog, 2)
+	assert.Equal(t, "d4", loaded.CommitLog[0].Hash)
+	assert.Equal(t, "c3", loaded.CommitLog[1].Hash)
+	assert.Equal(t, 9, loaded.Iterations, "counters are kept by default")
+	assert.Equal(t, 4, loaded.Commits)
+
+	// --reset-counters zeroes them
+	memoryPruneReset = true
+	output = captureStdout(t, func() {
+		assert.NoError(t, runMemoryPrune(nil, nil))
+	})
+	assert.Contains(t, output, "Iteration and commit counts reset.")
+
+	loaded, err = memory.Load(path)
+	require.NoError(t, err)
+	assert.Len(t, loaded.CommitLog, 2)
+	assert.Equal(t, 0, loaded.Iterations)
+	assert.Equal(t, 0, loaded.Commits)
+}
+
+func TestMemoryPrune_NoFile(t *testing.T) {
+	withTempDir(t)
+
+	output := captureStdout(t, func() {
+		assert.NoError(t, runMemoryPrune(nil, nil))
+	})
+	assert.Contains(t, output, "No session memory found.")
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// promptCmd groups commands that work on the prompt file
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Work with the prompt file",
+	Long:  `Commands for checking the prompt file before a run.`,
+}
+
+// promptLintCmd checks the prompt file for leftover template text
+var promptLintCmd = &cobra.Command{
+	Use:   "lint [file]",
+	Short: "Check the prompt file for common mistakes",
+	Long: `Scan the prompt file for things that trip up a run:
+
+  - Template placeholders left in place, like "(your first task)" or
+    "[Describe what you want the agent to do here]"
+  - TODO and FIXME markers
+  - An empty Task section
+
+The file defaults to the configured prompt_file (PROMPT.md).
+Exits non-zero if any problems are found.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runPromptLint,
+}
+
+func init() {
+	rootCmd.AddCommand(promptCmd)
+	promptCmd.AddCommand(promptLintCmd)
+}
+
+var (
+	// headingPattern matches a markdown heading and captures its text
+	headingPattern = regexp.MustCompile(`^#+\s*(.*?)\s*$`)
+
+	// placeholderItemPattern matches a task list item whose text is only a
+	// parenthesized placeholder: "- [ ] (your first task)"
+	placeholderItemPattern = regexp.MustCompile(`^\s*[-*+]\s+\[[ xX]\]\s+(\(.*\))\s*$`)
+
+	// placeholderLinePattern matches a line that is only a bracketed
+	// placeholder: "[Describe what you want the agent to do here]"
+	placeholderLinePattern = regexp.MustCompile(`^\s*(\[[^\]]{3,}\])\s*$`)
+
+	// todoPattern matches TODO and FIXME markers, but not the word "TODOs"
+	todoPattern = regexp.MustCompile(`\b(TODO|FIXME)\b`)
+)
+
+// lintWarning is a problem found in a prompt
+type lintWarning struct {
+	Line    int
+	Message string
+}
+
+// lintPrompt returns a warning for each problem found in a prompt
+func lintPrompt(content string) []lintWarning {
+	var warnings []lintWarning
+	inTask := false
+	taskLine := 0
+	taskHasContent := false
+
+	endTask := func() {
+		if inTask && !taskHasContent {
+			warnings = append(warnings, lintWarning{taskLine, "Task section is empty"})
+		}
+		inTask = false
+	}
+
+	for i, line := range strings.Split(content, "\n") {
+		n := i + 1
+
+		if match := headingPattern.FindStringSubmatch(line); match != nil {
+			endTask()
+			if strings.EqualFold(match[1], "task") {
+				inTask = true
+				taskLine = n
+				taskHasContent = false
+			}
+			continue
+		}
+		if inTask && strings.TrimSpace(line) != "" {
+			taskHasContent = true
+		}
+
+		if match := placeholderItemPattern.FindStringSubmatch(line); match != nil {
+			warnings = append(warnings, lintWarning{n, "placeholder plan item " + match[1]})
+		} else if match := placeholderLinePattern.FindStringSubmatch(line); match != nil {
+			warnings = append(warnings, lintWarning{n, "placeholder text " + match[1]})
+		}
+		if match := todoPattern.FindString(line); match != "" {
+			warnings = append(warnings, lintWarning{n, match + " marker"})
+		}
+	}
+	endTask()
+
+	return warnings
+}
+
+func runPromptLint(cmd *cobra.Command, args []string) error {
+	path := viper.GetString("prompt_file")
+	if path == "" {
+		path = "PROMPT.md"
+	}
+	if len(args) > 0 {
+		path = args[0]
+	} else if viper.GetBool("prompt_search") {
+		path = findPromptFile(path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read prompt file: %w", err)
+	}
+
+	warnings := lintPrompt(string(content))
+	if len(warnings) == 0 {
+		fmt.Printf("✅ %s looks good\n", path)
+		return nil
+	}
+
+	for _, w := range warnings {
+		fmt.Printf("⚠️  %s:%d: %s\n", path, w.Line, w.Message)
+	}
+	return fmt.Errorf("%d problem(s) found in %s", len(warnings), path)
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cleanPrompt = `# Task
+
+Add input validation to the login form.
+
+# Plan
+
+- [x] Validate the email field
+- [ ] Validate the password field
+
+# Rules
+
+ONE task per session. No placeholders or TODOs.
+`
+
+func TestLintPrompt_Template(t *testing.T) {
+	warnings := lintPrompt(defaultPromptTemplate)
+
+	var messages []string
+	for _, w := range warnings {
+		messages = append(messages, w.Message)
+	}
+	assert.Contains(t, messages, "placeholder text [Describe what you want the agent to do here]")
+	assert.Contains(t, messages, "placeholder plan item (your first task)")
+	assert.Contains(t, messages, "placeholder plan item (your second task)")
+}
+
+func TestLintPrompt_Clean(t *testing.T) {
+	assert.Empty(t, lintPrompt(cleanPrompt))
+}
+
+func TestLintPrompt_TodoAndEmptyTask(t *testing.T) {
+	prompt := "# Task\n\n# Plan\n\n- [ ] Wire up the API (TODO: pick a client)\n"
+
+	assert.Equal(t, []lintWarning{
+		{Line: 1, Message: "Task section is empty"},
+		{Line: 5, Message: "TODO marker"},
+	}, lintPrompt(prompt))
+}
+
+func TestPromptLint_Command(t *testing.T) {
+	withTempDir(t)
+	viper.Reset()
+
+	require.NoError(t, os.WriteFile("PROMPT.md", []byte(defaultPromptTemplate), 0644))
+	var err error
+	output := captureStdout(t, func() { err = runPromptLint(nil, nil) })
+	assert.Error(t, err)
+	assert.Contains(t, output, "PROMPT.md:14: placeholder plan item (your first task)")
+
+	require.NoError(t, os.WriteFile("CLEAN.md", []byte(cleanPrompt), 0644))
+	output = captureStdout(t, func() { err = runPromptLint(nil, []string{"CLEAN.md"}) })
+	assert.NoError(t, err)
+	assert.Contains(t, output, "CLEAN.md looks good")
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/adriancodes/gumloop/internal/git"
+	"github.com/adriancodes/gumloop/internal/github"
+	"github.com/adriancodes/gumloop/internal/logging"
+)
+
+// promptCacheDir holds remote prompts saved by --prompt-cache. It lives in
+// the git directory, so a cached prompt never counts as a working tree change.
+const promptCacheDir = "gumloop/prompt-cache"
+
+// fetchIssuePrompt fetches a GitHub issue as a prompt (a var so tests can
+// count fetches)
+var fetchIssuePrompt = func(ref github.IssueRef) (string, error) {
+	issue, err := github.FetchIssue(ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch issue %s: %w", ref, err)
+	}
+	return issue.Prompt(), nil
+}
+
+// promptCachePath returns the cache file for a remote prompt source
+func promptCachePath(source string) (string, error) {
+	dir, err := git.GitPath(promptCacheDir)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(source))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".md"), nil
+}
+
+// cachedPrompt returns the cached prompt for source, or calls fetch and
+// caches the result. With refresh, the cached copy is ignored and replaced.
+// A cache that can't be written only costs a warning.
+func cachedPrompt(source string, refresh bool, fetch func() (string, error)) (string, error) {
+	path, pathErr := promptCachePath(source)
+	if pathErr == nil && !refresh {
+		if content, err := os.ReadFile(path); err == nil {
+			logging.Infof("Using cached prompt for %s (--refresh-prompt to fetch it again)", source)
+			return string(content), nil
+		}
+	}
+
+	prompt, err := fetch()
+	if err != nil {
+		return "", err
+	}
+
+	if pathErr != nil {
+		logging.Warnf("Can't cache the prompt: %v", pathErr)
+	} else if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		logging.Warnf("Failed to create prompt cache: %v", err)
+	} else if err := os.WriteFile(path, []byte(prompt), 0644); err != nil {
+		logging.Warnf("Failed to cache prompt: %v", err)
+	}
+	return prompt, nil
+}
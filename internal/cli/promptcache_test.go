@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/adriancodes/gumloop/internal/agent"
+	"github.com/adriancodes/gumloop/internal/config"
+	"github.com/adriancodes/gumloop/internal/git"
+	"github.com/adriancodes/gumloop/internal/github"
+	"github.com/adriancodes/gumloop/internal/runner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingIssueFetcher replaces fetchIssuePrompt for the rest of the test
+// and returns the number of fetches made so far
+func countingIssueFetcher(t *testing.T, prompt string) *int {
+	t.Helper()
+	fetches := 0
+	orig := fetchIssuePrompt
+	fetchIssuePrompt = func(ref github.IssueRef) (string, error) {
+		fetches++
+		return prompt, nil
+	}
+	t.Cleanup(func() { fetchIssuePrompt = orig })
+	return &fetches
+}
+
+func TestIssuePrompt_CacheHit(t *testing.T) {
+	withTempRepo(t)
+	fetches := countingIssueFetcher(t, "# Paginate the user list\n")
+
+	runCache = true
+	defer func() { runCache = false }()
+
+	prompt, err := issuePrompt("acme/app#12")
+	require.NoError(t, err)
+	assert.Equal(t, "# Paginate the user list\n", prompt)
+	assert.Equal(t, 1, *fetches)
+
+	// Same issue by URL: served from the cache
+	prompt, err = issuePrompt("https://github.com/acme/app/issues/12")
+	require.NoError(t, err)
+	assert.Equal(t, "# Paginate the user list\n", prompt)
+	assert.Equal(t, 1, *fetches)
+
+	// A different issue is fetched
+	_, err = issuePrompt("acme/app#13")
+	require.NoError(t, err)
+	assert.Equal(t, 2, *fetches)
+}
+
+func TestIssuePrompt_RefreshPrompt(t *testing.T) {
+	withTempRepo(t)
+	fetches := countingIssueFetcher(t, "# New title\n")
+	path, err := promptCachePath("acme/app#12")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("# Old title\n"), 0644))
+
+	runRefresh = true
+	defer func() { runRefresh = false }()
+
+	prompt, err := issuePrompt("acme/app#12")
+	require.NoError(t, err)
+	assert.Equal(t, "# New title\n", prompt)
+	assert.Equal(t, 1, *fetches)
+
+	// The cache was updated
+	cached, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# New title\n", string(cached))
+}
+
+func TestIssuePrompt_NoCache(t *testing.T) {
+	withTempRepo(t)
+	fetches := countingIssueFetcher(t, "# Paginate the user list\n")
+
+	for i := 0; i < 2; i++ {
+		_, err := issuePrompt("acme/app#12")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, *fetches)
+	dir, err := git.GitPath(promptCacheDir)
+	require.NoError(t, err)
+	assert.NoDirExists(t, dir)
+}
+
+func TestCachedPrompt_FetchError(t *testing.T) {
+	withTempRepo(t)
+
+	_, err := cachedPrompt("acme/app#12", false, func() (string, error) {
+		return "", errors.New("rate limited")
+	})
+	assert.EqualError(t, err, "rate limited")
+	path, err := promptCachePath("acme/app#12")
+	require.NoError(t, err)
+	assert.NoFileExists(t, path)
+}
+
+func TestCachedPrompt_TreeStaysClean(t *testing.T) {
+	withTempRepo(t)
+	require.NoError(t, exec.Command("git", "commit", "-q", "--allow-empty", "-m", "initial").Run())
+
+	prompt, err := cachedPrompt("acme/app#12", false, func() (string, error) {
+		return "# Paginate the user list\n", nil
+	})
+	require.NoError(t, err)
+
+	changed, err := git.HasChanges()
+	require.NoError(t, err)
+	assert.False(t, changed, "a cached prompt isn't a working tree change")
+
+	// An agent that changes nothing ends the loop as complete, not stuck
+	shell := &agent.Agent{ID: "shell", Name: "Shell", Command: "sh", AutonomousFlags: []string{"-c"}, PromptStyle: agent.PromptStyleArg}
+	r := runner.New(&config.Config{StuckThreshold: 3}, prompt+"\necho nothing left to do", shell, true, 3, nil)
+	var exitCode runner.ExitCode
+	captureStdout(t, func() { exitCode = r.Run() })
+	assert.Equal(t, runner.ExitSuccess, exitCode)
+	assert.Equal(t, 1, r.GetMetrics().Iterations)
+}
@@ -1,7 +1,7 @@
 package cli
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -13,6 +13,17 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	// recoverTo is set by the --to flag
+	recoverTo string
+
+	// recoverPick is set by the --pick flag
+	recoverPick bool
+)
+
+// recoverPickCount is how many recent commits --pick offers
+const recoverPickCount = 30
+
 // recoverCmd represents the recover command
 var recoverCmd = &cobra.Command{
 	Use:   "recover [N]",
@@ -25,15 +36,25 @@ With no arguments:
 With N:
   Resets the last N commits (git reset --hard HEAD~N)
 
+With --to <ref>:
+  Resets to the given commit, which must be an ancestor of HEAD
+
+With --pick:
+  Lists recent commits to choose the one to reset to
+
 Examples:
-  gumloop recover       # Discard uncommitted changes
-  gumloop recover 3     # Reset last 3 commits`,
+  gumloop recover             # Discard uncommitted changes
+  gumloop recover 3           # Reset last 3 commits
+  gumloop recover --to a1b2c3 # Reset back to commit a1b2c3
+  gumloop recover --pick      # Choose the commit to reset to from a list`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runRecover,
 }
 
 func init() {
 	rootCmd.AddCommand(recoverCmd)
+	recoverCmd.Flags().StringVar(&recoverTo, "to", "", "Reset to this commit (must be an ancestor of HEAD)")
+	recoverCmd.Flags().BoolVar(&recoverPick, "pick", false, "Choose the commit to reset to from a list of recent commits")
 }
 
 func runRecover(cmd *cobra.Command, args []string) error {
@@ -42,6 +63,22 @@ func runRecover(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("not in a git repository")
 	}
 
+	// Pick the commit to reset to
+	if recoverPick {
+		if len(args) > 0 || recoverTo != "" {
+			return fmt.Errorf("cannot combine --pick with N or --to")
+		}
+		return recoverPickCommit()
+	}
+
+	// Reset to a specific ref
+	if recoverTo != "" {
+		if len(args) > 0 {
+			return fmt.Errorf("cannot combine N with --to")
+		}
+		return recoverToRef(recoverTo)
+	}
+
 	// Determine mode: discard changes or reset commits
 	if len(args) == 0 {
 		return recoverDiscardChanges()
@@ -96,7 +133,11 @@ func recoverDiscardChanges() error {
 	fmt.Println()
 
 	// Confirm with user
-	if !confirmAction("Discard all changes?") {
+	if !ui.Confirm(promptInput, os.Stdout, "Discard all changes?", false) {
+		fmt.Println("Cancelled.")
+		return nil
+	}
+	if !confirmSubmodules() {
 		fmt.Println("Cancelled.")
 		return nil
 	}
@@ -151,7 +192,11 @@ func recoverResetCommits(n int) error {
 	fmt.Println()
 
 	// Confirm with user
-	if !confirmAction(fmt.Sprintf("Reset last %d commit(s)?", n)) {
+	if !ui.Confirm(promptInput, os.Stdout, fmt.Sprintf("Reset last %d commit(s)?", n), false) {
+		fmt.Println("Cancelled.")
+		return nil
+	}
+	if !confirmSubmodules() {
 		fmt.Println("Cancelled.")
 		return nil
 	}
@@ -168,10 +213,138 @@ func recoverResetCommits(n int) error {
 	return nil
 }
 
+// recoverToRef resets the branch back to ref, which must be an ancestor of HEAD
+func recoverToRef(ref string) error {
+	// Validate the ref
+	hash, err := git.ResolveRef(ref)
+	if err != nil {
+		return fmt.Errorf("invalid ref: %w", err)
+	}
+
+	// Safety check: only move backwards along the current history
+	isAncestor, err := git.IsAncestor(hash, "HEAD")
+	if err != nil {
+		return err
+	}
+	if !isAncestor {
+		return fmt.Errorf("refusing to reset: '%s' is not an ancestor of HEAD", ref)
+	}
+
+	n, err := git.CountCommitsSince(hash)
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		fmt.Println(ui.SuccessStyle.Render(fmt.Sprintf("✓ Already at %s", ref)))
+		return nil
+	}
+
+	// Get current branch
+	branch, err := git.GetBranch()
+	if err != nil {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+
+	// Show what will be affected
+	fmt.Println(ui.WarningStyle.Render(fmt.Sprintf("⚠ This will reset branch '%s' to %s (%d commit(s))", branch, ref, n)))
+	fmt.Println()
+
+	if err := showCommitsToReset(n); err != nil {
+		return fmt.Errorf("failed to show commits: %w", err)
+	}
+
+	fmt.Println()
+
+	// Confirm with user
+	if !ui.Confirm(promptInput, os.Stdout, fmt.Sprintf("Reset to %s?", ref), false) {
+		fmt.Println("Cancelled.")
+		return nil
+	}
+	if !confirmSubmodules() {
+		fmt.Println("Cancelled.")
+		return nil
+	}
+
+	if err := git.ResetHard(hash); err != nil {
+		return fmt.Errorf("failed to reset to %s: %w", ref, err)
+	}
+
+	fmt.Println()
+	fmt.Println(ui.SuccessStyle.Render(fmt.Sprintf("✓ Reset to %s", ref)))
+
+	return nil
+}
+
+// recoverPickCommit lets the user choose a recent commit, then resets to it
+// with the same checks and confirmation as --to
+func recoverPickCommit() error {
+	commits, err := git.GetRecentCommits(recoverPickCount)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		return fmt.Errorf("no commits to reset")
+	}
+
+	choices := make([]ui.CommitChoice, len(commits))
+	for i, c := range commits {
+		choices[i] = ui.CommitChoice{Hash: c.Hash, Message: c.Message}
+	}
+
+	index, err := ui.PickCommit(choices)
+	if errors.Is(err, ui.ErrPickerCancelled) {
+		fmt.Println("Cancelled.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ref, err := pickedRef(commits, index)
+	if err != nil {
+		return err
+	}
+	return recoverToRef(ref)
+}
+
+// pickedRef returns the commit to reset to for the picker's selection,
+// where index 0 is HEAD
+func pickedRef(commits []git.CommitInfo, index int) (string, error) {
+	if index < 0 || index >= len(commits) {
+		return "", fmt.Errorf("no commit at position %d", index)
+	}
+	return commits[index].Hash, nil
+}
+
+// confirmSubmodules asks again before a reset in a repository with
+// submodules, since reset and clean leave their contents alone.
+// Returns true straight away when there are none.
+func confirmSubmodules() bool {
+	paths, err := git.Submodules()
+	if err == nil && len(paths) == 0 {
+		return true
+	}
+
+	fmt.Println()
+	if err != nil {
+		fmt.Println(ui.WarningStyle.Render(fmt.Sprintf("⚠ Could not check for submodules: %v", err)))
+	} else {
+		fmt.Println(ui.WarningStyle.Render(fmt.Sprintf("⚠ This repository has submodules: %s", strings.Join(paths, ", "))))
+	}
+	fmt.Println("  Submodules are not reset: changes inside them are kept, and their")
+	fmt.Println("  checked-out commits may no longer match. Sync them afterwards with:")
+	fmt.Println("  git submodule update --init --recursive")
+	fmt.Println()
+
+	return ui.Confirm(promptInput, os.Stdout, "Continue anyway?", false)
+}
+
 // showCommitsToReset displays the commits that will be reset
 func showCommitsToReset(n int) error {
 	// Use git log to show the last N commits
 	cmd := exec.Command("git", "log", "--oneline", "-n", strconv.Itoa(n))
+	cmd.Dir = git.Dir
 	output, err := cmd.Output()
 	if err != nil {
 		return err
@@ -187,18 +360,3 @@ func showCommitsToReset(n int) error {
 
 	return nil
 }
-
-// confirmAction prompts the user for confirmation
-func confirmAction(prompt string) bool {
-	reader := bufio.NewReader(os.Stdin)
-
-	fmt.Printf("%s (y/N): ", prompt)
-
-	response, err := reader.ReadString('\n')
-	if err != nil {
-		return false
-	}
-
-	response = strings.TrimSpace(strings.ToLower(response))
-	return response == "y" || response == "yes"
-}
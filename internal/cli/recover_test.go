@@ -1,12 +1,16 @@
 package cli
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/adriancodes/gumloop/internal/git"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -283,3 +287,152 @@ func TestRecoverNoCommits(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "no commits to reset")
 }
+
+func TestRecoverToRef(t *testing.T) {
+	repoDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createCommit(t, repoDir, "file1.txt", "content 1")
+	createCommit(t, repoDir, "file2.txt", "content 2")
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(repoDir))
+	defer os.Chdir(originalDir)
+
+	t.Run("invalid ref", func(t *testing.T) {
+		err := recoverToRef("no-such-ref")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid ref")
+	})
+
+	t.Run("non-ancestor ref is rejected", func(t *testing.T) {
+		cmd := exec.Command("git", "checkout", "-q", "-b", "side", "HEAD~1")
+		cmd.Dir = repoDir
+		require.NoError(t, cmd.Run())
+		createCommit(t, repoDir, "side.txt", "side")
+		cmd = exec.Command("git", "checkout", "-q", "-")
+		cmd.Dir = repoDir
+		require.NoError(t, cmd.Run())
+
+		err := recoverToRef("side")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "not an ancestor of HEAD")
+		assert.Equal(t, 2, countCommits(t, repoDir))
+	})
+
+	t.Run("current HEAD is a no-op", func(t *testing.T) {
+		err := recoverToRef("HEAD")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, countCommits(t, repoDir))
+	})
+
+	t.Run("cannot combine N with --to", func(t *testing.T) {
+		recoverTo = "HEAD~1"
+		defer func() { recoverTo = "" }()
+
+		err := runRecover(recoverCmd, []string{"1"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot combine")
+	})
+}
+
+func TestPickedRef(t *testing.T) {
+	repoDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createCommit(t, repoDir, "file1.txt", "content 1")
+	createCommit(t, repoDir, "file2.txt", "content 2")
+	createCommit(t, repoDir, "file3.txt", "content 3")
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(repoDir))
+	defer os.Chdir(originalDir)
+
+	commits, err := git.GetRecentCommits(recoverPickCount)
+	require.NoError(t, err)
+	require.Len(t, commits, 3)
+
+	// The list is most recent first, so position i is HEAD~i
+	for i := range commits {
+		ref, err := pickedRef(commits, i)
+		require.NoError(t, err)
+		want, err := git.ResolveRef(fmt.Sprintf("HEAD~%d", i))
+		require.NoError(t, err)
+		resolved, err := git.ResolveRef(ref)
+		require.NoError(t, err)
+		assert.Equal(t, want, resolved, "position %d", i)
+	}
+
+	_, err = pickedRef(commits, 3)
+	assert.ErrorContains(t, err, "no commit at position 3")
+	_, err = pickedRef(commits, -1)
+	assert.Error(t, err)
+}
+
+func TestRecoverPickWithCount(t *testing.T) {
+	repoDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	createCommit(t, repoDir, "file.txt", "content")
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(repoDir))
+	defer os.Chdir(originalDir)
+
+	recoverPick = true
+	defer func() { recoverPick = false }()
+
+	err = runRecover(recoverCmd, []string{"1"})
+	assert.ErrorContains(t, err, "cannot combine --pick")
+}
+
+// addSubmodule adds a one-commit repository as a submodule at path and commits it
+func addSubmodule(t *testing.T, repoDir, path string) {
+	subDir, cleanup := setupTestRepo(t)
+	t.Cleanup(cleanup)
+	createCommit(t, subDir, "lib.txt", "lib")
+
+	cmd := exec.Command("git", "-c", "protocol.file.allow=always", "submodule", "add", subDir, path)
+	cmd.Dir = repoDir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	cmd = exec.Command("git", "commit", "-m", "add submodule")
+	cmd.Dir = repoDir
+	require.NoError(t, cmd.Run())
+}
+
+func TestConfirmSubmodules(t *testing.T) {
+	repoDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	createCommit(t, repoDir, "file1.txt", "content 1")
+
+	originalDir, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(repoDir))
+	defer os.Chdir(originalDir)
+
+	defer func(orig io.Reader) { promptInput = orig }(promptInput)
+
+	// Nothing to ask without submodules
+	promptInput = strings.NewReader("")
+	assert.True(t, confirmSubmodules())
+
+	addSubmodule(t, repoDir, "vendor/lib")
+
+	promptInput = strings.NewReader("n\n")
+	assert.False(t, confirmSubmodules())
+
+	promptInput = strings.NewReader("y\n")
+	assert.True(t, confirmSubmodules())
+
+	// The first answer confirms the discard, the second declines the
+	// submodule warning, so nothing is discarded
+	createCommit(t, repoDir, "file2.txt", "content 2")
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "file2.txt"), []byte("changed"), 0644))
+	promptInput = bufio.NewReader(strings.NewReader("y\nn\n"))
+	require.NoError(t, recoverDiscardChanges())
+	assert.True(t, hasUncommittedChanges(t, repoDir), "declining the submodule warning should keep changes")
+}
@@ -1,11 +1,16 @@
 package cli
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/adriancodes/gumloop/internal/config"
+	"github.com/adriancodes/gumloop/internal/git"
+	"github.com/adriancodes/gumloop/internal/logging"
 	"github.com/adriancodes/gumloop/internal/ui"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -17,13 +22,23 @@ var (
 )
 
 var (
-	// Debug is set by the --debug flag
+	// Debug is set by the --debug flag (shorthand for --log-level debug)
 	Debug bool
 
+	// logLevel is set by the --log-level flag
+	logLevel string
+
 	// cfgFile is set by the --config flag (optional)
 	cfgFile string
+
+	// repoDir is set by the --repo flag (optional)
+	repoDir string
 )
 
+// promptInput is where every command reads answers to prompts from. It is
+// buffered once so consecutive prompts don't drop piped answers.
+var promptInput io.Reader = bufio.NewReader(os.Stdin)
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "gumloop",
@@ -50,40 +65,48 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	// Persistent flags (available to all subcommands)
-	rootCmd.PersistentFlags().BoolVar(&Debug, "debug", false, "Show debug output")
+	rootCmd.PersistentFlags().BoolVar(&Debug, "debug", false, "Show debug output (same as --log-level debug)")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "Log level: error, warn, info, debug")
+	rootCmd.PersistentFlags().StringVar(&repoDir, "repo", "", "Run against the repository at this path instead of the current directory")
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "Config file (default is ./.gumloop.yaml or ~/.config/gumloop/config.yaml)")
 
 	// Customize help template to include Ralph ASCII art and quote
 	rootCmd.SetHelpTemplate(helpTemplate())
 }
 
+// setLogLevel applies --log-level, or debug if --debug is set
+func setLogLevel() error {
+	level, err := logging.ParseLevel(logLevel)
+	if err != nil {
+		return err
+	}
+	if Debug {
+		level = logging.LevelDebug
+	}
+	logging.SetLevel(level)
+	return nil
+}
+
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
-	if cfgFile != "" {
-		// Use config file from the flag.
-		viper.SetConfigFile(cfgFile)
-	} else {
-		// Set up config file search paths per SPEC section 3.4
-
-		// Look for project config first (./.gumloop.yaml)
-		viper.AddConfigPath(".")
-		viper.SetConfigName(".gumloop")
-		viper.SetConfigType("yaml")
-
-		// Also check for global config (~/.config/gumloop/config.yaml)
-		home, err := os.UserHomeDir()
-		if err == nil {
-			globalConfigDir := filepath.Join(home, ".config", "gumloop")
-			viper.AddConfigPath(globalConfigDir)
-			viper.SetConfigName("config")
-		}
+	if err := setLogLevel(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
-	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil && Debug {
-		fmt.Fprintf(os.Stderr, "Using config file: %s\n", viper.ConfigFileUsed())
+	// Switch to the target repo first so project config, prompt and memory
+	// files resolve there
+	if repoDir != "" {
+		if err := useRepo(repoDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
+	// Read the config files (global, then project over it) and remember
+	// which ones were there
+	configFiles = readConfigFiles(configFileCandidates())
+
 	// Set defaults from the config package
 	defaults := config.Defaults()
 	viper.SetDefault("cli", defaults.CLI)
@@ -91,7 +114,103 @@ func initConfig() {
 	viper.SetDefault("prompt_file", defaults.PromptFile)
 	viper.SetDefault("auto_push", defaults.AutoPush)
 	viper.SetDefault("stuck_threshold", defaults.StuckThreshold)
+	viper.SetDefault("max_no_change", defaults.MaxNoChange)
+	viper.SetDefault("rate_limit_wait", defaults.RateLimitWait)
 	viper.SetDefault("verify", defaults.Verify)
+	viper.SetDefault("show_banner", defaults.ShowBanner)
+	viper.SetDefault("theme", defaults.Theme)
+	viper.SetDefault("banner_style", defaults.BannerStyle)
+	viper.SetDefault("commit_count_source", defaults.CommitCountSource)
+	viper.SetDefault("max_line_size", defaults.MaxLineSize)
+}
+
+// ConfigFile is a config file gumloop looked for
+type ConfigFile struct {
+	Scope  string // "global", "project" or "--config"
+	Path   string // Absolute when it could be resolved
+	Loaded bool   // The file exists and was read
+}
+
+// configFiles are the files initConfig looked for, in load order
+var configFiles []ConfigFile
+
+// configFileCandidates returns the config files to read, lowest priority
+// first: the --config file alone, or the global then the project config
+func configFileCandidates() []ConfigFile {
+	if cfgFile != "" {
+		return []ConfigFile{{Scope: "--config", Path: absPath(cfgFile)}}
+	}
+
+	var files []ConfigFile
+	if home, err := os.UserHomeDir(); err == nil {
+		files = append(files, ConfigFile{Scope: "global", Path: filepath.Join(home, ".config", "gumloop", "config.yaml")})
+	}
+	return append(files, ConfigFile{Scope: "project", Path: absPath(".gumloop.yaml")})
+}
+
+// readConfigFiles reads each existing file into viper, each one merged over
+// the ones before it, and returns files with Loaded filled in
+func readConfigFiles(files []ConfigFile) []ConfigFile {
+	read := viper.ReadInConfig
+	for i, f := range files {
+		if _, err := os.Stat(f.Path); err != nil {
+			if f.Scope == "--config" {
+				logging.Warnf("Warning: config file %s not found", f.Path)
+			}
+			continue
+		}
+		viper.SetConfigFile(f.Path)
+		if err := read(); err != nil {
+			logging.Warnf("Warning: failed to read config file %s: %v", f.Path, err)
+			continue
+		}
+		files[i].Loaded = true
+		read = viper.MergeInConfig
+		logging.Debugf("Using config file: %s", f.Path)
+	}
+	return files
+}
+
+// describeConfigFiles lists files one per line, each marked (loaded) or
+// (not found)
+func describeConfigFiles(files []ConfigFile, indent string) string {
+	var b strings.Builder
+	for _, f := range files {
+		status := "not found"
+		if f.Loaded {
+			status = "loaded"
+		}
+		fmt.Fprintf(&b, "%s%s: %s (%s)\n", indent, f.Scope, f.Path, status)
+	}
+	return b.String()
+}
+
+// absPath returns path made absolute, or path itself if that fails
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// useRepo makes path the working directory for gumloop, the agent and git
+func useRepo(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("invalid --repo path %s: %w", path, err)
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return fmt.Errorf("--repo path %s does not exist", path)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("--repo path %s is not a directory", path)
+	}
+	if err := os.Chdir(absPath); err != nil {
+		return fmt.Errorf("failed to change to --repo path %s: %w", path, err)
+	}
+	git.Dir = absPath
+	return nil
 }
 
 // helpTemplate returns a custom help template with Ralph ASCII art and a random quote
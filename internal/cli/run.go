@@ -2,12 +2,19 @@ package cli
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/adriancodes/gumloop/internal/adapter"
 	"github.com/adriancodes/gumloop/internal/agent"
 	"github.com/adriancodes/gumloop/internal/config"
 	"github.com/adriancodes/gumloop/internal/git"
+	"github.com/adriancodes/gumloop/internal/github"
+	"github.com/adriancodes/gumloop/internal/logging"
 	"github.com/adriancodes/gumloop/internal/memory"
 	"github.com/adriancodes/gumloop/internal/runner"
 	"github.com/adriancodes/gumloop/internal/ui"
@@ -25,8 +32,47 @@ var (
 	runChooChooSet bool // Track if --choo-choo was explicitly set
 	runNoPush      bool
 	runStuck       int
+	runMaxNoChange int
 	runVerify      string
 	runMemory      bool
+	runNoMemory    bool
+	runWorkDir     string
+	runWatchPrompt bool
+	runNoPreflight bool
+	runExplain     bool
+	runCommitDirty bool
+	runFailDirty   bool
+	runPrefix      string
+	runSuccess     []int
+	runVerifyPar   bool
+	runProfile     string
+	runTee         string
+	runInteractive bool
+	runIssue       string
+	runWrapWidth   int
+	runStrict      bool
+	runCache       bool
+	runRefresh     bool
+	runTrailer     bool
+	runOutput      string
+	runMaxFiles    int
+	runPlanOnly    bool
+	runTerse       bool
+	runASCII       bool
+	runBanner      string
+	runCompact     bool
+	runStopFile    string
+	runCountSource string
+	runRetryVerify bool
+	runEventsTo    string
+	runCommitIntr  bool
+	runModelMemory bool
+	runNoAdapter   bool
+	runLineSize    int
+	runCommitEvery int
+	runAllowDanger bool
+	runNoStuck     bool
+	runTerseIters  bool
 )
 
 // runCmd represents the run command
@@ -63,46 +109,136 @@ func init() {
 	// Define flags per SPEC section 2.2
 	runCmd.Flags().StringVarP(&runPrompt, "prompt", "p", "", "Inline prompt text (required if no --prompt-file)")
 	runCmd.Flags().StringVar(&runPromptFile, "prompt-file", "", "Path to prompt file (default from config)")
-	runCmd.Flags().StringVar(&runCLI, "cli", "", "Agent to use (claude, codex, gemini, opencode, cursor, ollama)")
+	runCmd.Flags().StringVar(&runIssue, "prompt-from-issue", "", "Use a GitHub issue as the prompt (URL or owner/repo#N; GITHUB_TOKEN for private repos)")
+	runCmd.Flags().BoolVar(&runCache, "prompt-cache", false, "Reuse a remote prompt (--prompt-from-issue) saved in <git dir>/gumloop/prompt-cache by an earlier run")
+	runCmd.Flags().BoolVar(&runRefresh, "refresh-prompt", false, "Fetch the remote prompt again and update the prompt cache")
+	runCmd.Flags().StringVar(&runCLI, "cli", "", "Agent to use (claude, codex, gemini, opencode, cursor, ollama, or auto for the first installed)")
 	runCmd.Flags().StringVar(&runModel, "model", "", "Model override")
+	runCmd.Flags().BoolVar(&runModelMemory, "model-from-memory", false, "Use the model from the previous session's memory (same agent only); --model still wins")
+	runCmd.Flags().StringVar(&runProfile, "profile", "", "Apply a named profile from the config's profiles section")
 	runCmd.Flags().IntVar(&runChooChoo, "choo-choo", 0, "Loop mode. Optional max iterations (0 = unlimited)")
 	runCmd.Flags().BoolVar(&runNoPush, "no-push", false, "Don't push to remote")
+	runCmd.Flags().BoolVar(&runNoPreflight, "no-preflight", false, "Skip the agent login and SSH push checks before the loop starts")
 	runCmd.Flags().IntVar(&runStuck, "stuck-threshold", 0, "Exit after N iterations without commits")
+	runCmd.Flags().BoolVar(&runNoStuck, "no-stuck-detection", false, "Never exit as stuck, however many iterations pass without commits")
+	runCmd.Flags().IntVar(&runMaxNoChange, "max-no-change", 0, "Exit as complete after N consecutive iterations with no changes (default 1)")
+	runCmd.Flags().StringVar(&runPrefix, "prompt-prefix", "", "Instruction placed before the prompt on every iteration")
+	runCmd.Flags().IntVar(&runMaxFiles, "max-file-changes", 0, "Stop with exit code 2 if an iteration changes more than N files, committed or not (0 = no limit)")
 	runCmd.Flags().StringVar(&runVerify, "verify", "", "Command to run after each iteration")
+	runCmd.Flags().BoolVar(&runVerifyPar, "verify-parallel", false, "Run each line of --verify as a separate command, in parallel")
+	runCmd.Flags().BoolVar(&runCommitDirty, "commit-if-dirty", false, "Commit changes the agent left uncommitted at the end of an iteration")
+	runCmd.Flags().IntVar(&runCommitEvery, "commit-interval", 0, "Commit uncommitted changes after N iterations in a row leave the tree dirty (0 = never)")
+	runCmd.Flags().BoolVar(&runCommitIntr, "commit-on-interrupt", false, "On Ctrl+C or --stop-file, commit uncommitted changes as \"WIP (interrupted)\" before exiting")
+	runCmd.Flags().BoolVar(&runAllowDanger, "allow-dangerous-path", false, "Allow running in a system or config directory after typing its path to confirm ($HOME and / are always refused)")
+	runCmd.Flags().BoolVar(&runFailDirty, "fail-fast-on-dirty", false, "Refuse to start if the working tree has uncommitted changes")
+	runCmd.Flags().BoolVar(&runTrailer, "commit-trailer", false, "Add Gumloop-Iteration and Gumloop-Agent trailers to the session's commits")
+	runCmd.Flags().BoolVar(&runStrict, "strict-commits", false, "Undo an iteration's commits if a message doesn't match commit_message_pattern")
 	runCmd.Flags().BoolVar(&runMemory, "memory", false, "Enable session memory (persists context between runs)")
+	runCmd.Flags().BoolVar(&runNoMemory, "no-memory", false, "Disable session memory for this run")
+	runCmd.Flags().BoolVar(&runWatchPrompt, "watch-prompt", false, "Re-read the prompt file before each iteration (choo-choo mode)")
+	runCmd.Flags().IntSliceVar(&runSuccess, "success-codes", nil, "Exit codes to report as 0 (e.g. 0,3 to treat max iterations as success)")
+	runCmd.Flags().BoolVar(&runPlanOnly, "plan-only", false, "Ask the agent for a plan without making changes (one iteration, nothing committed or pushed)")
+	runCmd.Flags().BoolVar(&runInteractive, "interactive", false, "Run the agent once with its own interactive interface (no output parsing or loop)")
+	runCmd.Flags().IntVar(&runWrapWidth, "prompt-wrap-width", 80, "Wrap the prompt shown in debug output at this width (0 = no wrapping; the agent gets it unchanged)")
+	runCmd.Flags().BoolVar(&runTerse, "summary-only-on-change", false, "Show the full run summary only if something changed; otherwise print one line")
+	runCmd.Flags().BoolVar(&runTerseIters, "iteration-header-only-on-change", false, "Show one-line iteration headers, and full iteration summaries only for iterations with commits or changes")
+	runCmd.Flags().StringVar(&runBanner, "banner-style", "", "Startup banner style: full or compact (one line)")
+	runCmd.Flags().BoolVar(&runCompact, "compact", false, "Show the one-line startup banner (same as --banner-style compact)")
+	runCmd.Flags().BoolVar(&runASCII, "ascii", false, "Use ASCII status icons like [OK] and [STOP] instead of emoji (on by default for dumb or non-UTF-8 terminals)")
+	runCmd.Flags().BoolVar(&runExplain, "explain", false, "Print why the loop stopped after the run summary")
+	runCmd.Flags().BoolVar(&runNoAdapter, "no-adapter", false, "Pass the agent's output through unparsed (for agents whose output format breaks parsing)")
+	runCmd.Flags().IntVar(&runLineSize, "max-line-size", 0, "Longest line of agent output to read, in MiB (overrides max_line_size)")
+	runCmd.Flags().StringVar(&runOutput, "output-format", runner.FormatHuman, "Output format: human, json (one JSON event per line, then a summary) or quiet (summary only)")
+	runCmd.Flags().StringVar(&runEventsTo, "json-events-to", "", "Also stream JSON events to a socket: host:port for TCP, or a Unix socket path (best-effort, reconnects on failure)")
+	runCmd.Flags().StringVar(&runStopFile, "stop-file", "", "Stop the loop (exit code 130) when this file exists at the start of an iteration; the file is removed")
+	runCmd.Flags().BoolVar(&runRetryVerify, "retry-failed-verify-with-prompt", false, "When verification fails, add its output (the last 8KB) to the next iteration's prompt")
+	runCmd.Flags().StringVar(&runCountSource, "commit-count-source", "", "Commits to credit iterations with: session (made since the run started) or branch (all new commits on the branch)")
+	runCmd.Flags().StringVar(&runTee, "tee", "", "Also write all output to this file (without colors)")
+	runCmd.Flags().StringVar(&runWorkDir, "workdir", "", "Run the agent in this subdirectory of the repo (git stays at the root)")
 
 	// Track if --choo-choo was explicitly set (for distinguishing between not set and set to 0)
 	runCmd.Flags().Lookup("choo-choo").NoOptDefVal = "-1" // Special value to indicate flag without value
 }
 
 func runRun(cmd *cobra.Command, args []string) error {
+	// Mirror everything from here on into the --tee file
+	exit := os.Exit
+	var stdout io.Writer = os.Stdout
+	if runTee != "" {
+		if err := checkTeePath(runTee); err != nil {
+			return err
+		}
+		stopTee, err := startTee(runTee)
+		if err != nil {
+			return err
+		}
+		defer stopTee()
+		stdout = teeStdout{}
+		exit = func(code int) {
+			stopTee()
+			os.Exit(code)
+		}
+	}
+
+	// Emoji turn into mojibake on terminals that can't render them
+	ui.SetASCII(runASCII || ui.DetectASCII())
+
 	// Load configuration using the cascade system
 	cfg, err := loadRunConfig()
 	if err != nil {
 		return fmt.Errorf("config error: %w", err)
 	}
 
+	// Cut long agent lines on the terminal (with --tee, the file keeps them
+	// whole)
+	out, err := runner.NewOutput(runOutput, stdout, cfg.MaxLineLength)
+	if err != nil {
+		return err
+	}
+	if runEventsTo != "" {
+		events := runner.NewEventSocket(runEventsTo)
+		defer events.Close()
+		out = runner.TeeOutput(out, runner.NewEventStream(events))
+		// Send the queued events (the summary last) before exiting
+		exitAfterTee := exit
+		exit = func(code int) {
+			events.Close()
+			exitAfterTee(code)
+		}
+	}
+
 	// Validate configuration
 	if err := validateRunConfig(cfg); err != nil {
 		// Check if this is a safety error that needs a special exit code
 		if safetyErr, ok := err.(*SafetyError); ok {
 			fmt.Fprintf(os.Stderr, "Error: %s\n", safetyErr.Message)
-			os.Exit(int(safetyErr.Code))
+			exit(int(safetyErr.Code))
 		}
 		return err
 	}
 
+	// Fetch the issue only once the rest of the config is known to be good
+	if runPrompt == "" && runIssue != "" {
+		if cfg.Prompt, err = issuePrompt(runIssue); err != nil {
+			return err
+		}
+	}
+
 	// Debug output
-	if Debug {
-		fmt.Fprintf(os.Stderr, "Run configuration:\n")
-		fmt.Fprintf(os.Stderr, "  CLI: %s\n", cfg.CLI)
-		fmt.Fprintf(os.Stderr, "  Model: %s\n", cfg.Model)
-		fmt.Fprintf(os.Stderr, "  Prompt: %s\n", cfg.Prompt)
-		fmt.Fprintf(os.Stderr, "  PromptFile: %s\n", cfg.PromptFile)
-		fmt.Fprintf(os.Stderr, "  ChooChoo: %v (max: %d)\n", cfg.ChooChoo, cfg.MaxIterations)
-		fmt.Fprintf(os.Stderr, "  AutoPush: %v\n", cfg.AutoPush)
-		fmt.Fprintf(os.Stderr, "  StuckThreshold: %d\n", cfg.StuckThreshold)
-		fmt.Fprintf(os.Stderr, "  Verify: %s\n", cfg.Verify)
+	if logging.Enabled(logging.LevelDebug) {
+		logging.Debugf("Config files:\n%s", strings.TrimRight(describeConfigFiles(cfg.ConfigFiles, "    "), "\n"))
+		logging.Debugf("Run configuration:")
+		logging.Debugf("  CLI: %s", cfg.CLI)
+		logging.Debugf("  Model: %s", cfg.Model)
+		logging.Debugf("  Prompt:\n%s", indentLines(ui.WrapText(cfg.Prompt, runWrapWidth), "    "))
+		logging.Debugf("  PromptFile: %s", cfg.PromptFile)
+		logging.Debugf("  ChooChoo: %v (max: %d)", cfg.ChooChoo, cfg.MaxIterations)
+		logging.Debugf("  AutoPush: %v", cfg.AutoPush)
+		logging.Debugf("  StuckThreshold: %d", cfg.StuckThreshold)
+		logging.Debugf("  StuckDisabled: %t", cfg.StuckDisabled)
+		logging.Debugf("  MaxNoChange: %d", cfg.MaxNoChange)
+		logging.Debugf("  Verify: %s", cfg.Verify)
+		logging.Debugf("  WorkDir: %s", cfg.WorkDir)
 	}
 
 	// Get the agent
@@ -111,58 +247,358 @@ func runRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("agent error: %w", err)
 	}
 
-	// Load session memory if enabled
-	var mem *memory.SessionMemory
-	if cfg.Memory {
-		existing, err := memory.Load(memory.DefaultFileName)
+	ag = configureAgent(ag, cfg)
+
+	// Hand the terminal to the agent; the safety checks above still apply
+	if runInteractive {
+		code, err := execInteractive(ag, promptPreamble(cfg.PromptPrefix, "")+cfg.Prompt, cfg.Model, cfg.WorkDir)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "⚠️  Warning: failed to load session memory: %v\n", err)
+			return err
 		}
+		exit(code)
+	}
 
-		// Inject previous session context into the prompt
-		if existing != nil {
-			context := existing.ToPromptContext()
-			if context != "" {
-				cfg.Prompt = context + "\n" + cfg.Prompt
-			}
+	branch, _ := git.GetBranch()
+
+	// Load session memory if enabled
+	mem, memoryContext := loadSessionMemory(cfg, branch, ag)
+
+	// Prefix and memory context go in front of the task prompt
+	preamble := promptPreamble(cfg.PromptPrefix, memoryContext)
+	cfg.Prompt = preamble + cfg.Prompt
+	logging.Debugf("Prompt: %s tokens (%s from memory)", ui.FormatTokens(ui.EstimateTokens(cfg.Prompt)), ui.FormatTokens(ui.EstimateTokens(memoryContext)))
+
+	// Display startup banner
+	if banner := renderStartupBanner(cfg, branch, memoryContext); banner != "" && isHumanOutput(runOutput) {
+		fmt.Println(banner)
+	}
+
+	// Warn early if the agent isn't logged in
+	if !runNoPreflight {
+		if err := ag.CheckAuth(); err != nil {
+			logging.Warnf("Warning: %v", err)
 		}
+	}
 
-		// Create a fresh memory for this session
-		branch, _ := git.GetBranch()
-		mem = &memory.SessionMemory{
-			StartedAt: time.Now(),
-			Branch:    branch,
-			AgentName: ag.Name,
+	// Warn early if pushes are likely to fail
+	if cfg.AutoPush && !runNoPreflight {
+		if remoteURL, err := git.GetRemoteURL("origin"); err == nil {
+			if err := git.CheckPushPreflight(remoteURL, git.CountSSHIdentities); err != nil {
+				logging.Warnf("Warning: %v", err)
+			}
 		}
 	}
 
+	// Let the adapters read agent output lines up to max_line_size
+	if cfg.MaxLineSize > 0 {
+		adapter.MaxLineSize = cfg.MaxLineSize << 20
+	}
+
 	// Create and run the runner
 	r := runner.New(&cfg.Config, cfg.Prompt, ag, cfg.ChooChoo, cfg.MaxIterations, mem)
+	if runWatchPrompt && cfg.ChooChoo {
+		r.WatchPromptFile(cfg.PromptFile, preamble)
+	}
+	if cfg.Memory && runPrompt == "" && runIssue == "" {
+		r.TrackPlan(cfg.PromptFile)
+	}
+	if cfg.LoopPrompt != "" {
+		r.UseLoopPrompt(preamble + cfg.LoopPrompt)
+	}
+	if runNoAdapter {
+		if cfg.DoneSignal != "" {
+			logging.Warnf("Warning: done_signal can't be detected with --no-adapter")
+		}
+		r.NoAdapter()
+	}
+	if runStrict {
+		r.StrictCommits()
+	}
+	if runRetryVerify {
+		r.RetryFailedVerify()
+	}
+	if runMaxFiles > 0 {
+		r.LimitFileChanges(runMaxFiles)
+	}
+	if runCommitEvery > 0 {
+		r.CommitEvery(runCommitEvery)
+	}
+	if runPlanOnly {
+		r.PlanOnly()
+	}
+	if runStopFile != "" {
+		r.StopOnFile(runStopFile)
+	}
+	if runTerseIters {
+		r.HeaderOnlyOnChange()
+	}
+	if len(cfg.Pipeline) > 0 {
+		stages, err := pipelineStages(cfg)
+		if err != nil {
+			return err
+		}
+		if !runNoPreflight {
+			if err := preflightStages(stages); err != nil {
+				return err
+			}
+		}
+		r.SetPipeline(stages)
+	}
+	r.SetOutput(out)
 	exitCode := r.Run()
 
+	exit(finishRun(r, out, exitCode, ag.Name, branch))
+	return nil
+}
+
+// finishRun shows the run summary (and --explain) for a finished run and
+// returns the process exit code, which --success-codes may remap to 0.
+// The summary always shows the real exit reason.
+func finishRun(r *runner.Runner, out runner.Output, exitCode runner.ExitCode, agentName, branch string) int {
 	// Display run summary
 	metrics := r.GetMetrics()
-	summary := ui.RenderRunSummary(ui.SummaryConfig{
-		Agent:      ag.Name,
+	summaryCfg := ui.SummaryConfig{
+		Agent:      agentName,
 		Iterations: metrics.Iterations,
 		Commits:    metrics.Commits,
 		Duration:   metrics.Duration(),
 		ExitCode:   ui.ExitCode(exitCode),
-	})
-	fmt.Println()
-	fmt.Println(summary)
+		Errors:     metrics.Errors,
+		LastError:  metrics.LastError,
+	}
+	// Only a run that finished normally can be summed up in one line, and
+	// only if the tree is known to be clean
+	if runTerse && (exitCode == runner.ExitSuccess || exitCode == runner.ExitMaxIterations) {
+		hasChanges, err := git.HasChanges()
+		if err != nil {
+			logging.Warnf("failed to check for changes: %v", err)
+		} else {
+			summaryCfg.TerseIfUnchanged = true
+			summaryCfg.HasChanges = hasChanges
+		}
+	}
+	if ahead, behind, err := git.GetAheadBehind(branch); err == nil {
+		summaryCfg.HasUpstream = true
+		summaryCfg.Ahead = ahead
+		summaryCfg.Behind = behind
+	}
+	out.Summary(summaryCfg)
+	if runExplain {
+		// Keep stdout to JSON records in json mode
+		w := os.Stdout
+		if runOutput == runner.FormatJSON {
+			w = os.Stderr
+		}
+		fmt.Fprintln(w)
+		fmt.Fprint(w, r.Explain())
+	}
+
+	code := remapExitCode(int(exitCode), runSuccess)
+	if code != int(exitCode) {
+		out.Notice(runner.IconInfo, fmt.Sprintf("Exit code %d reported as 0 (--success-codes)", exitCode))
+	}
+	return code
+}
+
+// loadSessionMemory reads the previous session's memory and starts this
+// session's, if memory is enabled. Returns the new memory (nil when disabled)
+// and the previous session's context for the prompt. With
+// --model-from-memory, cfg.Model is set to the previous session's model
+// unless --model was given.
+func loadSessionMemory(cfg *RunConfig, branch string, ag *agent.Agent) (*memory.SessionMemory, string) {
+	if !cfg.Memory {
+		if runModelMemory {
+			logging.Warnf("Warning: --model-from-memory needs session memory (--memory or memory: true); using the configured model")
+		}
+		return nil, ""
+	}
+
+	existing, err := memory.Load(memory.DefaultFileName)
+	if err != nil {
+		logging.Warnf("Warning: failed to load session memory: %v", err)
+	}
+
+	// Inject previous session context into the prompt
+	var memoryContext string
+	if existing != nil {
+		memoryContext = existing.ToPromptContext()
+	}
+
+	// Reuse the previous model only for the same agent; model names don't
+	// carry over between agents
+	if runModelMemory && runModel == "" && existing != nil && existing.Model != "" {
+		if existing.AgentName == ag.Name {
+			logging.Debugf("Using model %s from session memory", existing.Model)
+			cfg.Model = existing.Model
+		} else {
+			logging.Warnf("Warning: session memory is from %s, not %s; ignoring its model %s", existing.AgentName, ag.Name, existing.Model)
+		}
+	}
+
+	// Create a fresh memory for this session
+	mem := &memory.SessionMemory{
+		StartedAt: time.Now(),
+		Branch:    branch,
+		AgentName: ag.Name,
+		Model:     cfg.Model,
+	}
+	return mem, memoryContext
+}
 
-	// Exit with the appropriate code
-	os.Exit(int(exitCode))
+// configureAgent applies the configured system prompt, base URL and tool
+// patterns to a copy of ag so the registry stays untouched
+func configureAgent(ag *agent.Agent, cfg *RunConfig) *agent.Agent {
+	if cfg.SystemPrompt == "" && cfg.BaseURL == "" && len(cfg.ToolPatterns) == 0 {
+		return ag
+	}
+
+	configured := *ag
+	configured.SystemPrompt = cfg.SystemPrompt
+	configured.BaseURL = cfg.BaseURL
+	configured.ToolPatterns = cfg.ToolPatterns
+
+	if cfg.BaseURL != "" && agent.BaseURLEnvVar(ag.ID) == "" {
+		logging.Warnf("Warning: base_url is not supported by %s and will be ignored", ag.Name)
+	}
+	return &configured
+}
+
+// registerCustomAgents adds the agents defined in config to the registry,
+// so cli and pipeline stages can select them like built-ins
+func registerCustomAgents(defs []config.CustomAgent) error {
+	for _, def := range defs {
+		name := def.Name
+		if name == "" {
+			name = def.ID
+		}
+		ag := &agent.Agent{
+			ID:               def.ID,
+			Name:             name,
+			Command:          def.Command,
+			AutonomousFlags:  def.Flags,
+			InteractiveFlags: def.InteractiveFlags,
+			TerminalFlags:    def.TerminalFlags,
+			ModelFlag:        def.ModelFlag,
+			PromptStyle:      agent.PromptStyle(def.PromptStyle),
+		}
+		if fields := strings.Fields(def.Command); len(fields) > 0 {
+			ag.CheckCommand = fields[0]
+		}
+		if err := agent.RegisterCustomAgent(ag); err != nil {
+			return fmt.Errorf("invalid agents config: %w", err)
+		}
+	}
+	return nil
+}
+
+// pipelineStages resolves the agents of the configured pipeline. A stage
+// without a model uses the run's.
+func pipelineStages(cfg *RunConfig) ([]runner.Stage, error) {
+	var stages []runner.Stage
+	for i, stage := range cfg.Pipeline {
+		ag, err := agent.GetAgent(stage.Agent)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline stage %d: %w", i+1, err)
+		}
+		model := stage.Model
+		if model == "" {
+			model = cfg.Model
+		}
+		stages = append(stages, runner.Stage{
+			Agent:        configureAgent(ag, cfg),
+			Model:        model,
+			PromptSuffix: stage.PromptSuffix,
+		})
+	}
+	return stages, nil
+}
+
+// preflightStages checks that every pipeline stage's agent is installed,
+// and warns about any that isn't logged in
+func preflightStages(stages []runner.Stage) error {
+	for i, stage := range stages {
+		if !stage.Agent.Installed() {
+			return fmt.Errorf("pipeline stage %d: %s is not installed (%s not found in PATH)", i+1, stage.Agent.Name, stage.Agent.CheckCommand)
+		}
+		if err := stage.Agent.CheckAuth(); err != nil {
+			logging.Warnf("pipeline stage %d: %v", i+1, err)
+		}
+	}
 	return nil
 }
 
+// isHumanOutput reports whether format is the default terminal output
+func isHumanOutput(format string) bool {
+	return format == "" || format == runner.FormatHuman
+}
+
+// indentLines prefixes every line of s with indent
+func indentLines(s, indent string) string {
+	return indent + strings.ReplaceAll(strings.TrimRight(s, "\n"), "\n", "\n"+indent)
+}
+
+// remapExitCode returns 0 if code is one of successCodes, otherwise code
+func remapExitCode(code int, successCodes []int) int {
+	for _, c := range successCodes {
+		if c == code {
+			return 0
+		}
+	}
+	return code
+}
+
+// promptPreamble returns the text placed before the task prompt:
+// the configured prefix, then the previous session's memory context
+func promptPreamble(prefix, memoryContext string) string {
+	var preamble string
+	if prefix != "" {
+		preamble = prefix + "\n\n"
+	}
+	if memoryContext != "" {
+		preamble += memoryContext + "\n"
+	}
+	return preamble
+}
+
+// renderStartupBanner returns the banner shown before the first iteration,
+// or an empty string when show_banner is disabled. memoryContext is the part
+// of cfg.Prompt that came from session memory, for the token estimate.
+func renderStartupBanner(cfg *RunConfig, branch, memoryContext string) string {
+	if !cfg.ShowBanner {
+		return ""
+	}
+
+	promptSource := cfg.PromptFile
+	if runPrompt != "" {
+		promptSource = "(inline)"
+	} else if runIssue != "" {
+		promptSource = runIssue
+	}
+
+	banner := ui.BannerConfig{
+		Version:       Version,
+		CLI:           cfg.CLI,
+		Model:         cfg.Model,
+		Autonomous:    cfg.ChooChoo,
+		PromptFile:    promptSource,
+		Branch:        branch,
+		MaxIterations: cfg.MaxIterations,
+		PromptTokens:  ui.EstimateTokens(cfg.Prompt),
+		MemoryTokens:  ui.EstimateTokens(memoryContext),
+	}
+	if cfg.BannerStyle == ui.BannerStyleCompact {
+		return ui.RenderCompactBanner(banner)
+	}
+	return ui.RenderBanner(banner)
+}
+
 // RunConfig extends the base Config with run-specific fields
 type RunConfig struct {
 	config.Config
-	Prompt        string // The actual prompt text (from -p or file)
-	ChooChoo      bool   // Whether loop mode is enabled
-	MaxIterations int    // Max iterations (0 = unlimited)
+	Prompt        string       // The actual prompt text (from -p or file)
+	ChooChoo      bool         // Whether loop mode is enabled
+	MaxIterations int          // Max iterations (0 = unlimited)
+	ConfigFiles   []ConfigFile // Config files looked for, and whether each was loaded
 }
 
 // loadRunConfig loads config from cascade (defaults → global → project → flags)
@@ -172,20 +608,81 @@ func loadRunConfig() (*RunConfig, error) {
 
 	// Create base config from viper (which has already loaded files via initConfig)
 	cfg := &RunConfig{
+		ConfigFiles: configFiles,
 		Config: config.Config{
-			CLI:            viper.GetString("cli"),
-			Model:          viper.GetString("model"),
-			PromptFile:     viper.GetString("prompt_file"),
-			AutoPush:       viper.GetBool("auto_push"),
-			StuckThreshold: viper.GetInt("stuck_threshold"),
-			Verify:         viper.GetString("verify"),
+			CLI:                   viper.GetString("cli"),
+			Model:                 viper.GetString("model"),
+			PromptFile:            viper.GetString("prompt_file"),
+			PromptSearch:          viper.GetBool("prompt_search"),
+			AutoPush:              viper.GetBool("auto_push"),
+			StuckThreshold:        viper.GetInt("stuck_threshold"),
+			StuckDisabled:         viper.GetBool("stuck_disabled"),
+			MaxNoChange:           viper.GetInt("max_no_change"),
+			RateLimitWait:         viper.GetInt("rate_limit_wait"),
+			CommitGraceIterations: viper.GetInt("commit_grace_iterations"),
+			Verify:                viper.GetString("verify"),
+			VerifyParallel:        viper.GetBool("verify_parallel"),
+			VerifyShell:           viper.GetString("verify_shell"),
+			Memory:                viper.GetBool("memory"),
+			CommitIfDirty:         viper.GetBool("commit_if_dirty"),
+			CommitOnInterrupt:     viper.GetBool("commit_on_interrupt"),
+			RequireCleanTree:      viper.GetBool("require_clean_tree"),
+			CommitTrailer:         viper.GetBool("commit_trailer"),
+			WorkDir:               viper.GetString("workdir"),
+			SystemPrompt:          viper.GetString("system_prompt"),
+			PromptPrefix:          viper.GetString("prompt_prefix"),
+			StuckHint:             viper.GetString("stuck_hint"),
+			LoopPrompt:            viper.GetString("loop_prompt"),
+			DoneSignal:            viper.GetString("done_signal"),
+			CommitMessagePattern:  viper.GetString("commit_message_pattern"),
+			ShowBanner:            viper.GetBool("show_banner"),
+			HideTools:             viper.GetStringSlice("hide_tools"),
+			BaseURL:               viper.GetString("base_url"),
+			Theme:                 viper.GetString("theme"),
+			BannerStyle:           viper.GetString("banner_style"),
+			MaxLineLength:         viper.GetInt("max_line_length"),
+			MaxLineSize:           viper.GetInt("max_line_size"),
+			CommitCountSource:     viper.GetString("commit_count_source"),
+			ToolPatterns:          viper.GetStringSlice("tool_patterns"),
+			PushArgs:              viper.GetStringSlice("push_args"),
 		},
 	}
 
+	// Apply the selected profile over the config files (flags still win)
+	if err := viper.UnmarshalKey("profiles", &cfg.Profiles); err != nil {
+		return nil, fmt.Errorf("invalid profiles config: %w", err)
+	}
+	if err := viper.UnmarshalKey("pipeline", &cfg.Pipeline); err != nil {
+		return nil, fmt.Errorf("invalid pipeline config: %w", err)
+	}
+	if err := viper.UnmarshalKey("agents", &cfg.Agents); err != nil {
+		return nil, fmt.Errorf("invalid agents config: %w", err)
+	}
+	if err := registerCustomAgents(cfg.Agents); err != nil {
+		return nil, err
+	}
+	if runProfile != "" {
+		profiled, err := config.ApplyProfile(cfg.Config, runProfile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Config = profiled
+	}
+
 	// Apply flag overrides (flags have highest priority)
 	if runCLI != "" {
 		cfg.CLI = runCLI
 	}
+
+	// cli: auto picks the first installed agent
+	if cfg.CLI == agent.Auto {
+		ag, err := agent.DetectInstalled()
+		if err != nil {
+			return nil, err
+		}
+		logging.Infof("🔍 cli is auto: using %s (%s)", ag.Name, ag.ID)
+		cfg.CLI = ag.ID
+	}
 	if runModel != "" {
 		cfg.Model = runModel
 	}
@@ -198,12 +695,54 @@ func loadRunConfig() (*RunConfig, error) {
 	if runStuck > 0 {
 		cfg.StuckThreshold = runStuck
 	}
+	if runNoStuck {
+		cfg.StuckDisabled = true
+	}
+	if runMaxNoChange > 0 {
+		cfg.MaxNoChange = runMaxNoChange
+	}
 	if runVerify != "" {
 		cfg.Verify = runVerify
 	}
+	if runPrefix != "" {
+		cfg.PromptPrefix = runPrefix
+	}
 	if runMemory {
 		cfg.Memory = true
 	}
+	if runNoMemory {
+		cfg.Memory = false // --no-memory overrides config
+	}
+	if runVerifyPar {
+		cfg.VerifyParallel = true
+	}
+	if runTrailer {
+		cfg.CommitTrailer = true
+	}
+	if runCommitDirty {
+		cfg.CommitIfDirty = true
+	}
+	if runCommitIntr {
+		cfg.CommitOnInterrupt = true
+	}
+	if runFailDirty {
+		cfg.RequireCleanTree = true
+	}
+	if runWorkDir != "" {
+		cfg.WorkDir = runWorkDir
+	}
+	if runBanner != "" {
+		cfg.BannerStyle = runBanner
+	}
+	if runLineSize != 0 {
+		cfg.MaxLineSize = runLineSize
+	}
+	if runCompact {
+		cfg.BannerStyle = ui.BannerStyleCompact
+	}
+	if runCountSource != "" {
+		cfg.CommitCountSource = runCountSource
+	}
 
 	// Handle --choo-choo flag
 	// The flag can be: not set, set without value (use -1 as signal), or set with value
@@ -218,16 +757,23 @@ func loadRunConfig() (*RunConfig, error) {
 	}
 	// If runChooChoo == 0, flag was not set, so ChooChoo stays false
 
-	// Handle prompt: inline (-p) takes precedence over file
+	// Handle prompt: inline (-p) takes precedence over an issue, then the
+	// file. An issue is fetched by runRun once the config has been validated.
 	if runPrompt != "" {
 		cfg.Prompt = runPrompt
-	} else {
+	} else if runIssue == "" {
 		// Load from prompt file
 		promptFile := cfg.PromptFile
 		if promptFile == "" {
 			promptFile = defaults.PromptFile // Use default if not set
 		}
 
+		// prompt_search: look in parent directories too
+		if cfg.PromptSearch {
+			promptFile = findPromptFile(promptFile)
+			cfg.PromptFile = promptFile
+		}
+
 		// Read prompt file if it exists
 		if _, err := os.Stat(promptFile); err == nil {
 			content, err := os.ReadFile(promptFile)
@@ -241,27 +787,192 @@ func loadRunConfig() (*RunConfig, error) {
 	return cfg, nil
 }
 
+// issuePrompt fetches a GitHub issue and formats it as the prompt,
+// going through the prompt cache with --prompt-cache or --refresh-prompt
+func issuePrompt(ref string) (string, error) {
+	issueRef, err := github.ParseIssueRef(ref)
+	if err != nil {
+		return "", err
+	}
+	fetch := func() (string, error) { return fetchIssuePrompt(issueRef) }
+	if !runCache && !runRefresh {
+		return fetch()
+	}
+	// Keyed by the parsed issue so a URL and owner/repo#N share an entry
+	return cachedPrompt(issueRef.String(), runRefresh, fetch)
+}
+
+// findPromptFile looks for a relative prompt file in the current directory,
+// then in each parent up to the repo root (the first directory with a .git
+// entry), so a run from a monorepo subpackage finds the root prompt.
+// Returns name unchanged if it's absolute or isn't found anywhere.
+func findPromptFile(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	if _, err := os.Stat(name); err == nil {
+		return name
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return name
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return name // Reached the repo root
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return name
+		}
+		dir = parent
+
+		candidate := filepath.Join(dir, name)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+}
+
 // validateRunConfig validates the run configuration
 func validateRunConfig(cfg *RunConfig) error {
-	// Must have a prompt
-	if cfg.Prompt == "" {
+	// Must have a prompt (an issue is only fetched after validation)
+	fromIssue := runPrompt == "" && runIssue != ""
+	if cfg.Prompt == "" && !fromIssue {
 		return fmt.Errorf("prompt required: use -p flag or create %s", cfg.PromptFile)
 	}
+	if fromIssue {
+		if _, err := github.ParseIssueRef(runIssue); err != nil {
+			return err
+		}
+	}
+
+	// --interactive runs the agent once, outside the loop
+	if runInteractive && cfg.ChooChoo {
+		return fmt.Errorf("--interactive cannot be used with --choo-choo")
+	}
+	if runInteractive && runTee != "" {
+		return fmt.Errorf("--interactive cannot be used with --tee: the agent's own interface can't be mirrored to a file")
+	}
+
+	// --watch-prompt needs a file to watch
+	if runWatchPrompt && runPrompt != "" {
+		return fmt.Errorf("--watch-prompt cannot be used with an inline prompt (-p)")
+	}
+	if runWatchPrompt && runIssue != "" {
+		return fmt.Errorf("--watch-prompt cannot be used with --prompt-from-issue")
+	}
 
 	// Validate stuck threshold
 	if cfg.StuckThreshold < 0 {
 		return fmt.Errorf("stuck_threshold must be a positive integer, got %d", cfg.StuckThreshold)
 	}
 
+	// Validate max no-change iterations
+	if cfg.MaxNoChange < 0 {
+		return fmt.Errorf("max_no_change must be a positive integer, got %d", cfg.MaxNoChange)
+	}
+
+	// Validate rate limit wait (0 means unset, as in the config files)
+	if cfg.RateLimitWait < 0 {
+		return fmt.Errorf("rate_limit_wait must be at least 1, got %d", cfg.RateLimitWait)
+	}
+
+	// Validate commit grace iterations
+	if cfg.CommitGraceIterations < 0 {
+		return fmt.Errorf("commit_grace_iterations must be a positive integer, got %d", cfg.CommitGraceIterations)
+	}
+
+	// Validate max line length
+	if cfg.MaxLineLength < 0 {
+		return fmt.Errorf("max_line_length must be a positive integer, got %d", cfg.MaxLineLength)
+	}
+
+	// Validate max line size (0 means unset: the adapters keep their default)
+	if cfg.MaxLineSize < 0 {
+		return fmt.Errorf("max_line_size must be at least 1, got %d", cfg.MaxLineSize)
+	}
+
 	// Validate max iterations
 	if cfg.MaxIterations < 0 {
 		return fmt.Errorf("max iterations must be non-negative, got %d", cfg.MaxIterations)
 	}
 
-	// Validate agent exists
-	if _, err := agent.GetAgent(cfg.CLI); err != nil {
+	// A plan is a single iteration
+	if runPlanOnly && cfg.ChooChoo {
+		return fmt.Errorf("--plan-only runs a single iteration and can't be combined with --choo-choo")
+	}
+
+	// Validate the file change guardrail
+	if runMaxFiles < 0 {
+		return fmt.Errorf("--max-file-changes must be non-negative, got %d", runMaxFiles)
+	}
+
+	// Validate the commit interval
+	if runCommitEvery < 0 {
+		return fmt.Errorf("--commit-interval must be non-negative, got %d", runCommitEvery)
+	}
+
+	// Validate push args
+	if err := config.ValidatePushArgs(cfg.PushArgs); err != nil {
+		return err
+	}
+
+	// Validate tool patterns
+	if _, err := adapter.ParseToolPatterns(cfg.ToolPatterns); err != nil {
+		return err
+	}
+
+	// Validate done signal
+	if _, err := regexp.Compile(cfg.DoneSignal); err != nil {
+		return fmt.Errorf("invalid done_signal: %w", err)
+	}
+
+	// Validate commit message pattern
+	if _, err := regexp.Compile(cfg.CommitMessagePattern); err != nil {
+		return fmt.Errorf("invalid commit_message_pattern: %w", err)
+	}
+	if runStrict && cfg.CommitMessagePattern == "" {
+		return fmt.Errorf("--strict-commits requires commit_message_pattern to be set")
+	}
+
+	// Validate banner style
+	if cfg.BannerStyle != "" && !contains(ui.BannerStyles(), cfg.BannerStyle) {
+		return fmt.Errorf("invalid banner_style '%s' (valid: %s)", cfg.BannerStyle, strings.Join(ui.BannerStyles(), ", "))
+	}
+
+	if cfg.CommitCountSource != "" && !contains(config.CommitCountSources(), cfg.CommitCountSource) {
+		return fmt.Errorf("invalid commit_count_source '%s' (valid: %s)", cfg.CommitCountSource, strings.Join(config.CommitCountSources(), ", "))
+	}
+
+	// Validate theme (and select it for all output)
+	if err := ui.SetTheme(cfg.Theme); err != nil {
+		return err
+	}
+
+	// Validate agent exists and is complete
+	ag, err := agent.GetAgent(cfg.CLI)
+	if err != nil {
 		return fmt.Errorf("invalid agent: %w", err)
 	}
+	if err := ag.Validate(); err != nil {
+		return fmt.Errorf("invalid agent: %w", err)
+	}
+
+	// Validate pipeline stages
+	for i, stage := range cfg.Pipeline {
+		if stage.Agent == "" {
+			return fmt.Errorf("pipeline stage %d has no agent", i+1)
+		}
+		stageAgent, err := agent.GetAgent(stage.Agent)
+		if err != nil {
+			return fmt.Errorf("invalid agent in pipeline stage %d: %w", i+1, err)
+		}
+		if err := stageAgent.Validate(); err != nil {
+			return fmt.Errorf("invalid agent in pipeline stage %d: %w", i+1, err)
+		}
+	}
 
 	// Safety check: Must be in a git repository
 	if !git.IsInsideWorkTree() {
@@ -271,22 +982,67 @@ func validateRunConfig(cfg *RunConfig) error {
 		}
 	}
 
-	// Safety check: Refuse dangerous paths (no override)
+	// Safety check: Looping on a detached HEAD can't push and strands commits
+	if cfg.ChooChoo {
+		detached, err := git.IsDetachedHead()
+		if err != nil {
+			return fmt.Errorf("failed to check branch: %w", err)
+		}
+		if detached {
+			return &SafetyError{
+				Code:    runner.ExitSafety,
+				Message: "refusing to run --choo-choo on a detached HEAD.\n\nCommits would not belong to any branch and cannot be pushed.\nCheck out a branch first: git switch -c <branch>",
+			}
+		}
+	}
+
+	// Safety check: Don't let the agent commit the user's unrelated work
+	if cfg.RequireCleanTree {
+		dirty, err := git.HasChanges()
+		if err != nil {
+			return fmt.Errorf("failed to check working tree: %w", err)
+		}
+		if dirty {
+			return &SafetyError{
+				Code:    runner.ExitSafety,
+				Message: "refusing to start with uncommitted changes (require_clean_tree).\n\nThe agent could commit them along with its own work.\nCommit or stash them first: git stash -u",
+			}
+		}
+	}
+
+	// Safety check: Refuse dangerous paths ($HOME and / with no override)
 	cwd, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("failed to get current directory: %w", err)
 	}
 
 	if git.IsDangerousPath(cwd) {
-		return &SafetyError{
-			Code:    runner.ExitSafety,
-			Message: fmt.Sprintf("refusing to run in dangerous path: %s\n\nFor safety, gumloop refuses to run in system directories.\nPlease run from a project directory.", cwd),
+		if !runAllowDanger || git.IsAlwaysRefusedPath(cwd) {
+			return &SafetyError{
+				Code:    runner.ExitSafety,
+				Message: fmt.Sprintf("refusing to run in dangerous path: %s\n\nFor safety, gumloop refuses to run in system directories.\nPlease run from a project directory.", cwd),
+			}
+		}
+		if !confirmDangerousPath(promptInput, os.Stdout, cwd) {
+			return &SafetyError{
+				Code:    runner.ExitSafety,
+				Message: fmt.Sprintf("refusing to run in dangerous path: %s (not confirmed)", cwd),
+			}
+		}
+	}
+
+	// Validate the agent working directory (must be inside the repo)
+	if cfg.WorkDir != "" {
+		workDir, err := resolveWorkDir(cfg.WorkDir)
+		if err != nil {
+			return err
 		}
+		cfg.WorkDir = workDir
 	}
 
 	// Safety check: Warn if in home subdirectory with choo-choo mode
 	if cfg.ChooChoo && git.IsHomeSubdirectory(cwd) {
-		if !git.ConfirmHomeSubdirectory() {
+		if !confirmHomeSubdirectory(promptInput, os.Stdout) {
 			return &SafetyError{
 				Code:    runner.ExitInterrupt,
 				Message: "cancelled by user",
@@ -297,6 +1053,44 @@ func validateRunConfig(cfg *RunConfig) error {
 	return nil
 }
 
+// resolveWorkDir validates that dir exists and is inside the current repository.
+// Returns the absolute path of the directory.
+func resolveWorkDir(dir string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid workdir %s: %w", dir, err)
+	}
+
+	info, err := os.Stat(absDir)
+	if err != nil {
+		return "", fmt.Errorf("workdir %s does not exist", dir)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("workdir %s is not a directory", dir)
+	}
+
+	root, err := git.GetRepoRoot()
+	if err != nil {
+		return "", err
+	}
+
+	// Resolve symlinks on both sides so /tmp vs /private/tmp style aliases compare equal
+	resolvedDir, err := filepath.EvalSymlinks(absDir)
+	if err != nil {
+		return "", fmt.Errorf("invalid workdir %s: %w", dir, err)
+	}
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("invalid repository root %s: %w", root, err)
+	}
+
+	if resolvedDir != resolvedRoot && !strings.HasPrefix(resolvedDir, resolvedRoot+string(filepath.Separator)) {
+		return "", fmt.Errorf("workdir %s is outside the repository (%s)", dir, root)
+	}
+
+	return absDir, nil
+}
+
 // SafetyError represents a safety check failure with an associated exit code
 type SafetyError struct {
 	Code    runner.ExitCode
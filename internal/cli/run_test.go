@@ -1,11 +1,22 @@
 package cli
 
 import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/adriancodes/gumloop/internal/agent"
 	"github.com/adriancodes/gumloop/internal/config"
+	"github.com/adriancodes/gumloop/internal/git"
+	"github.com/adriancodes/gumloop/internal/github"
+	"github.com/adriancodes/gumloop/internal/memory"
+	"github.com/adriancodes/gumloop/internal/runner"
+	"github.com/adriancodes/gumloop/internal/ui"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -105,6 +116,284 @@ func TestLoadRunConfig_InlinePrompt(t *testing.T) {
 	runPrompt = ""
 }
 
+func TestLoadRunConfig_AutoCLI(t *testing.T) {
+	viper.Reset()
+	defaults := config.Defaults()
+	viper.SetDefault("prompt_file", defaults.PromptFile)
+	viper.Set("cli", "auto")
+	runPrompt = "Fix the tests"
+	defer func() { runPrompt = "" }()
+
+	// Only codex is installed
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "codex"), []byte("#!/bin/sh\n"), 0755))
+	t.Setenv("PATH", bin)
+
+	cfg, err := loadRunConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "codex", cfg.CLI)
+
+	// Nothing installed
+	t.Setenv("PATH", t.TempDir())
+	_, err = loadRunConfig()
+	assert.ErrorContains(t, err, "cli is 'auto' but no supported agent is installed")
+}
+
+func TestLoadRunConfig_PromptFromIssue(t *testing.T) {
+	viper.Reset()
+	defaults := config.Defaults()
+	viper.SetDefault("cli", defaults.CLI)
+	viper.SetDefault("prompt_file", defaults.PromptFile)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/repos/acme/app/issues/12", r.URL.Path)
+		w.Write([]byte(`{"number": 12, "title": "Paginate the user list", "body": "50 per page."}`))
+	}))
+	defer server.Close()
+	origAPI := github.APIURL
+	github.APIURL = server.URL
+	defer func() { github.APIURL = origAPI }()
+
+	runIssue = "acme/app#12"
+	defer func() { runIssue = "" }()
+
+	// Loading and validating the config doesn't fetch the issue...
+	cfg, err := loadRunConfig()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Prompt)
+	cfg.StuckThreshold = -1
+	assert.ErrorContains(t, validateRunConfig(cfg), "stuck_threshold")
+	cfg.StuckThreshold = 3
+	assert.NoError(t, validateRunConfig(cfg))
+	assert.Zero(t, requests, "the issue is fetched only after validation")
+
+	// ...runRun fetches it afterwards
+	prompt, err := issuePrompt(runIssue)
+	require.NoError(t, err)
+	assert.Equal(t, "# Paginate the user list\n\n50 per page.\n", prompt)
+	assert.Equal(t, 1, requests)
+
+	runIssue = "not an issue"
+	assert.ErrorContains(t, validateRunConfig(cfg), "invalid issue")
+}
+
+func TestLoadRunConfig_PromptPrefix(t *testing.T) {
+	viper.Reset()
+	viper.Set("prompt_prefix", "Always run gofmt.")
+	runPrompt = "Fix the tests"
+	defer func() { runPrompt = "" }()
+
+	cfg, err := loadRunConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "Always run gofmt.", cfg.PromptPrefix)
+	// The prefix is applied by runRun, so an empty prompt still fails validation
+	assert.Equal(t, "Fix the tests", cfg.Prompt)
+
+	// --prompt-prefix overrides config
+	runPrefix = "Use tabs."
+	defer func() { runPrefix = "" }()
+
+	cfg, err = loadRunConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "Use tabs.", cfg.PromptPrefix)
+}
+
+func TestLoadRunConfig_NoMemory(t *testing.T) {
+	viper.Reset()
+	viper.Set("memory", true)
+	runPrompt = "Fix the tests"
+	defer func() { runPrompt = "" }()
+
+	cfg, err := loadRunConfig()
+	require.NoError(t, err)
+	assert.True(t, cfg.Memory)
+
+	// --no-memory overrides config
+	runNoMemory = true
+	defer func() { runNoMemory = false }()
+
+	cfg, err = loadRunConfig()
+	require.NoError(t, err)
+	assert.False(t, cfg.Memory)
+
+	// ...and wins over --memory too
+	runMemory = true
+	defer func() { runMemory = false }()
+
+	cfg, err = loadRunConfig()
+	require.NoError(t, err)
+	assert.False(t, cfg.Memory)
+}
+
+func TestPromptPreamble(t *testing.T) {
+	memoryContext := "## Previous Session Context\nCommits made: 2\n"
+
+	t.Run("prefix then memory context then prompt", func(t *testing.T) {
+		prompt := promptPreamble("Always run gofmt.", memoryContext) + "Fix the tests"
+		assert.Equal(t, "Always run gofmt.\n\n"+memoryContext+"\nFix the tests", prompt)
+	})
+
+	t.Run("prefix only", func(t *testing.T) {
+		assert.Equal(t, "Always run gofmt.\n\n", promptPreamble("Always run gofmt.", ""))
+	})
+
+	t.Run("memory context only", func(t *testing.T) {
+		assert.Equal(t, memoryContext+"\n", promptPreamble("", memoryContext))
+	})
+
+	t.Run("neither", func(t *testing.T) {
+		assert.Equal(t, "", promptPreamble("", ""))
+	})
+}
+
+func TestLoadRunConfig_CustomAgent(t *testing.T) {
+	viper.Reset()
+	viper.SetConfigType("yaml")
+	require.NoError(t, viper.ReadConfig(strings.NewReader(`cli: my-agent
+agents:
+  - id: my-agent
+    command: my-agent run
+    flags: ["--yes"]
+    interactive_flags: ["--ask", "--print"]
+    terminal_flags: ["--ask"]
+    model_flag: --model
+    prompt_style: pipe
+  - id: plain-agent
+    command: plain-agent
+    interactive_flags: ["--print"]
+    prompt_style: arg
+`)))
+	runPrompt = "Fix the tests"
+	defer func() {
+		runPrompt = ""
+		delete(agent.Registry, "my-agent")
+		delete(agent.Registry, "plain-agent")
+	}()
+
+	cfg, err := loadRunConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "my-agent", cfg.CLI)
+
+	ag, err := agent.GetAgent("my-agent")
+	require.NoError(t, err)
+	assert.Equal(t, "my-agent", ag.Name)
+	assert.Equal(t, "my-agent", ag.CheckCommand)
+	assert.Equal(t, agent.PromptStylePipe, ag.PromptStyle)
+	assert.Equal(t, []string{"my-agent", "run", "--yes", "--model", "m1"}, ag.BuildCommand("hi", "m1", true))
+	// Autonomous flags stay out of single runs, and single-run flags out
+	// of --interactive
+	assert.Equal(t, []string{"my-agent", "run", "--ask", "--print", "--model", "m1"}, ag.BuildCommand("hi", "m1", false))
+	assert.Equal(t, []string{"my-agent", "run", "--ask"}, ag.BuildTerminalCommand("hi", ""))
+
+	plain, err := agent.GetAgent("plain-agent")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"plain-agent", "hi"}, plain.BuildTerminalCommand("hi", ""))
+
+	// Built-ins are still there
+	_, err = agent.GetAgent("claude")
+	assert.NoError(t, err)
+
+	// Loading the config again re-registers the agent
+	_, err = loadRunConfig()
+	assert.NoError(t, err)
+}
+
+func TestLoadRunConfig_MaxLineSize(t *testing.T) {
+	viper.Reset()
+	viper.SetConfigType("yaml")
+	require.NoError(t, viper.ReadConfig(strings.NewReader("max_line_size: 32\n")))
+	runPrompt = "Fix the tests"
+	defer func() { runPrompt = ""; runLineSize = 0 }()
+
+	cfg, err := loadRunConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 32, cfg.MaxLineSize)
+
+	// --max-line-size wins
+	runLineSize = 64
+	cfg, err = loadRunConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 64, cfg.MaxLineSize)
+
+	runLineSize = -1
+	cfg, err = loadRunConfig()
+	require.NoError(t, err)
+	assert.ErrorContains(t, validateRunConfig(cfg), "max_line_size must be at least 1, got -1")
+}
+
+func TestRegisterCustomAgents_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		def     config.CustomAgent
+		wantErr string
+	}{
+		{"replaces a built-in", config.CustomAgent{ID: "claude", Command: "my-claude", PromptStyle: "arg"}, "built in"},
+		{"no command", config.CustomAgent{ID: "broken-agent", PromptStyle: "arg"}, "has no command"},
+		{"bad prompt style", config.CustomAgent{ID: "broken-agent", Command: "broken", PromptStyle: "fax"}, "unknown prompt style 'fax'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := registerCustomAgents([]config.CustomAgent{tt.def})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	_, err := agent.GetAgent("broken-agent")
+	assert.Error(t, err)
+	claude, err := agent.GetAgent("claude")
+	require.NoError(t, err)
+	assert.Equal(t, "claude", claude.Command)
+}
+
+func TestLoadRunConfig_Profile(t *testing.T) {
+	viper.Reset()
+	defaults := config.Defaults()
+	viper.SetDefault("cli", defaults.CLI)
+	viper.SetDefault("stuck_threshold", defaults.StuckThreshold)
+	viper.Set("model", "sonnet")
+	viper.Set("profiles", map[string]interface{}{
+		"thorough": map[string]interface{}{"model": "opus", "verify": "make ci", "stuck_threshold": 6},
+	})
+	runPrompt = "Fix the tests"
+	defer func() { runPrompt = ""; runProfile = ""; runVerify = "" }()
+
+	t.Run("profile overrides config", func(t *testing.T) {
+		runProfile = "thorough"
+		cfg, err := loadRunConfig()
+		require.NoError(t, err)
+		assert.Equal(t, "claude", cfg.CLI)
+		assert.Equal(t, "opus", cfg.Model)
+		assert.Equal(t, "make ci", cfg.Verify)
+		assert.Equal(t, 6, cfg.StuckThreshold)
+	})
+
+	t.Run("flags override the profile", func(t *testing.T) {
+		runProfile = "thorough"
+		runVerify = "go test ./..."
+		cfg, err := loadRunConfig()
+		require.NoError(t, err)
+		assert.Equal(t, "go test ./...", cfg.Verify)
+		runVerify = ""
+	})
+
+	t.Run("no profile leaves config alone", func(t *testing.T) {
+		runProfile = ""
+		cfg, err := loadRunConfig()
+		require.NoError(t, err)
+		assert.Equal(t, "sonnet", cfg.Model)
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		runProfile = "fast"
+		_, err := loadRunConfig()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown profile 'fast' (available: thorough)")
+	})
+}
+
 func TestLoadRunConfig_PromptFromFile(t *testing.T) {
 	// Create temp prompt file
 	tmpDir := t.TempDir()
@@ -130,6 +419,64 @@ func TestLoadRunConfig_PromptFromFile(t *testing.T) {
 	runPromptFile = ""
 }
 
+func TestLoadRunConfig_PromptSearch(t *testing.T) {
+	origDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(origDir)
+
+	// outside/PROMPT.md sits above the repo and is never used
+	outside, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "PROMPT.md"), []byte("Outside prompt"), 0644))
+	root := filepath.Join(outside, "repo")
+	nested := filepath.Join(root, "packages", "api")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".git"), 0755))
+	require.NoError(t, os.MkdirAll(nested, 0755))
+	require.NoError(t, os.Chdir(nested))
+
+	load := func(t *testing.T, search bool) *RunConfig {
+		t.Helper()
+		viper.Reset()
+		viper.SetDefault("cli", config.Defaults().CLI)
+		viper.SetDefault("prompt_file", config.Defaults().PromptFile)
+		viper.Set("prompt_search", search)
+		cfg, err := loadRunConfig()
+		require.NoError(t, err)
+		return cfg
+	}
+
+	t.Run("stops at the repo root", func(t *testing.T) {
+		cfg := load(t, true)
+		assert.Empty(t, cfg.Prompt)
+		assert.Equal(t, "PROMPT.md", cfg.PromptFile)
+	})
+
+	rootPrompt := filepath.Join(root, "PROMPT.md")
+	require.NoError(t, os.WriteFile(rootPrompt, []byte("Root prompt"), 0644))
+
+	t.Run("finds the root prompt from a nested dir", func(t *testing.T) {
+		cfg := load(t, true)
+		assert.Equal(t, "Root prompt", cfg.Prompt)
+		assert.Equal(t, rootPrompt, cfg.PromptFile)
+	})
+
+	t.Run("off by default", func(t *testing.T) {
+		cfg := load(t, false)
+		assert.Empty(t, cfg.Prompt)
+	})
+
+	t.Run("nearest file wins", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(root, "packages", "PROMPT.md"), []byte("Packages prompt"), 0644))
+		cfg := load(t, true)
+		assert.Equal(t, "Packages prompt", cfg.Prompt)
+
+		require.NoError(t, os.WriteFile("PROMPT.md", []byte("Local prompt"), 0644))
+		cfg = load(t, true)
+		assert.Equal(t, "Local prompt", cfg.Prompt)
+		assert.Equal(t, "PROMPT.md", cfg.PromptFile)
+	})
+}
+
 func TestLoadRunConfig_ChooChooUnlimited(t *testing.T) {
 	// Reset viper
 	viper.Reset()
@@ -235,6 +582,28 @@ func TestValidateRunConfig_NegativeMaxIterations(t *testing.T) {
 	assert.Contains(t, err.Error(), "max iterations must be non-negative")
 }
 
+func TestValidateRunConfig_CommitMessagePattern(t *testing.T) {
+	cfg := &RunConfig{
+		Config: config.Config{
+			CLI:                  "claude",
+			StuckThreshold:       3,
+			CommitMessagePattern: "^(feat",
+		},
+		Prompt: "test",
+	}
+
+	err := validateRunConfig(cfg)
+	assert.ErrorContains(t, err, "invalid commit_message_pattern")
+
+	// --strict-commits needs a pattern to enforce
+	cfg.CommitMessagePattern = ""
+	runStrict = true
+	defer func() { runStrict = false }()
+
+	err = validateRunConfig(cfg)
+	assert.ErrorContains(t, err, "--strict-commits requires commit_message_pattern")
+}
+
 func TestValidateRunConfig_InvalidAgent(t *testing.T) {
 	cfg := &RunConfig{
 		Config: config.Config{
@@ -250,6 +619,14 @@ func TestValidateRunConfig_InvalidAgent(t *testing.T) {
 	assert.Contains(t, err.Error(), "invalid agent")
 }
 
+func TestBuiltinAgentsValidate(t *testing.T) {
+	for _, id := range agent.ListAgents() {
+		ag, err := agent.GetAgent(id)
+		require.NoError(t, err)
+		assert.NoError(t, ag.Validate(), "built-in agent %s", id)
+	}
+}
+
 func TestValidateRunConfig_NotInGitRepo(t *testing.T) {
 	// Create a temp directory that's not a git repo
 	tmpDir := t.TempDir()
@@ -289,3 +666,468 @@ func TestSafetyError(t *testing.T) {
 
 	assert.Equal(t, "test safety error", err.Error())
 }
+
+func TestResolveWorkDir(t *testing.T) {
+	repoDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	origDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(origDir)
+	require.NoError(t, os.Chdir(repoDir))
+
+	require.NoError(t, os.MkdirAll(filepath.Join("packages", "api"), 0755))
+
+	t.Run("subdirectory inside repo", func(t *testing.T) {
+		dir, err := resolveWorkDir(filepath.Join("packages", "api"))
+		require.NoError(t, err)
+		assert.True(t, filepath.IsAbs(dir))
+		assert.Equal(t, "api", filepath.Base(dir))
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		_, err := resolveWorkDir("does-not-exist")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "does not exist")
+	})
+
+	t.Run("directory outside repo", func(t *testing.T) {
+		outside := t.TempDir()
+		_, err := resolveWorkDir(outside)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "outside the repository")
+	})
+}
+
+func TestRenderStartupBanner(t *testing.T) {
+	runPrompt = ""
+	cfg := &RunConfig{
+		Config: config.Config{
+			CLI:        "claude",
+			Model:      "sonnet",
+			PromptFile: "PROMPT.md",
+			ShowBanner: true,
+		},
+		ChooChoo:      true,
+		MaxIterations: 10,
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		banner := renderStartupBanner(cfg, "main", "")
+		assert.Contains(t, banner, "CLI:    claude")
+		assert.Contains(t, banner, "Prompt: PROMPT.md")
+		assert.Contains(t, banner, "Branch: main")
+	})
+
+	t.Run("token estimate", func(t *testing.T) {
+		memoryContext := strings.Repeat("m", 400)
+		withPrompt := *cfg
+		withPrompt.Prompt = memoryContext + strings.Repeat("p", 800)
+		banner := renderStartupBanner(&withPrompt, "main", memoryContext)
+		assert.Contains(t, banner, "Tokens: ~300 (~100 from memory)")
+
+		withPrompt.BannerStyle = ui.BannerStyleCompact
+		assert.Contains(t, renderStartupBanner(&withPrompt, "main", memoryContext), "~300 tokens")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		disabled := *cfg
+		disabled.ShowBanner = false
+		assert.Empty(t, renderStartupBanner(&disabled, "main", ""))
+	})
+
+	t.Run("compact", func(t *testing.T) {
+		compact := *cfg
+		compact.BannerStyle = ui.BannerStyleCompact
+		banner := renderStartupBanner(&compact, "main", "")
+		assert.Equal(t, 1, strings.Count(banner, "\n"), "compact banner should be one line: %q", banner)
+		assert.Contains(t, banner, "claude/sonnet")
+		assert.NotContains(t, banner, "CLI:")
+	})
+}
+
+func TestValidateRunConfig_BannerStyle(t *testing.T) {
+	cfg := &RunConfig{
+		Config: config.Config{
+			CLI:         "claude",
+			BannerStyle: "tiny",
+		},
+		Prompt: "test",
+	}
+
+	err := validateRunConfig(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid banner_style 'tiny'")
+}
+
+func TestValidateRunConfig_CommitCountSource(t *testing.T) {
+	cfg := &RunConfig{
+		Config: config.Config{
+			CLI:               "claude",
+			CommitCountSource: "all",
+		},
+		Prompt: "test",
+	}
+
+	err := validateRunConfig(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid commit_count_source 'all' (valid: session, branch)")
+}
+
+func TestValidateRunConfig_InteractiveWithTee(t *testing.T) {
+	runInteractive, runTee = true, "run.log"
+	defer func() { runInteractive, runTee = false, "" }()
+
+	cfg := &RunConfig{
+		Config: config.Config{CLI: "claude"},
+		Prompt: "test",
+	}
+
+	err := validateRunConfig(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--interactive cannot be used with --tee")
+}
+
+func TestUseRepo(t *testing.T) {
+	repoDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	createCommit(t, repoDir, "README.md", "hello")
+
+	withTempDir(t)
+	defer func() { git.Dir = "" }()
+
+	require.NoError(t, useRepo(repoDir))
+
+	// Both the process and git now point at the repo
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	expected, err := filepath.EvalSymlinks(repoDir)
+	require.NoError(t, err)
+	actual, err := filepath.EvalSymlinks(cwd)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	assert.True(t, git.IsInsideWorkTree())
+
+	count, err := git.CountCommits()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestUseRepo_Invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	err := useRepo(filepath.Join(dir, "missing"))
+	assert.ErrorContains(t, err, "does not exist")
+
+	file := filepath.Join(dir, "file.txt")
+	require.NoError(t, os.WriteFile(file, []byte("x"), 0644))
+	err = useRepo(file)
+	assert.ErrorContains(t, err, "is not a directory")
+}
+
+func TestValidateRunConfig_DetachedHead(t *testing.T) {
+	repoDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	createCommit(t, repoDir, "a.txt", "a")
+	createCommit(t, repoDir, "b.txt", "b")
+
+	cmd := exec.Command("git", "checkout", "-q", "HEAD~1")
+	cmd.Dir = repoDir
+	require.NoError(t, cmd.Run())
+
+	origDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(origDir)
+	require.NoError(t, os.Chdir(repoDir))
+
+	cfg := &RunConfig{
+		Config: config.Config{
+			CLI:            "claude",
+			StuckThreshold: 3,
+		},
+		Prompt:   "test",
+		ChooChoo: true,
+	}
+
+	err = validateRunConfig(cfg)
+	safetyErr, ok := err.(*SafetyError)
+	require.True(t, ok, "expected SafetyError, got %v", err)
+	assert.Contains(t, safetyErr.Message, "detached HEAD")
+
+	// A single run is still allowed
+	cfg.ChooChoo = false
+	assert.NoError(t, validateRunConfig(cfg))
+}
+
+func TestValidateRunConfig_RequireCleanTree(t *testing.T) {
+	repoDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	createCommit(t, repoDir, "a.txt", "a")
+
+	origDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(origDir)
+	require.NoError(t, os.Chdir(repoDir))
+
+	cfg := &RunConfig{
+		Config: config.Config{
+			CLI:              "claude",
+			StuckThreshold:   3,
+			RequireCleanTree: true,
+		},
+		Prompt: "test",
+	}
+
+	// A clean tree is fine
+	dirty, err := git.HasChanges()
+	require.NoError(t, err)
+	require.False(t, dirty)
+	assert.NoError(t, validateRunConfig(cfg))
+
+	// Uncommitted work blocks the run
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "wip.txt"), []byte("wip"), 0644))
+	dirty, err = git.HasChanges()
+	require.NoError(t, err)
+	require.True(t, dirty)
+
+	err = validateRunConfig(cfg)
+	safetyErr, ok := err.(*SafetyError)
+	require.True(t, ok, "expected SafetyError, got %v", err)
+	assert.Contains(t, safetyErr.Message, "uncommitted changes")
+	assert.Contains(t, safetyErr.Message, "stash")
+
+	// It's opt-in
+	cfg.RequireCleanTree = false
+	assert.NoError(t, validateRunConfig(cfg))
+}
+
+func TestRemapExitCode(t *testing.T) {
+	successCodes := []int{0, int(runner.ExitMaxIterations)}
+
+	// The process exit is remapped...
+	assert.Equal(t, 0, remapExitCode(int(runner.ExitMaxIterations), successCodes))
+	assert.Equal(t, 0, remapExitCode(int(runner.ExitSuccess), successCodes))
+	assert.Equal(t, int(runner.ExitStuck), remapExitCode(int(runner.ExitStuck), successCodes))
+	assert.Equal(t, int(runner.ExitStuck), remapExitCode(int(runner.ExitStuck), nil))
+
+}
+
+func TestFinishRun_SuccessCodes(t *testing.T) {
+	withTempDir(t)
+	runSuccess = []int{0, int(runner.ExitMaxIterations)}
+	defer func() { runSuccess = nil }()
+
+	ag, err := agent.GetAgent("claude")
+	require.NoError(t, err)
+	r := runner.New(&config.Config{}, "task", ag, true, 3, nil)
+
+	var buf bytes.Buffer
+	out, err := runner.NewOutput(runner.FormatHuman, &buf, 0)
+	require.NoError(t, err)
+
+	// The process exit is remapped...
+	code := finishRun(r, out, runner.ExitMaxIterations, ag.Name, "main")
+	assert.Equal(t, 0, code)
+
+	// ...while the summary keeps the original reason
+	assert.Contains(t, buf.String(), "Max iterations reached")
+	assert.Contains(t, buf.String(), "Exit code 3 reported as 0")
+
+	// Codes that aren't listed pass through
+	buf.Reset()
+	assert.Equal(t, int(runner.ExitStuck), finishRun(r, out, runner.ExitStuck, ag.Name, "main"))
+	assert.NotContains(t, buf.String(), "reported as 0")
+}
+
+func TestFinishRun_SummaryOnlyOnChange(t *testing.T) {
+	runTerse = true
+	defer func() { runTerse = false }()
+
+	ag, err := agent.GetAgent("claude")
+	require.NoError(t, err)
+	r := runner.New(&config.Config{}, "task", ag, true, 3, nil)
+
+	summary := func(exitCode runner.ExitCode) string {
+		var buf bytes.Buffer
+		out, err := runner.NewOutput(runner.FormatHuman, &buf, 0)
+		require.NoError(t, err)
+		finishRun(r, out, exitCode, ag.Name, "main")
+		return buf.String()
+	}
+
+	withTempRepo(t)
+	assert.NotContains(t, summary(runner.ExitSuccess), "╭", "a clean, complete run gets one line")
+	assert.NotContains(t, summary(runner.ExitMaxIterations), "╭")
+	assert.Contains(t, summary(runner.ExitStuck), "╭", "a stuck run gets the full summary")
+	assert.Contains(t, summary(runner.ExitError), "╭")
+
+	// Without a repo to check, the full summary is shown
+	withTempDir(t)
+	assert.Contains(t, summary(runner.ExitSuccess), "╭")
+}
+
+func TestFinishRun_ShowsIterationErrors(t *testing.T) {
+	withTempRepo(t)
+
+	// The agent commits, but verification fails every time
+	shell := &agent.Agent{ID: "shell", Name: "Shell", Command: "sh", AutonomousFlags: []string{"-c"}, PromptStyle: agent.PromptStyleArg}
+	cfg := &config.Config{StuckThreshold: 3, Verify: "echo broken >&2; exit 1"}
+	r := runner.New(cfg, "git commit -q --allow-empty -m work", shell, true, 2, nil)
+
+	var buf bytes.Buffer
+	out, err := runner.NewOutput(runner.FormatHuman, &buf, 0)
+	require.NoError(t, err)
+	r.SetOutput(out)
+
+	captureStdout(t, func() {
+		finishRun(r, out, r.Run(), shell.Name, "main")
+	})
+
+	assert.Contains(t, buf.String(), "2 iteration error(s)")
+	assert.Contains(t, buf.String(), "verification failed")
+}
+
+func TestIndentLines(t *testing.T) {
+	assert.Equal(t, "    # Task\n    \n    Fix it", indentLines("# Task\n\nFix it\n", "    "))
+	assert.Equal(t, "  one line", indentLines("one line", "  "))
+}
+
+func TestLoadSessionMemory_ModelFromMemory(t *testing.T) {
+	origDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(origDir)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	defer func() { runModelMemory, runModel = false, "" }()
+
+	claude, err := agent.GetAgent("claude")
+	require.NoError(t, err)
+	previous := &memory.SessionMemory{AgentName: claude.Name, Model: "opus"}
+	require.NoError(t, previous.Save(memory.DefaultFileName))
+
+	load := func(configModel string) (*RunConfig, *memory.SessionMemory) {
+		cfg := &RunConfig{Config: config.Config{CLI: "claude", Model: configModel, Memory: true}}
+		mem, _ := loadSessionMemory(cfg, "main", claude)
+		return cfg, mem
+	}
+
+	t.Run("off by default", func(t *testing.T) {
+		cfg, mem := load("sonnet")
+		assert.Equal(t, "sonnet", cfg.Model)
+		assert.Equal(t, "sonnet", mem.Model, "the session's model should be recorded")
+	})
+
+	t.Run("reuses the previous model", func(t *testing.T) {
+		runModelMemory = true
+		cfg, mem := load("sonnet")
+		assert.Equal(t, "opus", cfg.Model)
+		assert.Equal(t, "opus", mem.Model)
+	})
+
+	t.Run("--model wins", func(t *testing.T) {
+		runModelMemory, runModel = true, "haiku"
+		defer func() { runModel = "" }()
+		cfg, _ := load("haiku") // loadRunConfig has already applied --model
+		assert.Equal(t, "haiku", cfg.Model)
+	})
+
+	t.Run("other agent's model is ignored", func(t *testing.T) {
+		runModelMemory = true
+		other := &memory.SessionMemory{AgentName: "OpenAI Codex", Model: "gpt-5"}
+		require.NoError(t, other.Save(memory.DefaultFileName))
+		defer previous.Save(memory.DefaultFileName)
+
+		cfg, _ := load("sonnet")
+		assert.Equal(t, "sonnet", cfg.Model)
+	})
+}
+
+func TestLoadRunConfig_ConfigFiles(t *testing.T) {
+	origDir, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(origDir)
+	project := t.TempDir()
+	require.NoError(t, os.Chdir(project))
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	globalPath := filepath.Join(home, ".config", "gumloop", "config.yaml")
+	projectPath, err := filepath.Abs(".gumloop.yaml")
+	require.NoError(t, err)
+
+	load := func(t *testing.T) *RunConfig {
+		t.Helper()
+		viper.Reset()
+		defer func() { configFiles = nil }()
+		configFiles = readConfigFiles(configFileCandidates())
+		cfg, err := loadRunConfig()
+		require.NoError(t, err)
+		return cfg
+	}
+
+	t.Run("neither file", func(t *testing.T) {
+		cfg := load(t)
+		assert.Equal(t, []ConfigFile{
+			{Scope: "global", Path: globalPath},
+			{Scope: "project", Path: projectPath},
+		}, cfg.ConfigFiles)
+		assert.Equal(t, "global: "+globalPath+" (not found)\nproject: "+projectPath+" (not found)\n", describeConfigFiles(cfg.ConfigFiles, ""))
+	})
+
+	t.Run("project only", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(".gumloop.yaml", []byte("cli: codex\n"), 0644))
+		defer os.Remove(".gumloop.yaml")
+
+		cfg := load(t)
+		assert.False(t, cfg.ConfigFiles[0].Loaded)
+		assert.True(t, cfg.ConfigFiles[1].Loaded)
+		assert.Equal(t, "codex", cfg.CLI)
+	})
+
+	t.Run("both, project over global", func(t *testing.T) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(globalPath), 0755))
+		require.NoError(t, os.WriteFile(globalPath, []byte("cli: gemini\nverify: make test\n"), 0644))
+		require.NoError(t, os.WriteFile(".gumloop.yaml", []byte("cli: codex\n"), 0644))
+		defer os.Remove(".gumloop.yaml")
+
+		cfg := load(t)
+		assert.True(t, cfg.ConfigFiles[0].Loaded)
+		assert.True(t, cfg.ConfigFiles[1].Loaded)
+		assert.Equal(t, "codex", cfg.CLI)
+		assert.Equal(t, "make test", cfg.Verify, "global settings the project doesn't set should be kept")
+	})
+
+	t.Run("--config replaces both", func(t *testing.T) {
+		cfgFile = filepath.Join(project, "ci.yaml")
+		defer func() { cfgFile = "" }()
+		require.NoError(t, os.WriteFile(cfgFile, []byte("cli: opencode\n"), 0644))
+
+		cfg := load(t)
+		assert.Equal(t, []ConfigFile{{Scope: "--config", Path: cfgFile, Loaded: true}}, cfg.ConfigFiles)
+		assert.Equal(t, "opencode", cfg.CLI)
+	})
+}
+
+func TestPipelineStages(t *testing.T) {
+	cfg := &RunConfig{Config: config.Config{
+		Model: "sonnet",
+		Pipeline: []config.PipelineStage{
+			{Agent: "claude"},
+			{Agent: "codex", Model: "o3", PromptSuffix: "Review the last commit"},
+		},
+	}}
+
+	stages, err := pipelineStages(cfg)
+	require.NoError(t, err)
+	require.Len(t, stages, 2)
+	assert.Equal(t, "sonnet", stages[0].Model, "a stage without a model uses the run's")
+	assert.Equal(t, "o3", stages[1].Model)
+	assert.Equal(t, "Review the last commit", stages[1].PromptSuffix)
+}
+
+func TestPreflightStages(t *testing.T) {
+	installed := &agent.Agent{Name: "Shell", CheckCommand: "sh"}
+	missing := &agent.Agent{Name: "Missing", CheckCommand: "gumloop-no-such-agent"}
+
+	assert.NoError(t, preflightStages([]runner.Stage{{Agent: installed}}))
+	assert.EqualError(t, preflightStages([]runner.Stage{{Agent: installed}, {Agent: missing}}),
+		"pipeline stage 2: Missing is not installed (gumloop-no-such-agent not found in PATH)")
+}
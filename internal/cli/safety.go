@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/adriancodes/gumloop/internal/ui"
+)
+
+// confirmHomeSubdirectory asks the user on out to confirm running in
+// choo-choo mode when the current directory is a subdirectory of $HOME,
+// reading the answer from in.
+// Returns true if the user confirms, false otherwise.
+func confirmHomeSubdirectory(in io.Reader, out io.Writer) bool {
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "⚠️  WARNING: You are running in choo-choo mode under your home directory.")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "   Autonomous agents will make changes without asking for permission.")
+	fmt.Fprintln(out, "   Git is your safety net, but use caution.")
+	fmt.Fprintln(out)
+
+	return ui.Confirm(in, out, "Continue?", false)
+}
+
+// confirmDangerousPath asks the user on out to confirm running in path, a
+// dangerous directory allowed by --allow-dangerous-path, reading answers
+// from in. A y/n answer isn't enough: the user must also type the path
+// itself, so piping "yes" in can't approve it by accident.
+// Returns true if the user confirms, false otherwise.
+func confirmDangerousPath(in io.Reader, out io.Writer, path string) bool {
+	// Both answers come from one reader so the first can't swallow the second
+	reader := bufio.NewReader(in)
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "⚠️  WARNING: %s is a system or configuration directory.\n", path)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "   Autonomous agents will change files here without asking for permission.")
+	fmt.Fprintln(out, "   A mistake can break your system or expose credentials.")
+	fmt.Fprintln(out)
+
+	if !ui.Confirm(reader, out, "Run here anyway?", false) {
+		return false
+	}
+
+	fmt.Fprintf(out, "Type the full path (%s) to confirm: ", path)
+	typed, err := reader.ReadString('\n')
+	if err != nil && typed == "" {
+		fmt.Fprintln(out)
+		return false
+	}
+	typed = strings.TrimSpace(typed)
+	return typed != "" && filepath.Clean(typed) == filepath.Clean(path)
+}
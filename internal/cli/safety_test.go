@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfirmHomeSubdirectory(t *testing.T) {
+	var out strings.Builder
+	assert.True(t, confirmHomeSubdirectory(strings.NewReader("y\n"), &out))
+	assert.Contains(t, out.String(), "WARNING: You are running in choo-choo mode under your home directory")
+	assert.Contains(t, out.String(), "Continue? (y/N): ")
+
+	assert.False(t, confirmHomeSubdirectory(strings.NewReader("\n"), io.Discard))
+	assert.False(t, confirmHomeSubdirectory(strings.NewReader(""), io.Discard))
+}
+
+func TestConfirmDangerousPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{"yes and the path", "y\n/etc\n", true},
+		{"trailing slash and spaces", "yes\n  /etc/ \n", true},
+		{"no last newline", "y\n/etc", true},
+		{"yes alone", "y\n", false},
+		{"yes twice", "y\ny\n", false},
+		{"wrong path", "y\n/usr\n", false},
+		{"empty path", "y\n\n", false},
+		{"path without yes", "/etc\n/etc\n", false},
+		{"no", "n\n/etc\n", false},
+		{"no input", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			assert.Equal(t, tt.want, confirmDangerousPath(strings.NewReader(tt.input), &out, "/etc"))
+			assert.Contains(t, out.String(), "WARNING: /etc is a system or configuration directory")
+			assert.Contains(t, out.String(), "Run here anyway? (y/N): ")
+		})
+	}
+
+	// The path is only asked for after a yes
+	var out strings.Builder
+	confirmDangerousPath(strings.NewReader("n\n"), &out, "/etc")
+	assert.NotContains(t, out.String(), "Type the full path")
+	out.Reset()
+	confirmDangerousPath(strings.NewReader("y\n/etc\n"), &out, "/etc")
+	assert.Contains(t, out.String(), "Type the full path (/etc) to confirm: ")
+}
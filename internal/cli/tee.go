@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/adriancodes/gumloop/internal/git"
+)
+
+// ansiPattern matches ANSI escape sequences (colors, cursor movement)
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// ansiPrefix matches the start of an escape sequence cut off mid-way
+var ansiPrefix = regexp.MustCompile(`\x1b(\[[0-9;?]*)?$`)
+
+// ansiStripWriter writes to w with ANSI escape sequences removed.
+// An escape sequence split across writes is held back until it completes.
+type ansiStripWriter struct {
+	w       io.Writer
+	pending []byte
+}
+
+func (s *ansiStripWriter) Write(p []byte) (int, error) {
+	data := append(s.pending, p...)
+	s.pending = nil
+
+	// Hold back a trailing escape sequence that hasn't been terminated yet
+	if loc := ansiPrefix.FindIndex(data); loc != nil {
+		s.pending = append([]byte(nil), data[loc[0]:]...)
+		data = data[:loc[0]]
+	}
+
+	if _, err := s.w.Write(ansiPattern.ReplaceAll(data, nil)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Control bytes marking the two versions of an agent message line cut at
+// max_line_length (see teeStdout): the cut line is only shown on the
+// terminal, the full line is only written to the file
+const (
+	terminalOnlyStart = 0x1c
+	terminalOnlyEnd   = 0x1d
+	fileOnlyStart     = 0x1e
+	fileOnlyEnd       = 0x1f
+)
+
+// teeStdout writes to os.Stdout while a tee is running. It's a
+// runner.LineCutter: a cut line and its full version both go through the
+// stdout pipe, in order with everything else, marked for the side of the
+// tee that shows them.
+type teeStdout struct{}
+
+func (teeStdout) Write(p []byte) (int, error) {
+	return os.Stdout.Write(p)
+}
+
+func (teeStdout) WriteCutLine(cut, full string) error {
+	line := make([]byte, 0, len(cut)+len(full)+5)
+	line = append(line, terminalOnlyStart)
+	line = append(line, cut...)
+	line = append(line, terminalOnlyEnd, fileOnlyStart)
+	line = append(line, full...)
+	line = append(line, fileOnlyEnd, '\n')
+	_, err := os.Stdout.Write(line)
+	return err
+}
+
+// sideWriter writes one side of the tee's stdout to w: text marked for the
+// other side (between drop and its end marker) is left out, and the
+// markers are removed
+type sideWriter struct {
+	w        io.Writer
+	drop     byte // terminalOnlyStart or fileOnlyStart
+	dropping bool
+}
+
+func (s *sideWriter) Write(p []byte) (int, error) {
+	out := make([]byte, 0, len(p))
+	for _, b := range p {
+		switch b {
+		case terminalOnlyStart, fileOnlyStart:
+			s.dropping = b == s.drop
+		case terminalOnlyEnd, fileOnlyEnd:
+			s.dropping = false
+		default:
+			if !s.dropping {
+				out = append(out, b)
+			}
+		}
+	}
+	if _, err := s.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// checkTeePath refuses a --tee file inside the git working tree unless it
+// is git-ignored: it would be an untracked file that counts as a change
+// every iteration, and gets swept into the commits of leftover changes.
+func checkTeePath(path string) error {
+	root, err := git.GetRepoRoot()
+	if err != nil {
+		// Not in a repository; the run's own checks report that
+		return nil
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("invalid --tee path %s: %w", path, err)
+	}
+	// git reports the root with symlinks resolved, so resolve the file's
+	// directory (the file itself may not exist yet) the same way
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(absPath)); err == nil {
+		absPath = filepath.Join(dir, filepath.Base(absPath))
+	}
+
+	rel, err := filepath.Rel(root, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+	if rel == ".git" || strings.HasPrefix(rel, ".git"+string(filepath.Separator)) {
+		return nil
+	}
+	if ignored, err := git.IgnoredPaths([]string{absPath}); err == nil && len(ignored) > 0 {
+		return nil
+	}
+	return fmt.Errorf("--tee file %s is inside the repository, where it would count as an uncommitted change: write it outside the repository or add it to .gitignore", path)
+}
+
+// startTee mirrors everything written to os.Stdout and os.Stderr into the
+// file at path, with ANSI escape sequences stripped from the copy. Child
+// processes started afterwards inherit the redirected streams too.
+// The returned function restores the original streams and closes the file;
+// it must be called before the process exits or the tail of the output is
+// lost. Calling it more than once is safe.
+// Write agent output through teeStdout to cut long lines on the terminal
+// only.
+func startTee(path string) (func() error, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tee file: %w", err)
+	}
+	stripped := &ansiStripWriter{w: file}
+
+	origStdout, origStderr := os.Stdout, os.Stderr
+	var mu sync.Mutex // Serializes stdout and stderr writes into the file
+	var wg sync.WaitGroup
+
+	redirect := func(orig, mirror io.Writer) (*os.File, error) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return nil, err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, 32*1024)
+			for {
+				n, err := r.Read(buf)
+				if n > 0 {
+					mu.Lock()
+					io.MultiWriter(orig, mirror).Write(buf[:n])
+					mu.Unlock()
+				}
+				if err != nil {
+					r.Close()
+					return
+				}
+			}
+		}()
+		return w, nil
+	}
+
+	stdoutW, err := redirect(&sideWriter{w: origStdout, drop: fileOnlyStart}, &sideWriter{w: stripped, drop: terminalOnlyStart})
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to redirect stdout: %w", err)
+	}
+	stderrW, err := redirect(origStderr, stripped)
+	if err != nil {
+		stdoutW.Close()
+		wg.Wait()
+		file.Close()
+		return nil, fmt.Errorf("failed to redirect stderr: %w", err)
+	}
+	os.Stdout, os.Stderr = stdoutW, stderrW
+
+	var once sync.Once
+	var closeErr error
+	stop := func() error {
+		once.Do(func() {
+			os.Stdout, os.Stderr = origStdout, origStderr
+			stdoutW.Close()
+			stderrW.Close()
+			wg.Wait()
+			closeErr = file.Close()
+		})
+		return closeErr
+	}
+	return stop, nil
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adriancodes/gumloop/internal/adapter"
+	"github.com/adriancodes/gumloop/internal/config"
+	"github.com/adriancodes/gumloop/internal/runner"
+	"github.com/adriancodes/gumloop/internal/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnsiStripWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := &ansiStripWriter{w: &buf}
+
+	// The second sequence is split across writes
+	_, err := w.Write([]byte("\x1b[1;32mgreen\x1b[0m and \x1b[3"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("1mred\x1b[0m\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "green and red\n", buf.String())
+}
+
+func TestStartTee(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.log")
+	runPrompt = ""
+	cfg := &RunConfig{
+		Config: config.Config{CLI: "claude", PromptFile: "PROMPT.md", ShowBanner: true},
+	}
+
+	stop, err := startTee(path)
+	require.NoError(t, err)
+
+	fmt.Println(renderStartupBanner(cfg, "main", ""))
+	fmt.Fprintln(os.Stderr, "\x1b[33m⚠️  Warning: something\x1b[0m")
+	// Child processes inherit the redirected streams
+	cmd := exec.Command("echo", "from the agent")
+	cmd.Stdout = os.Stdout
+	require.NoError(t, cmd.Run())
+	fmt.Println(ui.RenderRunSummary(ui.SummaryConfig{Agent: "Claude", Iterations: 2, Commits: 1, Duration: time.Minute}))
+
+	require.NoError(t, stop())
+	require.NoError(t, stop(), "stop should be safe to call twice")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	content := string(data)
+
+	assert.Contains(t, content, "CLI:    claude")
+	assert.Contains(t, content, "Branch: main")
+	assert.Contains(t, content, "⚠️  Warning: something")
+	assert.Contains(t, content, "from the agent")
+	assert.Contains(t, content, "RUN COMPLETE")
+	assert.NotContains(t, content, "\x1b[")
+}
+
+func TestCheckTeePath(t *testing.T) {
+	repoDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	withTempDir(t)
+	require.NoError(t, os.Chdir(repoDir))
+	require.NoError(t, os.WriteFile(".gitignore", []byte("*.log\n"), 0644))
+
+	// A file in the working tree would be an untracked change...
+	err := checkTeePath("run.txt")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "inside the repository")
+	assert.Error(t, checkTeePath(filepath.Join(repoDir, "logs", "run.txt")))
+
+	// ...unless it's git-ignored, in the git directory or outside the repo
+	assert.NoError(t, checkTeePath("run.log"))
+	assert.NoError(t, checkTeePath(filepath.Join(".git", "run.txt")))
+	assert.NoError(t, checkTeePath(filepath.Join(t.TempDir(), "run.txt")))
+}
+
+func TestSideWriter(t *testing.T) {
+	marked := []byte{terminalOnlyStart, 'c', 'u', 't', terminalOnlyEnd, fileOnlyStart, 'f', 'u', 'l', 'l', fileOnlyEnd, '\n'}
+
+	// Markers split across writes still route the text
+	var terminal, file bytes.Buffer
+	for _, side := range []*sideWriter{{w: &terminal, drop: fileOnlyStart}, {w: &file, drop: terminalOnlyStart}} {
+		_, err := side.Write(append([]byte("before\n"), marked[:7]...))
+		require.NoError(t, err)
+		_, err = side.Write(append(marked[7:], "after\n"...))
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, "before\ncut\nafter\n", terminal.String())
+	assert.Equal(t, "before\nfull\nafter\n", file.String())
+}
+
+func TestStartTee_MaxLineLength(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.log")
+
+	// Stand in for the terminal
+	terminalPath := filepath.Join(t.TempDir(), "terminal")
+	terminalFile, err := os.Create(terminalPath)
+	require.NoError(t, err)
+	origStdout := os.Stdout
+	os.Stdout = terminalFile
+	defer func() { os.Stdout = origStdout }()
+
+	stop, err := startTee(path)
+	require.NoError(t, err)
+
+	long := strings.Repeat("base64", 50)
+	out, err := runner.NewOutput(runner.FormatHuman, teeStdout{}, 20)
+	require.NoError(t, err)
+	out.Event(adapter.AssistantMessage{Text: "short\n" + long})
+	// Other long lines aren't cut
+	fmt.Println(long)
+
+	require.NoError(t, stop())
+	require.NoError(t, terminalFile.Close())
+
+	onTerminal, err := os.ReadFile(terminalPath)
+	require.NoError(t, err)
+	assert.Equal(t, "short\n"+long[:20]+runner.TruncatedSuffix+"\n"+long+"\n", string(onTerminal))
+
+	inFile, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "short\n"+long+"\n"+long+"\n", string(inFile), "the tee file should keep the full line")
+}
@@ -1,11 +1,9 @@
 package cli
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/adriancodes/gumloop/internal/ui"
 	"github.com/spf13/cobra"
@@ -70,7 +68,7 @@ func uninstall() error {
 	fmt.Println()
 
 	// Confirm removal
-	if !confirm("Remove gumloop binary?") {
+	if !ui.Confirm(promptInput, os.Stdout, "Remove gumloop binary?", false) {
 		fmt.Println(ui.MutedStyle.Render("Cancelled."))
 		return nil
 	}
@@ -83,7 +81,7 @@ func uninstall() error {
 
 	// Ask about config directory
 	if configExists {
-		if confirm("Remove global config directory?") {
+		if ui.Confirm(promptInput, os.Stdout, "Remove global config directory?", false) {
 			if err := os.RemoveAll(configDir); err != nil {
 				return fmt.Errorf("failed to remove config directory: %w", err)
 			}
@@ -97,17 +95,3 @@ func uninstall() error {
 	fmt.Println(ui.SuccessStyle.Render("✓ gumloop has been uninstalled"))
 	return nil
 }
-
-// confirm prompts the user for yes/no confirmation
-func confirm(prompt string) bool {
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Printf("%s (y/N): ", prompt)
-
-	response, err := reader.ReadString('\n')
-	if err != nil {
-		return false
-	}
-
-	response = strings.TrimSpace(strings.ToLower(response))
-	return response == "y" || response == "yes"
-}
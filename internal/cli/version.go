@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"runtime"
 
+	"github.com/adriancodes/gumloop/internal/logging"
 	"github.com/spf13/cobra"
 )
 
@@ -36,7 +37,7 @@ func runVersion(cmd *cobra.Command, args []string) {
 	)
 
 	// Show additional build info in debug mode
-	if Debug {
+	if logging.Enabled(logging.LevelDebug) {
 		fmt.Printf("\nBuild info:\n")
 		fmt.Printf("  Commit:    %s\n", GitCommit)
 		fmt.Printf("  BuildDate: %s\n", BuildDate)
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/adriancodes/gumloop/internal/git"
+	"github.com/adriancodes/gumloop/internal/watch"
+	"github.com/spf13/cobra"
+)
+
+// watchDebounce is set by the --debounce flag
+var watchDebounce time.Duration
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch [-- run flags]",
+	Short: "Run the agent once whenever files change",
+	Long: `Watch the repository and run the agent once (a single gumloop run)
+each time files change, for "fix on save" workflows.
+
+Changes are collected until nothing has changed for the --debounce delay,
+then one run starts. Files ignored by .gitignore, the .git directory and
+editor swap files don't trigger a run, and changes made during a run
+(usually by the agent) are ignored.
+
+Flags after -- are passed to gumloop run.
+
+Examples:
+  gumloop watch
+  gumloop watch -- --prompt "Make the failing tests pass"
+  gumloop watch --debounce 2s -- --verify "go test ./..."`,
+	RunE: runWatch,
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", 500*time.Millisecond, "Wait until files have stopped changing for this long before running")
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	runArgs, err := watchRunArgs(args)
+	if err != nil {
+		return err
+	}
+	if watchDebounce <= 0 {
+		return fmt.Errorf("--debounce must be positive, got %s", watchDebounce)
+	}
+
+	root, err := git.GetRepoRoot()
+	if err != nil {
+		return err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the gumloop executable: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	w := &watch.Watcher{
+		Root:    root,
+		Delay:   watchDebounce,
+		Ignored: git.IgnoredPaths,
+		OnChange: func(paths []string) {
+			fmt.Printf("\n👀 %s changed, running gumloop\n\n", describeChanges(paths))
+			run := exec.CommandContext(ctx, exe, runArgs...)
+			run.Stdin = os.Stdin
+			run.Stdout = os.Stdout
+			run.Stderr = os.Stderr
+			if err := run.Run(); err != nil && ctx.Err() == nil {
+				var exitErr *exec.ExitError
+				if errors.As(err, &exitErr) {
+					fmt.Printf("\ngumloop run exited with code %d\n", exitErr.ExitCode())
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: failed to start gumloop run: %v\n", err)
+				}
+			}
+			fmt.Printf("\nWatching %s for changes (Ctrl+C to stop)\n", root)
+		},
+	}
+
+	fmt.Printf("Watching %s for changes (Ctrl+C to stop)\n", root)
+	return w.Run(ctx)
+}
+
+// watchRunArgs returns the arguments for the gumloop run started on each
+// change. Watch runs the agent once per change, so --choo-choo is refused.
+func watchRunArgs(args []string) ([]string, error) {
+	for _, arg := range args {
+		if arg == "--choo-choo" || strings.HasPrefix(arg, "--choo-choo=") {
+			return nil, fmt.Errorf("--choo-choo cannot be used with watch (each change starts a single run)")
+		}
+	}
+
+	runArgs := []string{"run"}
+	if cfgFile != "" {
+		runArgs = append(runArgs, "--config", cfgFile)
+	}
+	return append(runArgs, args...), nil
+}
+
+// describeChanges names the changed files, or counts them if there are many
+func describeChanges(paths []string) string {
+	const maxNamed = 3
+	if len(paths) <= maxNamed {
+		return strings.Join(paths, ", ")
+	}
+	return fmt.Sprintf("%s and %d more files", strings.Join(paths[:maxNamed], ", "), len(paths)-maxNamed)
+}
@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchRunArgs(t *testing.T) {
+	args, err := watchRunArgs([]string{"--prompt", "fix the tests"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"run", "--prompt", "fix the tests"}, args)
+
+	cfgFile = "ci.yaml"
+	defer func() { cfgFile = "" }()
+	args, err = watchRunArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"run", "--config", "ci.yaml"}, args)
+
+	for _, arg := range []string{"--choo-choo", "--choo-choo=5"} {
+		_, err = watchRunArgs([]string{arg})
+		assert.Error(t, err, arg)
+	}
+}
+
+func TestDescribeChanges(t *testing.T) {
+	assert.Equal(t, "main.go", describeChanges([]string{"main.go"}))
+	assert.Equal(t, "a.go, b.go, c.go", describeChanges([]string{"a.go", "b.go", "c.go"}))
+	assert.Equal(t, "a.go, b.go, c.go and 2 more files", describeChanges([]string{"a.go", "b.go", "c.go", "d.go", "e.go"}))
+}
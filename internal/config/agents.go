@@ -0,0 +1,45 @@
+package config
+
+// CustomAgent defines an agent gumloop doesn't ship with. Custom agents are
+// registered alongside the built-ins at startup and selected by id like
+// any other, with cli or a pipeline stage.
+type CustomAgent struct {
+	ID               string   `yaml:"id" mapstructure:"id"`
+	Name             string   `yaml:"name,omitempty" mapstructure:"name"`                           // Defaults to the id
+	Command          string   `yaml:"command" mapstructure:"command"`                               // e.g. "my-agent run"
+	Flags            []string `yaml:"flags,omitempty" mapstructure:"flags"`                         // Passed in choo-choo mode
+	InteractiveFlags []string `yaml:"interactive_flags,omitempty" mapstructure:"interactive_flags"` // Passed in single runs
+	TerminalFlags    []string `yaml:"terminal_flags,omitempty" mapstructure:"terminal_flags"`       // Passed with run --interactive
+	ModelFlag        string   `yaml:"model_flag,omitempty" mapstructure:"model_flag"`               // Empty if the agent takes no model
+	PromptStyle      string   `yaml:"prompt_style" mapstructure:"prompt_style"`                     // arg, pipe, stream or ollama
+}
+
+// hasAgent reports whether agents defines an agent with the given id
+func hasAgent(agents []CustomAgent, id string) bool {
+	for _, a := range agents {
+		if a.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeAgents returns base with each of overrides added, replacing any
+// agent in base with the same id
+func mergeAgents(base, overrides []CustomAgent) []CustomAgent {
+	result := append([]CustomAgent(nil), base...)
+	for _, override := range overrides {
+		replaced := false
+		for i := range result {
+			if result[i].ID == override.ID {
+				result[i] = override
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			result = append(result, override)
+		}
+	}
+	return result
+}
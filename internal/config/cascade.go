@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -65,12 +66,23 @@ func loadFromFile(path string) (Config, error) {
 	return cfg, nil
 }
 
-// validate checks if the config values are valid.
-// Returns an error if any values are invalid with helpful suggestions.
-func validate(cfg *Config) error {
-	// Validate CLI agent
-	if cfg.CLI != "" {
-		validAgents := []string{"claude", "codex", "gemini", "opencode", "cursor", "ollama"}
+// ValidatePushArgs checks that every push_args entry is an option, so
+// nothing can be mistaken for the remote or branch git push is given
+func ValidatePushArgs(args []string) error {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			return fmt.Errorf("push_args must be git push options starting with '-', got '%s'", arg)
+		}
+	}
+	return nil
+}
+
+// ValidateCLI checks that cli names a built-in agent or one of cfg's custom
+// agents. It is run on the merged config rather than on each file, since
+// the agent cli selects may be defined in another file.
+func ValidateCLI(cfg Config) error {
+	if cfg.CLI != "" && !hasAgent(cfg.Agents, cfg.CLI) {
+		validAgents := []string{"claude", "codex", "gemini", "opencode", "cursor", "ollama", "auto"}
 		valid := false
 		for _, agent := range validAgents {
 			if cfg.CLI == agent {
@@ -87,12 +99,48 @@ func validate(cfg *Config) error {
 			return fmt.Errorf("unknown agent '%s' (available: %v)", cfg.CLI, validAgents)
 		}
 	}
+	return nil
+}
 
+// validate checks if the config values are valid.
+// Returns an error if any values are invalid with helpful suggestions.
+// cli is left to ValidateCLI, once the files are merged.
+func validate(cfg *Config) error {
 	// Validate stuck_threshold
 	if cfg.StuckThreshold < 0 {
 		return fmt.Errorf("stuck_threshold must be a positive integer, got '%d'", cfg.StuckThreshold)
 	}
 
+	// Validate max_no_change
+	if cfg.MaxNoChange < 0 {
+		return fmt.Errorf("max_no_change must be a positive integer, got '%d'", cfg.MaxNoChange)
+	}
+
+	// Validate rate_limit_wait (0 here means the file doesn't set it)
+	if cfg.RateLimitWait < 0 {
+		return fmt.Errorf("rate_limit_wait must be at least 1, got '%d'", cfg.RateLimitWait)
+	}
+
+	// Validate max_line_length
+	if cfg.MaxLineLength < 0 {
+		return fmt.Errorf("max_line_length must be a positive integer, got '%d'", cfg.MaxLineLength)
+	}
+
+	// Validate max_line_size (0 here means the file doesn't set it)
+	if cfg.MaxLineSize < 0 {
+		return fmt.Errorf("max_line_size must be at least 1, got '%d'", cfg.MaxLineSize)
+	}
+
+	// Validate push_args
+	if err := ValidatePushArgs(cfg.PushArgs); err != nil {
+		return err
+	}
+
+	// Validate commit_grace_iterations
+	if cfg.CommitGraceIterations < 0 {
+		return fmt.Errorf("commit_grace_iterations must be a positive integer, got '%d'", cfg.CommitGraceIterations)
+	}
+
 	return nil
 }
 
@@ -184,6 +232,9 @@ func Merge(configs ...Config) Config {
 			result.PromptFile = cfg.PromptFile
 		}
 
+		// PromptSearch: always override (same limitation as AutoPush)
+		result.PromptSearch = cfg.PromptSearch
+
 		// AutoPush: always override (bool has no "empty" value, so we need to track if it was set)
 		// For now, we override if it's different from the default
 		// This is a limitation of using plain bool - consider using *bool in the future
@@ -194,6 +245,24 @@ func Merge(configs ...Config) Config {
 			result.StuckThreshold = cfg.StuckThreshold
 		}
 
+		// StuckDisabled: always override (same limitation as AutoPush)
+		result.StuckDisabled = cfg.StuckDisabled
+
+		// MaxNoChange: override if non-zero
+		if cfg.MaxNoChange != 0 {
+			result.MaxNoChange = cfg.MaxNoChange
+		}
+
+		// RateLimitWait: override if non-zero
+		if cfg.RateLimitWait != 0 {
+			result.RateLimitWait = cfg.RateLimitWait
+		}
+
+		// CommitGraceIterations: override if non-zero
+		if cfg.CommitGraceIterations != 0 {
+			result.CommitGraceIterations = cfg.CommitGraceIterations
+		}
+
 		// Verify: override if non-empty
 		if cfg.Verify != "" {
 			result.Verify = cfg.Verify
@@ -201,6 +270,132 @@ func Merge(configs ...Config) Config {
 
 		// Memory: always override (same limitation as AutoPush)
 		result.Memory = cfg.Memory
+
+		// VerifyParallel: always override (same limitation as AutoPush)
+		result.VerifyParallel = cfg.VerifyParallel
+
+		// VerifyShell: override if non-empty
+		if cfg.VerifyShell != "" {
+			result.VerifyShell = cfg.VerifyShell
+		}
+
+		// CommitIfDirty: always override (same limitation as AutoPush)
+		result.CommitIfDirty = cfg.CommitIfDirty
+
+		// CommitOnInterrupt: always override (same limitation as AutoPush)
+		result.CommitOnInterrupt = cfg.CommitOnInterrupt
+
+		// RequireCleanTree: always override (same limitation as AutoPush)
+		result.RequireCleanTree = cfg.RequireCleanTree
+
+		// CommitTrailer: always override (same limitation as AutoPush)
+		result.CommitTrailer = cfg.CommitTrailer
+
+		// WorkDir: override if non-empty
+		if cfg.WorkDir != "" {
+			result.WorkDir = cfg.WorkDir
+		}
+
+		// ModelsFile: override if non-empty
+		if cfg.ModelsFile != "" {
+			result.ModelsFile = cfg.ModelsFile
+		}
+
+		// HideTools: override if non-empty
+		if len(cfg.HideTools) > 0 {
+			result.HideTools = cfg.HideTools
+		}
+
+		// ToolPatterns: override if non-empty
+		if len(cfg.ToolPatterns) > 0 {
+			result.ToolPatterns = cfg.ToolPatterns
+		}
+
+		// PushArgs: override if non-empty
+		if len(cfg.PushArgs) > 0 {
+			result.PushArgs = cfg.PushArgs
+		}
+
+		// Theme: override if non-empty
+		if cfg.Theme != "" {
+			result.Theme = cfg.Theme
+		}
+
+		// BannerStyle: override if non-empty
+		if cfg.BannerStyle != "" {
+			result.BannerStyle = cfg.BannerStyle
+		}
+
+		// MaxLineLength: override if non-zero
+		if cfg.MaxLineLength != 0 {
+			result.MaxLineLength = cfg.MaxLineLength
+		}
+
+		// MaxLineSize: override if non-zero
+		if cfg.MaxLineSize != 0 {
+			result.MaxLineSize = cfg.MaxLineSize
+		}
+
+		// CommitCountSource: override if non-empty
+		if cfg.CommitCountSource != "" {
+			result.CommitCountSource = cfg.CommitCountSource
+		}
+
+		// BaseURL: override if non-empty
+		if cfg.BaseURL != "" {
+			result.BaseURL = cfg.BaseURL
+		}
+
+		// ShowBanner: always override (same limitation as AutoPush)
+		result.ShowBanner = cfg.ShowBanner
+
+		// PromptPrefix: override if non-empty
+		if cfg.PromptPrefix != "" {
+			result.PromptPrefix = cfg.PromptPrefix
+		}
+
+		// StuckHint: override if non-empty
+		if cfg.StuckHint != "" {
+			result.StuckHint = cfg.StuckHint
+		}
+
+		// LoopPrompt: override if non-empty
+		if cfg.LoopPrompt != "" {
+			result.LoopPrompt = cfg.LoopPrompt
+		}
+
+		// DoneSignal: override if non-empty
+		if cfg.DoneSignal != "" {
+			result.DoneSignal = cfg.DoneSignal
+		}
+
+		// CommitMessagePattern: override if non-empty
+		if cfg.CommitMessagePattern != "" {
+			result.CommitMessagePattern = cfg.CommitMessagePattern
+		}
+
+		// SystemPrompt: override if non-empty
+		if cfg.SystemPrompt != "" {
+			result.SystemPrompt = cfg.SystemPrompt
+		}
+
+		// Profiles: merge by name, later layers replace same-named profiles
+		for name, profile := range cfg.Profiles {
+			if result.Profiles == nil {
+				result.Profiles = make(map[string]Profile)
+			}
+			result.Profiles[name] = profile
+		}
+
+		// Agents: merge by id, later layers replace same-id agents
+		if len(cfg.Agents) > 0 {
+			result.Agents = mergeAgents(result.Agents, cfg.Agents)
+		}
+
+		// Pipeline: a later layer's pipeline replaces the whole list
+		if len(cfg.Pipeline) > 0 {
+			result.Pipeline = cfg.Pipeline
+		}
 	}
 
 	return result
@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -78,28 +80,28 @@ prompt_file: PROMPT.md
 	}
 }
 
-func TestValidate_ValidAgent(t *testing.T) {
-	validAgents := []string{"claude", "codex", "gemini", "opencode", "cursor", "ollama"}
+func TestValidateCLI_ValidAgent(t *testing.T) {
+	validAgents := []string{"claude", "codex", "gemini", "opencode", "cursor", "ollama", "auto"}
 	for _, agent := range validAgents {
 		cfg := Config{CLI: agent}
-		if err := validate(&cfg); err != nil {
+		if err := ValidateCLI(cfg); err != nil {
 			t.Errorf("Expected no error for valid agent %s, got: %v", agent, err)
 		}
 	}
 }
 
-func TestValidate_InvalidAgent(t *testing.T) {
+func TestValidateCLI_InvalidAgent(t *testing.T) {
 	cfg := Config{CLI: "invalid-agent"}
-	err := validate(&cfg)
+	err := ValidateCLI(cfg)
 	if err == nil {
 		t.Error("Expected error for invalid agent, got nil")
 	}
 }
 
-func TestValidate_InvalidAgentWithSuggestion(t *testing.T) {
+func TestValidateCLI_InvalidAgentWithSuggestion(t *testing.T) {
 	// Test typo that should get a suggestion
 	cfg := Config{CLI: "cluade"} // typo of "claude"
-	err := validate(&cfg)
+	err := ValidateCLI(cfg)
 	if err == nil {
 		t.Error("Expected error for invalid agent, got nil")
 	}
@@ -109,6 +111,43 @@ func TestValidate_InvalidAgentWithSuggestion(t *testing.T) {
 	}
 }
 
+func TestValidateCLI_CustomAgentFromAnotherLayer(t *testing.T) {
+	dir := t.TempDir()
+	globalPath := filepath.Join(dir, "config.yaml")
+	projectPath := filepath.Join(dir, ".gumloop.yaml")
+	globalContent := `agents:
+  - id: mine
+    command: mine run
+`
+	if err := os.WriteFile(globalPath, []byte(globalContent), 0644); err != nil {
+		t.Fatalf("Failed to write global config: %v", err)
+	}
+	if err := os.WriteFile(projectPath, []byte("cli: mine\n"), 0644); err != nil {
+		t.Fatalf("Failed to write project config: %v", err)
+	}
+
+	// The project file alone doesn't know the agent, so it isn't rejected...
+	global, err := loadFromFile(globalPath)
+	if err != nil {
+		t.Fatalf("Expected the global config to load, got: %v", err)
+	}
+	project, err := loadFromFile(projectPath)
+	if err != nil {
+		t.Fatalf("Expected a cli defined in another layer to load, got: %v", err)
+	}
+
+	// ...and the merged config, which does, accepts it
+	merged := Merge(Defaults(), global, project)
+	if err := ValidateCLI(merged); err != nil {
+		t.Errorf("Expected the global custom agent to be valid for the project cli, got: %v", err)
+	}
+
+	// Without the global agent, the merged config still rejects it
+	if err := ValidateCLI(Merge(Defaults(), Config{}, project)); err == nil {
+		t.Error("Expected an undefined agent to be rejected after merging, got nil")
+	}
+}
+
 func TestValidate_NegativeStuckThreshold(t *testing.T) {
 	cfg := Config{StuckThreshold: -1}
 	err := validate(&cfg)
@@ -355,3 +394,122 @@ func TestLoadProject_Integration(t *testing.T) {
 	// Empty config is valid
 	_ = cfg
 }
+
+func TestMerge_Keys(t *testing.T) {
+	// Each key survives an empty project layer and is overridden by a
+	// project value. Booleans can't be unset by a layer, so they go from
+	// the default to a project value instead.
+	tests := []struct {
+		name        string
+		global      Config
+		project     Config
+		get         func(Config) any
+		wantGlobal  any
+		wantProject any
+	}{
+		{"PromptPrefix", Config{PromptPrefix: "Always run gofmt."}, Config{PromptPrefix: "Use tabs."}, func(c Config) any { return c.PromptPrefix }, "Always run gofmt.", "Use tabs."},
+		{"StuckHint", Config{StuckHint: "Commit what you have."}, Config{StuckHint: "Explain the blocker."}, func(c Config) any { return c.StuckHint }, "Commit what you have.", "Explain the blocker."},
+		{"LoopPrompt", Config{LoopPrompt: "Continue the task"}, Config{LoopPrompt: "Keep going"}, func(c Config) any { return c.LoopPrompt }, "Continue the task", "Keep going"},
+		{"DoneSignal", Config{DoneSignal: "TASK COMPLETE"}, Config{DoneSignal: "^DONE$"}, func(c Config) any { return c.DoneSignal }, "TASK COMPLETE", "^DONE$"},
+		{"CommitMessagePattern", Config{CommitMessagePattern: "^feat: "}, Config{CommitMessagePattern: "^\\[JIRA-\\d+\\] "}, func(c Config) any { return c.CommitMessagePattern }, "^feat: ", "^\\[JIRA-\\d+\\] "},
+		{"VerifyShell", Config{VerifyShell: "bash -c"}, Config{VerifyShell: "none"}, func(c Config) any { return c.VerifyShell }, "bash -c", "none"},
+		{"BannerStyle", Config{BannerStyle: "compact"}, Config{BannerStyle: "full"}, func(c Config) any { return c.BannerStyle }, "compact", "full"},
+		{"CommitCountSource", Config{CommitCountSource: "branch"}, Config{CommitCountSource: "session"}, func(c Config) any { return c.CommitCountSource }, "branch", "session"},
+		{"MaxLineLength", Config{MaxLineLength: 500}, Config{MaxLineLength: 200}, func(c Config) any { return c.MaxLineLength }, 500, 200},
+		{"MaxLineSize", Config{MaxLineSize: 64}, Config{MaxLineSize: 32}, func(c Config) any { return c.MaxLineSize }, 64, 32},
+		{"RateLimitWait", Config{RateLimitWait: 120}, Config{RateLimitWait: 30}, func(c Config) any { return c.RateLimitWait }, 120, 30},
+		{"CommitGraceIterations", Config{CommitGraceIterations: 2}, Config{CommitGraceIterations: 5}, func(c Config) any { return c.CommitGraceIterations }, 2, 5},
+		{"PushArgs", Config{PushArgs: []string{"--no-verify"}}, Config{PushArgs: []string{"-o", "ci.skip"}}, func(c Config) any { return c.PushArgs }, []string{"--no-verify"}, []string{"-o", "ci.skip"}},
+		{"CommitTrailer", Config{}, Config{CommitTrailer: true}, func(c Config) any { return c.CommitTrailer }, false, true},
+		{"StuckDisabled", Config{}, Config{StuckDisabled: true}, func(c Config) any { return c.StuckDisabled }, false, true},
+		{"PromptSearch", Config{}, Config{PromptSearch: true}, func(c Config) any { return c.PromptSearch }, false, true},
+		{"CommitOnInterrupt", Config{}, Config{CommitOnInterrupt: true}, func(c Config) any { return c.CommitOnInterrupt }, false, true},
+		{"RequireCleanTree", Config{}, Config{RequireCleanTree: true}, func(c Config) any { return c.RequireCleanTree }, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.get(Merge(Defaults(), tt.global, Config{})); !reflect.DeepEqual(got, tt.wantGlobal) {
+				t.Errorf("Expected %s=%v with an empty project layer, got: %v", tt.name, tt.wantGlobal, got)
+			}
+			if got := tt.get(Merge(Defaults(), tt.global, tt.project)); !reflect.DeepEqual(got, tt.wantProject) {
+				t.Errorf("Expected project %s=%v to override global, got: %v", tt.name, tt.wantProject, got)
+			}
+		})
+	}
+
+	// Setting stuck_disabled leaves the threshold alone
+	if result := Merge(Defaults(), Config{}, Config{StuckDisabled: true}); result.StuckThreshold != Defaults().StuckThreshold {
+		t.Errorf("Expected StuckThreshold to keep its default, got %d", result.StuckThreshold)
+	}
+	if result := Merge(Defaults(), Config{}, Config{}); result.CommitCountSource != CommitCountSession {
+		t.Errorf("Expected CommitCountSource to default to session, got: %q", result.CommitCountSource)
+	}
+}
+
+func TestMerge_Agents(t *testing.T) {
+	global := Config{Agents: []CustomAgent{{ID: "a", Command: "a-global"}, {ID: "b", Command: "b"}}}
+	result := Merge(Defaults(), global, Config{})
+	if len(result.Agents) != 2 {
+		t.Errorf("Expected global Agents to survive an empty project layer, got: %+v", result.Agents)
+	}
+
+	project := Config{Agents: []CustomAgent{{ID: "a", Command: "a-project"}, {ID: "c", Command: "c"}}}
+	result = Merge(Defaults(), global, project)
+	if len(result.Agents) != 3 || result.Agents[0].Command != "a-project" || result.Agents[2].ID != "c" {
+		t.Errorf("Expected project Agents to replace same-id global ones and add new ones, got: %+v", result.Agents)
+	}
+	if global.Agents[0].Command != "a-global" {
+		t.Errorf("Expected Merge not to modify the global layer, got: %+v", global.Agents)
+	}
+}
+
+func TestLoadFromFile_CustomAgent(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := `cli: my-agent
+agents:
+  - id: my-agent
+    command: my-agent run
+    flags: ["--yes"]
+    prompt_style: arg
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := loadFromFile(configPath)
+	if err != nil {
+		t.Fatalf("Expected a custom cli agent to be valid, got: %v", err)
+	}
+	if len(cfg.Agents) != 1 || cfg.Agents[0].Command != "my-agent run" || cfg.Agents[0].Flags[0] != "--yes" {
+		t.Errorf("Expected the custom agent to be loaded, got: %+v", cfg.Agents)
+	}
+}
+
+func TestMerge_Pipeline(t *testing.T) {
+	global := Config{Pipeline: []PipelineStage{{Agent: "claude"}}}
+	result := Merge(Defaults(), global, Config{})
+	if len(result.Pipeline) != 1 || result.Pipeline[0].Agent != "claude" {
+		t.Errorf("Expected global Pipeline to survive an empty project layer, got: %+v", result.Pipeline)
+	}
+
+	project := Config{Pipeline: []PipelineStage{{Agent: "codex"}, {Agent: "claude", PromptSuffix: "Review the last commit"}}}
+	result = Merge(Defaults(), global, project)
+	if len(result.Pipeline) != 2 || result.Pipeline[0].Agent != "codex" {
+		t.Errorf("Expected project Pipeline to replace global, got: %+v", result.Pipeline)
+	}
+}
+
+func TestValidatePushArgs(t *testing.T) {
+	if err := ValidatePushArgs([]string{"--no-verify", "--push-option=ci.skip"}); err != nil {
+		t.Errorf("Expected options to be valid, got: %v", err)
+	}
+
+	err := ValidatePushArgs([]string{"--force-with-lease", "upstream"})
+	if err == nil {
+		t.Fatal("Expected an error for an argument that isn't an option")
+	}
+	if !strings.Contains(err.Error(), "'upstream'") {
+		t.Errorf("Expected the error to name the bad argument, got: %v", err)
+	}
+}
@@ -13,15 +13,164 @@ type Config struct {
 	// PromptFile is the default prompt file path
 	PromptFile string `yaml:"prompt_file" mapstructure:"prompt_file"`
 
+	// PromptSearch looks for a relative prompt_file in parent directories,
+	// up to the repo root, when it isn't in the current directory
+	PromptSearch bool `yaml:"prompt_search" mapstructure:"prompt_search"`
+
 	// AutoPush determines whether to push to remote after commits
 	AutoPush bool `yaml:"auto_push" mapstructure:"auto_push"`
 
 	// StuckThreshold is the number of iterations with changes but no commits before exiting
 	StuckThreshold int `yaml:"stuck_threshold" mapstructure:"stuck_threshold"`
 
+	// StuckDisabled turns stuck detection off, so iterations without commits
+	// never end the loop. A stuck_threshold of 0 means "unset", not "off".
+	StuckDisabled bool `yaml:"stuck_disabled" mapstructure:"stuck_disabled"`
+
+	// MaxNoChange is how many consecutive iterations with no changes and no
+	// commits end the loop as complete (1 exits on the first one)
+	MaxNoChange int `yaml:"max_no_change" mapstructure:"max_no_change"`
+
+	// RateLimitWait is how many seconds to wait before the next iteration
+	// after the agent reports a rate limit, unless it suggests a wait itself
+	RateLimitWait int `yaml:"rate_limit_wait" mapstructure:"rate_limit_wait"`
+
+	// CommitGraceIterations is how many iterations at the start of a run
+	// are left out of stuck detection, so the agent can explore first
+	CommitGraceIterations int `yaml:"commit_grace_iterations,omitempty" mapstructure:"commit_grace_iterations"`
+
 	// Verify is the verification command to run after each iteration
 	Verify string `yaml:"verify" mapstructure:"verify"`
 
+	// VerifyParallel runs each line of Verify as a separate command, concurrently
+	VerifyParallel bool `yaml:"verify_parallel" mapstructure:"verify_parallel"`
+
+	// VerifyShell is the shell command Verify is passed to, like "bash -c"
+	// (empty for sh -c, or cmd /c on Windows). "none" runs each line of
+	// Verify directly, split on spaces, without a shell.
+	VerifyShell string `yaml:"verify_shell,omitempty" mapstructure:"verify_shell"`
+
 	// Memory enables session memory persistence between runs
 	Memory bool `yaml:"memory" mapstructure:"memory"`
+
+	// CommitIfDirty commits changes the agent left uncommitted at the end of an iteration
+	CommitIfDirty bool `yaml:"commit_if_dirty" mapstructure:"commit_if_dirty"`
+
+	// CommitOnInterrupt commits uncommitted changes as a "WIP (interrupted)"
+	// commit when the run is interrupted, so a later reset can't lose them
+	CommitOnInterrupt bool `yaml:"commit_on_interrupt" mapstructure:"commit_on_interrupt"`
+
+	// RequireCleanTree refuses to start a run while the working tree has
+	// uncommitted changes, so the agent can't commit unrelated work
+	RequireCleanTree bool `yaml:"require_clean_tree" mapstructure:"require_clean_tree"`
+
+	// CommitTrailer adds Gumloop-Iteration and Gumloop-Agent trailers to the
+	// commits made during a session
+	CommitTrailer bool `yaml:"commit_trailer" mapstructure:"commit_trailer"`
+
+	// WorkDir is the directory the agent runs in (empty uses the current directory).
+	// It must be inside the repository; git operations still run at the repo root.
+	WorkDir string `yaml:"workdir,omitempty" mapstructure:"workdir"`
+
+	// ModelsFile is a local models.dev-format JSON file used by the init wizard
+	// instead of fetching the model list over the network
+	ModelsFile string `yaml:"models_file,omitempty" mapstructure:"models_file"`
+
+	// SystemPrompt is sent to the agent with every prompt, via the agent's
+	// system-prompt flag when it has one, otherwise prepended to the prompt
+	SystemPrompt string `yaml:"system_prompt,omitempty" mapstructure:"system_prompt"`
+
+	// PromptPrefix is a standing instruction (e.g. "always run gofmt") placed
+	// at the very start of every prompt, before session memory context. Unlike
+	// SystemPrompt it is always part of the prompt text itself.
+	PromptPrefix string `yaml:"prompt_prefix,omitempty" mapstructure:"prompt_prefix"`
+
+	// StuckHint is appended to the prompt of the last iteration before stuck
+	// detection would stop the loop (e.g. "commit your progress or explain
+	// what's blocking you"). Empty disables the hint.
+	StuckHint string `yaml:"stuck_hint,omitempty" mapstructure:"stuck_hint"`
+
+	// LoopPrompt replaces the task prompt for iterations 2..N in choo-choo
+	// mode (e.g. "Continue the task"), for agents that keep their own state
+	// between runs. The first iteration always gets the full prompt, as
+	// does the one after a --watch-prompt edit. Empty sends the full prompt
+	// every iteration.
+	LoopPrompt string `yaml:"loop_prompt,omitempty" mapstructure:"loop_prompt"`
+
+	// DoneSignal is a regular expression (a plain string like "TASK COMPLETE"
+	// works too). When the agent's output matches it, the loop ends as complete.
+	DoneSignal string `yaml:"done_signal,omitempty" mapstructure:"done_signal"`
+
+	// CommitMessagePattern is a regular expression the first line of each
+	// commit the agent makes should match (e.g. conventional commits).
+	// Mismatches are warned about, or undone with --strict-commits.
+	CommitMessagePattern string `yaml:"commit_message_pattern,omitempty" mapstructure:"commit_message_pattern"`
+
+	// HideTools lists tool names (e.g. "Read", "TodoWrite") whose calls are not
+	// printed during a run. They are still counted in the iteration summary.
+	HideTools []string `yaml:"hide_tools,omitempty" mapstructure:"hide_tools"`
+
+	// ToolPatterns are "Name=regex" specs for spotting tool usage in plain-text
+	// agent output (e.g. "Bash=^Running: (.+)$"). Empty uses built-in patterns.
+	ToolPatterns []string `yaml:"tool_patterns,omitempty" mapstructure:"tool_patterns"`
+
+	// PushArgs are extra options for git push (e.g. "--no-verify",
+	// "--push-option=ci.skip"). Each must start with "-".
+	PushArgs []string `yaml:"push_args,omitempty" mapstructure:"push_args"`
+
+	// BaseURL is the API base URL for the agent (e.g., a corporate proxy),
+	// set via the agent's environment variable such as ANTHROPIC_BASE_URL
+	BaseURL string `yaml:"base_url,omitempty" mapstructure:"base_url"`
+
+	// Theme selects the emoji and separators used in run output (train, rocket, plain)
+	Theme string `yaml:"theme,omitempty" mapstructure:"theme"`
+
+	// ShowBanner controls whether the startup banner is printed by gumloop run
+	ShowBanner bool `yaml:"show_banner" mapstructure:"show_banner"`
+
+	// BannerStyle is the startup banner layout: full (a box) or compact (one line)
+	BannerStyle string `yaml:"banner_style,omitempty" mapstructure:"banner_style"`
+
+	// MaxLineLength cuts agent message lines longer than this many characters
+	// on the terminal (0 for no limit). The --tee file keeps them whole.
+	MaxLineLength int `yaml:"max_line_length,omitempty" mapstructure:"max_line_length"`
+
+	// MaxLineSize is the longest line of agent output (e.g. one JSON event)
+	// the adapters read, in MiB. A longer line ends the iteration with an error.
+	MaxLineSize int `yaml:"max_line_size,omitempty" mapstructure:"max_line_size"`
+
+	// CommitCountSource decides which commits an iteration is credited with:
+	// session (only commits made since the run started, the default) or
+	// branch (every commit added to the branch, including ones pulled in)
+	CommitCountSource string `yaml:"commit_count_source,omitempty" mapstructure:"commit_count_source"`
+
+	// Profiles are named sets of overrides selected with gumloop run --profile
+	Profiles map[string]Profile `yaml:"profiles,omitempty" mapstructure:"profiles"`
+
+	// Agents defines custom agents, added to the built-in ones and
+	// selectable by id
+	Agents []CustomAgent `yaml:"agents,omitempty" mapstructure:"agents"`
+
+	// Pipeline runs several agents one after another in each iteration,
+	// in place of the cli agent (empty for a single agent)
+	Pipeline []PipelineStage `yaml:"pipeline,omitempty" mapstructure:"pipeline"`
+}
+
+// Commit count sources for CommitCountSource
+const (
+	CommitCountSession = "session"
+	CommitCountBranch  = "branch"
+)
+
+// CommitCountSources returns the valid commit_count_source values
+func CommitCountSources() []string {
+	return []string{CommitCountSession, CommitCountBranch}
+}
+
+// PipelineStage is one agent in a pipeline. Each stage sees the working
+// tree the previous stage left behind.
+type PipelineStage struct {
+	Agent        string `yaml:"agent" mapstructure:"agent"`
+	Model        string `yaml:"model,omitempty" mapstructure:"model"`                 // Empty uses the run's model
+	PromptSuffix string `yaml:"prompt_suffix,omitempty" mapstructure:"prompt_suffix"` // Appended to the prompt for this stage
 }
@@ -3,12 +3,19 @@ package config
 // Defaults returns the default configuration values as defined in SPEC section 3.3.
 func Defaults() Config {
 	return Config{
-		CLI:            "claude",
-		Model:          "",
-		PromptFile:     "PROMPT.md",
-		AutoPush:       true,
-		StuckThreshold: 3,
-		Verify:         "",
-		Memory:         false,
+		CLI:               "claude",
+		Model:             "",
+		PromptFile:        "PROMPT.md",
+		AutoPush:          true,
+		StuckThreshold:    3,
+		MaxNoChange:       1,
+		RateLimitWait:     60,
+		Verify:            "",
+		Memory:            false,
+		ShowBanner:        true,
+		Theme:             "train",
+		BannerStyle:       "full",
+		CommitCountSource: CommitCountSession,
+		MaxLineSize:       16,
 	}
 }
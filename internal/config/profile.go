@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Profile is a named block of overrides (e.g. "fast", "thorough") applied
+// over the base config before CLI flags. Empty and zero values leave the
+// base setting unchanged.
+type Profile struct {
+	CLI            string `yaml:"cli,omitempty" mapstructure:"cli"`
+	Model          string `yaml:"model,omitempty" mapstructure:"model"`
+	Verify         string `yaml:"verify,omitempty" mapstructure:"verify"`
+	StuckThreshold int    `yaml:"stuck_threshold,omitempty" mapstructure:"stuck_threshold"`
+}
+
+// ApplyProfile returns cfg with the named profile's overrides applied.
+// Returns an error listing the available profiles if name is not defined.
+func ApplyProfile(cfg Config, name string) (Config, error) {
+	profile, ok := cfg.Profiles[name]
+	if !ok {
+		return cfg, fmt.Errorf("unknown profile '%s' (available: %s)", name, profileNames(cfg.Profiles))
+	}
+
+	if profile.CLI != "" {
+		cfg.CLI = profile.CLI
+	}
+	if profile.Model != "" {
+		cfg.Model = profile.Model
+	}
+	if profile.Verify != "" {
+		cfg.Verify = profile.Verify
+	}
+	if profile.StuckThreshold != 0 {
+		cfg.StuckThreshold = profile.StuckThreshold
+	}
+
+	return cfg, nil
+}
+
+// profileNames returns the sorted profile names, or "none" if there are none
+func profileNames(profiles map[string]Profile) string {
+	if len(profiles) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
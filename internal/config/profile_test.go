@@ -0,0 +1,80 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyProfile(t *testing.T) {
+	base := Defaults()
+	base.Verify = "go test ./..."
+	base.Profiles = map[string]Profile{
+		"fast":     {Model: "haiku", StuckThreshold: 1},
+		"thorough": {CLI: "codex", Verify: "make ci"},
+	}
+
+	result, err := ApplyProfile(base, "fast")
+	if err != nil {
+		t.Fatalf("ApplyProfile returned error: %v", err)
+	}
+	if result.Model != "haiku" {
+		t.Errorf("Expected Model=haiku, got: %s", result.Model)
+	}
+	if result.StuckThreshold != 1 {
+		t.Errorf("Expected StuckThreshold=1, got: %d", result.StuckThreshold)
+	}
+	// Keys the profile leaves empty keep the base values
+	if result.CLI != "claude" {
+		t.Errorf("Expected CLI=claude (base), got: %s", result.CLI)
+	}
+	if result.Verify != "go test ./..." {
+		t.Errorf("Expected Verify from base, got: %s", result.Verify)
+	}
+
+	result, err = ApplyProfile(base, "thorough")
+	if err != nil {
+		t.Fatalf("ApplyProfile returned error: %v", err)
+	}
+	if result.CLI != "codex" || result.Verify != "make ci" {
+		t.Errorf("Expected thorough overrides, got CLI=%s Verify=%s", result.CLI, result.Verify)
+	}
+	if result.StuckThreshold != 3 {
+		t.Errorf("Expected StuckThreshold=3 (base), got: %d", result.StuckThreshold)
+	}
+}
+
+func TestApplyProfile_Unknown(t *testing.T) {
+	base := Defaults()
+	base.Profiles = map[string]Profile{"thorough": {}, "fast": {}}
+
+	_, err := ApplyProfile(base, "slow")
+	if err == nil {
+		t.Fatal("Expected error for unknown profile")
+	}
+	if !strings.Contains(err.Error(), "unknown profile 'slow'") || !strings.Contains(err.Error(), "available: fast, thorough") {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+
+	_, err = ApplyProfile(Defaults(), "fast")
+	if err == nil || !strings.Contains(err.Error(), "available: none") {
+		t.Errorf("Expected 'available: none' error, got: %v", err)
+	}
+}
+
+func TestMerge_Profiles(t *testing.T) {
+	global := Config{Profiles: map[string]Profile{
+		"fast":     {Model: "haiku"},
+		"thorough": {Model: "opus"},
+	}}
+	project := Config{Profiles: map[string]Profile{
+		"fast": {Model: "sonnet"},
+	}}
+
+	result := Merge(global, project)
+	if result.Profiles["fast"].Model != "sonnet" {
+		t.Errorf("Expected project 'fast' profile to win, got: %s", result.Profiles["fast"].Model)
+	}
+	if result.Profiles["thorough"].Model != "opus" {
+		t.Errorf("Expected global 'thorough' profile to be kept, got: %s", result.Profiles["thorough"].Model)
+	}
+}
@@ -1,32 +1,95 @@
 package git
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/adriancodes/gumloop/internal/logging"
 )
 
+// Dir is the directory git commands run in (empty uses the current directory).
+// Set by the global --repo flag so gumloop can drive a repository elsewhere.
+var Dir string
+
+// command builds a git command that runs in Dir
+func command(args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = Dir
+	return cmd
+}
+
+// gitRun runs cmd, logging it at debug level
+func gitRun(cmd *exec.Cmd) error {
+	start := time.Now()
+	err := cmd.Run()
+	logCommand(cmd, nil, err, start)
+	return err
+}
+
+// gitOutput runs cmd and returns its stdout, logging it at debug level
+func gitOutput(cmd *exec.Cmd) ([]byte, error) {
+	start := time.Now()
+	output, err := cmd.Output()
+	logCommand(cmd, output, err, start)
+	return output, err
+}
+
+// gitCombinedOutput runs cmd and returns its stdout and stderr, logging it
+// at debug level
+func gitCombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	start := time.Now()
+	output, err := cmd.CombinedOutput()
+	logCommand(cmd, output, err, start)
+	return output, err
+}
+
+// logCommand logs a finished git command, its duration and its output
+func logCommand(cmd *exec.Cmd, output []byte, err error, start time.Time) {
+	if !logging.Enabled(logging.LevelDebug) {
+		return
+	}
+	logging.Debugf("%s (%s, error: %v)", strings.Join(cmd.Args, " "), time.Since(start).Round(time.Millisecond), err)
+	if out := strings.TrimSpace(string(output)); out != "" {
+		logging.Debugf("  %s", strings.ReplaceAll(out, "\n", "\n  "))
+	}
+}
+
 // IsInsideWorkTree checks if the current directory is inside a git repository
 func IsInsideWorkTree() bool {
-	cmd := exec.Command("git", "rev-parse", "--is-inside-work-tree")
-	err := cmd.Run()
+	cmd := command("rev-parse", "--is-inside-work-tree")
+	err := gitRun(cmd)
 	return err == nil
 }
 
+// GetRepoRoot returns the absolute path of the top-level directory of the repository
+func GetRepoRoot() (string, error) {
+	cmd := command("rev-parse", "--show-toplevel")
+	output, err := gitOutput(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to get repository root: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
 // GetBranch returns the current branch name
 func GetBranch() (string, error) {
 	// Try symbolic-ref first (works when on a branch)
-	cmd := exec.Command("git", "symbolic-ref", "--short", "HEAD")
-	output, err := cmd.Output()
+	cmd := command("symbolic-ref", "--short", "HEAD")
+	output, err := gitOutput(cmd)
 	if err == nil {
 		branch := strings.TrimSpace(string(output))
 		return branch, nil
 	}
 
 	// Fallback to rev-parse (works in detached HEAD, but may return "HEAD")
-	cmd = exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-	output, err = cmd.Output()
+	cmd = command("rev-parse", "--abbrev-ref", "HEAD")
+	output, err = gitOutput(cmd)
 	if err != nil {
 		return "", fmt.Errorf("failed to get current branch: %w", err)
 	}
@@ -35,10 +98,24 @@ func GetBranch() (string, error) {
 	return branch, nil
 }
 
+// IsDetachedHead reports whether HEAD points directly at a commit instead of a branch
+func IsDetachedHead() (bool, error) {
+	cmd := command("symbolic-ref", "-q", "HEAD")
+	err := gitRun(cmd)
+	if err == nil {
+		return false, nil
+	}
+	// Exit code 1 means HEAD is not a symbolic ref (detached)
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return true, nil
+	}
+	return false, fmt.Errorf("failed to check HEAD: %w", err)
+}
+
 // CountCommits returns the number of commits on the current branch
 func CountCommits() (int, error) {
-	cmd := exec.Command("git", "rev-list", "--count", "HEAD")
-	output, err := cmd.Output()
+	cmd := command("rev-list", "--count", "HEAD")
+	output, err := gitOutput(cmd)
 	if err != nil {
 		// If there are no commits yet (new repo), git exits non-zero
 		// Check stderr for the typical error message
@@ -62,12 +139,26 @@ func CountCommits() (int, error) {
 	return count, nil
 }
 
+// stateFiles are gumloop's own local state files. They are never committed,
+// and don't count as changes to the working tree.
+var stateFiles = []string{".gumloop-memory.yaml"}
+
+// withoutStateFiles appends pathspecs to args that cover the whole working
+// tree except stateFiles, in whatever directory they were written
+func withoutStateFiles(args ...string) []string {
+	args = append(args, "--", ":/")
+	for _, name := range stateFiles {
+		args = append(args, ":(top,exclude,glob)**/"+name)
+	}
+	return args
+}
+
 // HasChanges checks if there are any uncommitted changes (modified, staged, or untracked files)
 func HasChanges() (bool, error) {
 	// Check for changes using git status --porcelain
 	// This returns empty string if working tree is clean
-	cmd := exec.Command("git", "status", "--porcelain")
-	output, err := cmd.Output()
+	cmd := command(withoutStateFiles("status", "--porcelain")...)
+	output, err := gitOutput(cmd)
 	if err != nil {
 		return false, fmt.Errorf("failed to check for changes: %w", err)
 	}
@@ -78,8 +169,8 @@ func HasChanges() (bool, error) {
 
 // GetChangedFiles returns counts of changed files by category
 func GetChangedFiles() (modified int, staged int, untracked int, err error) {
-	cmd := exec.Command("git", "status", "--porcelain")
-	output, err := cmd.Output()
+	cmd := command(withoutStateFiles("status", "--porcelain")...)
+	output, err := gitOutput(cmd)
 	if err != nil {
 		return 0, 0, 0, fmt.Errorf("failed to get changed files: %w", err)
 	}
@@ -133,26 +224,327 @@ func GetChangedFiles() (modified int, staged int, untracked int, err error) {
 	return modified, staged, untracked, nil
 }
 
-// Push pushes the current branch to the remote
-func Push(branch string) error {
-	cmd := exec.Command("git", "push", "origin", branch)
-	output, err := cmd.CombinedOutput()
+// CountChangedFilesSince returns how many files differ between ref and the
+// working tree: changed in commits since ref, staged, modified or untracked
+// (not ignored), each file counted once. gumloop's state files don't count.
+func CountChangedFilesSince(ref string) (int, error) {
+	changed := make(map[string]bool)
+	for _, args := range [][]string{
+		withoutStateFiles("diff", "--name-only", "--no-renames", ref),
+		withoutStateFiles("ls-files", "--others", "--exclude-standard", "--full-name"),
+	} {
+		output, err := gitOutput(command(args...))
+		if err != nil {
+			return 0, fmt.Errorf("failed to list changed files: %w", err)
+		}
+		for _, path := range strings.Split(string(output), "\n") {
+			if path != "" {
+				changed[path] = true
+			}
+		}
+	}
+	return len(changed), nil
+}
+
+// WorkTreeHash returns a hash of the working tree's contents: HEAD plus
+// every uncommitted change and untracked (not ignored) file, other than
+// gumloop's state files. It's the same for two calls only if no file changed
+// in between. The files are staged into a scratch copy of the index, so the
+// real one isn't touched.
+func WorkTreeHash() (string, error) {
+	tmp, err := os.MkdirTemp("", "gumloop-index-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to hash working tree: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	index := filepath.Join(tmp, "index")
+
+	// Starting from the real index lets git skip files it knows are unchanged
+	if path, err := GitPath("index"); err == nil {
+		if data, err := os.ReadFile(path); err == nil {
+			os.WriteFile(index, data, 0600)
+		}
+	}
+
+	env := append(os.Environ(), "GIT_INDEX_FILE="+index)
+	cmd := command(withoutStateFiles("add", "-A")...)
+	cmd.Env = env
+	if output, err := gitCombinedOutput(cmd); err != nil {
+		return "", fmt.Errorf("failed to hash working tree: %w\nOutput: %s", err, string(output))
+	}
+	cmd = command("write-tree")
+	cmd.Env = env
+	output, err := gitOutput(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash working tree: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GitPath returns the path of name inside the repository's .git directory
+// (e.g. "index"), as git rev-parse --git-path resolves it
+func GitPath(name string) (string, error) {
+	output, err := gitOutput(command("rev-parse", "--git-path", name))
+	if err != nil {
+		return "", fmt.Errorf("failed to locate %s in the git directory: %w", name, err)
+	}
+	path := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(Dir, path)
+	}
+	return path, nil
+}
+
+// HasRemote reports whether the repository has an "origin" remote, the
+// remote Push targets
+func HasRemote() (bool, error) {
+	cmd := command("remote")
+	output, err := gitOutput(cmd)
+	if err != nil {
+		return false, fmt.Errorf("failed to list remotes: %w", err)
+	}
+	for _, name := range strings.Fields(string(output)) {
+		if name == "origin" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// runPush runs git push with args, returning its combined output
+// (replaced in tests)
+var runPush = func(args ...string) ([]byte, error) {
+	return gitCombinedOutput(command(args...))
+}
+
+// Push pushes the current branch to the remote. A branch without an
+// upstream is pushed with -u so it tracks origin from then on. extraArgs
+// (e.g. "--no-verify") go before the remote.
+func Push(branch string, extraArgs ...string) error {
+	args := []string{"push"}
+	if _, err := GetUpstream(branch); errors.Is(err, ErrNoUpstream) {
+		args = append(args, "-u")
+	}
+	args = append(args, extraArgs...)
+	args = append(args, "origin", branch)
+	output, err := runPush(args...)
 	if err != nil {
 		return fmt.Errorf("git push failed: %w\nOutput: %s", err, string(output))
 	}
 	return nil
 }
 
+// CommitAll stages all changes (including untracked files, but not
+// gumloop's state files) and commits them
+func CommitAll(message string) error {
+	cmd := command(withoutStateFiles("add", "-A")...)
+	output, err := gitCombinedOutput(cmd)
+	if err != nil {
+		return fmt.Errorf("git add failed: %w\nOutput: %s", err, string(output))
+	}
+
+	cmd = command("commit", "-q", "-m", message)
+	output, err = gitCombinedOutput(cmd)
+	if err != nil {
+		return fmt.Errorf("git commit failed: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
+// AddTrailers rewrites the last n commits to add the given trailers
+// (e.g. "Gumloop-Iteration: 3") to their messages. Only the messages change:
+// staged and unstaged changes stay uncommitted. A single commit is amended;
+// more are rewritten with a rebase, which is aborted on failure. Commits
+// that include a merge are left alone, since a rebase would flatten it.
+func AddTrailers(n int, trailers []string) error {
+	if n <= 0 || len(trailers) == 0 {
+		return nil
+	}
+
+	// --only with no paths amends the message without the staged changes
+	amend := []string{"commit", "--amend", "--only", "--no-edit", "--no-verify", "--allow-empty"}
+	for _, trailer := range trailers {
+		amend = append(amend, "--trailer", trailer)
+	}
+
+	if n == 1 {
+		output, err := gitCombinedOutput(command(amend...))
+		if err != nil {
+			return fmt.Errorf("git commit --amend failed: %w\nOutput: %s", err, string(output))
+		}
+		return nil
+	}
+
+	// The rebase runs the amend after replaying each commit
+	quoted := make([]string, len(amend))
+	for i, arg := range amend {
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	// --autostash sets uncommitted changes aside for the rebase, which
+	// refuses to run on a dirty tree
+	args := []string{"rebase", "--autostash", "--exec", "git " + strings.Join(quoted, " ")}
+	base := fmt.Sprintf("HEAD~%d", n)
+	rangeSpec := base + "..HEAD"
+	if _, err := ResolveRef(base); err == nil {
+		args = append(args, base)
+	} else {
+		// The commits go back to the start of the history
+		args = append(args, "--root")
+		rangeSpec = "HEAD"
+	}
+
+	// HEAD~n follows first parents, so with a merge among the commits it
+	// isn't the start of the session, and the rebase would drop the merge
+	merges, err := gitOutput(command("rev-list", "--merges", rangeSpec))
+	if err != nil {
+		return fmt.Errorf("failed to check for merge commits: %w", err)
+	}
+	if strings.TrimSpace(string(merges)) != "" {
+		return fmt.Errorf("not adding trailers: the last %d commits include a merge", n)
+	}
+
+	output, err := gitCombinedOutput(command(args...))
+	if err != nil {
+		_ = gitRun(command("rebase", "--abort"))
+		return fmt.Errorf("git rebase failed: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
 // ResetHard resets the working tree to the specified ref
 func ResetHard(ref string) error {
-	cmd := exec.Command("git", "reset", "--hard", ref)
-	output, err := cmd.CombinedOutput()
+	cmd := command("reset", "--hard", ref)
+	output, err := gitCombinedOutput(cmd)
+	if err != nil {
+		return fmt.Errorf("git reset failed: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
+// ResetSoft moves HEAD to the specified ref, keeping the undone commits'
+// changes staged
+func ResetSoft(ref string) error {
+	cmd := command("reset", "--soft", ref)
+	output, err := gitCombinedOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("git reset failed: %w\nOutput: %s", err, string(output))
 	}
 	return nil
 }
 
+// ResolveRef resolves a ref (hash, branch, tag, HEAD~N...) to its full commit hash
+func ResolveRef(ref string) (string, error) {
+	cmd := command("rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	output, err := gitOutput(cmd)
+	if err != nil {
+		return "", fmt.Errorf("unknown revision '%s'", ref)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// IsAncestor reports whether ancestor is reachable from (or equal to) descendant
+func IsAncestor(ancestor, descendant string) (bool, error) {
+	cmd := command("merge-base", "--is-ancestor", ancestor, descendant)
+	err := gitRun(cmd)
+	if err == nil {
+		return true, nil
+	}
+	// Exit code 1 means "not an ancestor"; anything else is a real failure
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check ancestry of %s: %w", ancestor, err)
+}
+
+// CountCommitsSince returns the number of commits reachable from HEAD but not from ref
+func CountCommitsSince(ref string) (int, error) {
+	cmd := command("rev-list", "--count", ref+"..HEAD")
+	output, err := gitOutput(cmd)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count commits since %s: %w", ref, err)
+	}
+
+	countStr := strings.TrimSpace(string(output))
+	count, err := strconv.Atoi(countStr)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse commit count '%s': %w", countStr, err)
+	}
+
+	return count, nil
+}
+
+// CountCommitsAfter returns the number of commits reachable from HEAD but not
+// from ref that were committed at or after since. Commits brought in from
+// elsewhere (e.g. a pull) keep their original dates and are not counted.
+// An empty ref counts from the start of history.
+func CountCommitsAfter(ref string, since time.Time) (int, error) {
+	rangeSpec := "HEAD"
+	if ref != "" {
+		rangeSpec = ref + "..HEAD"
+	}
+	// Filter dates ourselves: rev-list --since stops walking at the first
+	// older commit and would miss newer commits behind it
+	cmd := command("log", "--format=%ct", rangeSpec)
+	output, err := gitOutput(cmd)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count commits after %s: %w", since.Format(time.RFC3339), err)
+	}
+
+	count := 0
+	for _, line := range strings.Fields(string(output)) {
+		ts, err := strconv.ParseInt(line, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse commit time '%s': %w", line, err)
+		}
+		if ts >= since.Unix() {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// ErrNoUpstream is returned when a branch has no upstream configured
+var ErrNoUpstream = errors.New("no upstream configured")
+
+// GetUpstream returns the upstream branch tracks (e.g. "origin/main").
+// Returns ErrNoUpstream if it doesn't track one.
+func GetUpstream(branch string) (string, error) {
+	output, err := gitOutput(command("rev-parse", "--abbrev-ref", "--verify", "--quiet", branch+"@{u}"))
+	if err != nil {
+		return "", ErrNoUpstream
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetAheadBehind returns how many commits branch is ahead of and behind its
+// upstream. Returns ErrNoUpstream if the branch doesn't track one.
+func GetAheadBehind(branch string) (ahead int, behind int, err error) {
+	if _, err := GetUpstream(branch); err != nil {
+		return 0, 0, err
+	}
+
+	// Left side is the upstream (commits we're behind), right side is the branch
+	cmd := command("rev-list", "--left-right", "--count", branch+"@{u}..."+branch)
+	output, err := gitOutput(cmd)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to compare %s with upstream: %w", branch, err)
+	}
+
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output '%s'", strings.TrimSpace(string(output)))
+	}
+	if behind, err = strconv.Atoi(fields[0]); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse behind count '%s': %w", fields[0], err)
+	}
+	if ahead, err = strconv.Atoi(fields[1]); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse ahead count '%s': %w", fields[1], err)
+	}
+
+	return ahead, behind, nil
+}
+
 // CommitInfo holds a short hash and message for a single commit.
 type CommitInfo struct {
 	Hash    string
@@ -165,8 +557,8 @@ func GetRecentCommits(n int) ([]CommitInfo, error) {
 		return nil, nil
 	}
 
-	cmd := exec.Command("git", "log", "--oneline", "-n", strconv.Itoa(n))
-	output, err := cmd.Output()
+	cmd := command("log", "--oneline", "-n", strconv.Itoa(n))
+	output, err := gitOutput(cmd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get recent commits: %w", err)
 	}
@@ -191,10 +583,65 @@ func GetRecentCommits(n int) ([]CommitInfo, error) {
 
 // Clean removes all untracked files and directories
 func Clean() error {
-	cmd := exec.Command("git", "clean", "-fd")
-	output, err := cmd.CombinedOutput()
+	cmd := command("clean", "-fd")
+	output, err := gitCombinedOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("git clean failed: %w\nOutput: %s", err, string(output))
 	}
 	return nil
 }
+
+// Submodules returns the paths of the repository's submodules
+func Submodules() ([]string, error) {
+	cmd := command("submodule", "status")
+	output, err := gitOutput(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list submodules: %w", err)
+	}
+
+	// Each line is "<status><hash> <path> (<describe>)", where status is
+	// a space, "-" (not initialized), "+" (different commit) or "U" (conflict)
+	var paths []string
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 {
+			paths = append(paths, fields[1])
+		}
+	}
+	return paths, nil
+}
+
+// HasSubmodules reports whether the repository has any submodules
+func HasSubmodules() (bool, error) {
+	paths, err := Submodules()
+	if err != nil {
+		return false, err
+	}
+	return len(paths) > 0, nil
+}
+
+// IgnoredPaths returns the paths in paths that .gitignore (or another
+// exclude file) ignores. Tracked files are never reported as ignored.
+func IgnoredPaths(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	cmd := command("check-ignore", "--stdin")
+	cmd.Stdin = strings.NewReader(strings.Join(paths, "\n") + "\n")
+	output, err := gitOutput(cmd)
+	if err != nil {
+		// Exit code 1 means none of the paths are ignored
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to check ignored paths: %w", err)
+	}
+
+	var ignored []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line != "" {
+			ignored = append(ignored, line)
+		}
+	}
+	return ignored, nil
+}
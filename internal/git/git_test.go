@@ -1,11 +1,16 @@
 package git
 
 import (
+	"bytes"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/adriancodes/gumloop/internal/logging"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -112,6 +117,29 @@ func TestGetBranch(t *testing.T) {
 	assert.Equal(t, "feature-branch", branch)
 }
 
+func TestGitCommandDebugLogging(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	var buf bytes.Buffer
+	logging.SetOutput(&buf)
+	defer logging.SetOutput(nil)
+	defer logging.SetLevel(logging.LevelInfo)
+
+	// Info level: git commands aren't logged
+	logging.SetLevel(logging.LevelInfo)
+	_, err := GetBranch()
+	require.NoError(t, err)
+	assert.Empty(t, buf.String())
+
+	// Debug level: the command and its output are
+	logging.SetLevel(logging.LevelDebug)
+	branch, err := GetBranch()
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "[debug] git symbolic-ref --short HEAD")
+	assert.Contains(t, buf.String(), "[debug]   "+branch)
+}
+
 func TestGetBranchError(t *testing.T) {
 	// Test outside git repo
 	tmpDir, err := os.MkdirTemp("", "gumloop-no-git-*")
@@ -232,6 +260,77 @@ func TestHasChanges(t *testing.T) {
 	})
 }
 
+func TestCountChangedFilesSince(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+	createCommit(t, "file1.txt", "content1")
+	start, err := ResolveRef("HEAD")
+	require.NoError(t, err)
+
+	changed, err := CountChangedFilesSince(start)
+	require.NoError(t, err)
+	assert.Equal(t, 0, changed)
+
+	// Committed, modified, staged and untracked files all count, once each
+	createCommit(t, "file2.txt", "content2")
+	require.NoError(t, os.WriteFile("file2.txt", []byte("edited"), 0644))
+	require.NoError(t, os.WriteFile("file1.txt", []byte("edited"), 0644))
+	require.NoError(t, os.WriteFile("staged.txt", []byte("staged"), 0644))
+	require.NoError(t, exec.Command("git", "add", "staged.txt").Run())
+	require.NoError(t, os.WriteFile("untracked.txt", []byte("new"), 0644))
+	require.NoError(t, os.WriteFile(".gumloop-memory.yaml", []byte("iterations: 1\n"), 0644))
+
+	changed, err = CountChangedFilesSince(start)
+	require.NoError(t, err)
+	assert.Equal(t, 4, changed)
+}
+
+func TestWorkTreeHash(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+	createCommit(t, "file1.txt", "content1")
+
+	clean, err := WorkTreeHash()
+	require.NoError(t, err)
+	again, err := WorkTreeHash()
+	require.NoError(t, err)
+	assert.Equal(t, clean, again, "nothing changed")
+
+	// Modified, then untracked, files change the hash
+	require.NoError(t, os.WriteFile("file1.txt", []byte("modified"), 0644))
+	modified, err := WorkTreeHash()
+	require.NoError(t, err)
+	assert.NotEqual(t, clean, modified)
+
+	require.NoError(t, os.WriteFile("untracked.txt", []byte("new file"), 0644))
+	untracked, err := WorkTreeHash()
+	require.NoError(t, err)
+	assert.NotEqual(t, modified, untracked)
+
+	// Ignored files don't
+	require.NoError(t, os.WriteFile(".git/info/exclude", []byte("*.log\n"), 0644))
+	require.NoError(t, os.WriteFile("debug.log", []byte("noise"), 0644))
+	ignored, err := WorkTreeHash()
+	require.NoError(t, err)
+	assert.Equal(t, untracked, ignored)
+
+	// Nor do gumloop's state files
+	require.NoError(t, os.WriteFile(".gumloop-memory.yaml", []byte("iterations: 1\n"), 0644))
+	withState, err := WorkTreeHash()
+	require.NoError(t, err)
+	assert.Equal(t, ignored, withState)
+
+	// Committing the same content keeps the hash, and the real index is untouched
+	_, staged, untrackedFiles, err := GetChangedFiles()
+	require.NoError(t, err)
+	assert.Equal(t, 0, staged)
+	assert.Equal(t, 1, untrackedFiles, "untracked.txt is still untracked")
+	require.NoError(t, CommitAll("commit everything"))
+	committed, err := WorkTreeHash()
+	require.NoError(t, err)
+	assert.Equal(t, ignored, committed)
+}
+
 func TestGetChangedFiles(t *testing.T) {
 	_, cleanup := setupTestRepo(t)
 	defer cleanup()
@@ -378,6 +477,161 @@ func TestResetHardError(t *testing.T) {
 	assert.Contains(t, err.Error(), "git reset failed")
 }
 
+func TestResetSoft(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createCommit(t, "file1.txt", "content1")
+	createCommit(t, "file2.txt", "content2")
+
+	require.NoError(t, ResetSoft("HEAD~1"))
+
+	count, err := CountCommits()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// The undone commit's file is kept, staged
+	_, staged, _, err := GetChangedFiles()
+	require.NoError(t, err)
+	assert.Equal(t, 1, staged)
+}
+
+// commitMessages returns the full messages of the last n commits, newest first
+func commitMessages(t *testing.T, n int) []string {
+	t.Helper()
+	output, err := exec.Command("git", "log", "-n", strconv.Itoa(n), "--format=%B%x00").Output()
+	require.NoError(t, err)
+	var messages []string
+	for _, msg := range strings.Split(string(output), "\x00") {
+		if msg = strings.TrimSpace(msg); msg != "" {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+func TestAddTrailers(t *testing.T) {
+	trailers := []string{"Gumloop-Iteration: 2", "Gumloop-Agent: claude"}
+
+	t.Run("session commits only", func(t *testing.T) {
+		_, cleanup := setupTestRepo(t)
+		defer cleanup()
+
+		createCommit(t, "before.txt", "pre-existing")
+		createCommit(t, "file1.txt", "content1")
+		createCommit(t, "file2.txt", "content2")
+
+		require.NoError(t, AddTrailers(2, trailers))
+
+		messages := commitMessages(t, 3)
+		require.Len(t, messages, 3)
+		for _, msg := range messages[:2] {
+			assert.Equal(t, "test commit\n\nGumloop-Iteration: 2\nGumloop-Agent: claude", msg)
+		}
+		assert.Equal(t, "test commit", messages[2], "pre-existing commits are left alone")
+
+		// Files from the rewritten commits are still there
+		count, err := CountCommits()
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+		hasChanges, err := HasChanges()
+		require.NoError(t, err)
+		assert.False(t, hasChanges)
+	})
+
+	t.Run("single commit", func(t *testing.T) {
+		_, cleanup := setupTestRepo(t)
+		defer cleanup()
+
+		createCommit(t, "before.txt", "pre-existing")
+		createCommit(t, "file1.txt", "content1")
+
+		require.NoError(t, AddTrailers(1, trailers))
+
+		messages := commitMessages(t, 2)
+		assert.Equal(t, "test commit\n\nGumloop-Iteration: 2\nGumloop-Agent: claude", messages[0])
+		assert.Equal(t, "test commit", messages[1])
+	})
+
+	t.Run("whole history", func(t *testing.T) {
+		_, cleanup := setupTestRepo(t)
+		defer cleanup()
+
+		createCommit(t, "file1.txt", "content1")
+		createCommit(t, "file2.txt", "content2")
+
+		require.NoError(t, AddTrailers(2, trailers))
+
+		for _, msg := range commitMessages(t, 2) {
+			assert.Contains(t, msg, "Gumloop-Agent: claude")
+		}
+	})
+
+	t.Run("single commit with staged changes", func(t *testing.T) {
+		_, cleanup := setupTestRepo(t)
+		defer cleanup()
+
+		createCommit(t, "file1.txt", "content1")
+		require.NoError(t, os.WriteFile("staged.txt", []byte("staged"), 0644))
+		require.NoError(t, exec.Command("git", "add", "staged.txt").Run())
+
+		require.NoError(t, AddTrailers(1, trailers))
+
+		assert.Contains(t, commitMessages(t, 1)[0], "Gumloop-Agent: claude")
+		output, err := exec.Command("git", "show", "--name-only", "--format=", "HEAD").Output()
+		require.NoError(t, err)
+		assert.Equal(t, "file1.txt\n", string(output), "staged changes aren't folded into the commit")
+		_, staged, _, err := GetChangedFiles()
+		require.NoError(t, err)
+		assert.Equal(t, 1, staged)
+	})
+
+	t.Run("several commits on a dirty tree", func(t *testing.T) {
+		_, cleanup := setupTestRepo(t)
+		defer cleanup()
+
+		createCommit(t, "before.txt", "pre-existing")
+		createCommit(t, "file1.txt", "content1")
+		createCommit(t, "file2.txt", "content2")
+		require.NoError(t, os.WriteFile("file1.txt", []byte("edited"), 0644))
+		require.NoError(t, os.WriteFile("staged.txt", []byte("staged"), 0644))
+		require.NoError(t, exec.Command("git", "add", "staged.txt").Run())
+
+		require.NoError(t, AddTrailers(2, trailers))
+
+		for _, msg := range commitMessages(t, 2) {
+			assert.Contains(t, msg, "Gumloop-Agent: claude")
+		}
+		data, err := os.ReadFile("file1.txt")
+		require.NoError(t, err)
+		assert.Equal(t, "edited", string(data), "uncommitted changes survive the rebase")
+		assert.FileExists(t, "staged.txt")
+		hasChanges, err := HasChanges()
+		require.NoError(t, err)
+		assert.True(t, hasChanges)
+	})
+
+	t.Run("merge among the commits", func(t *testing.T) {
+		_, cleanup := setupTestRepo(t)
+		defer cleanup()
+
+		createCommit(t, "before.txt", "pre-existing")
+		require.NoError(t, exec.Command("git", "checkout", "-q", "-b", "side").Run())
+		createCommit(t, "side.txt", "side")
+		require.NoError(t, exec.Command("git", "checkout", "-q", "-").Run())
+		createCommit(t, "main.txt", "main")
+		require.NoError(t, exec.Command("git", "merge", "-q", "--no-edit", "side").Run())
+		head, err := ResolveRef("HEAD")
+		require.NoError(t, err)
+
+		assert.Error(t, AddTrailers(3, trailers))
+
+		after, err := ResolveRef("HEAD")
+		require.NoError(t, err)
+		assert.Equal(t, head, after, "history with a merge is left alone")
+	})
+}
+
 func TestClean(t *testing.T) {
 	_, cleanup := setupTestRepo(t)
 	defer cleanup()
@@ -420,3 +674,408 @@ func TestClean(t *testing.T) {
 	_, err = os.Stat("tracked.txt")
 	require.NoError(t, err)
 }
+
+func TestResolveRef(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createCommit(t, "file1.txt", "content1")
+
+	hash, err := ResolveRef("HEAD")
+	require.NoError(t, err)
+	assert.Len(t, hash, 40)
+
+	_, err = ResolveRef("no-such-ref")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown revision")
+}
+
+func TestIsAncestor(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createCommit(t, "file1.txt", "content1")
+	first, err := ResolveRef("HEAD")
+	require.NoError(t, err)
+
+	createCommit(t, "file2.txt", "content2")
+
+	t.Run("valid ancestor", func(t *testing.T) {
+		ok, err := IsAncestor(first, "HEAD")
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		count, err := CountCommitsSince(first)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("commit on another branch is not an ancestor", func(t *testing.T) {
+		require.NoError(t, exec.Command("git", "checkout", "-q", "-b", "side", first).Run())
+		createCommit(t, "side.txt", "side")
+		side, err := ResolveRef("HEAD")
+		require.NoError(t, err)
+		require.NoError(t, exec.Command("git", "checkout", "-q", "-").Run())
+
+		ok, err := IsAncestor(side, "HEAD")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestGetAheadBehind(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createCommit(t, "file1.txt", "content1")
+	require.NoError(t, exec.Command("git", "branch", "base").Run())
+	require.NoError(t, exec.Command("git", "checkout", "-q", "-b", "feature").Run())
+
+	t.Run("no upstream", func(t *testing.T) {
+		_, _, err := GetAheadBehind("feature")
+		assert.ErrorIs(t, err, ErrNoUpstream)
+	})
+
+	// Track the local base branch as the upstream
+	require.NoError(t, exec.Command("git", "branch", "--set-upstream-to=base").Run())
+
+	t.Run("up to date", func(t *testing.T) {
+		ahead, behind, err := GetAheadBehind("feature")
+		require.NoError(t, err)
+		assert.Equal(t, 0, ahead)
+		assert.Equal(t, 0, behind)
+	})
+
+	t.Run("ahead and behind", func(t *testing.T) {
+		createCommit(t, "feature1.txt", "one")
+		createCommit(t, "feature2.txt", "two")
+
+		require.NoError(t, exec.Command("git", "checkout", "-q", "base").Run())
+		createCommit(t, "base.txt", "base")
+		require.NoError(t, exec.Command("git", "checkout", "-q", "feature").Run())
+
+		ahead, behind, err := GetAheadBehind("feature")
+		require.NoError(t, err)
+		assert.Equal(t, 2, ahead)
+		assert.Equal(t, 1, behind)
+	})
+}
+
+func TestCountCommitsAfter(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createCommit(t, "file1.txt", "content1")
+	start, err := ResolveRef("HEAD")
+	require.NoError(t, err)
+	since := time.Now()
+
+	// Someone else's commit from before the session, pulled in mid-session
+	t.Setenv("GIT_COMMITTER_DATE", "2020-01-01T00:00:00Z")
+	createCommit(t, "theirs.txt", "theirs")
+	os.Unsetenv("GIT_COMMITTER_DATE")
+
+	createCommit(t, "ours.txt", "ours")
+
+	count, err := CountCommitsAfter(start, since)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	all, err := CountCommitsSince(start)
+	require.NoError(t, err)
+	assert.Equal(t, 2, all)
+
+	t.Run("empty ref counts from the start of history", func(t *testing.T) {
+		count, err := CountCommitsAfter("", time.Unix(0, 0))
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+	})
+}
+
+func TestDir(t *testing.T) {
+	repoPath, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createCommit(t, "a.txt", "a")
+	createCommit(t, "b.txt", "b")
+	require.NoError(t, os.WriteFile("dirty.txt", []byte("wip"), 0644))
+
+	// Move somewhere that is not a git repository and point Dir at the repo
+	require.NoError(t, os.Chdir(t.TempDir()))
+	Dir = repoPath
+	defer func() { Dir = "" }()
+
+	assert.True(t, IsInsideWorkTree())
+
+	count, err := CountCommits()
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	hasChanges, err := HasChanges()
+	require.NoError(t, err)
+	assert.True(t, hasChanges)
+
+	root, err := GetRepoRoot()
+	require.NoError(t, err)
+	expected, err := filepath.EvalSymlinks(repoPath)
+	require.NoError(t, err)
+	assert.Equal(t, expected, root)
+
+	// Without Dir, the cwd (not a repo) is used
+	Dir = ""
+	assert.False(t, IsInsideWorkTree())
+}
+
+func TestIsDetachedHead(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createCommit(t, "a.txt", "a")
+	createCommit(t, "b.txt", "b")
+
+	detached, err := IsDetachedHead()
+	require.NoError(t, err)
+	assert.False(t, detached)
+
+	// Check out a commit directly
+	cmd := exec.Command("git", "checkout", "-q", "HEAD~1")
+	require.NoError(t, cmd.Run())
+
+	detached, err = IsDetachedHead()
+	require.NoError(t, err)
+	assert.True(t, detached)
+
+	branch, err := GetBranch()
+	require.NoError(t, err)
+	assert.Equal(t, "HEAD", branch)
+}
+
+func TestCommitAll(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createCommit(t, "a.txt", "a")
+	require.NoError(t, os.WriteFile("a.txt", []byte("changed"), 0644))
+	require.NoError(t, os.WriteFile("new.txt", []byte("new"), 0644))
+
+	require.NoError(t, CommitAll("leftovers"))
+
+	count, err := CountCommits()
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	hasChanges, err := HasChanges()
+	require.NoError(t, err)
+	assert.False(t, hasChanges)
+
+	commits, err := GetRecentCommits(1)
+	require.NoError(t, err)
+	assert.Equal(t, "leftovers", commits[0].Message)
+}
+
+func TestCommitAll_SkipsStateFiles(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createCommit(t, "a.txt", "a")
+	require.NoError(t, os.Mkdir("sub", 0755))
+	for _, path := range []string{".gumloop-memory.yaml", filepath.Join("sub", ".gumloop-memory.yaml")} {
+		require.NoError(t, os.WriteFile(path, []byte("iterations: 1\n"), 0644))
+	}
+
+	// State files alone aren't changes...
+	hasChanges, err := HasChanges()
+	require.NoError(t, err)
+	assert.False(t, hasChanges)
+	_, _, untracked, err := GetChangedFiles()
+	require.NoError(t, err)
+	assert.Equal(t, 0, untracked)
+	assert.Error(t, CommitAll("nothing but state"))
+
+	// ...and aren't swept into a commit of real ones
+	require.NoError(t, os.WriteFile("new.txt", []byte("new"), 0644))
+	require.NoError(t, CommitAll("leftovers"))
+
+	output, err := exec.Command("git", "show", "--name-only", "--format=", "HEAD").Output()
+	require.NoError(t, err)
+	assert.Equal(t, "new.txt\n", string(output))
+	assert.FileExists(t, ".gumloop-memory.yaml")
+}
+
+func TestCommitAll_NothingToCommit(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createCommit(t, "a.txt", "a")
+	assert.Error(t, CommitAll("empty"))
+}
+
+func TestHasRemote(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	t.Run("without a remote", func(t *testing.T) {
+		hasRemote, err := HasRemote()
+		require.NoError(t, err)
+		assert.False(t, hasRemote)
+	})
+
+	t.Run("with only a non-origin remote", func(t *testing.T) {
+		require.NoError(t, exec.Command("git", "remote", "add", "upstream", "https://example.com/repo.git").Run())
+
+		hasRemote, err := HasRemote()
+		require.NoError(t, err)
+		assert.False(t, hasRemote, "Push targets origin, so other remotes don't count")
+	})
+
+	t.Run("with an origin remote", func(t *testing.T) {
+		require.NoError(t, exec.Command("git", "remote", "add", "origin", "https://example.com/repo.git").Run())
+
+		hasRemote, err := HasRemote()
+		require.NoError(t, err)
+		assert.True(t, hasRemote)
+	})
+}
+
+func TestGetUpstream(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createCommit(t, "file1.txt", "content1")
+	require.NoError(t, exec.Command("git", "branch", "base").Run())
+	require.NoError(t, exec.Command("git", "checkout", "-q", "-b", "feature").Run())
+
+	_, err := GetUpstream("feature")
+	assert.ErrorIs(t, err, ErrNoUpstream)
+
+	require.NoError(t, exec.Command("git", "branch", "--set-upstream-to=base").Run())
+	upstream, err := GetUpstream("feature")
+	require.NoError(t, err)
+	assert.Equal(t, "base", upstream)
+}
+
+// stubPush records the arguments Push runs git with instead of pushing
+func stubPush(t *testing.T) *[][]string {
+	t.Helper()
+	var calls [][]string
+	orig := runPush
+	runPush = func(args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		return nil, nil
+	}
+	t.Cleanup(func() { runPush = orig })
+	return &calls
+}
+
+func TestPush_SetsUpstream(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createCommit(t, "file1.txt", "content1")
+	require.NoError(t, exec.Command("git", "branch", "base").Run())
+	require.NoError(t, exec.Command("git", "checkout", "-q", "-b", "feature").Run())
+	calls := stubPush(t)
+
+	t.Run("without upstream", func(t *testing.T) {
+		*calls = nil
+		require.NoError(t, Push("feature"))
+		assert.Equal(t, [][]string{{"push", "-u", "origin", "feature"}}, *calls)
+	})
+
+	t.Run("with upstream", func(t *testing.T) {
+		*calls = nil
+		require.NoError(t, exec.Command("git", "branch", "--set-upstream-to=base").Run())
+		require.NoError(t, Push("feature"))
+		assert.Equal(t, [][]string{{"push", "origin", "feature"}}, *calls)
+	})
+}
+
+func TestPush_ExtraArgs(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	createCommit(t, "file1.txt", "content1")
+	require.NoError(t, exec.Command("git", "branch", "base").Run())
+	require.NoError(t, exec.Command("git", "checkout", "-q", "-b", "feature").Run())
+	calls := stubPush(t)
+
+	require.NoError(t, Push("feature", "--no-verify", "--push-option=ci.skip"))
+	assert.Equal(t, [][]string{{"push", "-u", "--no-verify", "--push-option=ci.skip", "origin", "feature"}}, *calls)
+
+	*calls = nil
+	require.NoError(t, exec.Command("git", "branch", "--set-upstream-to=base").Run())
+	require.NoError(t, Push("feature", "--no-verify"))
+	assert.Equal(t, [][]string{{"push", "--no-verify", "origin", "feature"}}, *calls)
+}
+
+func TestPush_FirstPushTracksOrigin(t *testing.T) {
+	repo, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	remote := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", "-q", "--bare", remote).Run())
+	require.NoError(t, exec.Command("git", "-C", repo, "remote", "add", "origin", remote).Run())
+	createCommit(t, "file1.txt", "content1")
+	require.NoError(t, exec.Command("git", "checkout", "-q", "-b", "feature").Run())
+
+	require.NoError(t, Push("feature"))
+	upstream, err := GetUpstream("feature")
+	require.NoError(t, err)
+	assert.Equal(t, "origin/feature", upstream)
+}
+
+func TestHasSubmodules(t *testing.T) {
+	t.Run("without submodules", func(t *testing.T) {
+		_, cleanup := setupTestRepo(t)
+		defer cleanup()
+		createCommit(t, "file.txt", "content")
+
+		has, err := HasSubmodules()
+		require.NoError(t, err)
+		assert.False(t, has)
+	})
+
+	t.Run("with a submodule", func(t *testing.T) {
+		// The repository to add as a submodule
+		libDir := t.TempDir()
+		for _, args := range [][]string{
+			{"init"},
+			{"-c", "user.email=test@example.com", "-c", "user.name=Test User", "commit", "--allow-empty", "-m", "lib"},
+		} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = libDir
+			require.NoError(t, cmd.Run())
+		}
+
+		_, cleanup := setupTestRepo(t)
+		defer cleanup()
+		createCommit(t, "file.txt", "content")
+
+		cmd := exec.Command("git", "-c", "protocol.file.allow=always", "submodule", "add", libDir, "vendor/lib")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+
+		has, err := HasSubmodules()
+		require.NoError(t, err)
+		assert.True(t, has)
+
+		paths, err := Submodules()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"vendor/lib"}, paths)
+	})
+}
+
+func TestIgnoredPaths(t *testing.T) {
+	_, cleanup := setupTestRepo(t)
+	defer cleanup()
+	createCommit(t, "tracked.log", "kept")
+	createCommit(t, ".gitignore", "node_modules/\n*.log\n")
+
+	ignored, err := IgnoredPaths([]string{"main.go", "debug.log", "node_modules/pkg/index.js", "tracked.log"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"debug.log", "node_modules/pkg/index.js"}, ignored)
+
+	ignored, err = IgnoredPaths([]string{"main.go"})
+	require.NoError(t, err)
+	assert.Empty(t, ignored)
+}
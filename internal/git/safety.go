@@ -1,8 +1,6 @@
 package git
 
 import (
-	"bufio"
-	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -19,8 +17,8 @@ func IsDangerousPath(path string) bool {
 		return true
 	}
 
-	// Clean the path to remove any .. or . components
-	absPath = filepath.Clean(absPath)
+	// Clean the path and follow symlinks
+	absPath = resolvePath(absPath)
 
 	// Get home directory
 	home, err := os.UserHomeDir()
@@ -41,6 +39,23 @@ func IsDangerousPath(path string) bool {
 		"/lib",
 	}
 
+	// Config and credential directories in $HOME, which hold dotfile repos
+	// as often as not. Unlike the paths above, everything under them is
+	// dangerous too (~/.config/nvim is as much a dotfile repo as ~/.config).
+	var dangerousRoots []string
+	if home != "" {
+		dangerousRoots = append(dangerousRoots,
+			filepath.Join(home, ".config"),
+			filepath.Join(home, ".local"),
+			filepath.Join(home, ".ssh"),
+		)
+	}
+	for _, env := range []string{"XDG_CONFIG_HOME", "XDG_DATA_HOME", "XDG_STATE_HOME"} {
+		if dir := os.Getenv(env); filepath.IsAbs(dir) {
+			dangerousRoots = append(dangerousRoots, dir)
+		}
+	}
+
 	// Add macOS-specific paths
 	if runtime.GOOS == "darwin" {
 		dangerousPaths = append(dangerousPaths,
@@ -63,9 +78,16 @@ func IsDangerousPath(path string) bool {
 		if dangerous == "" {
 			continue
 		}
-		// Clean the dangerous path as well for comparison
-		cleanDangerous := filepath.Clean(dangerous)
-		if absPath == cleanDangerous {
+		// Resolve the dangerous path as well for comparison
+		if absPath == resolvePath(dangerous) {
+			return true
+		}
+	}
+
+	// Check if absPath is a dangerous root or anywhere under one
+	for _, root := range dangerousRoots {
+		root = resolvePath(root)
+		if absPath == root || strings.HasPrefix(absPath, root+string(filepath.Separator)) {
 			return true
 		}
 	}
@@ -73,6 +95,32 @@ func IsDangerousPath(path string) bool {
 	return false
 }
 
+// IsAlwaysRefusedPath reports whether path is $HOME itself or a filesystem
+// root. These stay refused even with --allow-dangerous-path.
+func IsAlwaysRefusedPath(path string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return true
+	}
+	absPath = resolvePath(absPath)
+
+	if absPath == filepath.VolumeName(absPath)+string(filepath.Separator) {
+		return true
+	}
+	home, err := os.UserHomeDir()
+	return err == nil && absPath == resolvePath(home)
+}
+
+// resolvePath cleans path and follows any symlinks in it, so a link to a
+// dangerous directory is caught too. Paths that don't exist are only cleaned.
+func resolvePath(path string) string {
+	path = filepath.Clean(path)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
+
 // IsHomeSubdirectory checks if the given path is a subdirectory of $HOME
 // (but not $HOME itself, which is caught by IsDangerousPath).
 func IsHomeSubdirectory(path string) bool {
@@ -100,25 +148,3 @@ func IsHomeSubdirectory(path string) bool {
 	// Check if path starts with home directory
 	return strings.HasPrefix(absPath, home+string(filepath.Separator))
 }
-
-// ConfirmHomeSubdirectory prompts the user to confirm running in choo-choo mode
-// when the current directory is a subdirectory of $HOME.
-// Returns true if the user confirms, false otherwise.
-func ConfirmHomeSubdirectory() bool {
-	fmt.Println()
-	fmt.Println("⚠️  WARNING: You are running in choo-choo mode under your home directory.")
-	fmt.Println()
-	fmt.Println("   Autonomous agents will make changes without asking for permission.")
-	fmt.Println("   Git is your safety net, but use caution.")
-	fmt.Println()
-	fmt.Print("Continue? (y/N): ")
-
-	reader := bufio.NewReader(os.Stdin)
-	response, err := reader.ReadString('\n')
-	if err != nil {
-		return false
-	}
-
-	response = strings.TrimSpace(strings.ToLower(response))
-	return response == "y" || response == "yes"
-}
@@ -65,6 +65,32 @@ func TestIsDangerousPath(t *testing.T) {
 			path:     "/lib",
 			expected: true,
 		},
+		// Config and credential directories in home are dangerous
+		{
+			name:     "config directory",
+			path:     filepath.Join(home, ".config"),
+			expected: true,
+		},
+		{
+			name:     "local directory",
+			path:     filepath.Join(home, ".local"),
+			expected: true,
+		},
+		{
+			name:     "local share directory",
+			path:     filepath.Join(home, ".local", "share"),
+			expected: true,
+		},
+		{
+			name:     "ssh directory",
+			path:     filepath.Join(home, ".ssh"),
+			expected: true,
+		},
+		{
+			name:     "ssh directory with trailing slash",
+			path:     filepath.Join(home, ".ssh") + "/",
+			expected: true,
+		},
 		// Home subdirectories are NOT dangerous (handled by separate check)
 		{
 			name:     "home subdirectory",
@@ -154,6 +180,64 @@ func TestIsDangerousPath_WithDotComponents(t *testing.T) {
 	assert.True(t, result, "path with .. should be cleaned and recognized as dangerous")
 }
 
+func TestIsDangerousPath_XDGDirectories(t *testing.T) {
+	configHome := filepath.Join(t.TempDir(), "xdg-config")
+	dataHome := filepath.Join(t.TempDir(), "xdg-data")
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("XDG_DATA_HOME", dataHome)
+
+	assert.True(t, IsDangerousPath(configHome), "XDG_CONFIG_HOME should be dangerous")
+	assert.True(t, IsDangerousPath(dataHome), "XDG_DATA_HOME should be dangerous")
+	assert.True(t, IsDangerousPath(filepath.Join(configHome, "nvim")), "directories under XDG_CONFIG_HOME should be dangerous")
+	assert.False(t, IsDangerousPath(configHome+"-backup"), "a sibling sharing the prefix is not under it")
+
+	// A relative value is ignored, as the XDG spec requires
+	t.Setenv("XDG_CONFIG_HOME", "relative")
+	cwd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.False(t, IsDangerousPath(filepath.Join(cwd, "relative")))
+}
+
+func TestIsDangerousPath_NestedConfigDirectories(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_STATE_HOME", "")
+
+	for _, path := range []string{
+		filepath.Join(home, ".config", "nvim"),
+		filepath.Join(home, ".config", "nvim", "lua"),
+		filepath.Join(home, ".local", "share", "chezmoi"),
+		filepath.Join(home, ".ssh", "keys"),
+	} {
+		assert.True(t, IsDangerousPath(path), "%s should be dangerous", path)
+	}
+
+	// Other home subdirectories, including look-alike names, are not
+	assert.False(t, IsDangerousPath(filepath.Join(home, ".configs")))
+	assert.False(t, IsDangerousPath(filepath.Join(home, "projects", ".config")))
+}
+
+func TestIsDangerousPath_Symlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need extra privileges on Windows")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	assert.NoError(t, os.Mkdir(filepath.Join(home, ".ssh"), 0700))
+
+	// A link to ~/.ssh is as dangerous as ~/.ssh itself
+	link := filepath.Join(t.TempDir(), "keys")
+	assert.NoError(t, os.Symlink(filepath.Join(home, ".ssh"), link))
+	assert.True(t, IsDangerousPath(link), "symlink to ~/.ssh should be dangerous")
+
+	// ...and so is reaching home through a link
+	homeLink := filepath.Join(t.TempDir(), "home")
+	assert.NoError(t, os.Symlink(home, homeLink))
+	assert.True(t, IsDangerousPath(filepath.Join(homeLink, ".ssh")), "~/.ssh through a linked home should be dangerous")
+}
+
 func TestIsHomeSubdirectory(t *testing.T) {
 	home, err := os.UserHomeDir()
 	assert.NoError(t, err)
@@ -264,5 +348,14 @@ func TestSafetyChecksIntegration(t *testing.T) {
 	assert.True(t, isHomeSub, "project under home should be recognized as home subdirectory")
 }
 
-// Note: ConfirmHomeSubdirectory is not tested here because it requires user input.
-// It would need integration tests with mocked stdin or manual testing.
+func TestIsAlwaysRefusedPath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	assert.NoError(t, err)
+
+	assert.True(t, IsAlwaysRefusedPath("/"))
+	assert.True(t, IsAlwaysRefusedPath(home))
+	assert.True(t, IsAlwaysRefusedPath(home+"/"))
+	assert.False(t, IsAlwaysRefusedPath("/etc"), "dangerous, but can be allowed")
+	assert.False(t, IsAlwaysRefusedPath(filepath.Join(home, ".config")))
+	assert.False(t, IsAlwaysRefusedPath(filepath.Join(home, "projects", "myapp")))
+}
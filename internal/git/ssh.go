@@ -0,0 +1,76 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// GetRemoteURL returns the URL configured for the named remote
+func GetRemoteURL(remote string) (string, error) {
+	cmd := command("remote", "get-url", remote)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get URL for remote '%s': %w", remote, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// IsSSHURL reports whether a remote URL uses SSH
+// (ssh://host/path or the scp-like user@host:path form)
+func IsSSHURL(url string) bool {
+	if strings.HasPrefix(url, "ssh://") || strings.HasPrefix(url, "git+ssh://") {
+		return true
+	}
+	if strings.Contains(url, "://") {
+		return false
+	}
+	// scp-like syntax: [user@]host:path, but not a local path like ./foo:bar
+	colon := strings.Index(url, ":")
+	slash := strings.Index(url, "/")
+	return colon > 0 && (slash == -1 || colon < slash)
+}
+
+// CountSSHIdentities returns the number of identities loaded in ssh-agent
+func CountSSHIdentities() (int, error) {
+	cmd := exec.Command("ssh-add", "-l")
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			switch exitErr.ExitCode() {
+			case 1:
+				// Agent is running but has no identities
+				return 0, nil
+			case 2:
+				return 0, fmt.Errorf("could not connect to ssh-agent")
+			}
+		}
+		return 0, fmt.Errorf("failed to list ssh identities: %w", err)
+	}
+
+	count := 0
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line != "" {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CheckPushPreflight checks that pushing to remoteURL is likely to work.
+// Only SSH remotes are checked: countIdentities (normally CountSSHIdentities)
+// must report at least one loaded identity. Returns nil if nothing looks wrong.
+func CheckPushPreflight(remoteURL string, countIdentities func() (int, error)) error {
+	if !IsSSHURL(remoteURL) {
+		return nil
+	}
+
+	count, err := countIdentities()
+	if err != nil {
+		return fmt.Errorf("%v; pushes to %s may fail (run ssh-add)", err, remoteURL)
+	}
+	if count == 0 {
+		return fmt.Errorf("no SSH identities loaded in ssh-agent; pushes to %s may fail (run ssh-add)", remoteURL)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package git
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSSHURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected bool
+	}{
+		{"git@github.com:adriancodes/gumloop.git", true},
+		{"ssh://git@github.com/adriancodes/gumloop.git", true},
+		{"git+ssh://git@example.com/repo.git", true},
+		{"github.com:repo.git", true},
+		{"https://github.com/adriancodes/gumloop.git", false},
+		{"file:///tmp/repo.git", false},
+		{"/tmp/repo.git", false},
+		{"./repos/a:b", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsSSHURL(tt.url))
+		})
+	}
+}
+
+func TestCheckPushPreflight(t *testing.T) {
+	loaded := func() (int, error) { return 2, nil }
+	empty := func() (int, error) { return 0, nil }
+	noAgent := func() (int, error) { return 0, errors.New("could not connect to ssh-agent") }
+
+	t.Run("ssh remote with identities loaded", func(t *testing.T) {
+		assert.NoError(t, CheckPushPreflight("git@github.com:a/b.git", loaded))
+	})
+
+	t.Run("ssh remote with no identities", func(t *testing.T) {
+		err := CheckPushPreflight("git@github.com:a/b.git", empty)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "no SSH identities loaded")
+	})
+
+	t.Run("ssh remote without agent", func(t *testing.T) {
+		err := CheckPushPreflight("ssh://git@github.com/a/b.git", noAgent)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "could not connect to ssh-agent")
+	})
+
+	t.Run("https remote is not checked", func(t *testing.T) {
+		called := false
+		check := func() (int, error) {
+			called = true
+			return 0, nil
+		}
+		assert.NoError(t, CheckPushPreflight("https://github.com/a/b.git", check))
+		assert.False(t, called, "identity check should not run for non-SSH remotes")
+	})
+}
@@ -0,0 +1,81 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const httpTimeout = 30 * time.Second
+
+// APIURL is the GitHub API base URL (overridden in tests)
+var APIURL = "https://api.github.com"
+
+// StatusError is a GitHub API response other than 200 OK
+type StatusError struct {
+	StatusCode int
+	Header     http.Header
+}
+
+func (e *StatusError) Error() string {
+	if !e.RateLimited() {
+		return fmt.Sprintf("GitHub API returned status %d", e.StatusCode)
+	}
+	msg := "GitHub API rate limit exceeded"
+	if reset, err := strconv.ParseInt(e.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		msg += fmt.Sprintf("; resets at %s", time.Unix(reset, 0).Format("15:04:05"))
+	}
+	return msg
+}
+
+// RateLimited reports whether the response was a rate limit rejection
+func (e *StatusError) RateLimited() bool {
+	if e.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return e.StatusCode == http.StatusForbidden && e.Header.Get("X-RateLimit-Remaining") == "0"
+}
+
+// HasToken reports whether GITHUB_TOKEN is set
+func HasToken() bool {
+	return os.Getenv("GITHUB_TOKEN") != ""
+}
+
+// GetJSON fetches path (e.g. "/repos/owner/repo/issues/1") from the GitHub
+// API and decodes the JSON response into v. GITHUB_TOKEN, if set, is sent
+// for private repositories and the higher rate limit. Responses other than
+// 200 OK are returned as *StatusError.
+func GetJSON(path string, v any) error {
+	client := &http.Client{Timeout: httpTimeout}
+
+	req, err := http.NewRequest("GET", strings.TrimSuffix(APIURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+
+	// Set User-Agent (GitHub API requires it)
+	req.Header.Set("User-Agent", "gumloop")
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{StatusCode: resp.StatusCode, Header: resp.Header}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid GitHub API response: %w", err)
+	}
+	return nil
+}
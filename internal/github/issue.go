@@ -0,0 +1,85 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Issue is the part of a GitHub issue used as a prompt
+type Issue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+}
+
+// IssueRef identifies an issue in a repository
+type IssueRef struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+func (r IssueRef) String() string {
+	return fmt.Sprintf("%s/%s#%d", r.Owner, r.Repo, r.Number)
+}
+
+var (
+	// issueURLPattern matches https://github.com/owner/repo/issues/N
+	issueURLPattern = regexp.MustCompile(`^https?://github\.com/([\w.-]+)/([\w.-]+)/issues/(\d+)/?$`)
+
+	// issueShorthandPattern matches owner/repo#N
+	issueShorthandPattern = regexp.MustCompile(`^([\w.-]+)/([\w.-]+)#(\d+)$`)
+)
+
+// ParseIssueRef parses an issue URL or owner/repo#N shorthand
+func ParseIssueRef(ref string) (IssueRef, error) {
+	ref = strings.TrimSpace(ref)
+	match := issueURLPattern.FindStringSubmatch(ref)
+	if match == nil {
+		match = issueShorthandPattern.FindStringSubmatch(ref)
+	}
+	if match == nil {
+		return IssueRef{}, fmt.Errorf("invalid issue '%s' (expected https://github.com/owner/repo/issues/N or owner/repo#N)", ref)
+	}
+	number, _ := strconv.Atoi(match[3])
+	return IssueRef{Owner: match[1], Repo: match[2], Number: number}, nil
+}
+
+// FetchIssue fetches an issue from the GitHub API. GITHUB_TOKEN, if set,
+// is sent for private repositories and the higher rate limit.
+func FetchIssue(ref IssueRef) (*Issue, error) {
+	var issue Issue
+	err := GetJSON(fmt.Sprintf("/repos/%s/%s/issues/%d", ref.Owner, ref.Repo, ref.Number), &issue)
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		if err != nil {
+			return nil, err
+		}
+		return &issue, nil
+	}
+	switch {
+	case statusErr.RateLimited() && !HasToken():
+		return nil, fmt.Errorf("%w (set GITHUB_TOKEN for a higher limit)", err)
+	case statusErr.StatusCode == http.StatusNotFound && !HasToken():
+		return nil, fmt.Errorf("issue %s not found (set GITHUB_TOKEN if the repository is private)", ref)
+	case statusErr.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("issue %s not found", ref)
+	case statusErr.StatusCode == http.StatusUnauthorized:
+		return nil, fmt.Errorf("GitHub rejected GITHUB_TOKEN (status 401)")
+	}
+	return nil, err
+}
+
+// Prompt formats the issue as a task prompt
+func (i *Issue) Prompt() string {
+	prompt := "# " + i.Title + "\n"
+	if body := strings.TrimSpace(i.Body); body != "" {
+		prompt += "\n" + body + "\n"
+	}
+	return prompt
+}
@@ -0,0 +1,93 @@
+package github
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockAPI points APIURL at handler for the rest of the test
+func mockAPI(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	orig := APIURL
+	APIURL = server.URL
+	t.Cleanup(func() {
+		APIURL = orig
+		server.Close()
+	})
+}
+
+func TestParseIssueRef(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    IssueRef
+		wantErr bool
+	}{
+		{"https://github.com/adriancodes/gumloop/issues/42", IssueRef{"adriancodes", "gumloop", 42}, false},
+		{"https://github.com/adriancodes/gumloop/issues/42/", IssueRef{"adriancodes", "gumloop", 42}, false},
+		{"adriancodes/gumloop#7", IssueRef{"adriancodes", "gumloop", 7}, false},
+		{"my.org/some-repo#1", IssueRef{"my.org", "some-repo", 1}, false},
+		{"https://github.com/adriancodes/gumloop/pull/42", IssueRef{}, true},
+		{"gumloop#7", IssueRef{}, true},
+		{"", IssueRef{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := ParseIssueRef(tt.ref)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFetchIssue(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "secret")
+	mockAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/adriancodes/gumloop/issues/42", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"number": 42, "title": "Add dark mode", "body": "Follow the system setting."}`))
+	})
+
+	issue, err := FetchIssue(IssueRef{"adriancodes", "gumloop", 42})
+	require.NoError(t, err)
+	assert.Equal(t, "Add dark mode", issue.Title)
+	assert.Equal(t, "# Add dark mode\n\nFollow the system setting.\n", issue.Prompt())
+}
+
+func TestFetchIssue_NotFoundWithoutToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	mockAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"))
+		http.NotFound(w, r)
+	})
+
+	_, err := FetchIssue(IssueRef{"acme", "private", 1})
+	assert.ErrorContains(t, err, "set GITHUB_TOKEN")
+}
+
+func TestFetchIssue_RateLimited(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	mockAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	_, err := FetchIssue(IssueRef{"acme", "app", 1})
+	assert.ErrorContains(t, err, "rate limit exceeded; resets at")
+	assert.ErrorContains(t, err, "GITHUB_TOKEN")
+}
+
+func TestIssuePrompt_EmptyBody(t *testing.T) {
+	issue := &Issue{Title: "Fix the build"}
+	assert.Equal(t, "# Fix the build\n", issue.Prompt())
+}
@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Level is a logging verbosity level. Messages at or below the current
+// level are written.
+type Level int
+
+const (
+	LevelError Level = iota
+	LevelWarn
+	LevelInfo
+	LevelDebug
+)
+
+var levelNames = map[Level]string{
+	LevelError: "error",
+	LevelWarn:  "warn",
+	LevelInfo:  "info",
+	LevelDebug: "debug",
+}
+
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// ParseLevel parses a level name (error, warn, info, debug).
+func ParseLevel(name string) (Level, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "warning" {
+		name = "warn"
+	}
+	for level, n := range levelNames {
+		if n == name {
+			return level, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("invalid log level '%s' (valid: error, warn, info, debug)", name)
+}
+
+var (
+	mu     sync.Mutex
+	level  = LevelInfo
+	output io.Writer // nil means os.Stderr at the time of writing
+)
+
+// SetLevel sets the most verbose level that is written.
+func SetLevel(l Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	level = l
+}
+
+// GetLevel returns the current level.
+func GetLevel() Level {
+	mu.Lock()
+	defer mu.Unlock()
+	return level
+}
+
+// Enabled reports whether messages at l are written.
+func Enabled(l Level) bool {
+	return GetLevel() >= l
+}
+
+// SetOutput redirects log output. A nil writer restores the default, which
+// is whatever os.Stderr is when a message is written.
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	output = w
+}
+
+func logf(l Level, prefix, format string, args ...interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+	if level < l {
+		return
+	}
+	w := output
+	if w == nil {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, prefix+format+"\n", args...)
+}
+
+// Errorf logs an error.
+func Errorf(format string, args ...interface{}) {
+	logf(LevelError, "❌ ", format, args...)
+}
+
+// Warnf logs a warning.
+func Warnf(format string, args ...interface{}) {
+	logf(LevelWarn, "⚠️  ", format, args...)
+}
+
+// Infof logs an informational message.
+func Infof(format string, args ...interface{}) {
+	logf(LevelInfo, "", format, args...)
+}
+
+// Debugf logs a debug message. Only shown with --log-level debug (or --debug).
+func Debugf(format string, args ...interface{}) {
+	logf(LevelDebug, "[debug] ", format, args...)
+}
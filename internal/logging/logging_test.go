@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// capture sets the level and collects output until the test ends
+func capture(t *testing.T, l Level) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	SetLevel(l)
+	t.Cleanup(func() {
+		SetOutput(nil)
+		SetLevel(LevelInfo)
+	})
+	return &buf
+}
+
+func logAll() {
+	Errorf("error %d", 1)
+	Warnf("warn %d", 2)
+	Infof("info %d", 3)
+	Debugf("debug %d", 4)
+}
+
+func TestInfoLevelExcludesDebug(t *testing.T) {
+	buf := capture(t, LevelInfo)
+	logAll()
+
+	out := buf.String()
+	for _, want := range []string{"❌ error 1", "⚠️  warn 2", "info 3"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "debug 4") {
+		t.Errorf("info level should not include debug lines:\n%s", out)
+	}
+}
+
+func TestDebugLevelIncludesDebug(t *testing.T) {
+	buf := capture(t, LevelDebug)
+	logAll()
+
+	if !strings.Contains(buf.String(), "[debug] debug 4") {
+		t.Errorf("debug level should include debug lines:\n%s", buf.String())
+	}
+}
+
+func TestErrorLevelOnlyErrors(t *testing.T) {
+	buf := capture(t, LevelError)
+	logAll()
+
+	if got := buf.String(); got != "❌ error 1\n" {
+		t.Errorf("error level output = %q, want only the error line", got)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Level
+		wantErr bool
+	}{
+		{"error", LevelError, false},
+		{"warn", LevelWarn, false},
+		{"warning", LevelWarn, false},
+		{"INFO", LevelInfo, false},
+		{"debug", LevelDebug, false},
+		{"verbose", LevelInfo, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLevel(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLevel(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseLevel(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Rotate copies the memory file at path to prevPath so the previous session
+// can be compared after the current one overwrites path. Does nothing if
+// path does not exist.
+func Rotate(path, prevPath string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read memory file: %w", err)
+	}
+
+	if err := os.WriteFile(prevPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write previous memory file: %w", err)
+	}
+	return nil
+}
+
+// FieldChange is a memory field whose value differs between two sessions.
+type FieldChange struct {
+	Field string // Display name, e.g. "Iterations"
+	Prev  string // Empty when the previous session didn't set it
+	Cur   string // Empty when the current session didn't set it
+}
+
+// Diff is the change from one session's memory to the next.
+type Diff struct {
+	Changed    []FieldChange  // Fields whose values differ, in file order
+	NewCommits []CommitRecord // Commit log entries not in the previous session
+}
+
+// Compare returns how cur differs from prev. Each session keeps its own
+// counts, so fields are compared by value rather than subtracted.
+func Compare(prev, cur *SessionMemory) Diff {
+	var d Diff
+	for _, f := range []struct {
+		name      string
+		prev, cur string
+	}{
+		{"Started", formatTime(prev.StartedAt), formatTime(cur.StartedAt)},
+		{"Branch", prev.Branch, cur.Branch},
+		{"Agent", prev.AgentName, cur.AgentName},
+		{"Model", prev.Model, cur.Model},
+		{"Iterations", strconv.Itoa(prev.Iterations), strconv.Itoa(cur.Iterations)},
+		{"Commits", strconv.Itoa(prev.Commits), strconv.Itoa(cur.Commits)},
+		{"Exit", prev.ExitReason, cur.ExitReason},
+		{"Exit code", formatCode(prev.ExitCode), formatCode(cur.ExitCode)},
+		{"Remaining", prev.Remaining, cur.Remaining},
+	} {
+		if f.prev != f.cur {
+			d.Changed = append(d.Changed, FieldChange{Field: f.name, Prev: f.prev, Cur: f.cur})
+		}
+	}
+
+	seen := make(map[string]bool, len(prev.CommitLog))
+	for _, c := range prev.CommitLog {
+		seen[c.Hash] = true
+	}
+	for _, c := range cur.CommitLog {
+		if !seen[c.Hash] {
+			d.NewCommits = append(d.NewCommits, c)
+		}
+	}
+	return d
+}
+
+// formatTime formats a session start time, or "" if unset
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05 MST")
+}
+
+// formatCode formats an exit code, or "" if none was recorded
+func formatCode(code *int) string {
+	if code == nil {
+		return ""
+	}
+	return strconv.Itoa(*code)
+}
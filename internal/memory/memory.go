@@ -13,18 +13,33 @@ const (
 	// DefaultFileName is the default memory file name
 	DefaultFileName = ".gumloop-memory.yaml"
 
+	// PrevFileName holds the previous session's memory, kept for memory
+	// diff. It lives in the git directory (see git.GitPath), out of the
+	// working tree.
+	PrevFileName = "gumloop-memory.prev.yaml"
+
 	// MaxCommitLog is the maximum number of commits to keep in memory
 	MaxCommitLog = 20
+
+	// MaxRemainingLength caps a Remaining note taken from the agent's output
+	MaxRemainingLength = 1000
+
+	// CurrentVersion is the memory file schema written by Save. Files
+	// without a version (0) predate versioning and are migrated by Load.
+	CurrentVersion = 1
 )
 
 // SessionMemory represents the persisted state between loop sessions.
 type SessionMemory struct {
+	Version    int            `yaml:"version"`
 	StartedAt  time.Time      `yaml:"started"`
 	Branch     string         `yaml:"branch"`
 	AgentName  string         `yaml:"agent"`
+	Model      string         `yaml:"model,omitempty"` // Empty for the agent's default
 	Iterations int            `yaml:"iterations"`
 	Commits    int            `yaml:"commits"`
 	ExitReason string         `yaml:"exit_reason"`
+	ExitCode   *int           `yaml:"exit_code,omitempty"` // Nil in files written before it was recorded
 	CommitLog  []CommitRecord `yaml:"commit_log"`
 	Remaining  string         `yaml:"remaining,omitempty"`
 }
@@ -33,6 +48,7 @@ type SessionMemory struct {
 type CommitRecord struct {
 	Hash    string `yaml:"hash"`
 	Message string `yaml:"message"`
+	Agent   string `yaml:"agent,omitempty"` // Set when a pipeline ran several agents
 }
 
 // Load reads the memory file from disk and parses it.
@@ -53,9 +69,28 @@ func Load(path string) (*SessionMemory, error) {
 		return &SessionMemory{}, nil
 	}
 
+	mem.migrate()
 	return &mem, nil
 }
 
+// migrate brings a memory loaded from an older file up to CurrentVersion.
+// Newer versions are left alone, so an older gumloop doesn't guess at
+// fields it doesn't know.
+func (m *SessionMemory) migrate() {
+	if m.Version >= CurrentVersion {
+		return
+	}
+
+	// v0 -> v1: every v1 field either exists in v0 files or means "unknown"
+	// when empty (model, exit_code, a commit's agent). A missing commit log
+	// loads as an empty one.
+	if m.CommitLog == nil {
+		m.CommitLog = []CommitRecord{}
+	}
+
+	m.Version = CurrentVersion
+}
+
 // Save writes the memory to disk as YAML with a header comment.
 func (m *SessionMemory) Save(path string) error {
 	f, err := os.Create(path)
@@ -65,7 +100,8 @@ func (m *SessionMemory) Save(path string) error {
 	defer f.Close()
 
 	// Write header comment
-	if _, err := f.WriteString("# gumloop session memory (auto-generated, safe to edit \"remaining\" field)\n\n"); err != nil {
+	m.Version = CurrentVersion
+	if _, err := fmt.Fprintf(f, "# gumloop session memory, format v%d (auto-generated, safe to edit \"remaining\" field)\n\n", CurrentVersion); err != nil {
 		return err
 	}
 
@@ -79,23 +115,32 @@ func (m *SessionMemory) Save(path string) error {
 }
 
 // ToPromptContext renders the memory as compact plain text for prompt injection.
-// Returns empty string if there's nothing useful to inject.
+// Returns empty string if there's nothing useful to inject: no iterations,
+// commits or note. Counters zeroed by ResetCounters leave the rest in use.
 func (m *SessionMemory) ToPromptContext() string {
-	if m.Iterations == 0 {
+	if m.Iterations == 0 && len(m.CommitLog) == 0 && m.Remaining == "" {
 		return ""
 	}
 
 	var b strings.Builder
 
 	b.WriteString("--- PREVIOUS SESSION ---\n")
-	b.WriteString(fmt.Sprintf("Last session: %d iterations, %d commits on branch %s\n",
-		m.Iterations, m.Commits, m.Branch))
+	if m.Iterations > 0 {
+		b.WriteString(fmt.Sprintf("Last session: %d iterations, %d commits on branch %s\n",
+			m.Iterations, m.Commits, m.Branch))
+	} else {
+		b.WriteString(fmt.Sprintf("Last session: on branch %s\n", m.Branch))
+	}
 	b.WriteString(fmt.Sprintf("Agent: %s | Exited: %s\n", m.AgentName, m.ExitReason))
 
 	if len(m.CommitLog) > 0 {
 		b.WriteString("\nCommits made:\n")
 		for _, c := range m.CommitLog {
-			b.WriteString(fmt.Sprintf("- %s %s\n", c.Hash, c.Message))
+			if c.Agent != "" {
+				b.WriteString(fmt.Sprintf("- %s %s (%s)\n", c.Hash, c.Message, c.Agent))
+			} else {
+				b.WriteString(fmt.Sprintf("- %s %s\n", c.Hash, c.Message))
+			}
 		}
 	}
 
@@ -122,7 +167,36 @@ func (m *SessionMemory) RecordIteration(commitsMade int, newCommits []CommitReco
 	}
 }
 
-// SetExit records why the loop stopped.
-func (m *SessionMemory) SetExit(reason string) {
+// Prune keeps only the keep most recent entries of the commit log and
+// returns how many were removed. The iteration and commit counts are left
+// as they are.
+func (m *SessionMemory) Prune(keep int) int {
+	if keep < 0 || len(m.CommitLog) <= keep {
+		return 0
+	}
+	removed := len(m.CommitLog) - keep
+	m.CommitLog = m.CommitLog[:keep]
+	return removed
+}
+
+// ResetCounters zeroes the iteration and commit counts.
+func (m *SessionMemory) ResetCounters() {
+	m.Iterations = 0
+	m.Commits = 0
+}
+
+// SetRemaining sets the note for the next session from the agent's last
+// message, cut to MaxRemainingLength characters.
+func (m *SessionMemory) SetRemaining(text string) {
+	text = strings.TrimSpace(text)
+	if runes := []rune(text); len(runes) > MaxRemainingLength {
+		text = strings.TrimSpace(string(runes[:MaxRemainingLength])) + "…"
+	}
+	m.Remaining = text
+}
+
+// SetExit records why the loop stopped and the process exit code.
+func (m *SessionMemory) SetExit(reason string, code int) {
 	m.ExitReason = reason
+	m.ExitCode = &code
 }
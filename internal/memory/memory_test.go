@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -19,6 +20,7 @@ func TestSaveAndLoad_RoundTrip(t *testing.T) {
 		StartedAt:  time.Date(2026, 2, 4, 14, 30, 5, 0, time.UTC),
 		Branch:     "feat/add-auth",
 		AgentName:  "Claude Code",
+		Model:      "claude-sonnet-4-5",
 		Iterations: 7,
 		Commits:    5,
 		ExitReason: "Max iterations reached",
@@ -41,6 +43,7 @@ func TestSaveAndLoad_RoundTrip(t *testing.T) {
 	// Verify fields
 	assert.Equal(t, original.Branch, loaded.Branch)
 	assert.Equal(t, original.AgentName, loaded.AgentName)
+	assert.Equal(t, original.Model, loaded.Model)
 	assert.Equal(t, original.Iterations, loaded.Iterations)
 	assert.Equal(t, original.Commits, loaded.Commits)
 	assert.Equal(t, original.ExitReason, loaded.ExitReason)
@@ -50,6 +53,20 @@ func TestSaveAndLoad_RoundTrip(t *testing.T) {
 	assert.Equal(t, original.CommitLog[0].Message, loaded.CommitLog[0].Message)
 }
 
+func TestSave_NoModel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.yaml")
+
+	// The agent's default model leaves the key out of the file
+	require.NoError(t, (&SessionMemory{AgentName: "Claude Code"}).Save(path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "model:")
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, loaded.Model)
+}
+
 func TestLoad_NoFile(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "nonexistent.yaml")
@@ -87,7 +104,7 @@ func TestToPromptContext_WithHistory(t *testing.T) {
 		ExitReason: "Complete (no changes)",
 		CommitLog: []CommitRecord{
 			{Hash: "abc1234", Message: "Fix login bug"},
-			{Hash: "def5678", Message: "Add tests"},
+			{Hash: "def5678", Message: "Add tests", Agent: "OpenAI Codex"},
 		},
 		Remaining: "All tests passing.",
 	}
@@ -100,7 +117,7 @@ func TestToPromptContext_WithHistory(t *testing.T) {
 	assert.Contains(t, ctx, "main")
 	assert.Contains(t, ctx, "Claude Code")
 	assert.Contains(t, ctx, "abc1234 Fix login bug")
-	assert.Contains(t, ctx, "def5678 Add tests")
+	assert.Contains(t, ctx, "def5678 Add tests (OpenAI Codex)")
 	assert.Contains(t, ctx, "All tests passing")
 	assert.Contains(t, ctx, "END PREVIOUS SESSION")
 }
@@ -145,6 +162,40 @@ func TestRecordIteration(t *testing.T) {
 	assert.Equal(t, "old1", mem.CommitLog[2].Hash)
 }
 
+func TestPrune(t *testing.T) {
+	mem := &SessionMemory{
+		Iterations: 7,
+		Commits:    3,
+		CommitLog: []CommitRecord{
+			{Hash: "new", Message: "Newest"},
+			{Hash: "mid", Message: "Middle"},
+			{Hash: "old", Message: "Oldest"},
+		},
+	}
+
+	assert.Equal(t, 2, mem.Prune(1))
+	require.Len(t, mem.CommitLog, 1)
+	assert.Equal(t, "new", mem.CommitLog[0].Hash)
+	assert.Equal(t, 7, mem.Iterations, "Prune leaves the counters alone")
+	assert.Equal(t, 3, mem.Commits)
+
+	assert.Equal(t, 0, mem.Prune(5), "nothing to remove")
+	assert.Len(t, mem.CommitLog, 1)
+
+	mem.ResetCounters()
+	assert.Equal(t, 0, mem.Iterations)
+	assert.Equal(t, 0, mem.Commits)
+}
+
+func TestSetRemaining(t *testing.T) {
+	mem := &SessionMemory{}
+	mem.SetRemaining("  Auth is done; the OAuth callback still needs tests.\n")
+	assert.Equal(t, "Auth is done; the OAuth callback still needs tests.", mem.Remaining)
+
+	mem.SetRemaining(strings.Repeat("é", MaxRemainingLength+50))
+	assert.Equal(t, strings.Repeat("é", MaxRemainingLength)+"…", mem.Remaining)
+}
+
 func TestToPromptContext_NoCommits(t *testing.T) {
 	// Iterations happened but no commits were made (e.g., agent explored but didn't commit)
 	mem := &SessionMemory{
@@ -184,6 +235,25 @@ func TestToPromptContext_NoRemaining(t *testing.T) {
 	assert.NotContains(t, ctx, "Note:") // No remaining note when field is empty
 }
 
+func TestToPromptContext_ResetCounters(t *testing.T) {
+	mem := &SessionMemory{
+		Branch:     "main",
+		AgentName:  "Claude Code",
+		Iterations: 4,
+		Commits:    1,
+		CommitLog:  []CommitRecord{{Hash: "abc1234", Message: "Add unit tests"}},
+		Remaining:  "Docs still to do.",
+	}
+	mem.ResetCounters()
+
+	// The commit log and note are still worth passing on
+	ctx := mem.ToPromptContext()
+	assert.Contains(t, ctx, "Last session: on branch main")
+	assert.NotContains(t, ctx, "0 iterations")
+	assert.Contains(t, ctx, "abc1234 Add unit tests")
+	assert.Contains(t, ctx, "Docs still to do.")
+}
+
 func TestRecordIteration_ZeroCommits(t *testing.T) {
 	// Iteration where agent made changes but didn't commit
 	mem := &SessionMemory{
@@ -260,15 +330,110 @@ func TestCommitLogCapping_AcrossIterations(t *testing.T) {
 
 func TestSetExit(t *testing.T) {
 	mem := &SessionMemory{}
-	mem.SetExit("Stuck (no commits)")
+	mem.SetExit("Stuck (no commits)", 4)
 	assert.Equal(t, "Stuck (no commits)", mem.ExitReason)
+	require.NotNil(t, mem.ExitCode)
+	assert.Equal(t, 4, *mem.ExitCode)
 }
 
 func TestSetExit_Overwrite(t *testing.T) {
 	// Exit reason can be overwritten (e.g., Ctrl+C replaces previous reason)
 	mem := &SessionMemory{ExitReason: "Max iterations reached"}
-	mem.SetExit("Interrupted")
+	mem.SetExit("Interrupted", 130)
 	assert.Equal(t, "Interrupted", mem.ExitReason)
+	require.NotNil(t, mem.ExitCode)
+	assert.Equal(t, 130, *mem.ExitCode)
+}
+
+func TestSaveAndLoad_ExitCode(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "memory.yaml")
+
+	original := &SessionMemory{Branch: "main", Iterations: 3}
+	original.SetExit("Max iterations reached", 3)
+	require.NoError(t, original.Save(path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "exit_code: 3")
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+	assert.Equal(t, "Max iterations reached", loaded.ExitReason)
+	require.NotNil(t, loaded.ExitCode)
+	assert.Equal(t, 3, *loaded.ExitCode)
+}
+
+func TestLoad_WithoutExitCode(t *testing.T) {
+	// Files written before exit_code existed still load
+	dir := t.TempDir()
+	path := filepath.Join(dir, "old.yaml")
+
+	content := `branch: main
+iterations: 2
+exit_reason: "Stuck (no commits)"
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	mem, err := Load(path)
+	require.NoError(t, err)
+	require.NotNil(t, mem)
+	assert.Equal(t, "Stuck (no commits)", mem.ExitReason)
+	assert.Nil(t, mem.ExitCode, "a missing exit code isn't code 0")
+}
+
+func TestLoad_MigratesUnversionedFile(t *testing.T) {
+	// Files written before versioning have no version key
+	dir := t.TempDir()
+	path := filepath.Join(dir, "v0.yaml")
+
+	content := `# gumloop session memory (auto-generated, safe to edit "remaining" field)
+
+started: "2026-02-04T14:30:05Z"
+branch: feat/add-auth
+agent: Claude Code
+iterations: 3
+commits: 1
+exit_reason: Max iterations reached
+remaining: Wire up the login page.
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	mem, err := Load(path)
+	require.NoError(t, err)
+	require.NotNil(t, mem)
+	assert.Equal(t, CurrentVersion, mem.Version)
+	assert.Equal(t, "feat/add-auth", mem.Branch)
+	assert.Equal(t, 3, mem.Iterations)
+	assert.Equal(t, "Wire up the login page.", mem.Remaining)
+	assert.NotNil(t, mem.CommitLog)
+	assert.Empty(t, mem.CommitLog)
+
+	// Saving writes the current version back out
+	require.NoError(t, mem.Save(path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), fmt.Sprintf("format v%d", CurrentVersion))
+	assert.Contains(t, string(data), fmt.Sprintf("version: %d\n", CurrentVersion))
+
+	reloaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, CurrentVersion, reloaded.Version)
+	assert.Equal(t, "Wire up the login page.", reloaded.Remaining)
+}
+
+func TestLoad_NewerVersionUntouched(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "future.yaml")
+
+	content := "version: 99\nbranch: main\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	mem, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 99, mem.Version)
+	assert.Nil(t, mem.CommitLog)
 }
 
 func TestSaveAndLoad_EmptyCommitLog(t *testing.T) {
@@ -350,3 +515,74 @@ func TestSave_CreatesFile(t *testing.T) {
 	assert.Contains(t, string(data), "# gumloop session memory")
 	assert.Contains(t, string(data), "branch: main")
 }
+
+func TestRotate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "memory.yaml")
+	prevPath := filepath.Join(dir, "memory.prev.yaml")
+
+	// Nothing to rotate yet
+	require.NoError(t, Rotate(path, prevPath))
+	_, err := os.Stat(prevPath)
+	assert.True(t, os.IsNotExist(err))
+
+	mem := &SessionMemory{Branch: "main", Iterations: 3}
+	require.NoError(t, mem.Save(path))
+	require.NoError(t, Rotate(path, prevPath))
+
+	prev, err := Load(prevPath)
+	require.NoError(t, err)
+	require.NotNil(t, prev)
+	assert.Equal(t, 3, prev.Iterations)
+
+	// The current file is left in place until the new session saves over it
+	assert.FileExists(t, path)
+}
+
+func TestCompare(t *testing.T) {
+	prev := &SessionMemory{
+		Iterations: 3,
+		Commits:    2,
+		CommitLog: []CommitRecord{
+			{Hash: "bbb2222", Message: "Add login handler"},
+			{Hash: "aaa1111", Message: "Add JWT middleware"},
+		},
+	}
+	cur := &SessionMemory{
+		Iterations: 5,
+		Commits:    4,
+		CommitLog: []CommitRecord{
+			{Hash: "ddd4444", Message: "Add refresh tokens"},
+			{Hash: "ccc3333", Message: "Add logout"},
+			{Hash: "bbb2222", Message: "Add login handler"},
+		},
+	}
+
+	d := Compare(prev, cur)
+	assert.Equal(t, []FieldChange{
+		{Field: "Iterations", Prev: "3", Cur: "5"},
+		{Field: "Commits", Prev: "2", Cur: "4"},
+	}, d.Changed)
+	assert.Equal(t, []CommitRecord{
+		{Hash: "ddd4444", Message: "Add refresh tokens"},
+		{Hash: "ccc3333", Message: "Add logout"},
+	}, d.NewCommits)
+}
+
+func TestCompare_FieldValues(t *testing.T) {
+	// Sessions keep their own counts, so a shorter session isn't a negative change
+	prev := &SessionMemory{Branch: "main", AgentName: "Claude Code", Iterations: 9, Commits: 4}
+	prev.SetExit("Max iterations reached", 3)
+	cur := &SessionMemory{Branch: "main", AgentName: "OpenAI Codex", Model: "gpt-5", Iterations: 2, Commits: 4}
+
+	d := Compare(prev, cur)
+	assert.Equal(t, []FieldChange{
+		{Field: "Agent", Prev: "Claude Code", Cur: "OpenAI Codex"},
+		{Field: "Model", Prev: "", Cur: "gpt-5"},
+		{Field: "Iterations", Prev: "9", Cur: "2"},
+		{Field: "Exit", Prev: "Max iterations reached", Cur: ""},
+		{Field: "Exit code", Prev: "3", Cur: ""},
+	}, d.Changed)
+
+	assert.Empty(t, Compare(cur, cur).Changed)
+}
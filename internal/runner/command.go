@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"time"
+
+	"github.com/adriancodes/gumloop/internal/git"
+)
+
+// Command is a process for a CommandRunner to run
+type Command struct {
+	Args   []string
+	Env    []string // nil inherits gumloop's environment
+	Dir    string
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// CommandRunner runs the agent and verify commands. Output is written to
+// the command's writers as it arrives and Run returns once the process has
+// exited. A non-zero exit is reported through exitCode; err is for failing
+// to run the command: a *StartError if it never started, otherwise an error
+// waiting for it (e.g. copying its output).
+type CommandRunner interface {
+	Run(ctx context.Context, cmd Command) (exitCode int, err error)
+}
+
+// StartError is a command that couldn't be started, e.g. because the
+// executable wasn't found
+type StartError struct {
+	Err error
+}
+
+func (e *StartError) Error() string { return e.Err.Error() }
+func (e *StartError) Unwrap() error { return e.Err }
+
+// execRunner runs commands as processes in their own process group, so
+// cancelling ctx stops them and everything they started
+type execRunner struct{}
+
+func (execRunner) Run(ctx context.Context, c Command) (int, error) {
+	cmd := exec.Command(c.Args[0], c.Args[1:]...)
+	cmd.Env = c.Env
+	cmd.Dir = c.Dir
+	cmd.Stdin = c.Stdin
+	cmd.Stdout = c.Stdout
+	cmd.Stderr = c.Stderr
+
+	exited, err := startInProcessGroup(ctx, cmd)
+	if err != nil {
+		return -1, &StartError{Err: err}
+	}
+	err = cmd.Wait()
+	close(exited)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	return 0, nil
+}
+
+// Repo is the git access a run needs: for iterations, for counting and
+// checking their commits, and for pushing
+type Repo interface {
+	CountCommits() (int, error)
+	CountCommitsAfter(ref string, since time.Time) (int, error)
+	GetChangedFiles() (modified, staged, untracked int, err error)
+	CountChangedFilesSince(ref string) (int, error)
+	HasChanges() (bool, error)
+	ResolveRef(ref string) (string, error)
+	GetRecentCommits(n int) ([]git.CommitInfo, error)
+	CommitAll(message string) error
+	ResetSoft(ref string) error
+	AddTrailers(n int, trailers []string) error
+	HasRemote() (bool, error)
+	GetBranch() (string, error)
+	Push(branch string) error
+	WorkTreeHash() (string, error)
+}
+
+// gitRepo is the Repo for the repository gumloop runs in
+type gitRepo struct {
+	pushArgs []string // Extra git push options (push_args)
+}
+
+func (gitRepo) CountCommits() (int, error) { return git.CountCommits() }
+func (gitRepo) CountCommitsAfter(ref string, since time.Time) (int, error) {
+	return git.CountCommitsAfter(ref, since)
+}
+func (gitRepo) GetChangedFiles() (int, int, int, error) { return git.GetChangedFiles() }
+func (gitRepo) CountChangedFilesSince(ref string) (int, error) {
+	return git.CountChangedFilesSince(ref)
+}
+func (gitRepo) HasChanges() (bool, error)                        { return git.HasChanges() }
+func (gitRepo) ResolveRef(ref string) (string, error)            { return git.ResolveRef(ref) }
+func (gitRepo) GetRecentCommits(n int) ([]git.CommitInfo, error) { return git.GetRecentCommits(n) }
+func (gitRepo) CommitAll(message string) error                   { return git.CommitAll(message) }
+func (gitRepo) ResetSoft(ref string) error                       { return git.ResetSoft(ref) }
+func (gitRepo) AddTrailers(n int, trailers []string) error       { return git.AddTrailers(n, trailers) }
+func (gitRepo) HasRemote() (bool, error)                         { return git.HasRemote() }
+func (gitRepo) GetBranch() (string, error)                       { return git.GetBranch() }
+func (g gitRepo) Push(branch string) error                       { return git.Push(branch, g.pushArgs...) }
+func (gitRepo) WorkTreeHash() (string, error)                    { return git.WorkTreeHash() }
@@ -0,0 +1,309 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/adriancodes/gumloop/internal/agent"
+	"github.com/adriancodes/gumloop/internal/git"
+	"github.com/adriancodes/gumloop/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCommands answers agent and verify commands without starting processes.
+// Commands run by sh -c are looked up by their script in verify, anything
+// else is the agent.
+type fakeCommands struct {
+	agentOutput string
+	agentStderr string
+	agentExit   int
+	agentErr    error
+	verify      map[string]int // Verify script -> exit code
+
+	// Called after the agent "runs", e.g. to make commits in a fakeRepo
+	afterAgent func()
+
+	ran [][]string
+}
+
+func (f *fakeCommands) Run(ctx context.Context, cmd Command) (int, error) {
+	f.ran = append(f.ran, cmd.Args)
+	if len(cmd.Args) == 3 && cmd.Args[0] == "sh" && cmd.Args[1] == "-c" {
+		if code, ok := f.verify[cmd.Args[2]]; ok {
+			fmt.Fprintf(cmd.Stdout, "ran %s\n", cmd.Args[2])
+			return code, nil
+		}
+	}
+
+	if f.agentErr != nil {
+		return -1, f.agentErr
+	}
+	cmd.Stdout.Write([]byte(f.agentOutput))
+	cmd.Stderr.Write([]byte(f.agentStderr))
+	if f.afterAgent != nil {
+		f.afterAgent()
+	}
+	return f.agentExit, nil
+}
+
+// fakeRepo is an in-memory Repo
+type fakeRepo struct {
+	commits                     int
+	modified, staged, untracked int
+	countErr                    error
+
+	commitsAfter int              // What CountCommitsAfter returns
+	dirty        bool             // What HasChanges returns; CommitAll clears it
+	recent       []git.CommitInfo // What GetRecentCommits returns, newest first
+	committed    []string         // Messages passed to CommitAll
+	resets       []string         // Refs passed to ResetSoft
+	trailers     []string         // Trailers passed to AddTrailers
+
+	noRemote bool
+	branch   string
+	pushErr  error
+	pushed   []string
+
+	tree string // What WorkTreeHash returns
+}
+
+func (f *fakeRepo) CountCommits() (int, error) { return f.commits, f.countErr }
+
+func (f *fakeRepo) CountCommitsAfter(string, time.Time) (int, error) { return f.commitsAfter, nil }
+func (f *fakeRepo) CountChangedFilesSince(string) (int, error)       { return f.modified, nil }
+func (f *fakeRepo) HasChanges() (bool, error)                        { return f.dirty, nil }
+func (f *fakeRepo) ResolveRef(string) (string, error)                { return "abc1234def", nil }
+
+func (f *fakeRepo) GetRecentCommits(n int) ([]git.CommitInfo, error) {
+	return f.recent[:min(n, len(f.recent))], nil
+}
+
+func (f *fakeRepo) CommitAll(message string) error {
+	f.committed = append(f.committed, message)
+	f.commits++
+	f.dirty = false
+	return nil
+}
+
+func (f *fakeRepo) ResetSoft(ref string) error {
+	f.resets = append(f.resets, ref)
+	return nil
+}
+
+func (f *fakeRepo) AddTrailers(n int, trailers []string) error {
+	f.trailers = append(f.trailers, trailers...)
+	return nil
+}
+
+func (f *fakeRepo) GetChangedFiles() (int, int, int, error) {
+	return f.modified, f.staged, f.untracked, nil
+}
+
+func (f *fakeRepo) HasRemote() (bool, error)      { return !f.noRemote, nil }
+func (f *fakeRepo) GetBranch() (string, error)    { return f.branch, nil }
+func (f *fakeRepo) WorkTreeHash() (string, error) { return f.tree, nil }
+
+func (f *fakeRepo) Push(branch string) error {
+	if f.pushErr != nil {
+		return f.pushErr
+	}
+	f.pushed = append(f.pushed, branch)
+	return nil
+}
+
+// fakeAgent is an agent whose command is never actually run
+func fakeAgent() *agent.Agent {
+	return &agent.Agent{ID: "fake", Name: "Fake", Command: "fake-agent", PromptStyle: agent.PromptStyleArg}
+}
+
+// runFakeIteration runs an iteration against fakes, collecting its output
+func runFakeIteration(t *testing.T, iter *Iteration) (IterationResult, error, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	iter.Agent = fakeAgent()
+	iter.Prompt = "do the thing"
+	iter.WorkDir = t.TempDir()
+	iter.Output = &humanOutput{w: &buf}
+	result, err := iter.Run(context.Background())
+	return result, err, buf.String()
+}
+
+func TestIteration_CountsCommits(t *testing.T) {
+	repo := &fakeRepo{commits: 5}
+	commands := &fakeCommands{
+		agentOutput: "Editing main.go\n",
+		afterAgent: func() {
+			repo.commits += 2
+			repo.modified, repo.untracked = 1, 3
+		},
+	}
+
+	result, err, output := runFakeIteration(t, &Iteration{Commands: commands, Repo: repo})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Commits)
+	assert.Equal(t, 1, result.Modified)
+	assert.Equal(t, 3, result.Untracked)
+	assert.Contains(t, output, "Editing main.go")
+
+	require.Len(t, commands.ran, 1)
+	assert.Equal(t, "fake-agent", commands.ran[0][0])
+	assert.Contains(t, commands.ran[0], "do the thing")
+}
+
+func TestIteration_CountCommitsError(t *testing.T) {
+	repo := &fakeRepo{countErr: errors.New("not a git repository")}
+	commands := &fakeCommands{}
+
+	_, err, _ := runFakeIteration(t, &Iteration{Commands: commands, Repo: repo})
+	assert.ErrorContains(t, err, "failed to count commits before iteration")
+	assert.Empty(t, commands.ran, "the agent shouldn't run without a commit count")
+}
+
+func TestIteration_Verify(t *testing.T) {
+	t.Run("passes", func(t *testing.T) {
+		commands := &fakeCommands{agentOutput: "done\n", verify: map[string]int{"make test": 0}}
+		result, err, output := runFakeIteration(t, &Iteration{Commands: commands, Repo: &fakeRepo{}, Verify: "make test"})
+
+		require.NoError(t, err)
+		assert.True(t, result.Verified)
+		assert.False(t, result.VerifyFailed)
+		assert.Contains(t, output, "🧪 Running verification: make test")
+		assert.Contains(t, output, "ran make test")
+	})
+
+	t.Run("fails", func(t *testing.T) {
+		commands := &fakeCommands{agentOutput: "done\n", verify: map[string]int{"make test": 2}}
+		result, err, _ := runFakeIteration(t, &Iteration{Commands: commands, Repo: &fakeRepo{}, Verify: "make test"})
+
+		assert.ErrorContains(t, err, "verification failed: exit status 2")
+		assert.True(t, result.VerifyFailed)
+		assert.False(t, result.Verified)
+	})
+
+	t.Run("parallel", func(t *testing.T) {
+		commands := &fakeCommands{agentOutput: "done\n", verify: map[string]int{"lint": 0, "test": 1}}
+		result, err, output := runFakeIteration(t, &Iteration{Commands: commands, Repo: &fakeRepo{}, Verify: "lint\ntest", VerifyParallel: true})
+
+		assert.ErrorContains(t, err, "1 of 2 commands failed: test")
+		assert.True(t, result.VerifyFailed)
+		assert.Contains(t, output, "✅ lint\nran lint\n❌ test\nran test\n")
+	})
+
+	t.Run("cached while the tree is unchanged", func(t *testing.T) {
+		commands := &fakeCommands{agentOutput: "done\n", verify: map[string]int{"make test": 1}}
+		result, err, output := runFakeIteration(t, &Iteration{Commands: commands, Repo: &fakeRepo{tree: "abc123"}, Verify: "make test", VerifiedTree: "abc123"})
+
+		require.NoError(t, err)
+		assert.True(t, result.Verified)
+		assert.True(t, result.VerifyCached)
+		assert.Len(t, commands.ran, 1, "verify shouldn't run")
+		assert.Contains(t, output, "Skipping verification: nothing changed since it last passed")
+	})
+
+	t.Run("runs again once the tree changes", func(t *testing.T) {
+		commands := &fakeCommands{agentOutput: "done\n", verify: map[string]int{"make test": 0}}
+		result, err, output := runFakeIteration(t, &Iteration{Commands: commands, Repo: &fakeRepo{tree: "def456"}, Verify: "make test", VerifiedTree: "abc123"})
+
+		require.NoError(t, err)
+		assert.False(t, result.VerifyCached)
+		assert.Equal(t, "def456", result.VerifiedTree)
+		assert.Contains(t, output, "ran make test")
+	})
+
+	t.Run("skipped when the agent can't start", func(t *testing.T) {
+		commands := &fakeCommands{agentExit: 1, verify: map[string]int{"make test": 0}}
+		_, err, _ := runFakeIteration(t, &Iteration{Commands: commands, Repo: &fakeRepo{}, Verify: "make test"})
+
+		var startupErr *AgentStartupError
+		assert.ErrorAs(t, err, &startupErr)
+		assert.Len(t, commands.ran, 1, "verify shouldn't run")
+	})
+}
+
+func TestIteration_StartupFailure(t *testing.T) {
+	t.Run("only stderr", func(t *testing.T) {
+		commands := &fakeCommands{agentStderr: "not authenticated\n", agentExit: 1}
+		_, err, _ := runFakeIteration(t, &Iteration{Commands: commands, Repo: &fakeRepo{}})
+
+		var startupErr *AgentStartupError
+		require.ErrorAs(t, err, &startupErr)
+		assert.Equal(t, "not authenticated", startupErr.Stderr)
+	})
+
+	t.Run("message with stderr", func(t *testing.T) {
+		commands := &fakeCommands{agentOutput: "Looking at the failing test\n", agentStderr: "warning: slow\n", agentExit: 1}
+		_, err, output := runFakeIteration(t, &Iteration{Commands: commands, Repo: &fakeRepo{}})
+
+		assert.NoError(t, err, "an agent that emitted messages isn't a startup failure")
+		assert.Contains(t, output, "Looking at the failing test")
+	})
+
+	t.Run("stderr after tool calls", func(t *testing.T) {
+		commands := &fakeCommands{agentOutput: "Reading main.go\n", agentStderr: "warning: slow\n", agentExit: 1}
+		_, err, _ := runFakeIteration(t, &Iteration{Commands: commands, Repo: &fakeRepo{}})
+
+		assert.NoError(t, err, "an agent that did work isn't a startup failure")
+	})
+}
+
+func TestIteration_NonZeroExitWithOutput(t *testing.T) {
+	repo := &fakeRepo{}
+	commands := &fakeCommands{agentOutput: "partial work\n", agentExit: 1, afterAgent: func() { repo.commits++ }}
+
+	result, err, _ := runFakeIteration(t, &Iteration{Commands: commands, Repo: repo})
+	assert.NoError(t, err, "a non-zero exit after output is only a warning")
+	assert.Equal(t, 1, result.Commits)
+}
+
+func TestIteration_NoOutput(t *testing.T) {
+	var stderr bytes.Buffer
+	logging.SetOutput(&stderr)
+	defer logging.SetOutput(os.Stderr)
+
+	result, err, _ := runFakeIteration(t, &Iteration{Commands: &fakeCommands{}, Repo: &fakeRepo{}})
+	require.NoError(t, err)
+	assert.True(t, result.NoOutput)
+	assert.Contains(t, stderr.String(), "Fake produced no output")
+
+	stderr.Reset()
+	result, err, _ = runFakeIteration(t, &Iteration{Commands: &fakeCommands{agentOutput: "Looking around\n"}, Repo: &fakeRepo{}})
+	require.NoError(t, err)
+	assert.False(t, result.NoOutput)
+	assert.Empty(t, stderr.String())
+}
+
+func TestIteration_RunError(t *testing.T) {
+	commands := &fakeCommands{agentErr: &StartError{Err: errors.New("executable file not found")}}
+
+	_, err, _ := runFakeIteration(t, &Iteration{Commands: commands, Repo: &fakeRepo{}})
+	assert.ErrorContains(t, err, "failed to start agent: executable file not found")
+
+	// Failing after the agent started isn't a start failure
+	commands = &fakeCommands{agentErr: errors.New("read |0: file already closed")}
+	_, err, _ = runFakeIteration(t, &Iteration{Commands: commands, Repo: &fakeRepo{}})
+	assert.EqualError(t, err, "agent failed: read |0: file already closed")
+}
+
+func TestExecRunner(t *testing.T) {
+	var out bytes.Buffer
+	code, err := execRunner{}.Run(context.Background(), Command{
+		Args:   []string{"sh", "-c", "pwd; echo oops >&2; exit 3"},
+		Dir:    "/",
+		Stdout: &out,
+		Stderr: &out,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, code)
+	assert.Equal(t, "/\noops\n", out.String())
+
+	_, err = execRunner{}.Run(context.Background(), Command{Args: []string{"gumloop-no-such-command"}})
+	assert.ErrorContains(t, err, "not found")
+	var startErr *StartError
+	assert.ErrorAs(t, err, &startErr)
+}
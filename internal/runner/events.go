@@ -0,0 +1,204 @@
+package runner
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adriancodes/gumloop/internal/adapter"
+	"github.com/adriancodes/gumloop/internal/logging"
+	"github.com/adriancodes/gumloop/internal/ui"
+)
+
+// Timeouts for the --json-events-to socket. Monitoring is best-effort, so a
+// slow or missing listener must never hold up the loop for long.
+const (
+	eventDialTimeout  = time.Second
+	eventWriteTimeout = 2 * time.Second
+	eventRedialDelay  = time.Second
+	eventFlushTimeout = 2 * time.Second
+)
+
+// eventQueueSize is how many records can wait for the socket before new ones
+// are dropped
+const eventQueueSize = 1024
+
+// EventSocket is a best-effort writer to a TCP or Unix socket. Writes are
+// queued and sent in the background, which connects on the first record,
+// reconnects after a failure, and drops what it can't send. Writes never
+// block on the network and never return an error; when the queue is full,
+// they drop the record.
+type EventSocket struct {
+	network string
+	addr    string
+
+	mu      sync.Mutex // Guards sending on queue against Close
+	queue   chan []byte
+	closed  bool
+	dropped bool
+	done    chan struct{}
+
+	// Owned by the sending goroutine
+	conn       net.Conn
+	nextDialAt time.Time // Don't redial before this, so a dead listener costs little
+	warned     bool
+}
+
+// NewEventSocket returns an EventSocket for addr: "unix:/path", a path
+// containing a slash for a Unix socket, or host:port for TCP
+func NewEventSocket(addr string) *EventSocket {
+	network := "tcp"
+	switch {
+	case strings.HasPrefix(addr, "unix:"):
+		network, addr = "unix", strings.TrimPrefix(addr, "unix:")
+	case strings.HasPrefix(addr, "tcp:"):
+		addr = strings.TrimPrefix(addr, "tcp:")
+	case strings.Contains(addr, "/"):
+		network = "unix"
+	}
+	s := &EventSocket{
+		network: network,
+		addr:    addr,
+		queue:   make(chan []byte, eventQueueSize),
+		done:    make(chan struct{}),
+	}
+	go s.drain()
+	return s
+}
+
+// Write queues a copy of p, or drops it if the queue is full or the socket
+// is closed
+func (s *EventSocket) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return len(p), nil
+	}
+	select {
+	case s.queue <- append([]byte(nil), p...):
+	default:
+		if !s.dropped {
+			logging.Debugf("Event socket %s: queue full, dropping events", s.addr)
+			s.dropped = true
+		}
+	}
+	return len(p), nil
+}
+
+// drain sends queued records until the queue is closed
+func (s *EventSocket) drain() {
+	defer close(s.done)
+	for p := range s.queue {
+		s.send(p)
+	}
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// send writes p, connecting first if needed. A failed write closes the
+// connection so the next one reconnects.
+func (s *EventSocket) send(p []byte) {
+	if s.conn == nil && !s.dial() {
+		return
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+	if _, err := s.conn.Write(p); err != nil {
+		logging.Debugf("Event socket %s: %v (will reconnect)", s.addr, err)
+		s.conn.Close()
+		s.conn = nil
+		// Retry once on a fresh connection: the listener may have restarted
+		if s.dial() {
+			s.conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+			if _, err := s.conn.Write(p); err != nil {
+				s.conn.Close()
+				s.conn = nil
+			}
+		}
+	}
+}
+
+// dial connects unless a recent attempt failed
+func (s *EventSocket) dial() bool {
+	if time.Now().Before(s.nextDialAt) {
+		return false
+	}
+	conn, err := net.DialTimeout(s.network, s.addr, eventDialTimeout)
+	if err != nil {
+		s.nextDialAt = time.Now().Add(eventRedialDelay)
+		if !s.warned {
+			logging.Warnf("Can't connect to event socket %s: %v (events are dropped until it's reachable)", s.addr, err)
+			s.warned = true
+		}
+		return false
+	}
+	s.conn = conn
+	return true
+}
+
+// Close stops accepting records and waits briefly for the queued ones to be
+// sent, then gives up on them
+func (s *EventSocket) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-s.done:
+	case <-time.After(eventFlushTimeout):
+		logging.Debugf("Event socket %s: gave up flushing queued events", s.addr)
+	}
+	return nil
+}
+
+// NewEventStream returns an Output writing --output-format json records to w
+func NewEventStream(w io.Writer) Output {
+	return &jsonOutput{enc: json.NewEncoder(w)}
+}
+
+// TeeOutput sends everything to both primary and secondary. Verify output
+// goes only to primary's CommandOutput.
+func TeeOutput(primary, secondary Output) Output {
+	return &teeOutput{primary: primary, secondary: secondary}
+}
+
+// teeOutput is the Output returned by TeeOutput
+type teeOutput struct {
+	primary, secondary Output
+}
+
+func (t *teeOutput) IterationStart(cfg ui.IterationConfig) {
+	t.primary.IterationStart(cfg)
+	t.secondary.IterationStart(cfg)
+}
+
+func (t *teeOutput) Event(event adapter.Event) {
+	t.primary.Event(event)
+	t.secondary.Event(event)
+}
+
+func (t *teeOutput) Notice(icon, msg string) {
+	t.primary.Notice(icon, msg)
+	t.secondary.Notice(icon, msg)
+}
+
+func (t *teeOutput) IterationEnd(cfg ui.IterationConfig) {
+	t.primary.IterationEnd(cfg)
+	t.secondary.IterationEnd(cfg)
+}
+
+func (t *teeOutput) Summary(cfg ui.SummaryConfig) {
+	t.primary.Summary(cfg)
+	t.secondary.Summary(cfg)
+}
+
+func (t *teeOutput) CommandOutput() io.Writer {
+	return t.primary.CommandOutput()
+}
@@ -0,0 +1,187 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adriancodes/gumloop/internal/adapter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readRecords accepts one connection on ln and decodes n JSON lines from it
+func readRecords(t *testing.T, ln net.Listener, n int) []map[string]any {
+	t.Helper()
+	conn, err := ln.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var records []map[string]any
+	scanner := bufio.NewScanner(conn)
+	for len(records) < n && scanner.Scan() {
+		var rec map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec), scanner.Text())
+		records = append(records, rec)
+	}
+	require.NoError(t, scanner.Err())
+	return records
+}
+
+func recordTypes(records []map[string]any) []any {
+	var types []any
+	for _, rec := range records {
+		types = append(types, rec["type"])
+	}
+	return types
+}
+
+func TestNewEventSocket_Address(t *testing.T) {
+	tests := []struct {
+		addr, network, want string
+	}{
+		{"localhost:9000", "tcp", "localhost:9000"},
+		{"tcp:127.0.0.1:9000", "tcp", "127.0.0.1:9000"},
+		{"unix:/tmp/gumloop.sock", "unix", "/tmp/gumloop.sock"},
+		{"/tmp/gumloop.sock", "unix", "/tmp/gumloop.sock"},
+		{"./gumloop.sock", "unix", "./gumloop.sock"},
+	}
+	for _, tt := range tests {
+		s := NewEventSocket(tt.addr)
+		assert.Equal(t, tt.network, s.network, tt.addr)
+		assert.Equal(t, tt.want, s.addr, tt.addr)
+	}
+}
+
+func TestEventStream_TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	got := make(chan []map[string]any)
+	go func() { got <- readRecords(t, ln, 7) }()
+
+	sock := NewEventSocket(ln.Addr().String())
+	defer sock.Close()
+	var terminal bytes.Buffer
+	human, err := NewOutput(FormatHuman, &terminal, 0)
+	require.NoError(t, err)
+	writeSampleRun(TeeOutput(human, NewEventStream(sock)))
+
+	records := <-got
+	assert.Equal(t, []any{"iteration_start", "tool_use", "message", "error", "notice", "iteration_end", "summary"}, recordTypes(records))
+	assert.Equal(t, "Fixed the bug", records[2]["text"])
+	assert.Contains(t, terminal.String(), "Fixed the bug", "terminal output is unchanged")
+}
+
+func TestEventStream_Unix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.sock")
+	ln, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer ln.Close()
+
+	got := make(chan []map[string]any)
+	go func() { got <- readRecords(t, ln, 1) }()
+
+	sock := NewEventSocket(path)
+	defer sock.Close()
+	NewEventStream(sock).Notice(IconInfo, "hello")
+
+	records := <-got
+	assert.Equal(t, "hello", records[0]["message"])
+}
+
+func TestEventSocket_Reconnects(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	sock := NewEventSocket(ln.Addr().String())
+	defer sock.Close()
+	events := NewEventStream(sock)
+
+	// The first connection is dropped by the listener after one record
+	first := make(chan []map[string]any)
+	go func() { first <- readRecords(t, ln, 1) }()
+	events.Notice(IconInfo, "one")
+	assert.Equal(t, "one", (<-first)[0]["message"])
+
+	// Writes to the closed connection fail at some point; the socket then
+	// reconnects and the listener sees later records on a new connection
+	second := make(chan []map[string]any)
+	go func() { second <- readRecords(t, ln, 1) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		events.Event(adapter.AssistantMessage{Text: "again"})
+		select {
+		case records := <-second:
+			assert.Equal(t, "again", records[0]["text"])
+			return
+		case <-time.After(50 * time.Millisecond):
+		}
+		require.True(t, time.Now().Before(deadline), "no reconnect")
+	}
+}
+
+func TestEventSocket_NoListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	sock := NewEventSocket(addr)
+	n, err := sock.Write([]byte("{}\n"))
+	assert.NoError(t, err, "a missing listener doesn't fail the run")
+	assert.Equal(t, 3, n)
+	start := time.Now()
+	sock.Close()
+	assert.Less(t, time.Since(start), eventFlushTimeout)
+
+	// Within the redial delay, records are dropped without dialing again
+	s := &EventSocket{network: "tcp", addr: addr}
+	s.send([]byte("{}\n"))
+	start = time.Now()
+	s.send([]byte("{}\n"))
+	assert.Less(t, time.Since(start), eventDialTimeout)
+	assert.Nil(t, s.conn)
+}
+
+func TestEventSocket_FullQueue(t *testing.T) {
+	// Nothing drains the queue, as with a listener that stopped reading
+	s := &EventSocket{addr: "test", queue: make(chan []byte, 1)}
+
+	buf := []byte("one\n")
+	s.Write(buf)
+	copy(buf, "two")
+	n, err := s.Write([]byte("three\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 6, n)
+
+	require.Len(t, s.queue, 1)
+	assert.Equal(t, "one\n", string(<-s.queue), "the queued record is a copy, and later ones are dropped")
+}
+
+func TestEventSocket_CloseFlushes(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	got := make(chan []map[string]any)
+	go func() { got <- readRecords(t, ln, 3) }()
+
+	sock := NewEventSocket(ln.Addr().String())
+	events := NewEventStream(sock)
+	events.Notice(IconInfo, "one")
+	events.Notice(IconInfo, "two")
+	events.Notice(IconInfo, "three")
+	sock.Close()
+	sock.Write([]byte("{}\n")) // Dropped, not a send on a closed queue
+
+	records := <-got
+	assert.Equal(t, "three", records[2]["message"])
+}
@@ -2,83 +2,164 @@ package runner
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/adriancodes/gumloop/internal/adapter"
 	"github.com/adriancodes/gumloop/internal/agent"
-	"github.com/adriancodes/gumloop/internal/git"
+	"github.com/adriancodes/gumloop/internal/logging"
 )
 
-// Iteration represents a single iteration of the agent loop
+// Iteration is a single run of the agent and the checks after it
 type Iteration struct {
-	Number    int
-	Agent     *agent.Agent
-	Prompt    string
-	Model     string
-	Verify    string
-	Autonomous bool
-	StartTime time.Time
-	Duration  time.Duration
-	Commits   int
-	Modified  int
-	Staged    int
-	Untracked int
-	Error     error
+	Agent             *agent.Agent
+	Prompt            string
+	Model             string
+	Verify            string
+	VerifyParallel    bool
+	VerifyShell       string // See config.Config.VerifyShell
+	VerifyOutputLimit int    // Keep up to this many bytes of failed verify output in the result (0 for none)
+	VerifiedTree      string // Skip verify if the working tree still has this hash (see git.WorkTreeHash)
+	Autonomous        bool
+	WorkDir           string         // Where the agent and verify run (current directory if empty)
+	HideTools         []string       // Tool calls counted but not shown
+	DoneSignal        *regexp.Regexp // Checked against agent messages (nil if unset)
+	Raw               bool           // Copy agent output to Output.CommandOutput() unparsed (no events)
+	Output            Output         // Human output on stdout if nil
+	Commands          CommandRunner  // Real processes if nil
+	Repo              Repo           // The current repository if nil
 }
 
-// RunIteration executes a single iteration of the agent
-// Returns the number of commits made and any error encountered
-func RunIteration(ag *agent.Agent, prompt string, model string, verify string, autonomous bool) (int, error) {
-	iter := &Iteration{
-		Agent:      ag,
-		Prompt:     prompt,
-		Model:      model,
-		Verify:     verify,
-		Autonomous: autonomous,
-		StartTime:  time.Now(),
+// IterationResult is what a single iteration produced
+type IterationResult struct {
+	Duration     time.Duration
+	Commits      int
+	Modified     int
+	Staged       int
+	Untracked    int
+	ToolCalls    []adapter.ToolUse // Every tool call, including hidden ones
+	HiddenTools  int               // How many of ToolCalls weren't printed
+	Verified     bool              // Verification ran and passed
+	VerifyFailed bool              // Verification ran and failed
+	VerifyOutput string            // The end of the failed verify output, if VerifyOutputLimit was set
+	VerifiedTree string            // The working tree hash verification passed on
+	VerifyCached bool              // Verification was skipped: the tree matched Iteration.VerifiedTree
+	DoneSignal   bool              // The agent's output matched the done signal
+	RateLimited  bool              // The agent reported a rate limit error
+	RetryAfter   time.Duration     // The wait the rate limit error suggested, if any
+	NoOutput     bool              // The agent emitted no messages, tool calls or errors
+	LastMessage  string            // The text of the agent's last message
+	Pushed       bool              // Set by the runner after a successful push
+	PushFailed   bool              // Set by the runner after a failed push
+}
+
+// startupFailureWindow is how soon after starting an agent a non-zero exit
+// that did no work is treated as a startup failure rather than an empty iteration
+const startupFailureWindow = 5 * time.Second
+
+// AgentStartupError is returned when the agent exits non-zero almost
+// immediately without doing any work, either silently or with only an
+// error on stderr (e.g. not authenticated).
+// Retrying would just spin, so the runner stops the loop.
+type AgentStartupError struct {
+	Err    error
+	Stderr string
+}
+
+func (e *AgentStartupError) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("agent failed to start: %v", e.Err)
+	}
+	return fmt.Sprintf("agent failed to start: %v\n%s", e.Err, e.Stderr)
+}
+
+func (e *AgentStartupError) Unwrap() error {
+	return e.Err
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Run executes a single iteration of the agent.
+// The agent and verify command run in WorkDir (current directory if empty),
+// while commit counting uses the enclosing repository.
+// Tool calls named in HideTools are counted but not printed.
+// With VerifyParallel set, each line of Verify runs as a separate concurrent command.
+// Cancelling ctx terminates the agent and everything it started.
+// If DoneSignal is set, the result records whether any agent message matched it.
+// Returns the iteration's result (Commits is set whenever it could be
+// counted, even alongside an error) and any error encountered
+func (it *Iteration) Run(ctx context.Context) (IterationResult, error) {
+	var result IterationResult
+	startTime := time.Now()
+
+	ag := it.Agent
+	out := it.Output
+	if out == nil {
+		out = &humanOutput{}
+	}
+	commands := it.Commands
+	if commands == nil {
+		commands = execRunner{}
+	}
+	repo := it.Repo
+	if repo == nil {
+		repo = gitRepo{}
 	}
 
 	// Count commits before
-	commitsBefore, err := git.CountCommits()
+	commitsBefore, err := repo.CountCommits()
 	if err != nil {
-		return 0, fmt.Errorf("failed to count commits before iteration: %w", err)
+		return result, fmt.Errorf("failed to count commits before iteration: %w", err)
 	}
 
 	// Build the command
-	cmdArgs := ag.BuildCommand(prompt, model, autonomous)
+	cmdArgs := ag.BuildCommand(it.Prompt, it.Model, it.Autonomous)
 	if len(cmdArgs) == 0 {
-		return 0, fmt.Errorf("agent BuildCommand returned empty command")
+		return result, fmt.Errorf("agent BuildCommand returned empty command")
 	}
 
-	// Create the command
-	cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
-	cmd.Dir, _ = os.Getwd()
-	cmd.Env = os.Environ()
-
-	// Handle prompt piping for PromptStylePipe
-	if ag.PromptStyle == agent.PromptStylePipe {
-		cmd.Stdin = bytes.NewBufferString(prompt)
+	// Resolve the directory the agent runs in
+	workDir := it.WorkDir
+	if workDir == "" {
+		workDir, _ = os.Getwd()
 	}
 
-	// Set up output capture
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return 0, fmt.Errorf("failed to create stdout pipe: %w", err)
+	cmd := Command{
+		Args: cmdArgs,
+		Env:  ag.BuildEnv(os.Environ()),
+		Dir:  workDir,
 	}
+	logging.Debugf("Agent command: %q (in %s)", cmdArgs, workDir)
 
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return 0, fmt.Errorf("failed to create stderr pipe: %w", err)
+	// Handle prompt piping for PromptStylePipe
+	if ag.PromptStyle == agent.PromptStylePipe {
+		cmd.Stdin = bytes.NewBufferString(ag.FramePrompt(it.Prompt))
 	}
 
-	// Start the command
-	if err := cmd.Start(); err != nil {
-		return 0, fmt.Errorf("failed to start agent: %w", err)
-	}
+	// Set up output capture: stdout and stderr share one pipe so the adapter
+	// sees them interleaved, and the command finishes copying before we close it.
+	// Stdout is counted for raw mode and stderr kept so startup failures can be reported.
+	outputReader, outputWriter := io.Pipe()
+	stdout := &countingWriter{w: outputWriter}
+	var stderr bytes.Buffer
+	cmd.Stdout = stdout
+	cmd.Stderr = io.MultiWriter(outputWriter, &stderr)
 
 	// Create event channel for adapter
 	events := make(chan adapter.Event, 100)
@@ -86,106 +167,209 @@ func RunIteration(ag *agent.Agent, prompt string, model string, verify string, a
 
 	// Select the appropriate adapter based on agent
 	var adapterImpl adapter.Adapter
-	switch ag.ID {
-	case "claude":
+	switch {
+	case it.Raw:
+		adapterImpl = adapter.NewRawAdapter(out.CommandOutput())
+	case ag.ID == "claude":
 		adapterImpl = &adapter.ClaudeAdapter{}
-	case "codex":
+	case ag.ID == "codex":
 		adapterImpl = &adapter.CodexAdapter{}
 	default:
-		// Use pass-through for gemini, opencode, cursor, ollama
-		adapterImpl = &adapter.PassThroughAdapter{}
+		// Plain text for gemini, opencode, cursor, ollama, with tool usage
+		// picked out heuristically
+		patterns, err := adapter.ParseToolPatterns(ag.ToolPatterns)
+		if err != nil {
+			logging.Warnf("%v. Using default tool patterns.", err)
+			patterns = nil
+		}
+		adapterImpl = adapter.NewPlainTextAdapter(patterns)
 	}
 
 	// Start processing output in a goroutine
 	go func() {
-		// Combine stdout and stderr
-		combined := io.MultiReader(stdout, stderr)
-		err := adapterImpl.Process(combined, events)
+		err := adapterImpl.Process(outputReader, events)
+		// Drain anything left so the agent never blocks on a full pipe
+		io.Copy(io.Discard, outputReader)
 		close(events)
 		adapterDone <- err
 	}()
 
 	// Display events as they arrive
+	displayDone := make(chan displayCounts, 1)
 	go func() {
-		for event := range events {
-			switch e := event.(type) {
-			case adapter.ToolUse:
-				fmt.Printf("🔧 %s\n", e.Name)
-			case adapter.AssistantMessage:
-				if e.Text != "" {
-					fmt.Println(e.Text)
-				}
-			case adapter.Error:
-				fmt.Printf("⚠️  %s\n", e.Message)
-			}
-		}
+		displayDone <- displayEvents(events, it.HideTools, it.DoneSignal, out)
 	}()
 
-	// Wait for command to complete
-	cmdErr := cmd.Wait()
+	// Run the agent (killing it and its children if ctx is cancelled), then
+	// signal EOF to the adapter
+	exitCode, err := commands.Run(ctx, cmd)
+	outputWriter.Close()
+	if err != nil {
+		<-adapterDone
+		<-displayDone
+		var startErr *StartError
+		if errors.As(err, &startErr) {
+			return result, fmt.Errorf("failed to start agent: %w", err)
+		}
+		return result, fmt.Errorf("agent failed: %w", err)
+	}
+	var cmdErr error
+	if exitCode != 0 {
+		cmdErr = fmt.Errorf("exit status %d", exitCode)
+	}
 
-	// Wait for adapter to finish
+	// Wait for adapter to finish and all events to be printed
 	adapterErr := <-adapterDone
+	counts := <-displayDone
+	result.ToolCalls = counts.tools
+	result.HiddenTools = counts.hidden
+	result.DoneSignal = counts.done
+	result.RateLimited = counts.rateLimited
+	result.RetryAfter = counts.retryAfter
+	result.NoOutput = len(counts.tools) == 0 && counts.messages == 0
+	if it.Raw {
+		// There are no events to count, only bytes
+		result.NoOutput = stdout.n == 0 && stderr.Len() == 0
+	}
+	result.LastMessage = counts.last
 
 	// Record duration
-	iter.Duration = time.Since(iter.StartTime)
+	result.Duration = time.Since(startTime)
+	logging.Debugf("Agent finished in %s (error: %v, tool calls: %d)", result.Duration, cmdErr, len(result.ToolCalls))
+
+	// An interrupted agent is neither a startup failure nor worth verifying,
+	// but report what it committed before it was stopped
+	if ctx.Err() != nil {
+		if commitsAfter, err := repo.CountCommits(); err == nil {
+			result.Commits = commitsAfter - commitsBefore
+		}
+		return result, fmt.Errorf("agent interrupted: %w", ctx.Err())
+	}
 
 	// Check for errors
 	if cmdErr != nil {
+		// An instant failure that emitted no events (at most a complaint on
+		// stderr) won't fix itself on the next iteration. Stderr shares the
+		// adapter's pipe, so messages only count if stdout said something.
+		quiet := result.NoOutput || (stderr.Len() > 0 && (counts.messages == 0 || stdout.n == 0))
+		if quiet && len(result.ToolCalls) == 0 && result.Duration < startupFailureWindow {
+			return result, &AgentStartupError{Err: cmdErr, Stderr: strings.TrimSpace(stderr.String())}
+		}
 		// Agent exit non-zero is a warning, not a failure
-		fmt.Printf("⚠️  Agent exited with code %v. Continuing...\n", cmdErr)
+		logging.Warnf("Agent exited with code %v. Continuing...", cmdErr)
 	}
 
 	if adapterErr != nil {
-		return 0, fmt.Errorf("adapter error: %w", adapterErr)
+		return result, fmt.Errorf("adapter error: %w", adapterErr)
 	}
 
 	// Count commits after
-	commitsAfter, err := git.CountCommits()
+	commitsAfter, err := repo.CountCommits()
 	if err != nil {
-		return 0, fmt.Errorf("failed to count commits after iteration: %w", err)
+		return result, fmt.Errorf("failed to count commits after iteration: %w", err)
 	}
+	result.Commits = commitsAfter - commitsBefore
 
-	commitsMade := commitsAfter - commitsBefore
+	// Nothing to show and nothing committed usually means the agent never
+	// got to work, not that there was nothing to do
+	if result.NoOutput && result.Commits == 0 {
+		logging.Warnf("Warning: %s produced no output. Check that its flags are right for the installed version and that it's logged in.", ag.Name)
+	}
 
 	// Get changed files
-	modified, staged, untracked, err := git.GetChangedFiles()
+	result.Modified, result.Staged, result.Untracked, err = repo.GetChangedFiles()
 	if err != nil {
-		return commitsMade, fmt.Errorf("failed to get changed files: %w", err)
+		return result, fmt.Errorf("failed to get changed files: %w", err)
 	}
 
-	iter.Commits = commitsMade
-	iter.Modified = modified
-	iter.Staged = staged
-	iter.Untracked = untracked
-
 	// Run verification command if specified
-	if verify != "" {
-		fmt.Printf("\n🧪 Running verification: %s\n", verify)
-		verifyCmd := exec.Command("sh", "-c", verify)
-		verifyCmd.Stdout = os.Stdout
-		verifyCmd.Stderr = os.Stderr
-		verifyCmd.Dir, _ = os.Getwd()
-
-		if err := verifyCmd.Run(); err != nil {
-			fmt.Printf("⚠️  Verification failed: %v\n", err)
-			return commitsMade, fmt.Errorf("verification failed: %w", err)
+	if it.Verify != "" {
+		// Nothing changed since verification last passed: reuse that result
+		tree, err := repo.WorkTreeHash()
+		if err != nil {
+			logging.Debugf("Not caching verification: %v", err)
+		}
+		if tree != "" && tree == it.VerifiedTree {
+			out.Notice(IconVerify, "Skipping verification: nothing changed since it last passed")
+			result.Verified, result.VerifyCached, result.VerifiedTree = true, true, tree
+			return result, nil
 		}
-		fmt.Println("✅ Verification passed")
-	}
 
-	// Display iteration summary
-	fmt.Println("\n──────────────────────────────────────")
-	fmt.Printf("  Iteration complete (%s)\n", FormatDuration(iter.Duration))
-	if commitsMade > 0 {
-		fmt.Printf("  ✅ Commits: %d\n", commitsMade)
-	} else {
-		fmt.Println("  ℹ️  No commits made")
+		out.Notice(IconVerify, fmt.Sprintf("Running verification: %s", it.Verify))
+		verifyStart := time.Now()
+		var stdout, stderr io.Writer = out.CommandOutput(), os.Stderr
+		var captured *tailBuffer
+		if it.VerifyOutputLimit > 0 {
+			captured = &tailBuffer{max: it.VerifyOutputLimit}
+			stdout, stderr = io.MultiWriter(stdout, captured), io.MultiWriter(stderr, captured)
+		}
+		err = runVerify(ctx, commands, it.VerifyShell, it.Verify, it.VerifyParallel, workDir, stdout, stderr)
+		logging.Debugf("Verification finished in %s", time.Since(verifyStart))
+		if err != nil {
+			result.VerifyFailed = true
+			if captured != nil {
+				result.VerifyOutput = captured.String()
+			}
+			logging.Warnf("Verification failed: %v", err)
+			return result, fmt.Errorf("verification failed: %w", err)
+		}
+		result.Verified, result.VerifiedTree = true, tree
 	}
-	if modified > 0 || staged > 0 || untracked > 0 {
-		fmt.Printf("  📝 Changes: %d modified, %d staged, %d new\n", modified, staged, untracked)
+
+	return result, nil
+}
+
+// displayCounts collects the tool calls seen while displaying events
+type displayCounts struct {
+	tools    []adapter.ToolUse
+	hidden   int
+	messages int    // Assistant messages with text, and errors
+	last     string // The last assistant message's text
+	done     bool   // An assistant message matched the done signal
+
+	rateLimited bool          // An error event reported a rate limit
+	retryAfter  time.Duration // The longest wait a rate limit error suggested
+}
+
+// displayEvents sends adapter events to out until the channel closes.
+// Tool calls named in hideTools are counted but not printed.
+// Assistant messages are checked against doneSignal, if set, and errors
+// for rate limits.
+func displayEvents(events <-chan adapter.Event, hideTools []string, doneSignal *regexp.Regexp, out Output) displayCounts {
+	hidden := make(map[string]bool, len(hideTools))
+	for _, name := range hideTools {
+		hidden[name] = true
 	}
-	fmt.Println("──────────────────────────────────────")
 
-	return commitsMade, nil
+	var counts displayCounts
+	for event := range events {
+		switch e := event.(type) {
+		case adapter.ToolUse:
+			counts.tools = append(counts.tools, e)
+			if hidden[e.Name] {
+				counts.hidden++
+				continue
+			}
+			out.Event(e)
+		case adapter.AssistantMessage:
+			out.Event(e)
+			if e.Text != "" {
+				counts.messages++
+				counts.last = e.Text
+			}
+			if doneSignal != nil && doneSignal.MatchString(e.Text) {
+				counts.done = true
+			}
+		case adapter.Error:
+			out.Event(e)
+			counts.messages++
+			if limited, wait := rateLimitDelay(e.Message); limited {
+				counts.rateLimited = true
+				if wait > counts.retryAfter {
+					counts.retryAfter = wait
+				}
+			}
+		}
+	}
+	return counts
 }
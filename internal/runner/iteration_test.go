@@ -0,0 +1,203 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adriancodes/gumloop/internal/adapter"
+	"github.com/adriancodes/gumloop/internal/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestRepo creates a temporary git repository with one commit and changes into it
+func setupTestRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test User"},
+		{"commit", "-q", "--allow-empty", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		require.NoError(t, cmd.Run())
+	}
+
+	orig, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(orig) })
+
+	return dir
+}
+
+// shellAgent returns a fake agent that runs the prompt as a shell script
+func shellAgent() *agent.Agent {
+	return &agent.Agent{
+		ID:               "shell",
+		Name:             "Shell",
+		Command:          "sh",
+		AutonomousFlags:  []string{"-c"},
+		InteractiveFlags: []string{"-c"},
+		PromptStyle:      agent.PromptStyleArg,
+	}
+}
+
+func TestIteration_WorkDir(t *testing.T) {
+	root := setupTestRepo(t)
+	subdir := filepath.Join(root, "packages", "app")
+	require.NoError(t, os.MkdirAll(subdir, 0755))
+
+	// The agent records its cwd and commits from inside the subdir
+	script := "pwd -P > cwd.txt && git add cwd.txt && git commit -q -m 'agent commit'"
+
+	result, err := (&Iteration{Agent: shellAgent(), Prompt: script, Autonomous: true, WorkDir: subdir}).Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Commits)
+
+	// cwd.txt was written in the subdir, not the root
+	data, err := os.ReadFile(filepath.Join(subdir, "cwd.txt"))
+	require.NoError(t, err)
+	resolved, err := filepath.EvalSymlinks(subdir)
+	require.NoError(t, err)
+	assert.Equal(t, resolved, strings.TrimSpace(string(data)))
+
+	_, err = os.Stat(filepath.Join(root, "cwd.txt"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestIteration_DefaultWorkDir(t *testing.T) {
+	root := setupTestRepo(t)
+
+	result, err := (&Iteration{Agent: shellAgent(), Prompt: "pwd -P > cwd.txt", Autonomous: true}).Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Commits)
+
+	data, err := os.ReadFile(filepath.Join(root, "cwd.txt"))
+	require.NoError(t, err)
+	resolved, err := filepath.EvalSymlinks(root)
+	require.NoError(t, err)
+	assert.Equal(t, resolved, strings.TrimSpace(string(data)))
+}
+
+func TestIteration_Result(t *testing.T) {
+	setupTestRepo(t)
+
+	// One commit, then leave one modified file and one new file behind
+	script := strings.Join([]string{
+		"echo Running make build",
+		"echo Editing README.md",
+		"echo v1 > tracked.txt && git add tracked.txt && git commit -q -m add",
+		"echo v2 > tracked.txt",
+		"echo new > new.txt",
+	}, "; ")
+
+	result, err := (&Iteration{Agent: shellAgent(), Prompt: script, Verify: "true", Autonomous: true, HideTools: []string{"Edit"}}).Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, result.Commits)
+	assert.Equal(t, 1, result.Modified)
+	assert.Equal(t, 0, result.Staged)
+	assert.Equal(t, 1, result.Untracked)
+	assert.Equal(t, []adapter.ToolUse{
+		{Name: "Bash", Input: "make build"},
+		{Name: "Edit", Input: "README.md"},
+	}, result.ToolCalls)
+	assert.Equal(t, 1, result.HiddenTools)
+	assert.True(t, result.Verified)
+	assert.False(t, result.VerifyFailed)
+	assert.Greater(t, result.Duration, time.Duration(0))
+	assert.False(t, result.Pushed)
+}
+
+func TestIteration_ResultVerifyFailed(t *testing.T) {
+	setupTestRepo(t)
+
+	result, err := (&Iteration{Agent: shellAgent(), Prompt: "true", Verify: "false", Autonomous: true}).Run(context.Background())
+	require.Error(t, err)
+	assert.True(t, result.VerifyFailed)
+	assert.False(t, result.Verified)
+}
+
+func TestIteration_StartupFailureIncludesStderr(t *testing.T) {
+	setupTestRepo(t)
+
+	_, err := (&Iteration{Agent: shellAgent(), Prompt: "echo 'not authenticated' >&2; exit 1", Autonomous: true}).Run(context.Background())
+
+	var startupErr *AgentStartupError
+	require.ErrorAs(t, err, &startupErr)
+	assert.Equal(t, "not authenticated", startupErr.Stderr)
+}
+
+func TestIteration_QuickFailureWithMessageIsNotStartupFailure(t *testing.T) {
+	setupTestRepo(t)
+
+	result, err := (&Iteration{Agent: shellAgent(), Prompt: "echo 'Tests are failing'; echo 'warning: low quota' >&2; exit 1", Autonomous: true}).Run(context.Background())
+
+	require.NoError(t, err)
+	assert.False(t, result.NoOutput)
+}
+
+func TestDisplayEvents_HideTools(t *testing.T) {
+	events := make(chan adapter.Event, 10)
+	events <- adapter.ToolUse{Name: "Read"}
+	events <- adapter.ToolUse{Name: "Edit"}
+	events <- adapter.ToolUse{Name: "TodoWrite"}
+	events <- adapter.AssistantMessage{Text: "Done"}
+	events <- adapter.ToolUse{Name: "Read"}
+	close(events)
+
+	var buf bytes.Buffer
+	counts := displayEvents(events, []string{"Read", "TodoWrite"}, nil, &humanOutput{w: &buf})
+
+	out := buf.String()
+	assert.Contains(t, out, "🔧 Edit")
+	assert.Contains(t, out, "Done")
+	assert.NotContains(t, out, "Read")
+	assert.NotContains(t, out, "TodoWrite")
+
+	// Hidden tools are still counted
+	assert.Len(t, counts.tools, 4)
+	assert.Equal(t, 3, counts.hidden)
+}
+
+func TestDisplayEvents_NoHiddenTools(t *testing.T) {
+	events := make(chan adapter.Event, 10)
+	events <- adapter.ToolUse{Name: "Read"}
+	events <- adapter.Error{Message: "rate limited"}
+	close(events)
+
+	var buf bytes.Buffer
+	counts := displayEvents(events, nil, nil, &humanOutput{w: &buf})
+
+	assert.Equal(t, "🔧 Read\n⚠️  rate limited\n", buf.String())
+	assert.Len(t, counts.tools, 1)
+	assert.Equal(t, 0, counts.hidden)
+}
+
+func TestDisplayEvents_DoneSignal(t *testing.T) {
+	events := make(chan adapter.Event, 10)
+	events <- adapter.AssistantMessage{Text: "Still working on it"}
+	events <- adapter.AssistantMessage{Text: "All tests pass. TASK COMPLETE"}
+	close(events)
+
+	var buf bytes.Buffer
+	counts := displayEvents(events, nil, regexp.MustCompile(`TASK COMPLETE`), &humanOutput{w: &buf})
+	assert.True(t, counts.done)
+
+	events = make(chan adapter.Event, 10)
+	events <- adapter.AssistantMessage{Text: "Still working on it"}
+	close(events)
+	counts = displayEvents(events, nil, regexp.MustCompile(`TASK COMPLETE`), &humanOutput{w: &buf})
+	assert.False(t, counts.done)
+}
@@ -11,6 +11,8 @@ type Metrics struct {
 	Commits    int
 	StartTime  time.Time
 	ExitReason string
+	Errors     int    // Iterations that ended with an error
+	LastError  string // The most recent iteration error, if any
 }
 
 // NewMetrics creates a new Metrics instance
@@ -20,6 +22,12 @@ func NewMetrics() *Metrics {
 	}
 }
 
+// RecordError counts an iteration error and keeps it as the last one
+func (m *Metrics) RecordError(err error) {
+	m.Errors++
+	m.LastError = err.Error()
+}
+
 // Duration returns the elapsed time since the run started
 func (m *Metrics) Duration() time.Duration {
 	return time.Since(m.StartTime)
@@ -0,0 +1,353 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/adriancodes/gumloop/internal/adapter"
+	"github.com/adriancodes/gumloop/internal/ui"
+)
+
+// Output formats for gumloop run --output-format
+const (
+	FormatHuman = "human" // Headers, agent output and summaries for a terminal
+	FormatJSON  = "json"  // One JSON object per line: events, then the summary
+	FormatQuiet = "quiet" // Only the run summary
+)
+
+// Output receives everything a run shows on stdout. Warnings and errors
+// still go to stderr through the logging package.
+type Output interface {
+	// IterationStart is called before the agent runs
+	IterationStart(cfg ui.IterationConfig)
+
+	// Event is called for each agent event that isn't hidden
+	Event(event adapter.Event)
+
+	// Notice reports something the runner did, like pushing or undoing
+	// commits. msg is plain text; icon (one of the Icon constants) is only
+	// shown by the human output.
+	Notice(icon, msg string)
+
+	// IterationEnd is called with the iteration's filled-in summary
+	IterationEnd(cfg ui.IterationConfig)
+
+	// Summary is called once, after the loop ends
+	Summary(cfg ui.SummaryConfig)
+
+	// CommandOutput is where the verify command's stdout goes
+	CommandOutput() io.Writer
+}
+
+// Notice icons, shown by the human output in front of the message
+const (
+	IconInfo    = "ℹ️"
+	IconWarning = "⚠️" // Also starts a new section
+	IconError   = "❌"
+	IconStop    = "⛔"
+	IconDone    = "🏁"
+	IconRetry   = "🔁"
+	IconHint    = "💡"
+	IconCommit  = "📦"
+	IconUndo    = "↩️"
+	IconPush    = "☁️"
+	IconWait    = "⏳"
+	IconStage   = "🔀"
+	IconVerify  = "🧪" // Also starts a new section
+)
+
+// asciiIcons are the human output's icons in ASCII mode (--ascii, or a
+// terminal that can't show emoji)
+var asciiIcons = map[string]string{
+	IconInfo:    "[INFO]",
+	IconWarning: "[WARN]",
+	IconError:   "[ERROR]",
+	IconStop:    "[STOP]",
+	IconDone:    "[DONE]",
+	IconRetry:   "[RETRY]",
+	IconHint:    "[HINT]",
+	IconCommit:  "[COMMIT]",
+	IconUndo:    "[UNDO]",
+	IconPush:    "[PUSH]",
+	IconWait:    "[WAIT]",
+	IconStage:   "[STAGE]",
+	IconVerify:  "[VERIFY]",
+}
+
+// withIcon puts icon in front of msg. Pass icons through ui.StatusIcon (or
+// noticeIcon) first, so ASCII mode gets a tag instead.
+func withIcon(icon, msg string) string {
+	// Many terminals draw icons ending in a variation selector one column
+	// wide, so they get an extra space
+	if strings.HasSuffix(icon, "\ufe0f") {
+		return icon + "  " + msg
+	}
+	return icon + " " + msg
+}
+
+// noticeIcon returns an Icon constant, or its ASCII tag in ASCII mode
+func noticeIcon(icon string) string {
+	return ui.StatusIcon(icon, asciiIcons[icon])
+}
+
+// TruncatedSuffix ends an agent message line cut at max_line_length
+const TruncatedSuffix = "… (truncated)"
+
+// NewOutput returns the Output for format, writing to w. The human format
+// cuts agent message lines longer than maxLineLength characters (0 for no
+// limit); JSON always has the full text.
+func NewOutput(format string, w io.Writer, maxLineLength int) (Output, error) {
+	switch format {
+	case FormatHuman, "":
+		return &humanOutput{w: w, maxLineLength: maxLineLength}, nil
+	case FormatJSON:
+		return &jsonOutput{enc: json.NewEncoder(w)}, nil
+	case FormatQuiet:
+		return &quietOutput{human: humanOutput{w: w, maxLineLength: maxLineLength}}, nil
+	default:
+		return nil, fmt.Errorf("invalid output format '%s' (valid: human, json, quiet)", format)
+	}
+}
+
+// humanOutput is the default terminal output
+type humanOutput struct {
+	w io.Writer // nil means os.Stdout at the time of writing
+
+	// maxLineLength cuts longer agent message lines (0 for no limit)
+	maxLineLength int
+}
+
+func (h *humanOutput) out() io.Writer {
+	if h.w == nil {
+		return os.Stdout
+	}
+	return h.w
+}
+
+func (h *humanOutput) IterationStart(cfg ui.IterationConfig) {
+	fmt.Fprintf(h.out(), "\n%s\n", ui.RenderIterationHeader(cfg))
+}
+
+func (h *humanOutput) Event(event adapter.Event) {
+	switch e := event.(type) {
+	case adapter.ToolUse:
+		fmt.Fprintln(h.out(), withIcon(ui.StatusIcon("🔧", "[TOOL]"), e.Name))
+	case adapter.AssistantMessage:
+		if e.Text != "" {
+			h.writeMessage(e.Text)
+		}
+	case adapter.Error:
+		fmt.Fprintln(h.out(), withIcon(noticeIcon(IconWarning), e.Message))
+	}
+}
+
+// LineCutter is an output writer that keeps a full copy of what the
+// terminal shows, like the --tee file. Agent message lines cut at
+// max_line_length are written through WriteCutLine, so the terminal shows
+// cut and the copy keeps full.
+type LineCutter interface {
+	WriteCutLine(cut, full string) error
+}
+
+// writeMessage writes an agent message, cutting lines longer than
+// maxLineLength (only on the terminal, if the writer is a LineCutter)
+func (h *humanOutput) writeMessage(text string) {
+	out := h.out()
+	cutter, _ := out.(LineCutter)
+	for _, line := range strings.Split(text, "\n") {
+		cut := truncateLine(line, h.maxLineLength)
+		if cut != line && cutter != nil {
+			cutter.WriteCutLine(cut, line)
+			continue
+		}
+		fmt.Fprintln(out, cut)
+	}
+}
+
+// truncateLine cuts line after max characters, ending it with
+// TruncatedSuffix. A max of 0 leaves it unchanged.
+func truncateLine(line string, max int) string {
+	if max <= 0 || utf8.RuneCountInString(line) <= max {
+		return line
+	}
+	return string([]rune(line)[:max]) + TruncatedSuffix
+}
+
+func (h *humanOutput) Notice(icon, msg string) {
+	if icon == IconWarning || icon == IconVerify {
+		fmt.Fprintln(h.out())
+	}
+	if icon == "" {
+		fmt.Fprintln(h.out(), msg)
+		return
+	}
+	fmt.Fprintln(h.out(), withIcon(noticeIcon(icon), msg))
+}
+
+func (h *humanOutput) IterationEnd(cfg ui.IterationConfig) {
+	fmt.Fprintf(h.out(), "\n%s", ui.RenderIterationSummary(cfg))
+}
+
+func (h *humanOutput) Summary(cfg ui.SummaryConfig) {
+	fmt.Fprintf(h.out(), "\n%s\n", ui.RenderRunSummary(cfg))
+}
+
+func (h *humanOutput) CommandOutput() io.Writer {
+	return h.out()
+}
+
+// quietOutput shows only the run summary
+type quietOutput struct {
+	human humanOutput
+}
+
+func (q *quietOutput) IterationStart(ui.IterationConfig) {}
+func (q *quietOutput) Event(adapter.Event)               {}
+func (q *quietOutput) Notice(string, string)             {}
+func (q *quietOutput) IterationEnd(ui.IterationConfig)   {}
+func (q *quietOutput) CommandOutput() io.Writer          { return io.Discard }
+
+func (q *quietOutput) Summary(cfg ui.SummaryConfig) {
+	q.human.Summary(cfg)
+}
+
+// jsonOutput writes one JSON object per line, each with a "type" field
+type jsonOutput struct {
+	mu  sync.Mutex // Events and notices can arrive from different goroutines
+	enc *json.Encoder
+}
+
+// jsonIterationStart is the iteration_start record
+type jsonIterationStart struct {
+	Type          string `json:"type"`
+	Iteration     int    `json:"iteration"`
+	MaxIterations int    `json:"max_iterations,omitempty"`
+	Agent         string `json:"agent"`
+	Time          string `json:"time"`
+}
+
+// jsonIterationEnd is the iteration_end record
+type jsonIterationEnd struct {
+	Type         string   `json:"type"`
+	Iteration    int      `json:"iteration"`
+	DurationMS   int64    `json:"duration_ms"`
+	Tools        []string `json:"tools,omitempty"`
+	Commits      int      `json:"commits"`
+	Modified     int      `json:"modified"`
+	Staged       int      `json:"staged"`
+	Untracked    int      `json:"untracked"`
+	Verified     bool     `json:"verified,omitempty"`
+	VerifyFailed bool     `json:"verify_failed,omitempty"`
+	Pushed       bool     `json:"pushed,omitempty"`
+	PushFailed   bool     `json:"push_failed,omitempty"`
+}
+
+// jsonExitReasons are the summary's exit_reason values, for scripts
+var jsonExitReasons = map[ExitCode]string{
+	ExitSuccess:       "complete",
+	ExitError:         "error",
+	ExitSafety:        "safety",
+	ExitMaxIterations: "max_iterations",
+	ExitStuck:         "stuck",
+	ExitInterrupt:     "interrupted",
+}
+
+// jsonSummary is the summary record
+type jsonSummary struct {
+	Type       string `json:"type"`
+	Agent      string `json:"agent"`
+	Iterations int    `json:"iterations"`
+	Commits    int    `json:"commits"`
+	DurationMS int64  `json:"duration_ms"`
+	ExitCode   int    `json:"exit_code"`
+	ExitReason string `json:"exit_reason"`
+	Errors     int    `json:"errors"`
+	LastError  string `json:"last_error,omitempty"`
+	Ahead      *int   `json:"ahead,omitempty"`
+	Behind     *int   `json:"behind,omitempty"`
+}
+
+func (j *jsonOutput) write(v any) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.enc.Encode(v)
+}
+
+func (j *jsonOutput) IterationStart(cfg ui.IterationConfig) {
+	j.write(jsonIterationStart{
+		Type:          "iteration_start",
+		Iteration:     cfg.Number,
+		MaxIterations: cfg.MaxIteration,
+		Agent:         cfg.CLI,
+		Time:          cfg.Timestamp.Format(time.RFC3339),
+	})
+}
+
+func (j *jsonOutput) Event(event adapter.Event) {
+	switch e := event.(type) {
+	case adapter.ToolUse:
+		j.write(map[string]string{"type": "tool_use", "name": e.Name, "input": e.Input})
+	case adapter.AssistantMessage:
+		if e.Text != "" {
+			j.write(map[string]string{"type": "message", "text": e.Text})
+		}
+	case adapter.Error:
+		j.write(map[string]string{"type": "error", "message": e.Message})
+	}
+}
+
+func (j *jsonOutput) Notice(_, msg string) {
+	j.write(map[string]string{"type": "notice", "message": msg})
+}
+
+func (j *jsonOutput) IterationEnd(cfg ui.IterationConfig) {
+	record := jsonIterationEnd{
+		Type:         "iteration_end",
+		Iteration:    cfg.Number,
+		DurationMS:   cfg.Duration.Milliseconds(),
+		Commits:      cfg.Commits,
+		Modified:     cfg.Modified,
+		Staged:       cfg.Staged,
+		Untracked:    cfg.Untracked,
+		Verified:     cfg.Verified,
+		VerifyFailed: cfg.VerifyFailed,
+		Pushed:       cfg.Pushed,
+		PushFailed:   cfg.PushFailed,
+	}
+	for _, t := range cfg.ToolCalls {
+		record.Tools = append(record.Tools, t.Name)
+	}
+	j.write(record)
+}
+
+func (j *jsonOutput) Summary(cfg ui.SummaryConfig) {
+	record := jsonSummary{
+		Type:       "summary",
+		Agent:      cfg.Agent,
+		Iterations: cfg.Iterations,
+		Commits:    cfg.Commits,
+		DurationMS: cfg.Duration.Milliseconds(),
+		ExitCode:   int(cfg.ExitCode),
+		ExitReason: cfg.ExitReason,
+		Errors:     cfg.Errors,
+		LastError:  cfg.LastError,
+	}
+	if record.ExitReason == "" {
+		record.ExitReason = jsonExitReasons[ExitCode(cfg.ExitCode)]
+	}
+	if cfg.HasUpstream {
+		record.Ahead, record.Behind = &cfg.Ahead, &cfg.Behind
+	}
+	j.write(record)
+}
+
+// CommandOutput sends verify output to stderr so stdout stays parseable
+func (j *jsonOutput) CommandOutput() io.Writer {
+	return os.Stderr
+}
@@ -0,0 +1,172 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adriancodes/gumloop/internal/adapter"
+	"github.com/adriancodes/gumloop/internal/config"
+	"github.com/adriancodes/gumloop/internal/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSampleRun sends one iteration and a summary through out
+func writeSampleRun(out Output) {
+	iter := ui.IterationConfig{Number: 1, MaxIteration: 5, Timestamp: time.Now(), CLI: "Claude Code"}
+	out.IterationStart(iter)
+	out.Event(adapter.ToolUse{Name: "Edit", Input: "main.go"})
+	out.Event(adapter.AssistantMessage{Text: "Fixed the bug"})
+	out.Event(adapter.Error{Message: "tool timed out"})
+	out.Notice(IconPush, "Pushing to origin/main...")
+
+	iter.ToolCalls = []ui.ToolCall{{Name: "Edit", Extra: "main.go"}}
+	iter.Commits = 1
+	iter.Pushed = true
+	out.IterationEnd(iter)
+
+	out.Summary(ui.SummaryConfig{Agent: "Claude Code", Iterations: 1, Commits: 1, ExitCode: ui.ExitMaxIterations})
+}
+
+func TestNewOutput_Invalid(t *testing.T) {
+	_, err := NewOutput("yaml", &bytes.Buffer{}, 0)
+	assert.ErrorContains(t, err, "invalid output format 'yaml'")
+}
+
+func TestOutput_Human(t *testing.T) {
+	var buf bytes.Buffer
+	out, err := NewOutput(FormatHuman, &buf, 0)
+	require.NoError(t, err)
+	writeSampleRun(out)
+
+	text := buf.String()
+	assert.Contains(t, text, "ITERATION 1 of 5")
+	assert.Contains(t, text, "🔧 Edit")
+	assert.Contains(t, text, "Fixed the bug")
+	assert.Contains(t, text, "⚠️  tool timed out")
+	assert.Contains(t, text, "☁️  Pushing to origin/main")
+	assert.Contains(t, text, "RUN COMPLETE")
+	assert.NotContains(t, text, `"type"`)
+}
+
+func TestOutput_HumanNotice(t *testing.T) {
+	var buf bytes.Buffer
+	out, err := NewOutput(FormatHuman, &buf, 0)
+	require.NoError(t, err)
+
+	out.Notice(IconDone, "Agent signalled the task is complete")
+	out.Notice(IconWarning, "Stopped: found STOP")
+	out.Notice("", "plain")
+	assert.Equal(t, "🏁 Agent signalled the task is complete\n\n⚠️  Stopped: found STOP\nplain\n", buf.String())
+}
+
+func TestOutput_HumanASCII(t *testing.T) {
+	ui.SetASCII(true)
+	t.Cleanup(func() { ui.SetASCII(false) })
+
+	var buf bytes.Buffer
+	out, err := NewOutput(FormatHuman, &buf, 0)
+	require.NoError(t, err)
+
+	out.Event(adapter.ToolUse{Name: "Bash"})
+	out.Event(adapter.Error{Message: "rate limited"})
+	out.Notice(IconDone, "Agent signalled the task is complete")
+	out.Notice(IconWarning, "Stopped: found STOP")
+	assert.Equal(t, "[TOOL] Bash\n[WARN] rate limited\n[DONE] Agent signalled the task is complete\n\n[WARN] Stopped: found STOP\n", buf.String())
+
+	// Every notice icon has a tag
+	for _, icon := range []string{IconInfo, IconWarning, IconError, IconStop, IconDone, IconRetry, IconHint, IconCommit, IconUndo, IconPush, IconWait, IconStage, IconVerify} {
+		assert.NotEmpty(t, asciiIcons[icon], "no ASCII tag for %s", icon)
+	}
+}
+
+func TestOutput_Quiet(t *testing.T) {
+	var buf bytes.Buffer
+	out, err := NewOutput(FormatQuiet, &buf, 0)
+	require.NoError(t, err)
+	writeSampleRun(out)
+
+	text := buf.String()
+	assert.Contains(t, text, "RUN COMPLETE")
+	assert.NotContains(t, text, "ITERATION")
+	assert.NotContains(t, text, "Fixed the bug")
+	assert.NotContains(t, text, "Pushing")
+}
+
+func TestOutput_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	out, err := NewOutput(FormatJSON, &buf, 0)
+	require.NoError(t, err)
+	writeSampleRun(out)
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record), "line %q", line)
+		records = append(records, record)
+	}
+
+	var types []string
+	for _, r := range records {
+		types = append(types, r["type"].(string))
+	}
+	assert.Equal(t, []string{"iteration_start", "tool_use", "message", "error", "notice", "iteration_end", "summary"}, types)
+
+	assert.Equal(t, "main.go", records[1]["input"])
+	assert.Equal(t, "Fixed the bug", records[2]["text"])
+	assert.Equal(t, "Pushing to origin/main...", records[4]["message"], "notices are plain text")
+	assert.Equal(t, float64(1), records[5]["commits"])
+	assert.Equal(t, true, records[5]["pushed"])
+
+	summary := records[6]
+	assert.Equal(t, float64(3), summary["exit_code"])
+	assert.Equal(t, "max_iterations", summary["exit_reason"])
+	assert.NotContains(t, summary, "ahead", "ahead/behind are only set with an upstream")
+}
+
+func TestRun_JSONOutput(t *testing.T) {
+	setupTestRepo(t)
+
+	var buf bytes.Buffer
+	out, err := NewOutput(FormatJSON, &buf, 0)
+	require.NoError(t, err)
+
+	cfg := &config.Config{StuckThreshold: 3}
+	r := New(cfg, "echo working; git commit -q --allow-empty -m work", shellAgent(), false, 0, nil)
+	r.SetOutput(out)
+
+	// Nothing reaches stdout directly
+	stdout := captureStdout(t, func() { r.Run() })
+	assert.Empty(t, stdout)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.NotEmpty(t, lines)
+	for _, line := range lines {
+		assert.True(t, json.Valid([]byte(line)), "line %q", line)
+	}
+	assert.Contains(t, lines[0], `"type":"iteration_start"`)
+	assert.Contains(t, buf.String(), `{"text":"working","type":"message"}`)
+	assert.Contains(t, lines[len(lines)-1], `"type":"iteration_end"`)
+	assert.Contains(t, lines[len(lines)-1], `"commits":1`)
+}
+
+func TestHumanOutput_MaxLineLength(t *testing.T) {
+	var buf bytes.Buffer
+	out, err := NewOutput(FormatHuman, &buf, 10)
+	require.NoError(t, err)
+
+	out.Event(adapter.AssistantMessage{Text: "short\n" + strings.Repeat("x", 40) + "\nlast"})
+	out.Event(adapter.AssistantMessage{Text: "ééééééééééé"})
+
+	assert.Equal(t, "short\nxxxxxxxxxx"+TruncatedSuffix+"\nlast\néééééééééé"+TruncatedSuffix+"\n", buf.String())
+
+	// JSON keeps the full text
+	buf.Reset()
+	out, err = NewOutput(FormatJSON, &buf, 10)
+	require.NoError(t, err)
+	out.Event(adapter.AssistantMessage{Text: strings.Repeat("x", 40)})
+	assert.Contains(t, buf.String(), strings.Repeat("x", 40))
+}
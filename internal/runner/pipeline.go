@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/adriancodes/gumloop/internal/agent"
+)
+
+// Stage is one agent in a pipeline
+type Stage struct {
+	Agent        *agent.Agent
+	Model        string // Empty uses the agent's default (the caller fills in the run's model)
+	PromptSuffix string // Appended to the iteration's prompt for this stage
+}
+
+// SetPipeline makes each iteration run stages one after another in the same
+// working tree, instead of the run's agent. Verification runs once, after
+// the last stage.
+func (r *Runner) SetPipeline(stages []Stage) {
+	r.pipeline = stages
+}
+
+// pipelineNames is the header's agent label for a pipeline
+func (r *Runner) pipelineNames() string {
+	names := make([]string, len(r.pipeline))
+	for i, stage := range r.pipeline {
+		names[i] = stage.Agent.Name
+	}
+	return strings.Join(names, " → ")
+}
+
+// agentLabel names the agent behind the run's changes: the pipeline's
+// stages, or the run's agent
+func (r *Runner) agentLabel() string {
+	if len(r.pipeline) > 0 {
+		return r.pipelineNames()
+	}
+	return r.agent.Name
+}
+
+// creditGumloopCommit records that gumloop itself made the newest commit,
+// so the stages' commits stay lined up with git log in commitAgents
+func (r *Runner) creditGumloopCommit() {
+	if len(r.pipeline) > 0 {
+		r.commitAgents = append([]string{""}, r.commitAgents...)
+	}
+}
+
+// runPipeline runs every stage for one iteration and combines their results.
+// It also returns the name of the stage that made each new commit, most
+// recent first (the order git log lists them). A stage that fails ends the
+// iteration without running the rest.
+func (r *Runner) runPipeline(ctx context.Context, prompt string) (IterationResult, []string, error) {
+	// The iteration is silent only if every stage that ran was
+	combined := IterationResult{NoOutput: true}
+	var commitAgents []string
+
+	for i, stage := range r.pipeline {
+		r.output.Notice(IconStage, fmt.Sprintf("Stage %d of %d: %s", i+1, len(r.pipeline), stage.Agent.Name))
+
+		iter := r.newIteration(stage.Agent, stagePrompt(prompt, stage.PromptSuffix), stage.Model)
+		if i < len(r.pipeline)-1 {
+			iter.Verify = ""
+		}
+		result, err := iter.Run(ctx)
+
+		combined.Duration += result.Duration
+		combined.Commits += result.Commits
+		combined.ToolCalls = append(combined.ToolCalls, result.ToolCalls...)
+		combined.HiddenTools += result.HiddenTools
+		combined.NoOutput = combined.NoOutput && result.NoOutput
+		combined.Modified, combined.Staged, combined.Untracked = result.Modified, result.Staged, result.Untracked
+		combined.Verified, combined.VerifyFailed = result.Verified, result.VerifyFailed
+		combined.VerifyOutput = result.VerifyOutput
+		combined.VerifiedTree, combined.VerifyCached = result.VerifiedTree, result.VerifyCached
+		combined.DoneSignal = result.DoneSignal
+		combined.LastMessage = result.LastMessage
+		if result.RateLimited {
+			combined.RateLimited = true
+			combined.RetryAfter = max(combined.RetryAfter, result.RetryAfter)
+		}
+		for range result.Commits {
+			commitAgents = append([]string{stage.Agent.Name}, commitAgents...)
+		}
+
+		if err != nil {
+			return combined, commitAgents, fmt.Errorf("pipeline stage %d (%s): %w", i+1, stage.Agent.Name, err)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return combined, commitAgents, nil
+}
+
+// stagePrompt appends a stage's prompt suffix to the iteration's prompt
+func stagePrompt(prompt, suffix string) string {
+	if suffix == "" {
+		return prompt
+	}
+	return prompt + "\n\n" + suffix
+}
@@ -0,0 +1,57 @@
+package runner
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// planHeadingPattern matches the "# Plan" heading at any level
+	planHeadingPattern = regexp.MustCompile(`(?i)^#+\s*plan\s*$`)
+
+	// checkboxPattern matches a markdown task list item: "- [ ] task" or "- [x] task"
+	checkboxPattern = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])\]`)
+)
+
+// PlanStatus counts the checkbox items in a prompt's "# Plan" section
+type PlanStatus struct {
+	Done      int
+	Remaining int
+}
+
+// ParsePlan counts checked and unchecked items in the "# Plan" section of a
+// prompt. The section runs until the next heading. Returns false if the
+// prompt has no plan section.
+func ParsePlan(content string) (PlanStatus, bool) {
+	var status PlanStatus
+	found := false
+	inPlan := false
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			if planHeadingPattern.MatchString(trimmed) {
+				found = true
+				inPlan = true
+			} else {
+				inPlan = false
+			}
+			continue
+		}
+		if !inPlan {
+			continue
+		}
+
+		match := checkboxPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if match[1] == " " {
+			status.Remaining++
+		} else {
+			status.Done++
+		}
+	}
+
+	return status, found
+}
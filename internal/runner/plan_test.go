@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePlan(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected PlanStatus
+		found    bool
+	}{
+		{
+			name: "mixed checked and unchecked",
+			content: `# Task
+
+Build the thing.
+
+# Plan
+
+- [x] Set up the project
+- [ ] Add the parser
+* [X] Write docs
+- [ ] Add tests
+  - [ ] Nested item
+
+# Rules
+
+- [ ] Not a plan item
+`,
+			expected: PlanStatus{Done: 2, Remaining: 3},
+			found:    true,
+		},
+		{
+			name:     "all done",
+			content:  "## Plan\n- [x] One\n- [x] Two\n",
+			expected: PlanStatus{Done: 2, Remaining: 0},
+			found:    true,
+		},
+		{
+			name:     "plan section without checkboxes",
+			content:  "# Plan\n\nTrack progress here.\n",
+			expected: PlanStatus{},
+			found:    true,
+		},
+		{
+			name:     "no plan section",
+			content:  "# Task\n- [ ] Looks like a plan item\n",
+			expected: PlanStatus{},
+			found:    false,
+		},
+		{
+			name:     "empty prompt",
+			content:  "",
+			expected: PlanStatus{},
+			found:    false,
+		},
+		{
+			name:     "heading that only contains the word plan",
+			content:  "# Planning notes\n- [ ] Not counted\n",
+			expected: PlanStatus{},
+			found:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, found := ParsePlan(tt.content)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.expected, status)
+		})
+	}
+}
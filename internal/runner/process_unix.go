@@ -0,0 +1,45 @@
+//go:build !windows
+
+package runner
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// killGracePeriod is how long a cancelled agent gets to exit after SIGTERM
+// before its process group is sent SIGKILL
+var killGracePeriod = 3 * time.Second
+
+// startInProcessGroup starts cmd in its own process group so the agent and
+// anything it spawns (test runners, dev servers) can be signalled together.
+// When ctx is cancelled the group gets SIGTERM, then SIGKILL after
+// killGracePeriod. The returned channel must be closed once cmd.Wait returns.
+func startInProcessGroup(ctx context.Context, cmd *exec.Cmd) (exited chan struct{}, err error) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	exited = make(chan struct{})
+	pgid := cmd.Process.Pid
+	go func() {
+		select {
+		case <-exited:
+			return
+		case <-ctx.Done():
+		}
+
+		syscall.Kill(-pgid, syscall.SIGTERM)
+		select {
+		case <-exited:
+		case <-time.After(killGracePeriod):
+		}
+		// Also catches children that outlived the agent itself
+		syscall.Kill(-pgid, syscall.SIGKILL)
+	}()
+
+	return exited, nil
+}
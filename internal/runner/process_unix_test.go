@@ -0,0 +1,56 @@
+//go:build !windows
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// processGone reports whether pid has exited (a zombie awaiting reaping counts as gone)
+func processGone(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil {
+		return true
+	}
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	return err == nil && strings.Contains(string(stat), ") Z ")
+}
+
+func TestIteration_CancelKillsProcessGroup(t *testing.T) {
+	setupTestRepo(t)
+
+	// The agent starts a long-running child, records its pid, and waits
+	script := "sleep 60 & echo $! > .git/child; wait"
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for {
+			if _, err := os.Stat(".git/child"); err == nil {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	start := time.Now()
+	_, err := (&Iteration{Agent: shellAgent(), Prompt: script, Autonomous: true}).Run(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 10*time.Second)
+
+	data, readErr := os.ReadFile(".git/child")
+	require.NoError(t, readErr)
+	pid, convErr := strconv.Atoi(strings.TrimSpace(string(data)))
+	require.NoError(t, convErr)
+
+	assert.Eventually(t, func() bool { return processGone(pid) }, 5*time.Second, 20*time.Millisecond,
+		"child process %d should be terminated", pid)
+}
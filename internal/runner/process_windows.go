@@ -0,0 +1,37 @@
+//go:build windows
+
+package runner
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+)
+
+// startInProcessGroup starts cmd so the agent and anything it spawns can be
+// stopped together. Windows has no process groups to signal, so when ctx is
+// cancelled the whole process tree is killed with taskkill, falling back to
+// killing just the agent. The returned channel must be closed once cmd.Wait
+// returns.
+func startInProcessGroup(ctx context.Context, cmd *exec.Cmd) (exited chan struct{}, err error) {
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	exited = make(chan struct{})
+	pid := cmd.Process.Pid
+	go func() {
+		select {
+		case <-exited:
+			return
+		case <-ctx.Done():
+		}
+
+		kill := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(pid))
+		if err := kill.Run(); err != nil {
+			cmd.Process.Kill()
+		}
+	}()
+
+	return exited, nil
+}
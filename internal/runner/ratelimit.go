@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// rateLimitPattern matches the ways agents report being rate limited
+	rateLimitPattern = regexp.MustCompile(`(?i)\b429\b|rate[ _-]?limit|too many requests|quota exceeded`)
+
+	// retryAfterPattern captures a suggested wait: "retry after 30s",
+	// "Retry-After: 30", "try again in 2 minutes"
+	retryAfterPattern = regexp.MustCompile(`(?i)(?:retry[ -]after|try again in)\W{0,3}(\d+)\s*(ms|milliseconds?|s|secs?|seconds?|m|mins?|minutes?)?\b`)
+)
+
+// rateLimitDelay reports whether an error message is a rate limit, and the
+// wait it suggests (0 if none)
+func rateLimitDelay(message string) (bool, time.Duration) {
+	if !rateLimitPattern.MatchString(message) {
+		return false, 0
+	}
+
+	match := retryAfterPattern.FindStringSubmatch(message)
+	if match == nil {
+		return true, 0
+	}
+	n, err := strconv.Atoi(match[1])
+	if err != nil {
+		return true, 0
+	}
+
+	unit := strings.ToLower(match[2])
+	switch {
+	case strings.HasPrefix(unit, "ms"), strings.HasPrefix(unit, "milli"):
+		return true, time.Duration(n) * time.Millisecond
+	case strings.HasPrefix(unit, "m"):
+		return true, time.Duration(n) * time.Minute
+	default:
+		// Seconds, which is also what a bare Retry-After value means
+		return true, time.Duration(n) * time.Second
+	}
+}
+
+// rateLimitSleep waits for d or until ctx is cancelled (a var so tests
+// don't actually wait)
+var rateLimitSleep = func(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adriancodes/gumloop/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitDelay(t *testing.T) {
+	tests := []struct {
+		message string
+		limited bool
+		wait    time.Duration
+	}{
+		{"429 Too Many Requests", true, 0},
+		{"Rate limit reached, retry after 30s", true, 30 * time.Second},
+		{"rate_limit_error: Retry-After: 12", true, 12 * time.Second},
+		{"Too many requests. Please try again in 2 minutes.", true, 2 * time.Minute},
+		{"rate limited (retry after 500ms)", true, 500 * time.Millisecond},
+		{"connection reset by peer", false, 0},
+		{"wrote 4290 lines", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			limited, wait := rateLimitDelay(tt.message)
+			assert.Equal(t, tt.limited, limited)
+			assert.Equal(t, tt.wait, wait)
+		})
+	}
+}
+
+// stubRateLimitSleep records the waits the runner asks for instead of sleeping
+func stubRateLimitSleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var waits []time.Duration
+	orig := rateLimitSleep
+	rateLimitSleep = func(ctx context.Context, d time.Duration) { waits = append(waits, d) }
+	t.Cleanup(func() { rateLimitSleep = orig })
+	return &waits
+}
+
+func TestRun_RateLimitWait(t *testing.T) {
+	setupTestRepo(t)
+	waits := stubRateLimitSleep(t)
+
+	// The codex adapter turns {"error": ...} lines into error events
+	ag := shellAgent()
+	ag.ID = "codex"
+
+	t.Run("retry after", func(t *testing.T) {
+		*waits = nil
+		cfg := &config.Config{StuckThreshold: 3, MaxNoChange: 1, RateLimitWait: 60}
+		script := `echo '{"error": "429 Too Many Requests, retry after 7s"}'`
+		r := New(cfg, script, ag, true, 2, nil)
+
+		captureStdout(t, func() { r.Run() })
+		// No wait after the last iteration
+		assert.Equal(t, []time.Duration{7 * time.Second}, *waits)
+		assert.Equal(t, 2, r.GetMetrics().Iterations)
+		assert.Contains(t, r.exitCondition, "max iterations")
+	})
+
+	t.Run("configured wait", func(t *testing.T) {
+		*waits = nil
+		cfg := &config.Config{StuckThreshold: 3, MaxNoChange: 1, RateLimitWait: 45}
+		r := New(cfg, `echo '{"error": "rate limit exceeded"}'`, ag, true, 2, nil)
+
+		captureStdout(t, func() { r.Run() })
+		assert.Equal(t, []time.Duration{45 * time.Second}, *waits)
+	})
+
+	t.Run("unset wait uses the default", func(t *testing.T) {
+		*waits = nil
+		cfg := &config.Config{StuckThreshold: 3, MaxNoChange: 1}
+		r := New(cfg, `echo '{"error": "rate limit exceeded"}'`, ag, true, 2, nil)
+
+		captureStdout(t, func() { r.Run() })
+		assert.Equal(t, []time.Duration{60 * time.Second}, *waits)
+	})
+
+	t.Run("no wait after the last iteration", func(t *testing.T) {
+		*waits = nil
+		cfg := &config.Config{StuckThreshold: 3, MaxNoChange: 1, RateLimitWait: 45}
+		r := New(cfg, `echo '{"error": "rate limit exceeded"}'`, ag, true, 1, nil)
+
+		var exitCode ExitCode
+		captureStdout(t, func() { exitCode = r.Run() })
+		assert.Empty(t, *waits)
+		assert.Equal(t, ExitMaxIterations, exitCode)
+	})
+
+	t.Run("other errors don't wait", func(t *testing.T) {
+		*waits = nil
+		cfg := &config.Config{StuckThreshold: 3, MaxNoChange: 1, RateLimitWait: 45}
+		r := New(cfg, `echo '{"error": "model not found"}'`, ag, true, 3, nil)
+
+		captureStdout(t, func() { r.Run() })
+		assert.Empty(t, *waits)
+		assert.Equal(t, 1, r.GetMetrics().Iterations)
+	})
+}
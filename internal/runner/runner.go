@@ -2,16 +2,21 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"regexp"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/adriancodes/gumloop/internal/agent"
 	"github.com/adriancodes/gumloop/internal/config"
 	"github.com/adriancodes/gumloop/internal/git"
+	"github.com/adriancodes/gumloop/internal/logging"
 	"github.com/adriancodes/gumloop/internal/memory"
+	"github.com/adriancodes/gumloop/internal/ui"
 )
 
 // ExitCode represents the exit code returned by the runner
@@ -39,21 +44,110 @@ const (
 
 // Runner manages the execution loop
 type Runner struct {
-	config  *config.Config
-	prompt  string
-	agent   *agent.Agent
-	maxIters int  // 0 means unlimited (loop until complete)
+	config    *config.Config
+	prompt    string
+	agent     *agent.Agent
+	maxIters  int  // 0 means unlimited (loop until complete)
 	singleRun bool // true if not in choo-choo mode
-	metrics *Metrics
-	memory  *memory.SessionMemory // nil if memory disabled
+	metrics   *Metrics
+	memory    *memory.SessionMemory // nil if memory disabled
+	output    Output
+
+	// How the agent and verify commands run, and the git access the
+	// iteration and push use (replaced in tests)
+	commands CommandRunner
+	repo     Repo
+
+	// For --watch-prompt: re-read promptFile before each iteration
+	promptFile     string
+	promptPreamble string
+
+	// loopPrompt replaces prompt after the first iteration (loop_prompt),
+	// except once after the watched prompt file changes
+	loopPrompt    string
+	promptChanged bool
+
+	// raw passes agent output through unparsed (--no-adapter)
+	raw bool
+
+	// planFile is parsed for "# Plan" checkboxes shown in the iteration header
+	planFile string
+
+	// doneSignal ends the loop as complete when the agent prints a match (nil if unset)
+	doneSignal *regexp.Regexp
+
+	// commitPattern is checked against each new commit's message (nil if unset);
+	// with strictCommits, an iteration's commits are undone on a mismatch
+	commitPattern *regexp.Regexp
+	strictCommits bool
+
+	// planOnly asks the agent for a plan instead of changes, and never
+	// commits or pushes on its behalf
+	planOnly bool
+
+	// pipeline replaces agent with several agents run in turn each
+	// iteration (nil for a single agent)
+	pipeline []Stage
+
+	// commitAgents names the agent behind each of the latest iteration's
+	// commits, most recent first (only set for a pipeline)
+	commitAgents []string
+
+	// maxFileChanges stops the run when an iteration changes more files
+	// than this (0 for no limit)
+	maxFileChanges int
+
+	// commitInterval commits uncommitted changes once they've been left
+	// for this many iterations in a row (0 for never); dirtyIterations
+	// counts them
+	commitInterval  int
+	dirtyIterations int
+
+	// terseIterations shows one-line iteration headers, and one-line
+	// summaries for iterations that changed nothing
+	terseIterations bool
+
+	// stopFile ends the loop when it exists at the top of an iteration
+	// (empty for none)
+	stopFile string
+
+	// retryVerify feeds failed verify output back to the agent; it is kept
+	// in verifyFeedback for the next iteration's prompt
+	retryVerify    bool
+	verifyFeedback string
+
+	// The working tree hash verification last passed on; verify is skipped
+	// while the tree still matches it
+	verifiedTree string
+
+	// Where HEAD was when the session started, so commits that land on the
+	// branch from elsewhere aren't credited to the agent
+	startCommitCount int
+	startHead        string
+
+	// Set once the repo turns out to have no remote, so push is skipped quietly
+	noRemote bool
 
 	// For stuck detection
 	iterationsWithoutCommit int
+
+	// For completion detection (consecutive iterations with no changes)
+	iterationsWithoutChange int
+
+	// For --explain: the last iteration's results and the condition that ended the loop
+	lastHasChanges  bool
+	lastCommitsMade int
+
+	// The latest iteration's last agent message, saved as the memory's
+	// Remaining note for the next session
+	lastMessage   string
+	wipCommit     string // Short hash of the commit_on_interrupt commit
+	exitCondition string
 }
 
 // New creates a new Runner instance
 func New(cfg *config.Config, prompt string, ag *agent.Agent, chooChoo bool, maxIters int, mem *memory.SessionMemory) *Runner {
-	return &Runner{
+	r := &Runner{
 		config:    cfg,
 		prompt:    prompt,
 		agent:     ag,
@@ -61,7 +155,108 @@ func New(cfg *config.Config, prompt string, ag *agent.Agent, chooChoo bool, maxI
 		singleRun: !chooChoo,
 		metrics:   NewMetrics(),
 		memory:    mem,
+		output:    &humanOutput{},
+		commands:  execRunner{},
+		repo:      gitRepo{pushArgs: cfg.PushArgs},
 	}
+	// Errors (not a repo, no commits yet) leave the session starting from nothing
+	r.startCommitCount, _ = r.repo.CountCommits()
+	r.startHead, _ = r.repo.ResolveRef("HEAD")
+	// done_signal is validated with the rest of the run config
+	if cfg.DoneSignal != "" {
+		r.doneSignal, _ = regexp.Compile(cfg.DoneSignal)
+	}
+	if cfg.CommitMessagePattern != "" {
+		r.commitPattern, _ = regexp.Compile(cfg.CommitMessagePattern)
+	}
+	return r
+}
+
+// WatchPromptFile makes the runner re-read path at the start of every
+// iteration after the first, so edits take effect without restarting.
+// preamble (e.g. session memory context) is kept in front of the file contents.
+func (r *Runner) WatchPromptFile(path, preamble string) {
+	r.promptFile = path
+	r.promptPreamble = preamble
+}
+
+// UseLoopPrompt sends prompt instead of the task prompt for every
+// iteration after the first (loop_prompt). With WatchPromptFile, the
+// iteration after the file changes gets the new task prompt instead.
+func (r *Runner) UseLoopPrompt(prompt string) {
+	r.loopPrompt = prompt
+}
+
+// NoAdapter passes the agent's output straight through instead of parsing
+// it into events. Commits, changes and the loop work as usual, but tool
+// calls, done_signal and rate limits can't be detected.
+func (r *Runner) NoAdapter() {
+	r.raw = true
+}
+
+// StrictCommits undoes an iteration's commits (keeping their changes staged)
+// when any of their messages doesn't match commit_message_pattern
+func (r *Runner) StrictCommits() {
+	r.strictCommits = true
+}
+
+// RetryFailedVerify appends the output of a failed verify command to the
+// next iteration's prompt, so the agent can fix what it reports
+func (r *Runner) RetryFailedVerify() {
+	r.retryVerify = true
+}
+
+// SetOutput sends the run's output to out instead of the default human
+// output on stdout
+func (r *Runner) SetOutput(out Output) {
+	r.output = out
+}
+
+// planOnlyInstruction is appended to the prompt in plan-only mode
+const planOnlyInstruction = "Don't change any files or make any commits yet. " +
+	"Reply with a step-by-step plan for the task above, then stop."
+
+// PlanOnly makes the run a single iteration that asks the agent for a plan.
+// Nothing is committed or pushed, and any changes the agent makes anyway
+// are reported as a warning.
+func (r *Runner) PlanOnly() {
+	r.planOnly = true
+	r.singleRun = true
+}
+
+// LimitFileChanges stops the run with ExitSafety when an iteration changes
+// more than n files, counting those in its commits and those it leaves
+// modified, staged or untracked. It's checked as soon as the agent exits,
+// before commit_if_dirty or --commit-interval commit anything or a push.
+func (r *Runner) LimitFileChanges(n int) {
+	r.maxFileChanges = n
+}
+
+// CommitEvery commits whatever the agent leaves uncommitted once the tree
+// has ended n iterations in a row dirty, so a bad iteration can only take
+// the last n iterations' work with it
+func (r *Runner) CommitEvery(n int) {
+	r.commitInterval = n
+}
+
+// HeaderOnlyOnChange shortens each iteration's header to one line, and its
+// summary too unless it made commits or changes (or failed verification),
+// to cut log volume from exploratory iterations
+func (r *Runner) HeaderOnlyOnChange() {
+	r.terseIterations = true
+}
+
+// StopOnFile ends the loop with ExitInterrupt when path exists at the top
+// of an iteration, so an orchestrator can stop it without a signal.
+// The file is removed so the next run starts normally.
+func (r *Runner) StopOnFile(path string) {
+	r.stopFile = path
+}
+
+// TrackPlan shows the number of unchecked "# Plan" items in path in each
+// iteration header.
+func (r *Runner) TrackPlan(path string) {
+	r.planFile = path
 }
 
 // Run executes the main loop and returns the exit code
@@ -75,96 +270,258 @@ func (r *Runner) Run() ExitCode {
 
 	go func() {
 		<-sigChan
-		fmt.Println("\n⚠️  Interrupted by user")
+		r.output.Notice(IconWarning, "Interrupted by user")
 		cancel()
 	}()
 
+	// Keep the previous session's memory for gumloop memory diff
+	if r.memory != nil {
+		prevPath, err := git.GitPath(memory.PrevFileName)
+		if err == nil {
+			err = memory.Rotate(memory.DefaultFileName, prevPath)
+		}
+		if err != nil {
+			logging.Warnf("Warning: failed to keep previous session memory: %v", err)
+		}
+	}
+
 	// Main loop
 	for {
 		// Check if context was cancelled (Ctrl+C)
 		select {
 		case <-ctx.Done():
+			r.commitInterrupted()
+			r.exitCondition = "interrupted (Ctrl+C or SIGTERM received)"
 			r.metrics.ExitReason = ExitReasonString(ExitInterrupt)
 			r.saveMemory(ExitInterrupt)
 			return ExitInterrupt
 		default:
 		}
 
+		// Check if something outside asked us to stop
+		if r.stopRequested() {
+			r.output.Notice(IconWarning, fmt.Sprintf("Stopped: found %s", r.stopFile))
+			r.commitInterrupted()
+			r.exitCondition = fmt.Sprintf("interrupted (stop file %s found)", r.stopFile)
+			r.metrics.ExitReason = ExitReasonString(ExitInterrupt)
+			r.saveMemory(ExitInterrupt)
+			return ExitInterrupt
+		}
+
 		// Check if we've reached max iterations
 		if r.maxIters > 0 && r.metrics.Iterations >= r.maxIters {
+			r.exitCondition = fmt.Sprintf("max iterations reached (%d of %d)", r.metrics.Iterations, r.maxIters)
 			r.metrics.ExitReason = ExitReasonString(ExitMaxIterations)
 			r.saveMemory(ExitMaxIterations)
 			return ExitMaxIterations
 		}
 
+		// Pick up prompt edits between iterations
+		if r.promptFile != "" && r.metrics.Iterations > 0 {
+			r.reloadPrompt()
+		}
+
 		// Increment iteration counter
 		r.metrics.Iterations++
 
 		// Display iteration header
-		if r.maxIters > 0 {
-			fmt.Printf("\n══════════════════════════════════════\n")
-			fmt.Printf("  🚂 ITERATION %d of %d\n", r.metrics.Iterations, r.maxIters)
-			fmt.Printf("  %s | %s\n", time.Now().Format("15:04:05"), r.agent.Name)
-			fmt.Printf("══════════════════════════════════════\n\n")
+		iterCfg := ui.IterationConfig{
+			Number:       r.metrics.Iterations,
+			MaxIteration: r.maxIters,
+			Timestamp:    time.Now(),
+			CLI:          r.agent.Name,
+			Status:       r.planStatus(),
+
+			TerseIfUnchanged: r.terseIterations,
+		}
+		if len(r.pipeline) > 0 {
+			iterCfg.CLI = r.pipelineNames()
+		}
+		r.output.IterationStart(iterCfg)
+
+		// Run the iteration (every stage of it, for a pipeline)
+		iterStart, _ := r.repo.ResolveRef("HEAD")
+		var result IterationResult
+		var err error
+		if len(r.pipeline) > 0 {
+			result, r.commitAgents, err = r.runPipeline(ctx, r.iterationPrompt())
 		} else {
-			fmt.Printf("\n══════════════════════════════════════\n")
-			fmt.Printf("  🚂 ITERATION %d\n", r.metrics.Iterations)
-			fmt.Printf("  %s | %s\n", time.Now().Format("15:04:05"), r.agent.Name)
-			fmt.Printf("══════════════════════════════════════\n\n")
+			result, err = r.newIteration(r.agent, r.iterationPrompt(), r.config.Model).Run(ctx)
+		}
+		commitsMade := result.Commits
+		r.lastMessage = result.LastMessage
+		if result.VerifiedTree != "" {
+			r.verifiedTree = result.VerifiedTree
 		}
 
-		// Run the iteration
-		commitsMade, err := RunIteration(
-			r.agent,
-			r.prompt,
-			r.config.Model,
-			r.config.Verify,
-			!r.singleRun, // autonomous mode = choo-choo mode
-		)
+		// Ctrl+C stopped the agent mid-iteration: keep what it committed,
+		// then let the check at the top of the loop exit
+		if ctx.Err() != nil {
+			if commitsMade > 0 {
+				commitsMade = r.scopeToSession(commitsMade)
+				r.metrics.Commits += commitsMade
+				r.recordMemory(commitsMade)
+			}
+			continue
+		}
+
+		// An agent that can't even start would fail the same way every iteration
+		var startupErr *AgentStartupError
+		if errors.As(err, &startupErr) {
+			r.output.Notice(IconError, err.Error())
+			r.metrics.RecordError(err)
+			r.exitCondition = "agent exited non-zero immediately with no output"
+			r.metrics.ExitReason = ExitReasonString(ExitError)
+			r.saveMemory(ExitError)
+			return ExitError
+		}
 
 		if err != nil {
-			fmt.Printf("⚠️  Iteration error: %v\n", err)
+			logging.Warnf("Iteration error: %v", err)
+			r.metrics.RecordError(err)
 			// Continue to next iteration on error (don't fail the whole loop)
 		}
 
+		// Show the agent what failed verification in the next iteration
+		if r.retryVerify {
+			r.verifyFeedback = ""
+			if result.VerifyFailed {
+				r.verifyFeedback = verifyFeedback(r.config.Verify, result.VerifyOutput)
+			}
+		}
+
+		if commitsMade > 0 {
+			commitsMade = r.scopeToSession(commitsMade)
+		}
+
+		// Guardrail: a runaway agent rewriting the repo stops the run, before
+		// anything it left is committed or pushed
+		if r.maxFileChanges > 0 {
+			if changed := r.filesChangedSince(iterStart, result); changed > r.maxFileChanges {
+				r.metrics.Commits += commitsMade
+				r.recordMemory(commitsMade)
+				result.Commits = commitsMade
+				fillSummary(&iterCfg, result)
+				r.output.IterationEnd(iterCfg)
+
+				r.output.Notice(IconStop, fmt.Sprintf("%d files changed, more than --max-file-changes %d. Stopping so you can review them.", changed, r.maxFileChanges))
+				r.lastCommitsMade = commitsMade
+				r.exitCondition = fmt.Sprintf("safety: %d changed files exceeded --max-file-changes %d", changed, r.maxFileChanges)
+				r.metrics.ExitReason = ExitReasonString(ExitSafety)
+				r.saveMemory(ExitSafety)
+				return ExitSafety
+			}
+		}
+
+		// Check the agent's commit messages against commit_message_pattern
+		rejected := false
+		if commitsMade > 0 && r.commitPattern != nil {
+			commitsMade, rejected = r.checkCommitMessages(commitsMade)
+			if rejected {
+				result.Modified, result.Staged, result.Untracked, _ = r.repo.GetChangedFiles()
+			}
+		}
+
+		// Commit what the agent left behind, but only if the iteration
+		// (including verification) succeeded
+		if err == nil && commitsMade == 0 && !rejected && r.config.CommitIfDirty && !r.planOnly {
+			commitsMade = r.commitLeftovers()
+			if commitsMade > 0 {
+				result.Modified, result.Staged, result.Untracked, _ = r.repo.GetChangedFiles()
+			}
+		}
+
+		// Checkpoint changes that have piled up over --commit-interval iterations
+		if r.commitInterval > 0 && !rejected && !r.planOnly {
+			if checkpoint := r.commitAtInterval(); checkpoint > 0 {
+				commitsMade += checkpoint
+				result.Modified, result.Staged, result.Untracked, _ = r.repo.GetChangedFiles()
+			}
+		}
+
+		// Tag this iteration's commits before they're recorded and pushed
+		if commitsMade > 0 && r.config.CommitTrailer && !r.planOnly {
+			r.addTrailers(commitsMade)
+		}
+
 		r.metrics.Commits += commitsMade
 
 		// Update session memory with iteration results
 		r.recordMemory(commitsMade)
 
-		// Push if commits were made and auto_push is enabled
-		if commitsMade > 0 && r.config.AutoPush {
-			branch, err := git.GetBranch()
-			if err != nil {
-				fmt.Printf("⚠️  Warning: failed to get branch name: %v\n", err)
-			} else {
-				fmt.Printf("☁️  Pushing to origin/%s...\n", branch)
-				if err := git.Push(branch); err != nil {
-					fmt.Printf("⚠️  Push failed: %v. Continuing without push.\n", err)
-				} else {
-					fmt.Printf("✅ Pushed to origin/%s\n", branch)
-				}
+		// A plan shouldn't come with changes; leave any for the user to review
+		if r.planOnly {
+			if changed := result.Modified + result.Staged + result.Untracked; commitsMade > 0 || changed > 0 {
+				logging.Warnf("--plan-only: the agent made %d commit(s) and left %d changed file(s). Nothing was pushed; review them before continuing.", commitsMade, changed)
 			}
 		}
 
-		// Check for changes
-		hasChanges, err := git.HasChanges()
-		if err != nil {
-			fmt.Printf("⚠️  Warning: failed to check for changes: %v\n", err)
-			hasChanges = false
+		// Push if commits were made and auto_push is enabled
+		if commitsMade > 0 && !r.planOnly {
+			r.push(&result)
 		}
 
-		// Exit condition: no changes (complete)
-		if !hasChanges && commitsMade == 0 {
+		// Display iteration summary
+		result.Commits = commitsMade
+		fillSummary(&iterCfg, result)
+		r.output.IterationEnd(iterCfg)
+
+		// Exit condition: the agent said it's done (and verification, if any, passed)
+		if result.DoneSignal && err == nil {
+			r.output.Notice(IconDone, "Agent signalled the task is complete")
+			r.lastCommitsMade = commitsMade
+			r.exitCondition = fmt.Sprintf("complete: agent output matched done_signal %q", r.config.DoneSignal)
 			r.metrics.ExitReason = ExitReasonString(ExitSuccess)
 			r.saveMemory(ExitSuccess)
 			return ExitSuccess
 		}
 
-		// Stuck detection: changes but no commits
-		if hasChanges && commitsMade == 0 {
+		// A rate limited iteration says nothing about progress: wait it out
+		// and try again without counting it toward completion or stuck
+		if result.RateLimited && commitsMade == 0 && !r.singleRun {
+			r.waitForRateLimit(ctx, result.RetryAfter)
+			continue
+		}
+
+		// Check for changes
+		hasChanges, err := r.repo.HasChanges()
+		if err != nil {
+			logging.Warnf("Warning: failed to check for changes: %v", err)
+			hasChanges = false
+		}
+		r.lastHasChanges = hasChanges
+		r.lastCommitsMade = commitsMade
+
+		// An agent that said nothing did nothing: that's a problem to count
+		// toward stuck detection, not a sign the work is complete
+		silent := result.NoOutput && commitsMade == 0
+
+		// Exit condition: no changes for max_no_change iterations in a row (complete)
+		if !hasChanges && commitsMade == 0 && !silent {
+			r.iterationsWithoutChange++
+			if r.iterationsWithoutChange >= r.maxNoChange() {
+				r.exitCondition = fmt.Sprintf("complete: no changes and no commits for %d consecutive iteration(s) (max_no_change %d)",
+					r.iterationsWithoutChange, r.maxNoChange())
+				r.metrics.ExitReason = ExitReasonString(ExitSuccess)
+				r.saveMemory(ExitSuccess)
+				return ExitSuccess
+			}
+		} else {
+			r.iterationsWithoutChange = 0
+		}
+
+		// Stuck detection: changes but no commits (not counted during
+		// commit_grace_iterations, and never tripping with stuck_disabled)
+		inGrace := r.metrics.Iterations <= r.config.CommitGraceIterations
+		if (hasChanges || silent) && commitsMade == 0 && !inGrace {
 			r.iterationsWithoutCommit++
-			if r.iterationsWithoutCommit >= r.config.StuckThreshold {
+			if !r.config.StuckDisabled && r.iterationsWithoutCommit >= r.config.StuckThreshold {
+				what := "changes but"
+				if !hasChanges {
+					what = "no agent output and"
+				}
+				r.exitCondition = fmt.Sprintf("stuck: %s no commits for %d iteration(s) (stuck_threshold %d)",
+					what, r.iterationsWithoutCommit, r.config.StuckThreshold)
 				r.metrics.ExitReason = ExitReasonString(ExitStuck)
 				r.saveMemory(ExitStuck)
 				return ExitStuck
@@ -176,6 +533,7 @@ func (r *Runner) Run() ExitCode {
 
 		// Exit after first iteration if single-run mode
 		if r.singleRun {
+			r.exitCondition = "single run (no --choo-choo), stopped after one iteration"
 			r.metrics.ExitReason = ExitReasonString(ExitSuccess)
 			r.saveMemory(ExitSuccess)
 			return ExitSuccess
@@ -185,6 +543,369 @@ func (r *Runner) Run() ExitCode {
 	}
 }
 
+// iterationPrompt returns the prompt for the next iteration: the task
+// prompt, plus the plan-only instruction, or the previous iteration's failed
+// verify output (with RetryFailedVerify) and the stuck hint when one more
+// iteration without a commit would trip stuck detection
+func (r *Runner) iterationPrompt() string {
+	if r.planOnly {
+		return r.prompt + "\n\n" + planOnlyInstruction
+	}
+
+	prompt := r.prompt
+	if r.loopPrompt != "" && r.metrics.Iterations > 1 && !r.promptChanged {
+		prompt = r.loopPrompt
+	}
+	r.promptChanged = false
+	if r.verifyFeedback != "" {
+		r.output.Notice(IconRetry, "Adding the failed verification output to the prompt")
+		prompt += "\n\n" + r.verifyFeedback
+	}
+
+	threshold := r.config.StuckThreshold
+	if r.config.StuckHint == "" || r.config.StuckDisabled || threshold < 2 || r.iterationsWithoutCommit != threshold-1 {
+		return prompt
+	}
+	r.output.Notice(IconHint, "Adding stuck hint to the prompt")
+	return prompt + "\n\n" + r.config.StuckHint
+}
+
+// fillSummary copies an iteration's result into the ui config used to
+// render its summary
+func fillSummary(cfg *ui.IterationConfig, result IterationResult) {
+	cfg.ToolCalls = make([]ui.ToolCall, len(result.ToolCalls))
+	for i, t := range result.ToolCalls {
+		cfg.ToolCalls[i] = ui.ToolCall{Name: t.Name, Extra: t.Input}
+	}
+	cfg.HiddenTools = result.HiddenTools
+	cfg.Duration = result.Duration
+	cfg.Commits = result.Commits
+	cfg.Modified = result.Modified
+	cfg.Staged = result.Staged
+	cfg.Untracked = result.Untracked
+	cfg.Verified = result.Verified
+	cfg.VerifyFailed = result.VerifyFailed
+	cfg.Pushed = result.Pushed
+	cfg.PushFailed = result.PushFailed
+}
+
+// planStatus returns the remaining plan items line of the iteration header.
+// Returns "" if no plan is tracked or the file has no plan section.
+func (r *Runner) planStatus() string {
+	if r.planFile == "" {
+		return ""
+	}
+	content, err := os.ReadFile(r.planFile)
+	if err != nil {
+		return ""
+	}
+	if status, ok := ParsePlan(string(content)); ok {
+		return fmt.Sprintf("📋 %d plan items left", status.Remaining)
+	}
+	return ""
+}
+
+// stopRequested reports whether the stop file exists, removing it so it
+// only stops one run
+func (r *Runner) stopRequested() bool {
+	if r.stopFile == "" {
+		return false
+	}
+	if _, err := os.Stat(r.stopFile); err != nil {
+		return false
+	}
+	if err := os.Remove(r.stopFile); err != nil {
+		logging.Warnf("Warning: failed to remove stop file %s: %v", r.stopFile, err)
+	}
+	return true
+}
+
+// reloadPrompt re-reads the watched prompt file. If the file is missing,
+// unreadable or empty, the previous prompt is kept. A changed prompt is
+// sent in full even when loop_prompt is set.
+func (r *Runner) reloadPrompt() {
+	content, err := os.ReadFile(r.promptFile)
+	if err != nil {
+		logging.Warnf("Warning: failed to re-read prompt file: %v. Using previous prompt.", err)
+		return
+	}
+	if strings.TrimSpace(string(content)) == "" {
+		logging.Warnf("Warning: prompt file %s is empty. Using previous prompt.", r.promptFile)
+		return
+	}
+	if prompt := r.promptPreamble + string(content); prompt != r.prompt {
+		r.prompt = prompt
+		r.promptChanged = true
+	}
+}
+
+// commitLeftovers commits any uncommitted changes for commit_if_dirty.
+// Returns the number of commits made (0 or 1).
+func (r *Runner) commitLeftovers() int {
+	dirty, err := r.repo.HasChanges()
+	if err != nil || !dirty {
+		return 0
+	}
+
+	message := fmt.Sprintf("gumloop: commit changes left by %s (iteration %d)", r.agentLabel(), r.metrics.Iterations)
+	if err := r.repo.CommitAll(message); err != nil {
+		logging.Warnf("Failed to commit leftover changes: %v", err)
+		return 0
+	}
+	r.creditGumloopCommit()
+	r.output.Notice(IconCommit, "Committed changes the agent left uncommitted")
+	return 1
+}
+
+// commitAtInterval counts iterations that end with uncommitted changes and
+// commits them once commitInterval is reached. Returns the number of
+// commits made (0 or 1).
+func (r *Runner) commitAtInterval() int {
+	dirty, err := r.repo.HasChanges()
+	if err != nil || !dirty {
+		r.dirtyIterations = 0
+		return 0
+	}
+	r.dirtyIterations++
+	if r.dirtyIterations < r.commitInterval {
+		return 0
+	}
+	r.dirtyIterations = 0
+
+	message := fmt.Sprintf("gumloop: checkpoint uncommitted changes from %s (iteration %d)", r.agentLabel(), r.metrics.Iterations)
+	if err := r.repo.CommitAll(message); err != nil {
+		logging.Warnf("Failed to commit accumulated changes: %v", err)
+		return 0
+	}
+	r.creditGumloopCommit()
+	r.output.Notice(IconCommit, fmt.Sprintf("Committed changes left uncommitted for %d iteration(s)", r.commitInterval))
+	return 1
+}
+
+// commitInterrupted commits uncommitted changes as a WIP commit for
+// commit_on_interrupt, remembering its hash for the memory note. The commit
+// counts toward the session but isn't pushed.
+func (r *Runner) commitInterrupted() {
+	if !r.config.CommitOnInterrupt || r.planOnly {
+		return
+	}
+	dirty, err := r.repo.HasChanges()
+	if err != nil || !dirty {
+		return
+	}
+
+	message := fmt.Sprintf("WIP (interrupted): gumloop iteration %d with %s", r.metrics.Iterations, r.agentLabel())
+	if err := r.repo.CommitAll(message); err != nil {
+		logging.Warnf("Failed to commit interrupted work: %v", err)
+		return
+	}
+	r.metrics.Commits++
+	r.wipCommit, _ = r.repo.ResolveRef("HEAD")
+	if len(r.wipCommit) > 7 {
+		r.wipCommit = r.wipCommit[:7]
+	}
+	r.output.Notice(IconCommit, fmt.Sprintf("Committed uncommitted work as %s (WIP, not pushed)", r.wipCommit))
+}
+
+// checkCommitMessages warns about new commits whose first line doesn't
+// match commit_message_pattern. In strict mode it undoes all of the
+// iteration's commits, keeping their changes staged, and returns 0 commits
+// and true.
+func (r *Runner) checkCommitMessages(commitsMade int) (int, bool) {
+	commits, err := r.repo.GetRecentCommits(commitsMade)
+	if err != nil {
+		logging.Warnf("Warning: failed to check commit messages: %v", err)
+		return commitsMade, false
+	}
+
+	mismatched := 0
+	for _, c := range commits {
+		if !r.commitPattern.MatchString(c.Message) {
+			mismatched++
+			logging.Warnf("Commit %s doesn't match commit_message_pattern: %q", c.Hash, c.Message)
+		}
+	}
+	if mismatched == 0 || !r.strictCommits {
+		return commitsMade, false
+	}
+
+	if err := r.repo.ResetSoft(fmt.Sprintf("HEAD~%d", commitsMade)); err != nil {
+		logging.Warnf("Failed to undo commits: %v", err)
+		return commitsMade, false
+	}
+	r.output.Notice(IconUndo, fmt.Sprintf("Undid %d commit(s) (--strict-commits); changes are kept staged", commitsMade))
+	return 0, true
+}
+
+// addTrailers adds Gumloop-Iteration and Gumloop-Agent trailers to the
+// iteration's commits. Only commits credited to the session are rewritten.
+func (r *Runner) addTrailers(commitsMade int) {
+	trailers := []string{
+		fmt.Sprintf("Gumloop-Iteration: %d", r.metrics.Iterations),
+		"Gumloop-Agent: " + r.agent.ID,
+	}
+	if err := r.repo.AddTrailers(commitsMade, trailers); err != nil {
+		logging.Warnf("Failed to add commit trailers: %v", err)
+	}
+}
+
+// push pushes the branch after an iteration that made commits, if auto_push
+// is enabled, recording the outcome in result. A missing origin remote turns
+// push off for the rest of the session; detached HEAD and push failures are
+// warnings.
+func (r *Runner) push(result *IterationResult) {
+	if !r.config.AutoPush || r.noRemote {
+		return
+	}
+	if hasRemote, err := r.repo.HasRemote(); err == nil && !hasRemote {
+		r.noRemote = true
+		r.output.Notice(IconInfo, "No origin remote configured, skipping push for this session.")
+		return
+	}
+
+	branch, err := r.repo.GetBranch()
+	if err != nil {
+		logging.Warnf("Warning: failed to get branch name: %v", err)
+		return
+	}
+	if branch == "HEAD" {
+		logging.Warnf("Detached HEAD, skipping push.")
+		return
+	}
+
+	r.output.Notice(IconPush, fmt.Sprintf("Pushing to origin/%s...", branch))
+	if err := r.repo.Push(branch); err != nil {
+		logging.Warnf("Push failed: %v. Continuing without push.", err)
+		result.PushFailed = true
+		return
+	}
+	result.Pushed = true
+}
+
+// waitForRateLimit pauses before the next iteration after a rate limit:
+// for the wait the agent suggested, or rate_limit_wait seconds. There's
+// nothing to wait for when max iterations leaves no next iteration.
+func (r *Runner) waitForRateLimit(ctx context.Context, retryAfter time.Duration) {
+	if r.maxIters > 0 && r.metrics.Iterations >= r.maxIters {
+		return
+	}
+	wait := retryAfter
+	if wait <= 0 {
+		// 0 means rate_limit_wait is unset, as Merge treats it
+		seconds := r.config.RateLimitWait
+		if seconds <= 0 {
+			seconds = config.Defaults().RateLimitWait
+		}
+		wait = time.Duration(seconds) * time.Second
+	}
+	r.output.Notice(IconWait, fmt.Sprintf("Rate limited, waiting %s before the next iteration", wait))
+	rateLimitSleep(ctx, wait)
+}
+
+// Explain returns a short trace of why the loop stopped: the final
+// iteration's results, the counters compared against their thresholds,
+// and the condition in Run that triggered the exit.
+func (r *Runner) Explain() string {
+	var b strings.Builder
+	b.WriteString("Exit explanation:\n")
+	fmt.Fprintf(&b, "  Condition:        %s\n", r.exitCondition)
+	fmt.Fprintf(&b, "  Last iteration:   %d\n", r.metrics.Iterations)
+	fmt.Fprintf(&b, "  Has changes:      %t\n", r.lastHasChanges)
+	fmt.Fprintf(&b, "  Commits made:     %d\n", r.lastCommitsMade)
+	if r.config.StuckDisabled {
+		fmt.Fprintf(&b, "  Without commit:   %d (stuck detection disabled)\n", r.iterationsWithoutCommit)
+	} else {
+		fmt.Fprintf(&b, "  Without commit:   %d of %d (stuck_threshold)\n", r.iterationsWithoutCommit, r.config.StuckThreshold)
+	}
+	fmt.Fprintf(&b, "  Without change:   %d of %d (max_no_change)\n", r.iterationsWithoutChange, r.maxNoChange())
+	if r.config.CommitGraceIterations > 0 {
+		fmt.Fprintf(&b, "  Commit grace:     first %d iteration(s) (commit_grace_iterations)\n", r.config.CommitGraceIterations)
+	}
+	return b.String()
+}
+
+// maxNoChange returns the configured no-change limit, treating unset as 1
+func (r *Runner) maxNoChange() int {
+	if r.config.MaxNoChange < 1 {
+		return 1
+	}
+	return r.config.MaxNoChange
+}
+
+// filesChangedSince counts the files an iteration that started at ref
+// touched: those in its commits plus those it left uncommitted. Without
+// ref (or if git can't tell), only the uncommitted ones in result count.
+func (r *Runner) filesChangedSince(ref string, result IterationResult) int {
+	if ref != "" {
+		if changed, err := r.repo.CountChangedFilesSince(ref); err == nil {
+			return changed
+		}
+	}
+	return result.Modified + result.Staged + result.Untracked
+}
+
+// newIteration returns an iteration of ag with the run's settings
+func (r *Runner) newIteration(ag *agent.Agent, prompt, model string) *Iteration {
+	return &Iteration{
+		Agent:             ag,
+		Prompt:            prompt,
+		Model:             model,
+		Verify:            r.config.Verify,
+		VerifyParallel:    r.config.VerifyParallel,
+		VerifyShell:       r.config.VerifyShell,
+		Autonomous:        !r.singleRun, // autonomous mode = choo-choo mode
+		WorkDir:           r.config.WorkDir,
+		HideTools:         r.config.HideTools,
+		DoneSignal:        r.doneSignal,
+		Raw:               r.raw,
+		VerifyOutputLimit: r.verifyOutputLimit(),
+		VerifiedTree:      r.verifiedTree,
+		Output:            r.output,
+		Commands:          r.commands,
+		Repo:              r.repo,
+	}
+}
+
+// maxVerifyFeedback is how much of a failed verify command's output (its
+// end, where test runners summarize) is fed back to the agent, in bytes
+const maxVerifyFeedback = 8 * 1024
+
+// verifyOutputLimit returns how much verify output iterations should keep
+func (r *Runner) verifyOutputLimit() int {
+	if r.retryVerify {
+		return maxVerifyFeedback
+	}
+	return 0
+}
+
+// scopeToSession caps an iteration's commit count at the commits made since
+// the session started that haven't been credited yet. Commits from other
+// authors pulled in mid-session raise the branch's commit count but predate
+// the session, so they're excluded.
+//
+// With commit_count_source: branch, every new commit on the branch counts.
+func (r *Runner) scopeToSession(commitsMade int) int {
+	if r.config.CommitCountSource == config.CommitCountBranch {
+		return commitsMade
+	}
+	current, err := r.repo.CountCommits()
+	if err != nil {
+		return commitsMade
+	}
+	session := current - r.startCommitCount
+	if recent, err := r.repo.CountCommitsAfter(r.startHead, r.metrics.StartTime); err == nil && recent < session {
+		session = recent
+	}
+
+	if remaining := session - r.metrics.Commits; commitsMade > remaining {
+		commitsMade = remaining
+	}
+	if commitsMade < 0 {
+		commitsMade = 0
+	}
+	return commitsMade
+}
+
 // recordMemory updates the session memory with results from the latest iteration.
 // Silently no-ops if memory is disabled.
 func (r *Runner) recordMemory(commitsMade int) {
@@ -195,13 +916,18 @@ func (r *Runner) recordMemory(commitsMade int) {
 	// Get commit details if commits were made
 	var newCommits []memory.CommitRecord
 	if commitsMade > 0 {
-		gitCommits, err := git.GetRecentCommits(commitsMade)
+		gitCommits, err := r.repo.GetRecentCommits(commitsMade)
 		if err == nil {
-			for _, c := range gitCommits {
-				newCommits = append(newCommits, memory.CommitRecord{
+			for i, c := range gitCommits {
+				record := memory.CommitRecord{
 					Hash:    c.Hash,
 					Message: c.Message,
-				})
+				}
+				// Pipeline commits are credited to the stage that made them
+				if i < len(r.commitAgents) {
+					record.Agent = r.commitAgents[i]
+				}
+				newCommits = append(newCommits, record)
 			}
 		}
 	}
@@ -210,7 +936,7 @@ func (r *Runner) recordMemory(commitsMade int) {
 
 	// Save after each iteration so Ctrl+C doesn't lose state
 	if err := r.memory.Save(memory.DefaultFileName); err != nil {
-		fmt.Printf("⚠️  Warning: failed to save session memory: %v\n", err)
+		logging.Warnf("Warning: failed to save session memory: %v", err)
 	}
 }
 
@@ -221,9 +947,17 @@ func (r *Runner) saveMemory(exitCode ExitCode) {
 		return
 	}
 
-	r.memory.SetExit(ExitReasonString(exitCode))
+	r.memory.SetExit(ExitReasonString(exitCode), int(exitCode))
+	remaining := r.lastMessage
+	if r.wipCommit != "" {
+		// Lead with it so a long last message can't cut it off
+		remaining = strings.TrimSpace(fmt.Sprintf("The run was interrupted; unfinished work was saved in WIP commit %s. Review it and finish or amend it.\n\n%s", r.wipCommit, remaining))
+	}
+	if remaining != "" {
+		r.memory.SetRemaining(remaining)
+	}
 	if err := r.memory.Save(memory.DefaultFileName); err != nil {
-		fmt.Printf("⚠️  Warning: failed to save session memory: %v\n", err)
+		logging.Warnf("Warning: failed to save session memory: %v", err)
 	}
 }
 
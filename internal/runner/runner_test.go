@@ -1,12 +1,24 @@
 package runner
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/adriancodes/gumloop/internal/agent"
 	"github.com/adriancodes/gumloop/internal/config"
+	"github.com/adriancodes/gumloop/internal/git"
+	"github.com/adriancodes/gumloop/internal/logging"
+	"github.com/adriancodes/gumloop/internal/memory"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNew(t *testing.T) {
@@ -95,3 +107,1152 @@ func TestExitCodes(t *testing.T) {
 // Note: Run() method integration tests will be added in CMD-005
 // after iteration execution is implemented. For now, we verify
 // that the runner structure is correct.
+
+func TestRun_StopsWhenAgentFailsImmediately(t *testing.T) {
+	setupTestRepo(t)
+
+	// Leave uncommitted changes so the loop would otherwise keep going
+	require.NoError(t, os.WriteFile("dirty.txt", []byte("wip"), 0644))
+
+	cfg := &config.Config{StuckThreshold: 3}
+	r := New(cfg, "echo 'not authenticated' >&2; exit 1", shellAgent(), true, 5, nil)
+
+	exitCode := r.Run()
+
+	assert.Equal(t, ExitError, exitCode)
+	assert.Equal(t, 1, r.GetMetrics().Iterations)
+	assert.Equal(t, ExitReasonString(ExitError), r.GetMetrics().ExitReason)
+}
+
+// countingScript bumps a counter kept under .git (invisible to git status)
+// and makes an empty commit on the given iteration
+func countingScript(commitOn int) string {
+	return fmt.Sprintf("n=$(cat .git/iter 2>/dev/null || echo 0); n=$((n+1)); echo $n > .git/iter; echo iteration $n; "+
+		"if [ $n -eq %d ]; then git commit -q --allow-empty -m \"iteration $n\"; fi", commitOn)
+}
+
+func TestRun_MaxNoChange(t *testing.T) {
+	t.Run("default exits on first no-change iteration", func(t *testing.T) {
+		setupTestRepo(t)
+		cfg := &config.Config{StuckThreshold: 3}
+		r := New(cfg, "echo nothing to do", shellAgent(), true, 10, nil)
+
+		assert.Equal(t, ExitSuccess, r.Run())
+		assert.Equal(t, 1, r.GetMetrics().Iterations)
+	})
+
+	t.Run("waits for N consecutive no-change iterations", func(t *testing.T) {
+		setupTestRepo(t)
+		cfg := &config.Config{StuckThreshold: 3, MaxNoChange: 3}
+		r := New(cfg, "echo nothing to do", shellAgent(), true, 10, nil)
+
+		assert.Equal(t, ExitSuccess, r.Run())
+		assert.Equal(t, 3, r.GetMetrics().Iterations)
+	})
+
+	t.Run("commit resets the count", func(t *testing.T) {
+		setupTestRepo(t)
+		cfg := &config.Config{StuckThreshold: 3, MaxNoChange: 2}
+		r := New(cfg, countingScript(2), shellAgent(), true, 10, nil)
+
+		// 1: no change, 2: commit (reset), 3-4: no change → exit
+		assert.Equal(t, ExitSuccess, r.Run())
+		assert.Equal(t, 4, r.GetMetrics().Iterations)
+		assert.Equal(t, 1, r.GetMetrics().Commits)
+	})
+
+	t.Run("max iterations still applies", func(t *testing.T) {
+		setupTestRepo(t)
+		cfg := &config.Config{StuckThreshold: 3, MaxNoChange: 5}
+		r := New(cfg, "true", shellAgent(), true, 2, nil)
+
+		assert.Equal(t, ExitMaxIterations, r.Run())
+		assert.Equal(t, 2, r.GetMetrics().Iterations)
+	})
+}
+
+func TestRun_StuckHint(t *testing.T) {
+	setupTestRepo(t)
+
+	// Every iteration leaves a change without committing; the hint records
+	// which iteration it was appended to
+	script := "n=$(cat .git/iter 2>/dev/null || echo 0); n=$((n+1)); echo $n > .git/iter; echo $n > work.txt"
+	cfg := &config.Config{StuckThreshold: 3, MaxNoChange: 10, StuckHint: "echo $n >> .git/hints"}
+	r := New(cfg, script, shellAgent(), true, 10, nil)
+
+	assert.Equal(t, ExitStuck, r.Run())
+	assert.Equal(t, 3, r.GetMetrics().Iterations)
+
+	hints, err := os.ReadFile(".git/hints")
+	require.NoError(t, err)
+	assert.Equal(t, "3\n", string(hints), "hint should be appended on the last iteration before stuck detection trips, only")
+}
+
+func TestRun_StuckDisabled(t *testing.T) {
+	// Every iteration leaves a change without committing
+	script := "n=$(cat .git/iter 2>/dev/null || echo 0); n=$((n+1)); echo $n > .git/iter; echo $n > work.txt"
+
+	t.Run("trips at the threshold", func(t *testing.T) {
+		setupTestRepo(t)
+		cfg := &config.Config{StuckThreshold: 2}
+		r := New(cfg, script, shellAgent(), true, 6, nil)
+
+		assert.Equal(t, ExitStuck, r.Run())
+		assert.Equal(t, 2, r.GetMetrics().Iterations)
+	})
+
+	t.Run("never trips when disabled", func(t *testing.T) {
+		setupTestRepo(t)
+		cfg := &config.Config{StuckThreshold: 2, StuckDisabled: true, StuckHint: "echo $n >> .git/hints"}
+		r := New(cfg, script, shellAgent(), true, 6, nil)
+
+		assert.Equal(t, ExitMaxIterations, r.Run())
+		assert.Equal(t, 6, r.GetMetrics().Iterations)
+		assert.NoFileExists(t, ".git/hints", "no stuck hint without stuck detection")
+		assert.Contains(t, r.Explain(), "Without commit:   6 (stuck detection disabled)")
+	})
+
+	t.Run("disabled with an unset threshold", func(t *testing.T) {
+		setupTestRepo(t)
+		cfg := &config.Config{StuckDisabled: true}
+		r := New(cfg, script, shellAgent(), true, 4, nil)
+
+		assert.Equal(t, ExitMaxIterations, r.Run())
+		assert.Equal(t, 4, r.GetMetrics().Iterations)
+	})
+}
+
+func TestRun_HeaderOnlyOnChange(t *testing.T) {
+	t.Run("compact when nothing changed", func(t *testing.T) {
+		setupTestRepo(t)
+		r := New(&config.Config{StuckThreshold: 3}, "echo looking around", shellAgent(), false, 0, nil)
+		r.HeaderOnlyOnChange()
+
+		output := captureStdout(t, func() { r.Run() })
+		assert.Contains(t, output, "Iteration 1 · ")
+		assert.Contains(t, output, "Iteration 1: no commits or changes")
+		assert.NotContains(t, output, "ITERATION 1")
+		assert.NotContains(t, output, "Iteration 1 complete")
+		assert.NotContains(t, output, "═")
+	})
+
+	t.Run("full summary when something changed", func(t *testing.T) {
+		setupTestRepo(t)
+		r := New(&config.Config{StuckThreshold: 3}, "echo work > work.txt", shellAgent(), false, 0, nil)
+		r.HeaderOnlyOnChange()
+
+		output := captureStdout(t, func() { r.Run() })
+		assert.Contains(t, output, "Iteration 1 · ")
+		assert.Contains(t, output, "Iteration 1 complete")
+		assert.Contains(t, output, "Changes: 0 modified, 0 staged, 1 new")
+		assert.NotContains(t, output, "no commits or changes")
+	})
+
+	t.Run("off by default", func(t *testing.T) {
+		setupTestRepo(t)
+		r := New(&config.Config{StuckThreshold: 3}, "echo looking around", shellAgent(), false, 0, nil)
+
+		output := captureStdout(t, func() { r.Run() })
+		assert.Contains(t, output, "ITERATION 1")
+		assert.Contains(t, output, "Iteration 1 complete")
+	})
+}
+
+// recorderAgent returns an agent that appends each prompt it gets to
+// .git/prompts, read back with recordedPrompts
+func recorderAgent() *agent.Agent {
+	script := `printf '%s\n=====\n' "$0" >> .git/prompts`
+	return &agent.Agent{
+		ID:               "recorder",
+		Name:             "Recorder",
+		Command:          "sh",
+		AutonomousFlags:  []string{"-c", script},
+		InteractiveFlags: []string{"-c", script},
+		PromptStyle:      agent.PromptStyleArg,
+	}
+}
+
+// recordedPrompts returns the prompts recorderAgent got, in order
+func recordedPrompts(t *testing.T) []string {
+	t.Helper()
+	data, err := os.ReadFile(".git/prompts")
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSuffix(string(data), "\n=====\n"), "\n=====\n")
+}
+
+func TestRun_RetryFailedVerify(t *testing.T) {
+	setupTestRepo(t)
+
+	// Verification fails after the first iteration only
+	verify := `n=$(cat .git/verified 2>/dev/null || echo 0); n=$((n+1)); echo $n > .git/verified; ` +
+		`if [ $n -eq 1 ]; then echo "FAIL: TestAdd expected 4, got 5"; exit 1; fi`
+
+	cfg := &config.Config{StuckThreshold: 3, MaxNoChange: 10, Verify: verify}
+	r := New(cfg, "Fix the tests", recorderAgent(), true, 3, nil)
+	r.RetryFailedVerify()
+	r.Run()
+
+	prompts := recordedPrompts(t)
+	require.Len(t, prompts, 3)
+
+	assert.Equal(t, "Fix the tests", prompts[0])
+	assert.True(t, strings.HasPrefix(prompts[1], "Fix the tests\n\nThe verification command"), prompts[1])
+	assert.Contains(t, prompts[1], "--- BEGIN VERIFY OUTPUT ---\nFAIL: TestAdd expected 4, got 5\n--- END VERIFY OUTPUT ---")
+	assert.Equal(t, "Fix the tests", prompts[2], "feedback should be dropped once verification passes")
+}
+
+func TestRun_LoopPrompt(t *testing.T) {
+	setupTestRepo(t)
+
+	cfg := &config.Config{StuckThreshold: 5, MaxNoChange: 10}
+	r := New(cfg, "Implement the parser described in SPEC.md", recorderAgent(), true, 3, nil)
+	r.UseLoopPrompt("Continue the task")
+	assert.Equal(t, ExitMaxIterations, r.Run())
+
+	assert.Equal(t, []string{
+		"Implement the parser described in SPEC.md",
+		"Continue the task",
+		"Continue the task",
+	}, recordedPrompts(t))
+}
+
+func TestRun_LoopPromptAfterPromptEdit(t *testing.T) {
+	setupTestRepo(t)
+
+	// The recorder edits the prompt file during the second iteration
+	ag := recorderAgent()
+	ag.AutonomousFlags[1] += `; if [ "$0" = "Continue the task" ] && [ ! -f .git/edited ]; then ` +
+		`touch .git/edited; printf 'Also update the docs' > .git/PROMPT.md; fi`
+	require.NoError(t, os.WriteFile(".git/PROMPT.md", []byte("Implement the parser"), 0644))
+
+	cfg := &config.Config{StuckThreshold: 5, MaxNoChange: 10}
+	r := New(cfg, "Implement the parser", ag, true, 4, nil)
+	r.WatchPromptFile(".git/PROMPT.md", "")
+	r.UseLoopPrompt("Continue the task")
+	assert.Equal(t, ExitMaxIterations, r.Run())
+
+	assert.Equal(t, []string{
+		"Implement the parser",
+		"Continue the task",
+		"Also update the docs",
+		"Continue the task",
+	}, recordedPrompts(t))
+}
+
+func TestRun_NoAdapter(t *testing.T) {
+	setupTestRepo(t)
+
+	// A claude agent whose output the stream-json adapter would drop
+	ag := shellAgent()
+	ag.ID = "claude"
+	script := countingScript(1) + `; printf '{"type":"partial\nRunning: go test\n\033[1mbold\033[0m'`
+	cfg := &config.Config{StuckThreshold: 3}
+	r := New(cfg, script, ag, true, 10, nil)
+	var buf bytes.Buffer
+	out, err := NewOutput(FormatHuman, &buf, 0)
+	require.NoError(t, err)
+	r.SetOutput(out)
+	r.NoAdapter()
+
+	// The loop still counts commits and stops when nothing changes
+	assert.Equal(t, ExitSuccess, r.Run())
+	assert.Equal(t, 2, r.GetMetrics().Iterations)
+	assert.Equal(t, 1, r.GetMetrics().Commits)
+
+	output := buf.String()
+	assert.Contains(t, output, "iteration 1\n{\"type\":\"partial\nRunning: go test\n\033[1mbold\033[0m")
+	assert.Contains(t, output, "iteration 2\n{\"type\":\"partial")
+	assert.NotContains(t, output, "🔧", "nothing should be parsed into tool calls")
+}
+
+func TestRun_CommitGraceIterations(t *testing.T) {
+	// Every iteration leaves a change without committing
+	script := "n=$(cat .git/iter 2>/dev/null || echo 0); n=$((n+1)); echo $n > .git/iter; echo $n > work.txt"
+
+	t.Run("no stuck exit within the grace window", func(t *testing.T) {
+		setupTestRepo(t)
+		cfg := &config.Config{StuckThreshold: 1, MaxNoChange: 10, CommitGraceIterations: 4}
+		r := New(cfg, script, shellAgent(), true, 4, nil)
+
+		assert.Equal(t, ExitMaxIterations, r.Run())
+		assert.Equal(t, 0, r.iterationsWithoutCommit)
+	})
+
+	t.Run("stuck counting resumes after it", func(t *testing.T) {
+		setupTestRepo(t)
+		cfg := &config.Config{StuckThreshold: 2, MaxNoChange: 10, CommitGraceIterations: 3}
+		r := New(cfg, script, shellAgent(), true, 10, nil)
+
+		assert.Equal(t, ExitStuck, r.Run())
+		assert.Equal(t, 5, r.GetMetrics().Iterations)
+		assert.Contains(t, r.Explain(), "Commit grace:     first 3 iteration(s)")
+	})
+}
+
+func TestRun_StopFile(t *testing.T) {
+	setupTestRepo(t)
+
+	// Every iteration commits; the second one also creates the stop file,
+	// as an orchestrator would between iterations
+	script := countingScript(0) + "; git commit -q --allow-empty -m \"iteration $n\"; if [ $n -eq 2 ]; then touch .git/stop; fi"
+	cfg := &config.Config{StuckThreshold: 3}
+	r := New(cfg, script, shellAgent(), true, 10, nil)
+	r.StopOnFile(".git/stop")
+
+	assert.Equal(t, ExitInterrupt, r.Run())
+	assert.Equal(t, 2, r.GetMetrics().Iterations)
+	assert.Equal(t, 2, r.GetMetrics().Commits)
+	assert.Contains(t, r.exitCondition, "stop file .git/stop found")
+	assert.NoFileExists(t, ".git/stop", "the stop file should be removed")
+}
+
+func TestRun_CommitOnInterrupt(t *testing.T) {
+	// The agent leaves work uncommitted, then Ctrl+C arrives mid-iteration
+	script := "echo 'half done' > work.txt; kill -INT $PPID; sleep 5"
+
+	t.Run("commits the work", func(t *testing.T) {
+		setupTestRepo(t)
+		require.NoError(t, os.WriteFile(".git/info/exclude", []byte(memory.DefaultFileName+"\n"), 0644))
+		mem := &memory.SessionMemory{}
+		cfg := &config.Config{StuckThreshold: 3, CommitOnInterrupt: true}
+		r := New(cfg, script, shellAgent(), true, 10, mem)
+
+		captureStdout(t, func() {
+			assert.Equal(t, ExitInterrupt, r.Run())
+		})
+		assert.Equal(t, 1, r.GetMetrics().Commits)
+
+		dirty, err := git.HasChanges()
+		require.NoError(t, err)
+		assert.False(t, dirty, "nothing should be left uncommitted")
+		commits, err := git.GetRecentCommits(1)
+		require.NoError(t, err)
+		assert.Equal(t, "WIP (interrupted): gumloop iteration 1 with Shell", commits[0].Message)
+		assert.Contains(t, mem.Remaining, "WIP commit "+r.wipCommit)
+	})
+
+	t.Run("off by default", func(t *testing.T) {
+		setupTestRepo(t)
+		cfg := &config.Config{StuckThreshold: 3}
+		r := New(cfg, script, shellAgent(), true, 10, nil)
+
+		captureStdout(t, func() {
+			assert.Equal(t, ExitInterrupt, r.Run())
+		})
+		assert.Equal(t, 0, r.GetMetrics().Commits)
+		dirty, err := git.HasChanges()
+		require.NoError(t, err)
+		assert.True(t, dirty, "the work should be left for the user")
+	})
+}
+
+func TestRun_NoOutputCountsTowardStuck(t *testing.T) {
+	setupTestRepo(t)
+	var stderr bytes.Buffer
+	logging.SetOutput(&stderr)
+	defer logging.SetOutput(os.Stderr)
+
+	// An agent that prints nothing and changes nothing isn't finished
+	cfg := &config.Config{StuckThreshold: 2}
+	r := New(cfg, "true", shellAgent(), true, 10, nil)
+
+	assert.Equal(t, ExitStuck, r.Run())
+	assert.Equal(t, 2, r.GetMetrics().Iterations)
+	assert.Contains(t, r.exitCondition, "stuck: no agent output and no commits for 2 iteration(s)")
+	assert.Contains(t, stderr.String(), "Shell produced no output")
+}
+
+func TestRun_LastMessageBecomesRemaining(t *testing.T) {
+	setupTestRepo(t)
+	require.NoError(t, os.WriteFile(".git/info/exclude", []byte(memory.DefaultFileName+"\n"), 0644))
+
+	// The second iteration commits nothing and ends the run; its last
+	// message is the handoff note
+	script := countingScript(1) + "; echo \"Left off at iteration $n: the parser still needs tests\""
+	mem := &memory.SessionMemory{}
+	cfg := &config.Config{StuckThreshold: 3}
+	r := New(cfg, script, shellAgent(), true, 10, mem)
+
+	captureStdout(t, func() {
+		assert.Equal(t, ExitSuccess, r.Run())
+	})
+	assert.Equal(t, 2, r.GetMetrics().Iterations)
+	assert.Equal(t, "Left off at iteration 2: the parser still needs tests", mem.Remaining)
+
+	saved, err := memory.Load(memory.DefaultFileName)
+	require.NoError(t, err)
+	assert.Equal(t, mem.Remaining, saved.Remaining)
+}
+
+func TestRun_DoneSignal(t *testing.T) {
+	setupTestRepo(t)
+
+	// The agent leaves uncommitted changes but says it's finished; the loop
+	// stops as complete instead of running on until stuck detection
+	script := "echo TASK COMPLETE; echo x > work.txt"
+	cfg := &config.Config{StuckThreshold: 3, MaxNoChange: 10, DoneSignal: "TASK COMPLETE"}
+	r := New(cfg, script, shellAgent(), true, 10, nil)
+
+	assert.Equal(t, ExitSuccess, r.Run())
+	assert.Equal(t, 1, r.GetMetrics().Iterations)
+	assert.Contains(t, r.exitCondition, "done_signal")
+}
+
+func TestRun_WatchPromptFile(t *testing.T) {
+	setupTestRepo(t)
+
+	// Each iteration appends the script's label to a log under .git, then the
+	// first iteration rewrites the prompt file for the next one
+	first := "echo first >> .git/log; git commit -q --allow-empty -m one; " +
+		"printf 'echo second | tee -a .git/log' > .git/PROMPT.md"
+	require.NoError(t, os.WriteFile(".git/PROMPT.md", []byte(first), 0644))
+
+	cfg := &config.Config{StuckThreshold: 3}
+	r := New(cfg, first, shellAgent(), true, 5, nil)
+	r.WatchPromptFile(".git/PROMPT.md", "")
+
+	assert.Equal(t, ExitSuccess, r.Run())
+	assert.Equal(t, 2, r.GetMetrics().Iterations)
+
+	log, err := os.ReadFile(".git/log")
+	require.NoError(t, err)
+	assert.Equal(t, "first\nsecond\n", string(log))
+}
+
+func TestRun_WatchPromptFileMissing(t *testing.T) {
+	setupTestRepo(t)
+
+	// The prompt file disappears after the first iteration; the runner keeps
+	// using the last prompt instead of failing
+	prompt := "n=$(cat .git/iter 2>/dev/null || echo 0); n=$((n+1)); echo $n | tee .git/iter; " +
+		"rm -f .git/PROMPT.md; if [ $n -eq 1 ]; then git commit -q --allow-empty -m one; fi"
+	require.NoError(t, os.WriteFile(".git/PROMPT.md", []byte(prompt), 0644))
+
+	cfg := &config.Config{StuckThreshold: 3}
+	r := New(cfg, prompt, shellAgent(), true, 5, nil)
+	r.WatchPromptFile(".git/PROMPT.md", "")
+
+	assert.Equal(t, ExitSuccess, r.Run())
+	assert.Equal(t, 2, r.GetMetrics().Iterations)
+}
+
+func TestRunner_ReloadPromptKeepsPreamble(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "PROMPT.md")
+	require.NoError(t, os.WriteFile(path, []byte("new task"), 0644))
+
+	r := New(&config.Config{}, "context\nold task", shellAgent(), true, 0, nil)
+	r.WatchPromptFile(path, "context\n")
+	r.reloadPrompt()
+
+	assert.Equal(t, "context\nnew task", r.prompt)
+}
+
+func TestRunner_Explain(t *testing.T) {
+	t.Run("stuck exit", func(t *testing.T) {
+		setupTestRepo(t)
+		require.NoError(t, os.WriteFile("dirty.txt", []byte("wip"), 0644))
+
+		cfg := &config.Config{StuckThreshold: 2}
+		r := New(cfg, "echo nothing to do", shellAgent(), true, 10, nil)
+		require.Equal(t, ExitStuck, r.Run())
+
+		explanation := r.Explain()
+		assert.Contains(t, explanation, "Condition:        stuck: changes but no commits for 2 iteration(s) (stuck_threshold 2)")
+		assert.Contains(t, explanation, "Last iteration:   2")
+		assert.Contains(t, explanation, "Has changes:      true")
+		assert.Contains(t, explanation, "Commits made:     0")
+		assert.Contains(t, explanation, "Without commit:   2 of 2 (stuck_threshold)")
+	})
+
+	t.Run("complete exit", func(t *testing.T) {
+		setupTestRepo(t)
+
+		cfg := &config.Config{StuckThreshold: 3}
+		r := New(cfg, "echo nothing to do", shellAgent(), true, 10, nil)
+		require.Equal(t, ExitSuccess, r.Run())
+
+		explanation := r.Explain()
+		assert.Contains(t, explanation, "Condition:        complete: no changes and no commits for 1 consecutive iteration(s) (max_no_change 1)")
+		assert.Contains(t, explanation, "Has changes:      false")
+		assert.Contains(t, explanation, "Without change:   1 of 1 (max_no_change)")
+	})
+}
+
+func TestRun_CommitIfDirty(t *testing.T) {
+	t.Run("dirty changes become a commit", func(t *testing.T) {
+		setupTestRepo(t)
+
+		cfg := &config.Config{StuckThreshold: 3, CommitIfDirty: true}
+		r := New(cfg, "echo work > work.txt", shellAgent(), false, 0, nil)
+
+		assert.Equal(t, ExitSuccess, r.Run())
+		assert.Equal(t, 1, r.GetMetrics().Commits)
+
+		hasChanges, err := git.HasChanges()
+		require.NoError(t, err)
+		assert.False(t, hasChanges)
+
+		commits, err := git.GetRecentCommits(1)
+		require.NoError(t, err)
+		assert.Contains(t, commits[0].Message, "commit changes left by Shell")
+	})
+
+	t.Run("disabled leaves the tree dirty", func(t *testing.T) {
+		setupTestRepo(t)
+
+		cfg := &config.Config{StuckThreshold: 3}
+		r := New(cfg, "echo work > work.txt", shellAgent(), false, 0, nil)
+
+		r.Run()
+		assert.Equal(t, 0, r.GetMetrics().Commits)
+
+		hasChanges, err := git.HasChanges()
+		require.NoError(t, err)
+		assert.True(t, hasChanges)
+	})
+
+	t.Run("failed verification skips the commit", func(t *testing.T) {
+		setupTestRepo(t)
+
+		cfg := &config.Config{StuckThreshold: 3, CommitIfDirty: true, Verify: "false"}
+		r := New(cfg, "echo work > work.txt", shellAgent(), false, 0, nil)
+
+		r.Run()
+		assert.Equal(t, 0, r.GetMetrics().Commits)
+	})
+}
+
+func TestRun_CommitInterval(t *testing.T) {
+	setupTestRepo(t)
+
+	// Every iteration adds to the tree without committing
+	cfg := &config.Config{StuckThreshold: 10}
+	r := New(cfg, "echo work >> work.txt", shellAgent(), true, 5, nil)
+	r.CommitEvery(2)
+
+	assert.Equal(t, ExitMaxIterations, r.Run())
+	assert.Equal(t, 2, r.GetMetrics().Commits, "iterations 2 and 4 commit")
+
+	commits, err := git.GetRecentCommits(2)
+	require.NoError(t, err)
+	assert.Contains(t, commits[0].Message, "checkpoint uncommitted changes from Shell (iteration 4)")
+	assert.Contains(t, commits[1].Message, "checkpoint uncommitted changes from Shell (iteration 2)")
+
+	// Iteration 5's changes are still waiting for the next interval
+	hasChanges, err := git.HasChanges()
+	require.NoError(t, err)
+	assert.True(t, hasChanges)
+}
+
+func TestRun_ExcludesCommitsFromBeforeSession(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	// Commits made elsewhere before the session, waiting on another branch
+	run := func(env []string, args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), env...)
+		require.NoError(t, cmd.Run())
+	}
+	old := []string{"GIT_COMMITTER_DATE=2020-01-01T00:00:00Z", "GIT_AUTHOR_DATE=2020-01-01T00:00:00Z"}
+	run(nil, "checkout", "-q", "-b", "upstream")
+	run(old, "commit", "-q", "--allow-empty", "-m", "theirs 1")
+	run(old, "commit", "-q", "--allow-empty", "-m", "theirs 2")
+	run(nil, "checkout", "-q", "-")
+
+	mem := &memory.SessionMemory{Branch: "main", AgentName: "Shell"}
+	cfg := &config.Config{StuckThreshold: 3}
+	script := "git merge -q --ff-only upstream && git commit -q --allow-empty -m ours"
+	r := New(cfg, script, shellAgent(), false, 0, mem)
+
+	assert.Equal(t, ExitSuccess, r.Run())
+	assert.Equal(t, 1, r.GetMetrics().Commits)
+	require.Len(t, mem.CommitLog, 1)
+	assert.Equal(t, "ours", mem.CommitLog[0].Message)
+}
+
+func TestRun_CommitCountSource(t *testing.T) {
+	tests := []struct {
+		source string
+		want   int
+	}{
+		{config.CommitCountSession, 1},
+		{config.CommitCountBranch, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			dir := setupTestRepo(t)
+
+			// Two commits that predate the session, merged in by the agent
+			old := []string{"GIT_COMMITTER_DATE=2020-01-01T00:00:00Z", "GIT_AUTHOR_DATE=2020-01-01T00:00:00Z"}
+			for _, args := range [][]string{
+				{"checkout", "-q", "-b", "upstream"},
+				{"commit", "-q", "--allow-empty", "-m", "theirs 1"},
+				{"commit", "-q", "--allow-empty", "-m", "theirs 2"},
+				{"checkout", "-q", "-"},
+			} {
+				cmd := exec.Command("git", args...)
+				cmd.Dir = dir
+				cmd.Env = append(os.Environ(), old...)
+				require.NoError(t, cmd.Run())
+			}
+
+			cfg := &config.Config{StuckThreshold: 3, CommitCountSource: tt.source}
+			script := "git merge -q --ff-only upstream && git commit -q --allow-empty -m ours"
+			r := New(cfg, script, shellAgent(), false, 0, nil)
+
+			assert.Equal(t, ExitSuccess, r.Run())
+			assert.Equal(t, tt.want, r.GetMetrics().Commits)
+		})
+	}
+}
+
+func TestRun_RotatesMemory(t *testing.T) {
+	setupTestRepo(t)
+
+	previous := &memory.SessionMemory{Branch: "main", Iterations: 4, Commits: 2}
+	require.NoError(t, previous.Save(memory.DefaultFileName))
+
+	mem := &memory.SessionMemory{Branch: "main", AgentName: "Shell"}
+	r := New(&config.Config{StuckThreshold: 3}, "true", shellAgent(), false, 0, mem)
+	r.Run()
+
+	prevPath, err := git.GitPath(memory.PrevFileName)
+	require.NoError(t, err)
+	assert.Equal(t, ".git", filepath.Base(filepath.Dir(prevPath)), "the previous memory is kept out of the working tree")
+	prev, err := memory.Load(prevPath)
+	require.NoError(t, err)
+	require.NotNil(t, prev)
+	assert.Equal(t, 4, prev.Iterations)
+
+	cur, err := memory.Load(memory.DefaultFileName)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cur.Iterations)
+}
+
+// captureStdout runs fn and returns what it printed to stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	orig := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = orig }()
+
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+
+	fn()
+	w.Close()
+	return <-out
+}
+
+func TestRun_RendersIterationWithUI(t *testing.T) {
+	setupTestRepo(t)
+
+	cfg := &config.Config{StuckThreshold: 3, Verify: "true"}
+	script := "echo work > work.txt && git add work.txt && git commit -q -m work"
+	r := New(cfg, script, shellAgent(), false, 1, nil)
+
+	output := captureStdout(t, func() { r.Run() })
+
+	assert.Contains(t, output, "ITERATION 1 of 1")
+	assert.Contains(t, output, "Iteration 1 complete")
+	assert.Contains(t, output, "✓ Commits: 1")
+	assert.Contains(t, output, "📝 Changes: 0 modified, 0 staged, 0 new")
+	assert.Contains(t, output, "✓ Verification passed")
+}
+
+func TestRun_SkipsPushWithoutRemote(t *testing.T) {
+	setupTestRepo(t)
+
+	var logs bytes.Buffer
+	logging.SetOutput(&logs)
+	defer logging.SetOutput(nil)
+
+	cfg := &config.Config{StuckThreshold: 3, AutoPush: true}
+	r := New(cfg, "git commit -q --allow-empty -m work", shellAgent(), true, 3, nil)
+
+	output := captureStdout(t, func() { r.Run() })
+
+	assert.Equal(t, 3, r.GetMetrics().Commits)
+	assert.Equal(t, 1, strings.Count(output, "No origin remote configured"), "the message should be shown once")
+	assert.NotContains(t, output, "Pushing to origin")
+	assert.NotContains(t, logs.String(), "Push failed")
+}
+
+func TestRun_CommitMessagePattern(t *testing.T) {
+	tests := []struct {
+		name        string
+		message     string
+		strict      bool
+		wantCommits int
+		wantWarning bool
+	}{
+		{"matching", "feat: add work", false, 1, false},
+		{"matching strict", "feat: add work", true, 1, false},
+		{"mismatch warns", "wip", false, 1, true},
+		{"mismatch strict undoes", "wip", true, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestRepo(t)
+			start, err := git.CountCommits()
+			require.NoError(t, err)
+
+			var logs bytes.Buffer
+			logging.SetOutput(&logs)
+			defer logging.SetOutput(nil)
+
+			cfg := &config.Config{StuckThreshold: 3, CommitMessagePattern: `^(feat|fix)(\(.+\))?: `}
+			script := fmt.Sprintf("echo work > work.txt; git add work.txt; git commit -q -m %q", tt.message)
+			r := New(cfg, script, shellAgent(), false, 0, nil)
+			if tt.strict {
+				r.StrictCommits()
+			}
+
+			captureStdout(t, func() { r.Run() })
+
+			assert.Equal(t, tt.wantCommits, r.GetMetrics().Commits)
+			count, err := git.CountCommits()
+			require.NoError(t, err)
+			assert.Equal(t, start+tt.wantCommits, count)
+			if tt.wantWarning {
+				assert.Contains(t, logs.String(), "doesn't match commit_message_pattern")
+			} else {
+				assert.Empty(t, logs.String())
+			}
+
+			if tt.strict && tt.wantCommits == 0 {
+				// The work is kept, staged, for the next iteration to commit properly
+				_, staged, _, err := git.GetChangedFiles()
+				require.NoError(t, err)
+				assert.Equal(t, 1, staged)
+			}
+		})
+	}
+}
+
+func TestRun_RecordsIterationErrors(t *testing.T) {
+	setupTestRepo(t)
+
+	var logs bytes.Buffer
+	logging.SetOutput(&logs)
+	defer logging.SetOutput(nil)
+
+	// The agent commits, but verification fails every time
+	cfg := &config.Config{StuckThreshold: 3, Verify: "echo broken >&2; exit 1"}
+	r := New(cfg, "git commit -q --allow-empty -m work", shellAgent(), true, 2, nil)
+
+	captureStdout(t, func() { r.Run() })
+
+	metrics := r.GetMetrics()
+	assert.Equal(t, 2, metrics.Errors)
+	assert.Contains(t, metrics.LastError, "verification failed")
+}
+
+func TestRun_CommitTrailer(t *testing.T) {
+	setupTestRepo(t)
+
+	cfg := &config.Config{StuckThreshold: 3, CommitTrailer: true}
+	script := "git commit -q --allow-empty -m one; git commit -q --allow-empty -m two"
+	r := New(cfg, script, shellAgent(), false, 0, nil)
+
+	captureStdout(t, func() { r.Run() })
+	assert.Equal(t, 2, r.GetMetrics().Commits)
+
+	output, err := exec.Command("git", "log", "--format=%s|%(trailers:separator=%x2C)").Output()
+	require.NoError(t, err)
+	assert.Equal(t,
+		"two|Gumloop-Iteration: 1,Gumloop-Agent: shell\n"+
+			"one|Gumloop-Iteration: 1,Gumloop-Agent: shell\n"+
+			"initial|\n",
+		string(output))
+}
+
+func TestRunner_Push(t *testing.T) {
+	tests := []struct {
+		name       string
+		autoPush   bool
+		repo       *fakeRepo
+		pushed     bool
+		pushFailed bool
+		noRemote   bool
+	}{
+		{"pushes the branch", true, &fakeRepo{branch: "main"}, true, false, false},
+		{"auto_push off", false, &fakeRepo{branch: "main"}, false, false, false},
+		{"no remote", true, &fakeRepo{branch: "main", noRemote: true}, false, false, true},
+		{"detached HEAD", true, &fakeRepo{branch: "HEAD"}, false, false, false},
+		{"push fails", true, &fakeRepo{branch: "main", pushErr: errors.New("rejected")}, false, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logging.SetOutput(&buf)
+			defer logging.SetOutput(os.Stderr)
+
+			r := New(&config.Config{AutoPush: tt.autoPush}, "", fakeAgent(), true, 0, nil)
+			r.repo = tt.repo
+			r.SetOutput(&humanOutput{w: &buf})
+
+			var result IterationResult
+			r.push(&result)
+			assert.Equal(t, tt.pushed, result.Pushed)
+			assert.Equal(t, tt.pushFailed, result.PushFailed)
+			assert.Equal(t, tt.noRemote, r.noRemote)
+			if tt.pushed {
+				assert.Equal(t, []string{tt.repo.branch}, tt.repo.pushed)
+			} else {
+				assert.Empty(t, tt.repo.pushed)
+			}
+		})
+	}
+}
+
+func TestRun_MaxFileChanges(t *testing.T) {
+	setupTestRepo(t)
+
+	cfg := &config.Config{StuckThreshold: 3}
+	r := New(cfg, "for i in 1 2 3 4 5; do echo x > file$i.txt; done", shellAgent(), true, 5, nil)
+	r.LimitFileChanges(3)
+
+	output := captureStdout(t, func() {
+		assert.Equal(t, ExitSafety, r.Run())
+	})
+	assert.Contains(t, output, "5 files changed, more than --max-file-changes 3")
+	assert.Equal(t, 1, r.GetMetrics().Iterations)
+	assert.Contains(t, r.exitCondition, "safety")
+
+	// Within the limit the run carries on as usual
+	setupTestRepo(t)
+	r = New(cfg, "echo x > one.txt; git add one.txt; git commit -qm one", shellAgent(), false, 0, nil)
+	r.LimitFileChanges(3)
+	captureStdout(t, func() {
+		assert.Equal(t, ExitSuccess, r.Run())
+	})
+}
+
+func TestRun_MaxFileChangesBeforeAutoCommit(t *testing.T) {
+	setupTestRepo(t)
+	before, err := git.CountCommits()
+	require.NoError(t, err)
+
+	// commit_if_dirty would commit the files; the guard stops it first
+	cfg := &config.Config{StuckThreshold: 3, CommitIfDirty: true}
+	r := New(cfg, "for i in 1 2 3 4 5; do echo x > file$i.txt; done", shellAgent(), true, 5, nil)
+	r.LimitFileChanges(3)
+
+	output := captureStdout(t, func() {
+		assert.Equal(t, ExitSafety, r.Run())
+	})
+	assert.Contains(t, output, "5 files changed, more than --max-file-changes 3")
+	after, err := git.CountCommits()
+	require.NoError(t, err)
+	assert.Equal(t, before, after, "nothing is committed")
+	_, _, untracked, err := git.GetChangedFiles()
+	require.NoError(t, err)
+	assert.Equal(t, 5, untracked, "the files are left for review")
+
+	// Files the agent committed itself count too
+	setupTestRepo(t)
+	r = New(cfg, "echo x > a.txt; echo x > b.txt; git add .; git commit -qm two; echo x > c.txt; echo x > d.txt", shellAgent(), true, 5, nil)
+	r.LimitFileChanges(3)
+	output = captureStdout(t, func() {
+		assert.Equal(t, ExitSafety, r.Run())
+	})
+	assert.Contains(t, output, "4 files changed, more than --max-file-changes 3")
+	assert.Equal(t, 1, r.GetMetrics().Commits)
+}
+
+// recordingRepo is the real repository, with a remote that records pushes
+type recordingRepo struct {
+	gitRepo
+	pushed []string
+}
+
+func (r *recordingRepo) HasRemote() (bool, error) { return true, nil }
+
+func (r *recordingRepo) Push(branch string) error {
+	r.pushed = append(r.pushed, branch)
+	return nil
+}
+
+// fakeRepoRunner returns a runner using repo, with notices written to out
+func fakeRepoRunner(cfg *config.Config, repo *fakeRepo, out io.Writer) *Runner {
+	r := New(cfg, "", fakeAgent(), true, 0, nil)
+	r.repo = repo
+	r.SetOutput(&humanOutput{w: out})
+	return r
+}
+
+func TestRunner_ScopeToSession(t *testing.T) {
+	tests := []struct {
+		name         string
+		source       string
+		repo         *fakeRepo
+		credited     int
+		commitsMade  int
+		wantCredited int
+	}{
+		{"own commits", "", &fakeRepo{commits: 7, commitsAfter: 2}, 0, 2, 2},
+		{"pulled commits are left out", "", &fakeRepo{commits: 10, commitsAfter: 2}, 0, 5, 2},
+		{"commits already credited", "", &fakeRepo{commits: 7, commitsAfter: 2}, 2, 1, 0},
+		{"branch source counts everything", config.CommitCountBranch, &fakeRepo{commits: 10, commitsAfter: 2}, 0, 5, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := fakeRepoRunner(&config.Config{CommitCountSource: tt.source}, tt.repo, io.Discard)
+			r.startCommitCount = 5
+			r.metrics.Commits = tt.credited
+			assert.Equal(t, tt.wantCredited, r.scopeToSession(tt.commitsMade))
+		})
+	}
+}
+
+func TestRunner_CommitLeftoversFakeRepo(t *testing.T) {
+	repo := &fakeRepo{dirty: true}
+	var out bytes.Buffer
+	r := fakeRepoRunner(&config.Config{}, repo, &out)
+	r.metrics.Iterations = 3
+
+	assert.Equal(t, 1, r.commitLeftovers())
+	assert.Equal(t, []string{"gumloop: commit changes left by Fake (iteration 3)"}, repo.committed)
+	assert.Contains(t, out.String(), "Committed changes the agent left uncommitted")
+
+	// Nothing left to commit
+	assert.Equal(t, 0, r.commitLeftovers())
+	assert.Len(t, repo.committed, 1)
+}
+
+func TestRunner_CheckCommitMessagesFakeRepo(t *testing.T) {
+	recent := []git.CommitInfo{{Hash: "bbb2222", Message: "fix typo"}, {Hash: "aaa1111", Message: "feat: add flag"}}
+
+	for _, strict := range []bool{false, true} {
+		t.Run(fmt.Sprintf("strict %t", strict), func(t *testing.T) {
+			var stderr bytes.Buffer
+			logging.SetOutput(&stderr)
+			defer logging.SetOutput(os.Stderr)
+
+			repo := &fakeRepo{recent: recent}
+			r := fakeRepoRunner(&config.Config{CommitMessagePattern: "^feat: "}, repo, io.Discard)
+			r.strictCommits = strict
+
+			commits, rejected := r.checkCommitMessages(2)
+			assert.Contains(t, stderr.String(), `Commit bbb2222 doesn't match commit_message_pattern: "fix typo"`)
+			assert.NotContains(t, stderr.String(), "aaa1111")
+			if !strict {
+				assert.Equal(t, 2, commits)
+				assert.False(t, rejected)
+				assert.Empty(t, repo.resets)
+				return
+			}
+			assert.Equal(t, 0, commits)
+			assert.True(t, rejected)
+			assert.Equal(t, []string{"HEAD~2"}, repo.resets)
+		})
+	}
+}
+
+func TestRun_VerifyCache(t *testing.T) {
+	verifyRuns := func(commands *fakeCommands) int {
+		n := 0
+		for _, args := range commands.ran {
+			if args[len(args)-1] == "make test" {
+				n++
+			}
+		}
+		return n
+	}
+
+	t.Run("unchanged tree verifies once", func(t *testing.T) {
+		setupTestRepo(t)
+		commands := &fakeCommands{agentOutput: "Nothing to do\n", verify: map[string]int{"make test": 0}}
+
+		cfg := &config.Config{StuckThreshold: 3, MaxNoChange: 3, Verify: "make test"}
+		r := New(cfg, "Tidy up", fakeAgent(), true, 0, nil)
+		r.commands = commands
+		output := captureStdout(t, func() {
+			assert.Equal(t, ExitSuccess, r.Run())
+		})
+		assert.Equal(t, 3, r.GetMetrics().Iterations)
+		assert.Equal(t, 1, verifyRuns(commands))
+		assert.Equal(t, 2, strings.Count(output, "Skipping verification"))
+	})
+
+	t.Run("changes verify every time", func(t *testing.T) {
+		setupTestRepo(t)
+		commands := &fakeCommands{agentOutput: "Working\n", verify: map[string]int{"make test": 0}}
+		commands.afterAgent = func() {
+			require.NoError(t, os.WriteFile("work.txt", []byte(fmt.Sprint(len(commands.ran))), 0644))
+		}
+
+		cfg := &config.Config{StuckThreshold: 10, Verify: "make test"}
+		r := New(cfg, "Keep working", fakeAgent(), true, 3, nil)
+		r.commands = commands
+		captureStdout(t, func() {
+			assert.Equal(t, ExitMaxIterations, r.Run())
+		})
+		assert.Equal(t, 3, verifyRuns(commands))
+	})
+
+	t.Run("failed verify isn't cached", func(t *testing.T) {
+		setupTestRepo(t)
+		commands := &fakeCommands{agentOutput: "Nothing to do\n", verify: map[string]int{"make test": 1}}
+
+		cfg := &config.Config{StuckThreshold: 3, MaxNoChange: 3, Verify: "make test"}
+		r := New(cfg, "Tidy up", fakeAgent(), true, 3, nil)
+		r.commands = commands
+		captureStdout(t, func() { r.Run() })
+		assert.Equal(t, 3, verifyRuns(commands))
+	})
+}
+
+func TestRun_PlanOnly(t *testing.T) {
+	for _, planOnly := range []bool{false, true} {
+		t.Run(fmt.Sprintf("plan only %t", planOnly), func(t *testing.T) {
+			setupTestRepo(t)
+			var stderr bytes.Buffer
+			logging.SetOutput(&stderr)
+			defer logging.SetOutput(os.Stderr)
+
+			// The agent commits even though it was asked not to
+			commands := &fakeCommands{agentOutput: "1. Add the flag\n2. Test it\n", afterAgent: func() {
+				require.NoError(t, exec.Command("git", "commit", "-q", "--allow-empty", "-m", "eager").Run())
+			}}
+			repo := &recordingRepo{}
+
+			cfg := &config.Config{AutoPush: true, StuckThreshold: 3, CommitIfDirty: true}
+			r := New(cfg, "Add a --plan-only flag", fakeAgent(), false, 0, nil)
+			r.commands, r.repo = commands, repo
+			if planOnly {
+				r.PlanOnly()
+			}
+			output := captureStdout(t, func() {
+				assert.Equal(t, ExitSuccess, r.Run())
+			})
+			assert.Equal(t, 1, r.GetMetrics().Iterations)
+
+			prompt := commands.ran[0][len(commands.ran[0])-1]
+			if !planOnly {
+				assert.Len(t, repo.pushed, 1, "without --plan-only the commit is pushed")
+				assert.NotContains(t, prompt, planOnlyInstruction)
+				return
+			}
+
+			assert.Equal(t, "Add a --plan-only flag\n\n"+planOnlyInstruction, prompt)
+			assert.Contains(t, output, "1. Add the flag")
+			assert.Empty(t, repo.pushed)
+			assert.Contains(t, stderr.String(), "--plan-only: the agent made 1 commit(s)")
+		})
+	}
+}
+
+func TestRun_Pipeline(t *testing.T) {
+	setupTestRepo(t)
+
+	// Two shell "agents" whose stage suffixes make one commit each
+	implementer, reviewer := shellAgent(), shellAgent()
+	implementer.Name, reviewer.Name = "Implementer", "Reviewer"
+
+	mem := &memory.SessionMemory{}
+	cfg := &config.Config{StuckThreshold: 3}
+	r := New(cfg, "echo task", shellAgent(), false, 0, mem)
+	r.SetPipeline([]Stage{
+		{Agent: implementer, PromptSuffix: "git commit -q --allow-empty -m implement"},
+		{Agent: reviewer, PromptSuffix: "git log -1 --format=%s; git commit -q --allow-empty -m review"},
+	})
+
+	output := captureStdout(t, func() {
+		assert.Equal(t, ExitSuccess, r.Run())
+	})
+	assert.Equal(t, 2, r.GetMetrics().Commits)
+
+	// The reviewer ran second and saw the implementer's commit
+	assert.Contains(t, output, "Implementer → Reviewer")
+	first := strings.Index(output, "Stage 1 of 2: Implementer")
+	second := strings.Index(output, "Stage 2 of 2: Reviewer")
+	require.True(t, first >= 0 && second > first, "stages out of order:\n%s", output)
+	assert.Contains(t, output[second:], "implement")
+
+	// Memory credits each commit to the stage that made it
+	require.Len(t, mem.CommitLog, 2)
+	assert.Equal(t, "review", mem.CommitLog[0].Message)
+	assert.Equal(t, "Reviewer", mem.CommitLog[0].Agent)
+	assert.Equal(t, "implement", mem.CommitLog[1].Message)
+	assert.Equal(t, "Implementer", mem.CommitLog[1].Agent)
+}
+
+func TestRun_PipelineSilentStages(t *testing.T) {
+	stages := func(reviewerPrompt string) []Stage {
+		implementer, reviewer := shellAgent(), shellAgent()
+		implementer.Name, reviewer.Name = "Implementer", "Reviewer"
+		return []Stage{{Agent: implementer}, {Agent: reviewer, PromptSuffix: reviewerPrompt}}
+	}
+
+	t.Run("every stage silent counts toward stuck", func(t *testing.T) {
+		setupTestRepo(t)
+		r := New(&config.Config{StuckThreshold: 2}, "true", shellAgent(), true, 10, nil)
+		r.SetPipeline(stages(""))
+
+		captureStdout(t, func() {
+			assert.Equal(t, ExitStuck, r.Run())
+		})
+		assert.Contains(t, r.exitCondition, "no agent output")
+	})
+
+	t.Run("one stage speaking is a normal iteration", func(t *testing.T) {
+		setupTestRepo(t)
+		r := New(&config.Config{StuckThreshold: 2}, "true", shellAgent(), true, 10, nil)
+		r.SetPipeline(stages("echo nothing left to do"))
+
+		captureStdout(t, func() {
+			assert.Equal(t, ExitSuccess, r.Run())
+		})
+	})
+}
+
+func TestRun_PipelineGumloopCommits(t *testing.T) {
+	// The implementer may commit; the reviewer leaves a file uncommitted
+	stages := func(implement string) []Stage {
+		implementer, reviewer := shellAgent(), shellAgent()
+		implementer.Name, reviewer.Name = "Implementer", "Reviewer"
+		return []Stage{
+			{Agent: implementer, PromptSuffix: implement},
+			{Agent: reviewer, PromptSuffix: "echo notes > review.txt"},
+		}
+	}
+
+	t.Run("leftover commit", func(t *testing.T) {
+		setupTestRepo(t)
+		mem := &memory.SessionMemory{}
+		r := New(&config.Config{StuckThreshold: 3, CommitIfDirty: true}, "echo task", shellAgent(), false, 0, mem)
+		r.SetPipeline(stages("true"))
+
+		captureStdout(t, func() { r.Run() })
+		require.Len(t, mem.CommitLog, 1)
+		assert.Equal(t, "gumloop: commit changes left by Implementer → Reviewer (iteration 1)", mem.CommitLog[0].Message)
+		assert.Empty(t, mem.CommitLog[0].Agent)
+	})
+
+	t.Run("checkpoint after a stage commit", func(t *testing.T) {
+		setupTestRepo(t)
+		mem := &memory.SessionMemory{}
+		r := New(&config.Config{StuckThreshold: 3}, "echo task", shellAgent(), false, 0, mem)
+		r.SetPipeline(stages("git commit -q --allow-empty -m implement"))
+		r.CommitEvery(1)
+
+		captureStdout(t, func() { r.Run() })
+		require.Len(t, mem.CommitLog, 2)
+		assert.Contains(t, mem.CommitLog[0].Message, "gumloop: checkpoint")
+		assert.Empty(t, mem.CommitLog[0].Agent, "gumloop's commit isn't credited to a stage")
+		assert.Equal(t, "implement", mem.CommitLog[1].Message)
+		assert.Equal(t, "Implementer", mem.CommitLog[1].Agent)
+	})
+}
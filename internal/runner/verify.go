@@ -0,0 +1,168 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/adriancodes/gumloop/internal/ui"
+)
+
+// verifyCommands splits a verify setting into one command per non-empty line
+func verifyCommands(verify string) []string {
+	var commands []string
+	for _, line := range strings.Split(verify, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			commands = append(commands, line)
+		}
+	}
+	return commands
+}
+
+// NoShell as verify_shell runs verify commands directly instead of through a shell
+const NoShell = "none"
+
+// shellArgs returns the command that runs script with shell: the platform
+// shell if shell is empty, or script split on spaces for NoShell
+func shellArgs(shell, script string) []string {
+	switch shell {
+	case "":
+		if runtime.GOOS == "windows" {
+			return []string{"cmd", "/c", script}
+		}
+		return []string{"sh", "-c", script}
+	case NoShell:
+		return strings.Fields(script)
+	default:
+		return append(strings.Fields(shell), script)
+	}
+}
+
+// runShell runs script with shell (see shellArgs), returning an error if it
+// exits non-zero
+func runShell(ctx context.Context, runner CommandRunner, shell, script, dir string, stdout, stderr io.Writer) error {
+	code, err := runner.Run(ctx, Command{Args: shellArgs(shell, script), Dir: dir, Stdout: stdout, Stderr: stderr})
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return fmt.Errorf("exit status %d", code)
+	}
+	return nil
+}
+
+// runVerify runs the verify command in workDir, through shell (see shellArgs).
+// With parallel set, each line of verify is run as a separate command,
+// at most GOMAXPROCS at a time. Every command runs even if another fails,
+// and the error lists the ones that failed. Their combined output is
+// written to stdout in command order once all have finished.
+//
+// With NoShell there is no shell to run a multi-line script, so without
+// parallel each line is run in turn, stopping at the first that fails.
+func runVerify(ctx context.Context, runner CommandRunner, shell, verify string, parallel bool, workDir string, stdout, stderr io.Writer) error {
+	commands := verifyCommands(verify)
+	if !parallel || len(commands) < 2 {
+		if shell != NoShell {
+			return runShell(ctx, runner, shell, verify, workDir, stdout, stderr)
+		}
+		for _, command := range commands {
+			if err := runShell(ctx, runner, shell, command, workDir, stdout, stderr); err != nil {
+				if len(commands) > 1 {
+					return fmt.Errorf("%s: %w", command, err)
+				}
+				return err
+			}
+		}
+		return nil
+	}
+
+	type result struct {
+		output bytes.Buffer
+		err    error
+	}
+	results := make([]result, len(commands))
+
+	limit := runtime.GOMAXPROCS(0)
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, command := range commands {
+		wg.Add(1)
+		go func(i int, command string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			output := &results[i].output
+			results[i].err = runShell(ctx, runner, shell, command, workDir, output, output)
+		}(i, command)
+	}
+	wg.Wait()
+
+	// Print outputs in command order so they don't interleave
+	var failed []string
+	for i, command := range commands {
+		status := ui.StatusIcon("✅", "[OK]")
+		if results[i].err != nil {
+			status = ui.StatusIcon("❌", "[FAIL]")
+			failed = append(failed, command)
+		}
+		fmt.Fprintln(stdout, withIcon(status, command))
+		stdout.Write(results[i].output.Bytes())
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d commands failed: %s", len(failed), len(commands), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// tailBuffer keeps the last max bytes written to it. It is safe for
+// concurrent writes, so it can collect both stdout and stderr.
+type tailBuffer struct {
+	mu        sync.Mutex
+	max       int
+	buf       []byte
+	truncated bool
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - b.max; over > 0 {
+		b.buf = append(b.buf[:0], b.buf[over:]...)
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+// String returns the kept output, starting at a whole character and marked
+// if earlier output was dropped
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.truncated {
+		return string(b.buf)
+	}
+	tail := b.buf
+	for len(tail) > 0 && !utf8.RuneStart(tail[0]) {
+		tail = tail[1:]
+	}
+	return "[... earlier output truncated ...]\n" + string(tail)
+}
+
+// verifyFeedback returns the prompt section that shows the agent the output
+// of the verify command that failed after the previous iteration
+func verifyFeedback(verify, output string) string {
+	output = strings.TrimRight(output, "\n")
+	if output == "" {
+		output = "(no output)"
+	}
+	return fmt.Sprintf("The verification command (%s) failed after the previous iteration. Its output is below; fix what it reports.\n\n"+
+		"--- BEGIN VERIFY OUTPUT ---\n%s\n--- END VERIFY OUTPUT ---", strings.TrimSpace(verify), output)
+}
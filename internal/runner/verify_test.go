@@ -0,0 +1,140 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/adriancodes/gumloop/internal/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyCommands(t *testing.T) {
+	assert.Equal(t, []string{"go vet ./...", "go test ./..."}, verifyCommands("go vet ./...\n\n  go test ./...  \n"))
+	assert.Equal(t, []string{"npm test"}, verifyCommands("npm test"))
+	assert.Nil(t, verifyCommands(""))
+}
+
+func TestRunVerify_Parallel(t *testing.T) {
+	dir := t.TempDir()
+	verify := "touch lint.ran && echo lint ok\n" +
+		"touch test.ran && echo 'FAIL: TestThing' && exit 1\n" +
+		"touch typecheck.ran"
+
+	var out bytes.Buffer
+	err := runVerify(context.Background(), execRunner{}, "", verify, true, dir, &out, &out)
+
+	// The failure is reported by command...
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 of 3 commands failed")
+	assert.Contains(t, err.Error(), "exit 1")
+	assert.NotContains(t, err.Error(), "lint.ran")
+
+	// ...every command still ran...
+	for _, name := range []string{"lint.ran", "test.ran", "typecheck.ran"} {
+		assert.FileExists(t, filepath.Join(dir, name))
+	}
+
+	// ...and output is grouped per command in order
+	assert.Equal(t, "✅ touch lint.ran && echo lint ok\nlint ok\n"+
+		"❌ touch test.ran && echo 'FAIL: TestThing' && exit 1\nFAIL: TestThing\n"+
+		"✅ touch typecheck.ran\n", out.String())
+}
+
+func TestRunVerify_ParallelASCII(t *testing.T) {
+	ui.SetASCII(true)
+	t.Cleanup(func() { ui.SetASCII(false) })
+
+	var out bytes.Buffer
+	err := runVerify(context.Background(), execRunner{}, "", "true\nfalse", true, t.TempDir(), &out, &out)
+	require.Error(t, err)
+	assert.Equal(t, "[OK] true\n[FAIL] false\n", out.String())
+}
+
+func TestRunVerify_ParallelAllPass(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, runVerify(context.Background(), execRunner{}, "", "true\ntrue", true, t.TempDir(), &out, &out))
+}
+
+func TestRunVerify_Serial(t *testing.T) {
+	dir := t.TempDir()
+
+	// Without parallel, the lines form one shell script that stops at the
+	// first failure (set -e is not implied, so make it explicit)
+	var out bytes.Buffer
+	err := runVerify(context.Background(), execRunner{}, "", "set -e\nfalse\ntouch after.ran", false, dir, &out, &out)
+	assert.Error(t, err)
+
+	_, statErr := os.Stat(filepath.Join(dir, "after.ran"))
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+func TestShellArgs(t *testing.T) {
+	assert.Equal(t, []string{"bash", "-lc", "make test"}, shellArgs("bash -lc", "make test"))
+	assert.Equal(t, []string{"go", "test", "./..."}, shellArgs(NoShell, "go test  ./..."))
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, []string{"sh", "-c", "make test"}, shellArgs("", "make test"))
+	}
+}
+
+func TestRunVerify_CompoundCommand(t *testing.T) {
+	dir := t.TempDir()
+
+	var out bytes.Buffer
+	err := runVerify(context.Background(), execRunner{}, "sh -c", "echo lint ok && touch tests.ran", false, dir, &out, &out)
+	require.NoError(t, err)
+	assert.Equal(t, "lint ok\n", out.String())
+	assert.FileExists(t, filepath.Join(dir, "tests.ran"))
+}
+
+func TestRunVerify_NoShell(t *testing.T) {
+	dir := t.TempDir()
+
+	// Without a shell, && and the rest are plain arguments to echo
+	var out bytes.Buffer
+	err := runVerify(context.Background(), execRunner{}, NoShell, "echo lint ok && touch tests.ran", false, dir, &out, &out)
+	require.NoError(t, err)
+	assert.Equal(t, "lint ok && touch tests.ran\n", out.String())
+	assert.NoFileExists(t, filepath.Join(dir, "tests.ran"))
+}
+
+func TestRunVerify_NoShellMultiLine(t *testing.T) {
+	dir := t.TempDir()
+
+	// Each line is its own command rather than one argv of every word
+	var out bytes.Buffer
+	err := runVerify(context.Background(), execRunner{}, NoShell, "echo lint ok\ntouch tests.ran", false, dir, &out, &out)
+	require.NoError(t, err)
+	assert.Equal(t, "lint ok\n", out.String())
+	assert.FileExists(t, filepath.Join(dir, "tests.ran"))
+
+	// The first failing line stops the rest
+	err = runVerify(context.Background(), execRunner{}, NoShell, "false\ntouch after.ran", false, dir, &out, &out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "false")
+	assert.NoFileExists(t, filepath.Join(dir, "after.ran"))
+}
+
+func TestTailBuffer(t *testing.T) {
+	b := &tailBuffer{max: 10}
+	b.Write([]byte("hello"))
+	assert.Equal(t, "hello", b.String())
+
+	b.Write([]byte(" world, bye"))
+	assert.Equal(t, "[... earlier output truncated ...]\nworld, bye", b.String())
+
+	// A cut through a multi-byte character starts at the next whole one
+	b = &tailBuffer{max: 4}
+	b.Write([]byte("aé✓"))
+	assert.Equal(t, "[... earlier output truncated ...]\n✓", b.String())
+}
+
+func TestVerifyFeedback(t *testing.T) {
+	assert.Equal(t, "The verification command (go test ./...) failed after the previous iteration. Its output is below; fix what it reports.\n\n"+
+		"--- BEGIN VERIFY OUTPUT ---\nFAIL\n--- END VERIFY OUTPUT ---", verifyFeedback("go test ./...\n", "FAIL\n\n"))
+	assert.Contains(t, verifyFeedback("make test", ""), "--- BEGIN VERIFY OUTPUT ---\n(no output)\n")
+}
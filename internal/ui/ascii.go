@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"os"
+	"strings"
+)
+
+// asciiMode replaces emoji status icons with plain ASCII tags, for
+// terminals that render emoji as mojibake
+var asciiMode bool
+
+// SetASCII turns ASCII icons on or off for all renderers.
+func SetASCII(on bool) {
+	asciiMode = on
+}
+
+// ASCII reports whether ASCII icons are in use.
+func ASCII() bool {
+	return asciiMode
+}
+
+// DetectASCII reports whether the terminal is unlikely to render emoji:
+// a dumb or Linux console TERM, or a locale that isn't UTF-8.
+func DetectASCII() bool {
+	switch os.Getenv("TERM") {
+	case "dumb", "linux":
+		return true
+	}
+
+	// The first locale variable that is set wins, as in setlocale(3)
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			upper := strings.ToUpper(value)
+			return !strings.Contains(upper, "UTF-8") && !strings.Contains(upper, "UTF8")
+		}
+	}
+	return false
+}
+
+// StatusIcon returns emoji, or its ASCII equivalent in ASCII mode
+func StatusIcon(emoji, ascii string) string {
+	if asciiMode {
+		return ascii
+	}
+	return emoji
+}
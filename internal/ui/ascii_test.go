@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// useASCII turns ASCII icons on for the duration of the test
+func useASCII(t *testing.T) {
+	t.Helper()
+	SetASCII(true)
+	t.Cleanup(func() { SetASCII(false) })
+}
+
+func TestFormatExitReason_ASCII(t *testing.T) {
+	useASCII(t)
+
+	tests := []struct {
+		code ExitCode
+		want string
+	}{
+		{ExitSuccess, "[OK]"},
+		{ExitError, "[ERROR]"},
+		{ExitSafety, "[STOP]"},
+		{ExitMaxIterations, "[TIME]"},
+		{ExitStuck, "[WARN]"},
+		{ExitInterrupt, "[PAUSE]"},
+		{ExitCode(42), "[?]"},
+	}
+	for _, tt := range tests {
+		icon, _ := formatExitReason(tt.code, "")
+		assert.Equal(t, tt.want, icon, "exit code %d", tt.code)
+	}
+
+	summary := RenderRunSummary(SummaryConfig{Agent: "claude", ExitCode: ExitSuccess})
+	assert.Contains(t, summary, "Exit: [OK] Complete (no changes)")
+	assert.Contains(t, summary, "RUN COMPLETE")
+	assert.NotContains(t, summary, "✅")
+	assert.NotContains(t, summary, "🍩")
+}
+
+func TestIterationRendering_ASCII(t *testing.T) {
+	useASCII(t)
+
+	cfg := IterationConfig{
+		Number:       3,
+		MaxIteration: 20,
+		Timestamp:    time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		CLI:          "claude",
+		ToolCalls:    []ToolCall{{Name: "Read"}, {Name: "Edit"}},
+		HiddenTools:  1,
+		Commits:      1,
+		Modified:     2,
+		VerifyFailed: true,
+		Pushed:       true,
+	}
+
+	header := RenderIterationHeader(cfg)
+	assert.Contains(t, header, "  ITERATION 3 of 20")
+	assert.NotContains(t, header, "🚂")
+
+	assert.Contains(t, RenderToolCall(ToolCall{Name: "Read", Extra: "main.go"}), "[TOOL] ")
+
+	summary := RenderIterationSummary(cfg)
+	assert.Contains(t, summary, "[OK] Commits: 1")
+	assert.Contains(t, summary, "[DIFF] Changes: 2 modified")
+	assert.Contains(t, summary, "[TOOL] Tools: 2 (1 hidden)")
+	assert.Contains(t, summary, "[FAIL] Verification failed")
+	assert.Contains(t, summary, "[PUSH] Pushed to origin")
+	for _, emoji := range []string{"✓", "✗", "📝", "🔧", "☁️"} {
+		assert.NotContains(t, summary, emoji)
+	}
+
+	cfg.Commits = 0
+	assert.Contains(t, RenderIterationSummary(cfg), "[--] Commits: 0")
+}
+
+func TestDetectASCII(t *testing.T) {
+	tests := []struct {
+		name                       string
+		term, lcAll, lcCtype, lang string
+		want                       bool
+	}{
+		{name: "utf-8 locale", term: "xterm-256color", lang: "en_US.UTF-8", want: false},
+		{name: "dumb terminal", term: "dumb", lang: "en_US.UTF-8", want: true},
+		{name: "linux console", term: "linux", lang: "en_US.UTF-8", want: true},
+		{name: "C locale", term: "xterm", lang: "C", want: true},
+		{name: "LC_ALL wins over LANG", term: "xterm", lcAll: "POSIX", lang: "en_US.UTF-8", want: true},
+		{name: "LC_CTYPE utf8", term: "xterm", lcCtype: "de_DE.utf8", lang: "C", want: false},
+		{name: "no locale set", term: "xterm", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TERM", tt.term)
+			t.Setenv("LC_ALL", tt.lcAll)
+			t.Setenv("LC_CTYPE", tt.lcCtype)
+			t.Setenv("LANG", tt.lang)
+			assert.Equal(t, tt.want, DetectASCII())
+		})
+	}
+}
@@ -7,6 +7,17 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
+// Banner styles for the run startup banner
+const (
+	BannerStyleFull    = "full"    // Title box and one line per setting
+	BannerStyleCompact = "compact" // A single line
+)
+
+// BannerStyles returns the valid banner styles.
+func BannerStyles() []string {
+	return []string{BannerStyleFull, BannerStyleCompact}
+}
+
 // BannerConfig contains all the information needed to render a startup banner.
 type BannerConfig struct {
 	Version        string // e.g., "v2.0.0"
@@ -17,6 +28,8 @@ type BannerConfig struct {
 	Branch         string // e.g., "main"
 	MaxIterations  int    // 0 for unlimited, >0 for specific max
 	ShowRalphQuote bool   // true to include Ralph ASCII art and quote
+	PromptTokens   int    // Estimated tokens in the resolved prompt (0 to hide)
+	MemoryTokens   int    // How many of PromptTokens come from session memory
 }
 
 // RenderBanner creates the startup banner display.
@@ -56,13 +69,18 @@ func RenderBanner(cfg BannerConfig) string {
 	// Mode line
 	mode := "Single run"
 	if cfg.Autonomous {
-		mode = "🚂 Choo-choo (autonomous)"
+		mode = WithIcon(currentTheme.IterationIcon, "Choo-choo (autonomous)")
 	}
 	lines = append(lines, fmt.Sprintf(" Mode:   %s", mode))
 
 	// Prompt file
 	lines = append(lines, fmt.Sprintf(" Prompt: %s", cfg.PromptFile))
 
+	// Prompt size (only show if estimated)
+	if cfg.PromptTokens > 0 {
+		lines = append(lines, fmt.Sprintf(" Tokens: %s", tokenSummary(cfg)))
+	}
+
 	// Branch
 	if cfg.Branch != "" {
 		lines = append(lines, fmt.Sprintf(" Branch: %s", cfg.Branch))
@@ -86,6 +104,44 @@ func RenderBanner(cfg BannerConfig) string {
 	return sb.String()
 }
 
+// RenderCompactBanner renders the banner as a single line, for small terminals.
+//
+// Example output:
+//
+//	gumloop v2.0.0 · claude/sonnet · choo-choo · main
+func RenderCompactBanner(cfg BannerConfig) string {
+	agent := cfg.CLI
+	if cfg.Model != "" {
+		agent += "/" + cfg.Model
+	}
+
+	mode := "single run"
+	if cfg.Autonomous {
+		mode = "choo-choo"
+		if cfg.MaxIterations > 0 {
+			mode = fmt.Sprintf("choo-choo (max %d)", cfg.MaxIterations)
+		}
+	}
+
+	fields := []string{"gumloop " + cfg.Version, agent, mode}
+	if cfg.Branch != "" {
+		fields = append(fields, cfg.Branch)
+	}
+	if cfg.PromptTokens > 0 {
+		fields = append(fields, FormatTokens(cfg.PromptTokens)+" tokens")
+	}
+	return strings.Join(fields, MutedStyle.Render(" · ")) + "\n"
+}
+
+// tokenSummary describes the prompt's estimated size, e.g. "~1.2k (~300 from memory)"
+func tokenSummary(cfg BannerConfig) string {
+	summary := FormatTokens(cfg.PromptTokens)
+	if cfg.MemoryTokens > 0 {
+		summary += fmt.Sprintf(" (%s from memory)", FormatTokens(cfg.MemoryTokens))
+	}
+	return summary
+}
+
 // RenderHelpBanner is a convenience function for rendering the banner in help output.
 // It includes Ralph ASCII art and a random quote.
 func RenderHelpBanner(version string) string {
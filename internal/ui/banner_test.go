@@ -242,6 +242,54 @@ func TestRenderStartupBanner(t *testing.T) {
 	}
 }
 
+func TestRenderCompactBanner(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  BannerConfig
+		want string
+	}{
+		{
+			name: "choo-choo with model and branch",
+			cfg:  BannerConfig{Version: "v2.0.0", CLI: "claude", Model: "sonnet", Autonomous: true, Branch: "main"},
+			want: "gumloop v2.0.0 · claude/sonnet · choo-choo · main",
+		},
+		{
+			name: "max iterations",
+			cfg:  BannerConfig{Version: "v2.0.0", CLI: "codex", Autonomous: true, MaxIterations: 20, Branch: "dev"},
+			want: "gumloop v2.0.0 · codex · choo-choo (max 20) · dev",
+		},
+		{
+			name: "single run without branch",
+			cfg:  BannerConfig{Version: "v2.0.0", CLI: "gemini"},
+			want: "gumloop v2.0.0 · gemini · single run",
+		},
+		{
+			name: "token estimate",
+			cfg:  BannerConfig{Version: "v2.0.0", CLI: "claude", Branch: "main", PromptTokens: 1234},
+			want: "gumloop v2.0.0 · claude · single run · main · ~1.2k tokens",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := RenderCompactBanner(tt.cfg)
+			assert.Equal(t, 1, strings.Count(result, "\n"), "compact banner should be a single line")
+			assert.Contains(t, result, tt.want)
+		})
+	}
+}
+
+func TestRenderBanner_Tokens(t *testing.T) {
+	cfg := BannerConfig{Version: "v2.0.0", CLI: "claude", PromptFile: "PROMPT.md"}
+	assert.NotContains(t, RenderBanner(cfg), "Tokens:")
+
+	cfg.PromptTokens = 850
+	assert.Contains(t, RenderBanner(cfg), " Tokens: ~850\n")
+
+	cfg.MemoryTokens = 120
+	assert.Contains(t, RenderBanner(cfg), " Tokens: ~850 (~120 from memory)\n")
+}
+
 func TestBannerConfig_Validation(t *testing.T) {
 	// Test that banner renders without panics even with unusual configs
 	tests := []struct {
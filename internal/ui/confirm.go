@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Confirm asks a yes/no question on w and reads the answer from r.
+// "y"/"yes" and "n"/"no" are accepted in any case; an empty answer,
+// anything else, or end of input returns def. The prompt gets a
+// "(y/N)" or "(Y/n)" suffix showing the default.
+//
+// Pass the same *bufio.Reader to consecutive prompts: a fresh buffer over
+// piped input can read ahead and swallow the next answer.
+func Confirm(r io.Reader, w io.Writer, prompt string, def bool) bool {
+	hint := "(y/N)"
+	if def {
+		hint = "(Y/n)"
+	}
+	fmt.Fprintf(w, "%s %s: ", prompt, hint)
+
+	// A final line without a newline still counts as an answer
+	response, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && response == "" {
+		fmt.Fprintln(w)
+		return def
+	}
+
+	switch strings.TrimSpace(strings.ToLower(response)) {
+	case "y", "yes":
+		return true
+	case "n", "no":
+		return false
+	default:
+		return def
+	}
+}
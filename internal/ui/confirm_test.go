@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		def   bool
+		want  bool
+	}{
+		{name: "yes", input: "yes\n", want: true},
+		{name: "y", input: "y\n", want: true},
+		{name: "uppercase Y", input: "Y\n", want: true},
+		{name: "whitespace around yes", input: "  yes  \n", want: true},
+		{name: "no", input: "no\n", def: true, want: false},
+		{name: "n", input: "n\n", def: true, want: false},
+		{name: "empty uses default no", input: "\n", def: false, want: false},
+		{name: "empty uses default yes", input: "\n", def: true, want: true},
+		{name: "unrecognized uses default", input: "maybe\n", def: true, want: true},
+		{name: "EOF uses default no", input: "", def: false, want: false},
+		{name: "EOF uses default yes", input: "", def: true, want: true},
+		{name: "answer without newline", input: "y", def: false, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			got := Confirm(strings.NewReader(tt.input), &out, "Proceed?", tt.def)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConfirm_PromptShowsDefault(t *testing.T) {
+	var out strings.Builder
+	Confirm(strings.NewReader("\n"), &out, "Overwrite?", false)
+	assert.Equal(t, "Overwrite? (y/N): ", out.String())
+
+	out.Reset()
+	Confirm(strings.NewReader("\n"), &out, "Overwrite?", true)
+	assert.Equal(t, "Overwrite? (Y/n): ", out.String())
+
+	// On EOF the prompt line is ended so later output starts on its own line
+	out.Reset()
+	Confirm(strings.NewReader(""), &out, "Overwrite?", false)
+	assert.Equal(t, "Overwrite? (y/N): \n", out.String())
+}
@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/adriancodes/gumloop/internal/logging"
+	"gopkg.in/yaml.v3"
+)
+
+// fallbackModelsFileName is the user's fallback model list, in the global
+// config directory (~/.config/gumloop)
+const fallbackModelsFileName = "fallback-models.yaml"
+
+// fallbackModelEntry is one model in the fallback models file
+type fallbackModelEntry struct {
+	ID   string `yaml:"id"`
+	Name string `yaml:"name"`
+	Desc string `yaml:"desc"`
+}
+
+// fallbackModels returns the models offered when the API fetch fails: the
+// built-in list for agentID, merged with the user's fallback models file
+func fallbackModels(agentID string) []modelOption {
+	builtin := builtinFallbackModels(agentID)
+
+	path, err := fallbackModelsPath()
+	if err != nil {
+		return builtin
+	}
+	overrides, err := loadFallbackModels(path)
+	if err != nil {
+		logging.Debugf("Ignoring fallback models file: %v", err)
+		return builtin
+	}
+	return mergeFallbackModels(builtin, overrides[agentID])
+}
+
+// fallbackModelsPath returns where the user's fallback models file lives
+func fallbackModelsPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".config", "gumloop", fallbackModelsFileName), nil
+}
+
+// loadFallbackModels reads a fallback models file: a list of models per
+// agent ID. A missing file has no models.
+func loadFallbackModels(path string) (map[string][]modelOption, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var file map[string][]fallbackModelEntry
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid fallback models file %s: %w", path, err)
+	}
+
+	models := make(map[string][]modelOption, len(file))
+	for agentID, entries := range file {
+		for _, entry := range entries {
+			if entry.ID == "" {
+				return nil, fmt.Errorf("invalid fallback models file %s: a %s model has no id", path, agentID)
+			}
+			name := entry.Name
+			if name == "" {
+				name = entry.ID
+			}
+			models[agentID] = append(models[agentID], modelOption{ID: entry.ID, Name: name, Desc: entry.Desc})
+		}
+	}
+	return models, nil
+}
+
+// mergeFallbackModels puts the user's models first, in their order, then
+// the built-in models they don't redefine (matched by ID)
+func mergeFallbackModels(builtin, overrides []modelOption) []modelOption {
+	if len(overrides) == 0 {
+		return builtin
+	}
+
+	merged := append([]modelOption{}, overrides...)
+	defined := make(map[string]bool, len(overrides))
+	for _, m := range overrides {
+		defined[m.ID] = true
+	}
+	for _, m := range builtin {
+		if !defined[m.ID] {
+			merged = append(merged, m)
+		}
+	}
+	return merged
+}
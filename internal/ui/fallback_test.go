@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFallbackModels writes content as the fallback models file in a
+// temporary home directory
+func writeFallbackModels(t *testing.T, content string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".config", "gumloop")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	path := filepath.Join(dir, fallbackModelsFileName)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func modelIDs(models []modelOption) []string {
+	ids := make([]string, len(models))
+	for i, m := range models {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+func TestFallbackModels_Override(t *testing.T) {
+	writeFallbackModels(t, `
+opencode:
+  - id: gpt-5-codex
+    name: GPT-5 Codex
+    desc: Updated description
+  - id: kimi-k2
+claude:
+  - id: claude-sonnet-5
+    name: Sonnet 5
+    desc: Newest
+`)
+
+	// Same IDs replace the built-in entry; new ones come first
+	opencode := fallbackModels("opencode")
+	assert.Equal(t, []string{"gpt-5-codex", "kimi-k2", "claude-sonnet-4-5"}, modelIDs(opencode))
+	assert.Equal(t, "Updated description", opencode[0].Desc)
+	assert.Equal(t, "kimi-k2", opencode[1].Name, "name defaults to the id")
+
+	claude := fallbackModels("claude")
+	assert.Equal(t, []string{"claude-sonnet-5", "sonnet", "opus", "haiku"}, modelIDs(claude))
+
+	// Agents the file doesn't mention keep the built-ins
+	assert.Equal(t, builtinFallbackModels("gemini"), fallbackModels("gemini"))
+
+	// The file also extends agents without built-ins
+	writeFallbackModels(t, "my-agent:\n  - id: local-model\n")
+	assert.Equal(t, []string{"local-model"}, modelIDs(fallbackModels("my-agent")))
+}
+
+func TestFallbackModels_NoFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	assert.Equal(t, builtinFallbackModels("codex"), fallbackModels("codex"))
+}
+
+func TestFallbackModels_InvalidFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"malformed yaml", "claude: [unclosed"},
+		{"wrong shape", "claude: sonnet"},
+		{"missing id", "claude:\n  - name: Sonnet\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFallbackModels(t, tt.content)
+
+			_, err := loadFallbackModels(path)
+			assert.Error(t, err)
+			assert.Equal(t, builtinFallbackModels("claude"), fallbackModels("claude"))
+		})
+	}
+}
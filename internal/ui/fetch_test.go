@@ -8,7 +8,7 @@ import (
 func TestFetchModels(t *testing.T) {
 	agents := []string{"claude", "codex", "gemini", "ollama", "cursor", "opencode"}
 	for _, agent := range agents {
-		models := modelsForAgent(agent)
+		models, _ := modelsForAgent(agent, ModelSource{})
 		fmt.Printf("\n=== %s (%d models) ===\n", agent, len(models))
 		for i, m := range models {
 			fmt.Printf("  %d: %s (%s)\n", i, m.Name, m.ID)
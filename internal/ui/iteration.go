@@ -12,7 +12,9 @@ type IterationConfig struct {
 	MaxIteration int           // Max iterations (0 for unlimited)
 	Timestamp    time.Time     // When the iteration started
 	CLI          string        // Agent name (e.g., "claude")
+	Status       string        // Optional extra header line (e.g., "📋 3 plan items left")
 	ToolCalls    []ToolCall    // Tools used during iteration
+	HiddenTools  int           // How many of ToolCalls were hidden from the live output
 	Duration     time.Duration // How long the iteration took
 	Commits      int           // Number of commits made
 	Modified     int           // Number of modified files
@@ -22,6 +24,11 @@ type IterationConfig struct {
 	VerifyFailed bool          // Whether verification failed (if verify command was run)
 	Pushed       bool          // Whether changes were pushed
 	PushFailed   bool          // Whether push failed
+
+	// With TerseIfUnchanged set, the header is one line (the outcome isn't
+	// known yet) and so is the summary of an iteration with no commits,
+	// no changes and no failed verification
+	TerseIfUnchanged bool
 }
 
 // ToolCall represents a single tool use during iteration
@@ -38,6 +45,10 @@ type ToolCall struct {
 //     14:32:15 | claude
 //   ══════════════════════════════════════
 func RenderIterationHeader(cfg IterationConfig) string {
+	if cfg.TerseIfUnchanged {
+		return renderTerseIterationHeader(cfg)
+	}
+
 	var sb strings.Builder
 
 	// Top separator
@@ -49,8 +60,8 @@ func RenderIterationHeader(cfg IterationConfig) string {
 	if cfg.MaxIteration > 0 {
 		maxDisplay = fmt.Sprintf(" of %d", cfg.MaxIteration)
 	}
-	iterLine := fmt.Sprintf("  🚂 ITERATION %d%s", cfg.Number, maxDisplay)
-	sb.WriteString(IterationHeaderStyle.Render(iterLine))
+	iterLine := "  " + WithIcon(currentTheme.IterationIcon, fmt.Sprintf("ITERATION %d%s", cfg.Number, maxDisplay))
+	sb.WriteString(IterationHeaderStyle.Border(currentTheme.Border, true, false, true, false).Render(iterLine))
 	sb.WriteString("\n")
 
 	// Timestamp and CLI line
@@ -59,6 +70,11 @@ func RenderIterationHeader(cfg IterationConfig) string {
 	sb.WriteString(MutedStyle.Render(infoLine))
 	sb.WriteString("\n")
 
+	if cfg.Status != "" {
+		sb.WriteString("  " + cfg.Status)
+		sb.WriteString("\n")
+	}
+
 	// Bottom separator
 	sb.WriteString(DoubleSeparator(38))
 	sb.WriteString("\n")
@@ -66,6 +82,22 @@ func RenderIterationHeader(cfg IterationConfig) string {
 	return sb.String()
 }
 
+// renderTerseIterationHeader renders the header on one line:
+//
+//   🚂 Iteration 3 of 20 · 14:32:15 · claude
+func renderTerseIterationHeader(cfg IterationConfig) string {
+	maxDisplay := ""
+	if cfg.MaxIteration > 0 {
+		maxDisplay = fmt.Sprintf(" of %d", cfg.MaxIteration)
+	}
+	line := WithIcon(currentTheme.IterationIcon, fmt.Sprintf("Iteration %d%s", cfg.Number, maxDisplay))
+	info := fmt.Sprintf(" · %s · %s", cfg.Timestamp.Format("15:04:05"), cfg.CLI)
+	if cfg.Status != "" {
+		info += " · " + cfg.Status
+	}
+	return line + MutedStyle.Render(info)
+}
+
 // RenderToolCall renders a single tool call line.
 //
 // Example output:
@@ -75,7 +107,7 @@ func RenderIterationHeader(cfg IterationConfig) string {
 func RenderToolCall(tc ToolCall) string {
 	var sb strings.Builder
 
-	sb.WriteString("🔧 ")
+	sb.WriteString(StatusIcon("🔧", "[TOOL]") + " ")
 	sb.WriteString(ToolStyle.Render(tc.Name))
 
 	if tc.Extra != "" {
@@ -98,6 +130,13 @@ func RenderToolCall(tc ToolCall) string {
 //     ☁️  Pushed to origin/main
 //   ──────────────────────────────────────
 func RenderIterationSummary(cfg IterationConfig) string {
+	// Nothing happened: one line is enough
+	changed := cfg.Commits > 0 || cfg.Modified+cfg.Staged+cfg.Untracked > 0
+	if cfg.TerseIfUnchanged && !changed && !cfg.VerifyFailed {
+		line := fmt.Sprintf("%s Iteration %d: no commits or changes (%s)", StatusIcon("○", "[--]"), cfg.Number, FormatDuration(cfg.Duration))
+		return MutedStyle.Render(line) + "\n"
+	}
+
 	var sb strings.Builder
 
 	// Top separator
@@ -111,10 +150,10 @@ func RenderIterationSummary(cfg IterationConfig) string {
 	sb.WriteString("\n")
 
 	// Commits line
-	commitIcon := "✓"
+	commitIcon := StatusIcon("✓", "[OK]")
 	commitStyle := SuccessStyle
 	if cfg.Commits == 0 {
-		commitIcon = "○"
+		commitIcon = StatusIcon("○", "[--]")
 		commitStyle = MutedStyle
 	}
 	commitsLine := fmt.Sprintf("  %s Commits: %d", commitIcon, cfg.Commits)
@@ -123,7 +162,7 @@ func RenderIterationSummary(cfg IterationConfig) string {
 
 	// Changes line
 	totalChanges := cfg.Modified + cfg.Staged + cfg.Untracked
-	changesIcon := "📝"
+	changesIcon := StatusIcon("📝", "[DIFF]")
 	changesLine := fmt.Sprintf("  %s Changes: %d modified, %d staged, %d new",
 		changesIcon, cfg.Modified, cfg.Staged, cfg.Untracked)
 	if totalChanges == 0 {
@@ -133,13 +172,20 @@ func RenderIterationSummary(cfg IterationConfig) string {
 	}
 	sb.WriteString("\n")
 
+	// Tools line (only show if some tool calls were hidden from the live output)
+	if cfg.HiddenTools > 0 {
+		toolsLine := fmt.Sprintf("  %s Tools: %d (%d hidden)", StatusIcon("🔧", "[TOOL]"), len(cfg.ToolCalls), cfg.HiddenTools)
+		sb.WriteString(MutedStyle.Render(toolsLine))
+		sb.WriteString("\n")
+	}
+
 	// Verification line (only show if verify command was configured)
 	if cfg.Verified || cfg.VerifyFailed {
-		verifyIcon := "✓"
+		verifyIcon := StatusIcon("✓", "[OK]")
 		verifyText := "Verification passed"
 		verifyStyle := SuccessStyle
 		if cfg.VerifyFailed {
-			verifyIcon = "✗"
+			verifyIcon = StatusIcon("✗", "[FAIL]")
 			verifyText = "Verification failed"
 			verifyStyle = ErrorStyle
 		}
@@ -151,10 +197,10 @@ func RenderIterationSummary(cfg IterationConfig) string {
 	// Push line (only show if push was attempted)
 	if cfg.Pushed || cfg.PushFailed {
 		if cfg.Pushed {
-			pushLine := "  ☁️  Pushed to origin"
+			pushLine := "  " + StatusIcon("☁️ ", "[PUSH]") + " Pushed to origin"
 			sb.WriteString(SuccessStyle.Render(pushLine))
 		} else if cfg.PushFailed {
-			pushLine := "  ⚠️  Push failed"
+			pushLine := "  " + StatusIcon("⚠️ ", "[WARN]") + " Push failed"
 			sb.WriteString(WarningStyle.Render(pushLine))
 		}
 		sb.WriteString("\n")
@@ -31,6 +31,19 @@ func TestRenderIterationHeader(t *testing.T) {
 				"═", // Double separator
 			},
 		},
+		{
+			name: "with status line",
+			cfg: IterationConfig{
+				Number:    2,
+				Timestamp: time.Date(2024, 1, 1, 14, 32, 15, 0, time.UTC),
+				CLI:       "claude",
+				Status:    "📋 3 plan items left",
+			},
+			contains: []string{
+				"ITERATION 2",
+				"📋 3 plan items left",
+			},
+		},
 		{
 			name: "without max iterations (unlimited)",
 			cfg: IterationConfig{
@@ -259,6 +272,29 @@ func TestRenderIterationSummary(t *testing.T) {
 				"☁️",
 			},
 		},
+		{
+			name: "hidden tools line",
+			cfg: IterationConfig{
+				Number:      1,
+				Duration:    10 * time.Second,
+				ToolCalls:   []ToolCall{{Name: "Read"}, {Name: "Read"}, {Name: "Edit"}},
+				HiddenTools: 2,
+			},
+			contains: []string{
+				"🔧 Tools: 3 (2 hidden)",
+			},
+		},
+		{
+			name: "no tools line when nothing hidden",
+			cfg: IterationConfig{
+				Number:    1,
+				Duration:  10 * time.Second,
+				ToolCalls: []ToolCall{{Name: "Read"}},
+			},
+			notContains: []string{
+				"Tools:",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -286,6 +322,36 @@ func TestRenderIterationSummary(t *testing.T) {
 	}
 }
 
+func TestRenderIteration_TerseIfUnchanged(t *testing.T) {
+	cfg := IterationConfig{
+		Number:           4,
+		MaxIteration:     20,
+		Timestamp:        time.Date(2024, 1, 1, 14, 32, 15, 0, time.UTC),
+		CLI:              "claude",
+		Status:           "📋 3 plan items left",
+		Duration:         12 * time.Second,
+		TerseIfUnchanged: true,
+	}
+
+	header := RenderIterationHeader(cfg)
+	assert.NotContains(t, header, "\n", "header is one line")
+	assert.Contains(t, header, "Iteration 4 of 20")
+	assert.Contains(t, header, "14:32:15 · claude · 📋 3 plan items left")
+	assert.NotContains(t, header, "═")
+
+	summary := RenderIterationSummary(cfg)
+	assert.Equal(t, 1, strings.Count(summary, "\n"), "summary is one line")
+	assert.Contains(t, summary, "Iteration 4: no commits or changes (12s)")
+
+	// Commits, changes and failed verification get the full summary
+	for _, changed := range []IterationConfig{{Commits: 1}, {Untracked: 2}, {VerifyFailed: true}} {
+		changed.Number, changed.TerseIfUnchanged = 4, true
+		summary := RenderIterationSummary(changed)
+		assert.Contains(t, summary, "Iteration 4 complete")
+		assert.Contains(t, summary, "─")
+	}
+}
+
 func TestRenderToolCalls(t *testing.T) {
 	tests := []struct {
 		name     string
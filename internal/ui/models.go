@@ -1,14 +1,27 @@
 package ui
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"os"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
 )
 
-const modelsAPIURL = "https://models.dev/api.json"
+// modelsAPIURL is the models.dev endpoint (a var so tests can point it at a local server)
+var modelsAPIURL = "https://models.dev/api.json"
+
+// modelsHTTPClient is used to fetch the model list (bounded by ModelSource.Timeout)
+var modelsHTTPClient = &http.Client{}
+
+// DefaultModelsTimeout is how long the wizard waits for models.dev by default
+const DefaultModelsTimeout = 5 * time.Second
+
+// modelsFetchAttempts is how many times the fetch is tried within the timeout
+const modelsFetchAttempts = 2
 
 // agentToProvider maps gumloop agent IDs to models.dev provider keys
 var agentToProvider = map[string][]string{
@@ -34,31 +47,108 @@ type modelData struct {
 	Name string `json:"name"`
 }
 
-// fetchModelsFromAPI fetches models from the models.dev API
+// ModelSource says where the wizard gets its model lists from
+type ModelSource struct {
+	// File is a path to a local JSON file in the models.dev API format.
+	// When set (or when GUMLOOP_MODELS_FILE is set), models are read from it
+	// instead of the network, for air-gapped environments. The env var takes
+	// precedence.
+	File string
+
+	// Timeout bounds the whole models.dev fetch, retries included
+	// (zero means DefaultModelsTimeout)
+	Timeout time.Duration
+}
+
+// filePath returns the local models file to use, or empty for the network
+func (s ModelSource) filePath() string {
+	if path := os.Getenv("GUMLOOP_MODELS_FILE"); path != "" {
+		return path
+	}
+	return s.File
+}
+
+// timeout returns the fetch timeout, defaulting when unset
+func (s ModelSource) timeout() time.Duration {
+	if s.Timeout <= 0 {
+		return DefaultModelsTimeout
+	}
+	return s.Timeout
+}
+
+// fetchModelsFromAPI fetches models from the models.dev API (or the local models file)
 // Returns nil if fetch fails (caller should use fallback)
-func fetchModelsFromAPI(agentID string) []modelOption {
+func fetchModelsFromAPI(agentID string, source ModelSource) []modelOption {
 	providers, ok := agentToProvider[agentID]
 	if !ok {
 		return nil
 	}
 
-	// Fetch with timeout
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Get(modelsAPIURL)
+	// Read from the local file instead of the network when configured
+	if path := source.filePath(); path != "" {
+		return loadModelsFromFile(path, providers)
+	}
+
+	// Fetch with timeout, retrying transient failures while time remains
+	ctx, cancel := context.WithTimeout(context.Background(), source.timeout())
+	defer cancel()
+
+	for attempt := 1; attempt <= modelsFetchAttempts && ctx.Err() == nil; attempt++ {
+		apiResp, retry := fetchModelsOnce(ctx)
+		if apiResp != nil {
+			return collectModels(apiResp, providers)
+		}
+		if !retry {
+			break
+		}
+	}
+	return nil
+}
+
+// fetchModelsOnce makes a single request to models.dev.
+// On failure it returns nil and whether the error is worth retrying.
+func fetchModelsOnce(ctx context.Context) (modelsAPIResponse, bool) {
+	req, err := http.NewRequestWithContext(ctx, "GET", modelsAPIURL, nil)
 	if err != nil {
-		return nil
+		return nil, false
+	}
+
+	resp, err := modelsHTTPClient.Do(req)
+	if err != nil {
+		return nil, true
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil
+		return nil, resp.StatusCode >= 500
 	}
 
 	var apiResp modelsAPIResponse
 	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, false
+	}
+
+	return apiResp, false
+}
+
+// loadModelsFromFile reads models from a local JSON file in the models.dev API format.
+// Returns nil if the file is missing or invalid (caller should use fallback)
+func loadModelsFromFile(path string, providers []string) []modelOption {
+	data, err := os.ReadFile(path)
+	if err != nil {
 		return nil
 	}
 
+	var apiResp modelsAPIResponse
+	if err := json.Unmarshal(data, &apiResp); err != nil {
+		return nil
+	}
+
+	return collectModels(apiResp, providers)
+}
+
+// collectModels gathers the "latest" models for the given providers, sorted by name
+func collectModels(apiResp modelsAPIResponse, providers []string) []modelOption {
 	// Collect models from all relevant providers
 	var models []modelOption
 	seen := make(map[string]bool)
@@ -158,39 +248,17 @@ func isLatestModel(id, name string) bool {
 	return true
 }
 
+// datePattern matches YYYY-MM-DD, or YYYYMMDD with a plausible model-release year (2020-2030)
+var datePattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}|(?:202\d|2030)\d{4}`)
+
 // containsDate checks if a string contains a date pattern (YYYY-MM-DD or YYYYMMDD)
 func containsDate(s string) bool {
-	// Check for patterns like 2024-05-13 or 20241022
-	for i := 0; i < len(s)-7; i++ {
-		// Check for YYYY-MM-DD pattern
-		if i+9 < len(s) && s[i+4] == '-' && s[i+7] == '-' {
-			if isDigits(s[i:i+4]) && isDigits(s[i+5:i+7]) && isDigits(s[i+8:i+10]) {
-				return true
-			}
-		}
-		// Check for YYYYMMDD pattern
-		if isDigits(s[i : i+8]) {
-			year := s[i : i+4]
-			if year >= "2020" && year <= "2030" {
-				return true
-			}
-		}
-	}
-	return false
-}
-
-// isDigits checks if a string contains only digits
-func isDigits(s string) bool {
-	for _, c := range s {
-		if c < '0' || c > '9' {
-			return false
-		}
-	}
-	return true
+	return datePattern.MatchString(s)
 }
 
-// fallbackModels returns hardcoded models when API fetch fails
-func fallbackModels(agentID string) []modelOption {
+// builtinFallbackModels returns the hardcoded models used when the API fetch
+// fails (see fallbackModels for the user's overrides)
+func builtinFallbackModels(agentID string) []modelOption {
 	switch agentID {
 	case "claude":
 		return []modelOption{
@@ -0,0 +1,266 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// modelsFixture is a small payload in the models.dev API shape
+const modelsFixture = `{
+  "anthropic": {
+    "id": "anthropic",
+    "name": "Anthropic",
+    "models": {
+      "claude-sonnet-4-5": {"id": "claude-sonnet-4-5", "name": "Claude Sonnet 4.5"},
+      "claude-opus-4-5": {"id": "claude-opus-4-5", "name": "Claude Opus 4.5"},
+      "claude-opus-4-5-20251101": {"id": "claude-opus-4-5-20251101", "name": "Claude Opus 4.5 (2025-11-01)"}
+    }
+  },
+  "openai": {
+    "id": "openai",
+    "name": "OpenAI",
+    "models": {
+      "gpt-4o": {"id": "gpt-4o", "name": "GPT-4o"},
+      "text-embedding-3-large": {"id": "text-embedding-3-large", "name": "Embedding 3 Large"}
+    }
+  }
+}`
+
+// writeModelsFile writes content to a temp file and returns its path
+func writeModelsFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "models.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestFetchModelsFromAPI_LocalFile(t *testing.T) {
+	t.Setenv("GUMLOOP_MODELS_FILE", writeModelsFile(t, modelsFixture))
+
+	models := fetchModelsFromAPI("claude", ModelSource{})
+	require.Len(t, models, 2)
+	// Sorted by name, dated version filtered out
+	assert.Equal(t, "claude-opus-4-5", models[0].ID)
+	assert.Equal(t, "claude-sonnet-4-5", models[1].ID)
+
+	models = fetchModelsFromAPI("codex", ModelSource{})
+	require.Len(t, models, 1)
+	assert.Equal(t, "gpt-4o", models[0].ID)
+}
+
+func TestFetchModelsFromAPI_ConfiguredFile(t *testing.T) {
+	t.Setenv("GUMLOOP_MODELS_FILE", "")
+
+	models := fetchModelsFromAPI("cursor", ModelSource{File: writeModelsFile(t, modelsFixture)})
+	ids := make([]string, 0, len(models))
+	for _, m := range models {
+		ids = append(ids, m.ID)
+	}
+	assert.ElementsMatch(t, []string{"claude-sonnet-4-5", "claude-opus-4-5", "gpt-4o"}, ids)
+}
+
+func TestModelsForAgent_LocalFileFallback(t *testing.T) {
+	tests := []struct {
+		name string
+		path func(t *testing.T) string
+	}{
+		{
+			name: "missing file",
+			path: func(t *testing.T) string { return filepath.Join(t.TempDir(), "nope.json") },
+		},
+		{
+			name: "invalid JSON",
+			path: func(t *testing.T) string { return writeModelsFile(t, "{not json") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GUMLOOP_MODELS_FILE", tt.path(t))
+
+			assert.Nil(t, fetchModelsFromAPI("claude", ModelSource{}))
+
+			// Fallback models plus (default) and Custom...
+			models, offline := modelsForAgent("claude", ModelSource{})
+			assert.True(t, offline)
+			fallback := fallbackModels("claude")
+			require.Len(t, models, len(fallback)+2)
+			assert.Equal(t, fallback[0].ID, models[0].ID)
+		})
+	}
+}
+
+// serveModels points the models.dev URL at a test server for the duration of the test
+func serveModels(t *testing.T, status int, body string) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	origURL := modelsAPIURL
+	modelsAPIURL = server.URL
+	t.Cleanup(func() { modelsAPIURL = origURL })
+	t.Setenv("GUMLOOP_MODELS_FILE", "")
+}
+
+func TestFetchModelsFromAPI_Server(t *testing.T) {
+	serveModels(t, http.StatusOK, modelsFixture)
+
+	models := fetchModelsFromAPI("claude", ModelSource{})
+	require.Len(t, models, 2)
+	assert.Equal(t, "claude-opus-4-5", models[0].ID)
+	assert.Equal(t, "claude-sonnet-4-5", models[1].ID)
+
+	// Embedding models are dropped
+	models = fetchModelsFromAPI("codex", ModelSource{})
+	require.Len(t, models, 1)
+	assert.Equal(t, "gpt-4o", models[0].ID)
+}
+
+func TestFetchModelsFromAPI_ServerErrors(t *testing.T) {
+	t.Run("non-200 status", func(t *testing.T) {
+		serveModels(t, http.StatusInternalServerError, modelsFixture)
+		assert.Nil(t, fetchModelsFromAPI("claude", ModelSource{}))
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		serveModels(t, http.StatusOK, "{not json")
+		assert.Nil(t, fetchModelsFromAPI("claude", ModelSource{}))
+	})
+
+	t.Run("unknown agent", func(t *testing.T) {
+		serveModels(t, http.StatusOK, modelsFixture)
+		assert.Nil(t, fetchModelsFromAPI("nope", ModelSource{}))
+	})
+}
+
+func TestCollectModels_DedupAndSort(t *testing.T) {
+	apiResp := modelsAPIResponse{
+		"anthropic": {Models: map[string]modelData{
+			"claude-sonnet-4-5": {ID: "claude-sonnet-4-5", Name: "Claude Sonnet 4.5"},
+			"claude-haiku-4-5":  {ID: "claude-haiku-4-5", Name: "Claude Haiku 4.5"},
+		}},
+		"openai": {Models: map[string]modelData{
+			"gpt-4o": {ID: "gpt-4o", Name: "GPT-4o"},
+			// Same ID listed under a second provider
+			"claude-sonnet-4-5": {ID: "claude-sonnet-4-5", Name: "Claude Sonnet 4.5"},
+		}},
+	}
+
+	models := collectModels(apiResp, []string{"anthropic", "openai", "missing"})
+
+	ids := make([]string, 0, len(models))
+	for _, m := range models {
+		ids = append(ids, m.ID)
+	}
+	assert.Equal(t, []string{"claude-haiku-4-5", "claude-sonnet-4-5", "gpt-4o"}, ids)
+}
+
+func TestIsLatestModel(t *testing.T) {
+	tests := []struct {
+		id       string
+		name     string
+		expected bool
+	}{
+		{"claude-sonnet-4-5", "Claude Sonnet 4.5", true},
+		{"claude-opus-4-5-20251101", "Claude Opus 4.5", false},
+		{"claude-3-5-sonnet-latest", "Claude 3.5 Sonnet", true},
+		{"gpt-4o", "GPT-4o", true},
+		{"gpt-4o-2024-05-13", "GPT-4o", false},
+		{"o3-mini", "o3-mini", true},
+		{"gemini-2.5-pro", "Gemini 2.5 Pro", true},
+		{"gemini-2.5-pro-preview-05-06", "Gemini 2.5 Pro Preview", false},
+		{"gemini-flash-latest", "Gemini Flash Latest", true},
+		{"qwen3-coder:480b", "Qwen3 Coder", true},
+		{"big-pickle", "Big Pickle", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isLatestModel(tt.id, tt.name))
+		})
+	}
+}
+
+func TestContainsDate(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected bool
+	}{
+		{"gpt-4o-2024-05-13-mini", true},
+		{"claude-3-5-sonnet-20240620-v1", true},
+		{"gpt-4o", false},
+		{"claude-sonnet-4-5", false},
+		{"model-12345678", false}, // 8 digits but not a plausible year
+		{"", false},
+
+		// Dates at the very end of the string
+		{"gpt-4o-2024-05-13", true},
+		{"claude-3-5-sonnet-20240620", true},
+		{"claude-opus-4-5-20251101", true},
+
+		// Boundary lengths: the date is the whole string, or one char short
+		{"2024-05-13", true},
+		{"20240620", true},
+		{"2024-05-1", false},
+		{"2024062", false},
+		{"a", false},
+		{"1234567", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			assert.Equal(t, tt.expected, containsDate(tt.s))
+		})
+	}
+}
+
+func TestFetchModelsFromAPI_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	origURL := modelsAPIURL
+	modelsAPIURL = server.URL
+	defer func() { modelsAPIURL = origURL }()
+	t.Setenv("GUMLOOP_MODELS_FILE", "")
+
+	start := time.Now()
+	assert.Nil(t, fetchModelsFromAPI("claude", ModelSource{Timeout: 50 * time.Millisecond}))
+	assert.Less(t, time.Since(start), time.Second, "retries should stay within the timeout")
+}
+
+func TestFetchModelsFromAPI_RetriesServerError(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(modelsFixture))
+	}))
+	defer server.Close()
+
+	origURL := modelsAPIURL
+	modelsAPIURL = server.URL
+	defer func() { modelsAPIURL = origURL }()
+	t.Setenv("GUMLOOP_MODELS_FILE", "")
+
+	models := fetchModelsFromAPI("claude", ModelSource{})
+	assert.Len(t, models, 2)
+	assert.Equal(t, 2, requests)
+}
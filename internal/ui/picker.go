@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ErrPickerCancelled is returned when the user leaves the commit picker
+// with Escape or Ctrl+C
+var ErrPickerCancelled = errors.New("commit picker cancelled by user")
+
+// CommitChoice is a commit shown in the commit picker
+type CommitChoice struct {
+	Hash    string
+	Message string
+}
+
+// Implement list.Item interface for CommitChoice
+func (c CommitChoice) FilterValue() string { return c.Hash + " " + c.Message }
+func (c CommitChoice) Title() string       { return c.Hash }
+func (c CommitChoice) Description() string { return c.Message }
+
+// commitItemDelegate renders one commit per line
+type commitItemDelegate struct{}
+
+func (d commitItemDelegate) Height() int                             { return 1 }
+func (d commitItemDelegate) Spacing() int                            { return 0 }
+func (d commitItemDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
+
+func (d commitItemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	item, ok := listItem.(CommitChoice)
+	if !ok {
+		return
+	}
+
+	cursor := "  "
+	hashStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")) // Gray
+	if index == m.Index() {
+		cursor = "> "
+		hashStyle = hashStyle.Foreground(lipgloss.Color("39")) // Blue
+	}
+
+	fmt.Fprintf(w, "%s%s %s", cursor, hashStyle.Render(item.Hash), item.Message)
+}
+
+// pickerModel is the BubbleTea model for the commit picker
+type pickerModel struct {
+	list      list.Model
+	selected  int // Index of the chosen commit, -1 until one is chosen
+	cancelled bool
+}
+
+// newPickerModel creates a picker over commits, most recent first
+func newPickerModel(commits []CommitChoice) pickerModel {
+	items := make([]list.Item, len(commits))
+	for i, c := range commits {
+		items[i] = c
+	}
+
+	l := list.New(items, commitItemDelegate{}, 72, 12)
+	l.SetShowTitle(false)
+	l.SetShowStatusBar(false)
+	l.SetShowHelp(false)
+	l.SetFilteringEnabled(true)
+	l.Styles.PaginationStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	l.FilterInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+
+	return pickerModel{list: l, selected: -1}
+}
+
+// PickCommit shows commits (most recent first) and returns the index of
+// the one the user picks. Returns ErrPickerCancelled if they leave without
+// picking one.
+func PickCommit(commits []CommitChoice) (int, error) {
+	if len(commits) == 0 {
+		return -1, fmt.Errorf("no commits to pick from")
+	}
+
+	finalModel, err := tea.NewProgram(newPickerModel(commits)).Run()
+	if err != nil {
+		return -1, fmt.Errorf("commit picker failed: %w", err)
+	}
+
+	result := finalModel.(pickerModel)
+	if result.cancelled || result.selected < 0 {
+		return -1, ErrPickerCancelled
+	}
+	return result.selected, nil
+}
+
+// Init initializes the picker model
+func (m pickerModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles key presses; navigation and filtering go to the list
+func (m pickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		filtering := m.list.FilterState() == list.Filtering
+		switch key.String() {
+		case "ctrl+c":
+			m.cancelled = true
+			return m, tea.Quit
+		case "esc":
+			// Escape clears a filter first, then cancels
+			if !filtering && m.list.FilterState() != list.FilterApplied {
+				m.cancelled = true
+				return m, tea.Quit
+			}
+		case "enter":
+			if !filtering {
+				if item, ok := m.list.SelectedItem().(CommitChoice); ok {
+					m.selected = m.indexOf(item)
+					return m, tea.Quit
+				}
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+// indexOf finds item among all commits, so a filtered list still maps back
+// to its position in history
+func (m pickerModel) indexOf(item CommitChoice) int {
+	for i, listItem := range m.list.Items() {
+		if listItem.(CommitChoice) == item {
+			return i
+		}
+	}
+	return -1
+}
+
+// View renders the picker
+func (m pickerModel) View() string {
+	if m.selected >= 0 || m.cancelled {
+		return ""
+	}
+
+	var s strings.Builder
+	s.WriteString(lipgloss.NewStyle().Bold(true).Render("Reset to which commit?"))
+	s.WriteString("\n")
+	s.WriteString(MutedStyle.Render("The chosen commit is kept; everything after it is reset."))
+	s.WriteString("\n\n")
+	s.WriteString(m.list.View())
+	s.WriteString("\n\n")
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
+	s.WriteString(helpStyle.Render("↑/↓: navigate • /: filter • enter: choose • esc: cancel"))
+	return s.String()
+}
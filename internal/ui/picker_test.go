@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPickerSelection(t *testing.T) {
+	commits := []CommitChoice{
+		{Hash: "c3c3c3c", Message: "Third"},
+		{Hash: "b2b2b2b", Message: "Second"},
+		{Hash: "a1a1a1a", Message: "First"},
+	}
+	var m tea.Model = newPickerModel(commits)
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	picker := m.(pickerModel)
+	if picker.selected != 2 {
+		t.Errorf("Expected the third commit to be selected, got index %d", picker.selected)
+	}
+	if cmd == nil {
+		t.Error("Expected enter to quit the picker")
+	}
+}
+
+func TestPickerCancel(t *testing.T) {
+	var m tea.Model = newPickerModel([]CommitChoice{{Hash: "a1a1a1a", Message: "First"}})
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	picker := m.(pickerModel)
+	if !picker.cancelled || picker.selected != -1 {
+		t.Errorf("Expected escape to cancel without a selection, got cancelled=%t selected=%d", picker.cancelled, picker.selected)
+	}
+}
@@ -111,12 +111,12 @@ func Separator(width int, char string) string {
 	return line
 }
 
-// DoubleSeparator creates a double-line horizontal separator
+// DoubleSeparator creates a double-line horizontal separator in the current theme
 func DoubleSeparator(width int) string {
-	return Separator(width, "═")
+	return Separator(width, currentTheme.Double)
 }
 
-// SimpleSeparator creates a single-line horizontal separator
+// SimpleSeparator creates a single-line horizontal separator in the current theme
 func SimpleSeparator(width int) string {
-	return Separator(width, "─")
+	return Separator(width, currentTheme.Single)
 }
@@ -28,8 +28,23 @@ type SummaryConfig struct {
 	Duration   time.Duration // Total run duration
 	ExitCode   ExitCode      // Exit code
 	ExitReason string        // Optional custom exit reason message
+
+	HasUpstream bool // Whether the branch tracks an upstream (shows Ahead/Behind)
+	Ahead       int  // Commits ahead of the upstream
+	Behind      int  // Commits behind the upstream
+
+	Errors    int    // Iterations that ended with an error
+	LastError string // The most recent iteration error, shown under the exit line
+
+	// With TerseIfUnchanged set, a run with no commits, no changes left
+	// in the tree (HasChanges) and no errors gets a one-line summary
+	TerseIfUnchanged bool
+	HasChanges       bool
 }
 
+// maxErrorLines caps how much of the last error the summary shows
+const maxErrorLines = 3
+
 // RenderRunSummary renders the summary shown at the end of a gumloop run.
 // Uses the Simpsons color theme for a distinctive, branded appearance.
 //
@@ -48,6 +63,11 @@ func RenderRunSummary(cfg SummaryConfig) string {
 	// Determine exit message and styling
 	exitIcon, exitText := formatExitReason(cfg.ExitCode, cfg.ExitReason)
 
+	// Nothing happened: one line is enough
+	if cfg.TerseIfUnchanged && cfg.Commits == 0 && !cfg.HasChanges && cfg.Errors == 0 {
+		return renderTerseSummary(cfg, exitIcon, exitText)
+	}
+
 	// Style definitions using Simpsons theme
 	labelStyle := lipgloss.NewStyle().Foreground(ColorMargeBlue)
 	valueStyle := lipgloss.NewStyle().Foreground(ColorWhite)
@@ -81,7 +101,11 @@ func RenderRunSummary(cfg SummaryConfig) string {
 	lines = append(lines, borderStyle.Render("╭"+strings.Repeat("─", innerWidth)+"╮"))
 
 	// Title line
-	title := titleStyle.Render("🍩 RUN COMPLETE 🍩")
+	title := "RUN COMPLETE"
+	if icon := currentTheme.SummaryIcon; icon != "" && !asciiMode {
+		title = icon + " " + title + " " + icon
+	}
+	title = titleStyle.Render(title)
 	titlePadded := lipgloss.NewStyle().Width(innerWidth).Align(lipgloss.Center).Render(title)
 	lines = append(lines, borderStyle.Render("│")+titlePadded+borderStyle.Render("│"))
 
@@ -95,6 +119,9 @@ func RenderRunSummary(cfg SummaryConfig) string {
 		{"Commits:", fmt.Sprintf("%d", cfg.Commits)},
 		{"Duration:", FormatDuration(cfg.Duration)},
 	}
+	if cfg.HasUpstream {
+		metrics = append(metrics, struct{ label, value string }{"Upstream:", fmt.Sprintf("Ahead: %d, Behind: %d", cfg.Ahead, cfg.Behind)})
+	}
 	for _, m := range metrics {
 		content := fmt.Sprintf("  %s %s", labelStyle.Render(fmt.Sprintf("%-12s", m.label)), valueStyle.Render(m.value))
 		lines = append(lines, borderStyle.Render("│")+pad(content, innerWidth)+borderStyle.Render("│"))
@@ -108,12 +135,48 @@ func RenderRunSummary(cfg SummaryConfig) string {
 	styledExit := styleExitLine(cfg.ExitCode, exitContent)
 	lines = append(lines, borderStyle.Render("│")+pad(styledExit, innerWidth)+borderStyle.Render("│"))
 
+	// Iteration errors, so a "Complete" exit doesn't hide them
+	if cfg.Errors > 0 {
+		content := fmt.Sprintf("  %d iteration error(s), last:", cfg.Errors)
+		lines = append(lines, borderStyle.Render("│")+pad(ErrorStyle.Render(content), innerWidth)+borderStyle.Render("│"))
+		for _, line := range errorLines(cfg.LastError, innerWidth-4) {
+			lines = append(lines, borderStyle.Render("│")+pad("  "+MutedStyle.Render(line), innerWidth)+borderStyle.Render("│"))
+		}
+	}
+
 	// Bottom border
 	lines = append(lines, borderStyle.Render("╰"+strings.Repeat("─", innerWidth)+"╯"))
 
 	return strings.Join(lines, "\n")
 }
 
+// renderTerseSummary renders the one-line summary of a run that changed nothing:
+//
+//	✅ Complete (no changes) · claude · 1 iteration, no commits · 12s
+func renderTerseSummary(cfg SummaryConfig, exitIcon, exitText string) string {
+	iterations := fmt.Sprintf("%d iterations", cfg.Iterations)
+	if cfg.Iterations == 1 {
+		iterations = "1 iteration"
+	}
+	line := fmt.Sprintf("%s %s · %s · %s, no commits · %s", exitIcon, exitText, cfg.Agent, iterations, FormatDuration(cfg.Duration))
+	return styleExitLine(cfg.ExitCode, line)
+}
+
+// errorLines wraps the first line of an error message to width, keeping
+// at most maxErrorLines lines
+func errorLines(msg string, width int) []string {
+	first, _, _ := strings.Cut(strings.TrimSpace(msg), "\n")
+	if first == "" {
+		return nil
+	}
+	lines := strings.Split(WrapText(first, width), "\n")
+	if len(lines) > maxErrorLines {
+		lines = lines[:maxErrorLines]
+		lines[maxErrorLines-1] += " …"
+	}
+	return lines
+}
+
 // formatExitReason returns the icon and text for an exit code
 func formatExitReason(code ExitCode, customReason string) (icon string, text string) {
 	if customReason != "" {
@@ -122,37 +185,37 @@ func formatExitReason(code ExitCode, customReason string) (icon string, text str
 
 	switch code {
 	case ExitSuccess:
-		icon = "✅"
+		icon = StatusIcon("✅", "[OK]")
 		if text == "" {
 			text = "Complete (no changes)"
 		}
 	case ExitError:
-		icon = "❌"
+		icon = StatusIcon("❌", "[ERROR]")
 		if text == "" {
 			text = "Error"
 		}
 	case ExitSafety:
-		icon = "🛑"
+		icon = StatusIcon("🛑", "[STOP]")
 		if text == "" {
 			text = "Safety refusal"
 		}
 	case ExitMaxIterations:
-		icon = "⏱️"
+		icon = StatusIcon("⏱️", "[TIME]")
 		if text == "" {
 			text = "Max iterations reached"
 		}
 	case ExitStuck:
-		icon = "⚠️"
+		icon = StatusIcon("⚠️", "[WARN]")
 		if text == "" {
 			text = "Stuck (no commits)"
 		}
 	case ExitInterrupt:
-		icon = "⏸️"
+		icon = StatusIcon("⏸️", "[PAUSE]")
 		if text == "" {
 			text = "Interrupted by user"
 		}
 	default:
-		icon = "❓"
+		icon = StatusIcon("❓", "[?]")
 		if text == "" {
 			text = fmt.Sprintf("Unknown (code %d)", code)
 		}
@@ -295,3 +295,100 @@ func TestSummaryWithLongDuration(t *testing.T) {
 		t.Error("output should contain formatted duration '2h 15m 30s'")
 	}
 }
+
+func TestSummaryWithUpstream(t *testing.T) {
+	config := SummaryConfig{
+		Agent:       "claude",
+		Iterations:  3,
+		Commits:     2,
+		ExitCode:    ExitSuccess,
+		HasUpstream: true,
+		Ahead:       2,
+		Behind:      1,
+	}
+
+	output := RenderRunSummary(config)
+	if !strings.Contains(output, "Ahead: 2, Behind: 1") {
+		t.Errorf("output should contain ahead/behind counts, got:\n%s", output)
+	}
+
+	config.HasUpstream = false
+	if strings.Contains(RenderRunSummary(config), "Ahead:") {
+		t.Error("output should not show ahead/behind without an upstream")
+	}
+}
+
+func TestSummaryWithErrors(t *testing.T) {
+	config := SummaryConfig{
+		Agent:      "claude",
+		Iterations: 3,
+		ExitCode:   ExitSuccess,
+		Errors:     2,
+		LastError:  "verification failed: exit status 1\nOutput: FAIL",
+	}
+
+	output := RenderRunSummary(config)
+	if !strings.Contains(output, "2 iteration error(s), last:") {
+		t.Errorf("output should contain the error count, got:\n%s", output)
+	}
+	if !strings.Contains(output, "verification failed: exit") || !strings.Contains(output, "status 1") {
+		t.Errorf("output should contain the last error, got:\n%s", output)
+	}
+	if strings.Contains(output, "Output: FAIL") {
+		t.Errorf("output should only show the first line of the error, got:\n%s", output)
+	}
+
+	config.Errors = 0
+	if strings.Contains(RenderRunSummary(config), "iteration error") {
+		t.Error("output should not show errors when there were none")
+	}
+}
+
+func TestErrorLines(t *testing.T) {
+	long := strings.Repeat("word ", 40)
+	lines := errorLines(long, 20)
+	if len(lines) != maxErrorLines {
+		t.Fatalf("expected %d lines, got %d: %q", maxErrorLines, len(lines), lines)
+	}
+	if !strings.HasSuffix(lines[len(lines)-1], " …") {
+		t.Errorf("truncated error should end with an ellipsis, got %q", lines[len(lines)-1])
+	}
+
+	if lines := errorLines("  ", 20); lines != nil {
+		t.Errorf("expected no lines for an empty error, got %q", lines)
+	}
+}
+
+func TestSummaryTerseIfUnchanged(t *testing.T) {
+	config := SummaryConfig{
+		Agent:            "claude",
+		Iterations:       1,
+		Duration:         12 * time.Second,
+		ExitCode:         ExitSuccess,
+		TerseIfUnchanged: true,
+	}
+
+	output := RenderRunSummary(config)
+	if strings.Contains(output, "\n") || strings.Contains(output, "RUN COMPLETE") {
+		t.Errorf("a run with no changes should get a one-line summary, got:\n%s", output)
+	}
+	for _, want := range []string{"Complete (no changes)", "claude", "1 iteration, no commits", "12s"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("terse summary should contain %q, got: %s", want, output)
+		}
+	}
+
+	// Commits, leftover changes or errors get the full box
+	for name, changed := range map[string]SummaryConfig{
+		"commits":     {Commits: 1},
+		"changes":     {HasChanges: true},
+		"errors":      {Errors: 1, LastError: "exit status 1"},
+		"not enabled": {},
+	} {
+		changed.Agent, changed.Iterations, changed.ExitCode = "claude", 1, ExitSuccess
+		changed.TerseIfUnchanged = name != "not enabled"
+		if !strings.Contains(RenderRunSummary(changed), "RUN COMPLETE") {
+			t.Errorf("%s: expected the full summary", name)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme is the set of emoji and separator characters used in iteration
+// headers, iteration summaries and the run summary.
+type Theme struct {
+	Name          string
+	IterationIcon string          // Shown before "ITERATION N" and the choo-choo mode
+	SummaryIcon   string          // Frames "RUN COMPLETE" in the run summary
+	Double        string          // Separator character for headers
+	Single        string          // Separator character for summaries
+	Border        lipgloss.Border // Border around the iteration number
+}
+
+// DefaultTheme is the theme used when none is configured
+const DefaultTheme = "train"
+
+var themes = map[string]Theme{
+	"train":  {Name: "train", IterationIcon: "🚂", SummaryIcon: "🍩", Double: "═", Single: "─", Border: lipgloss.DoubleBorder()},
+	"rocket": {Name: "rocket", IterationIcon: "🚀", SummaryIcon: "✨", Double: "═", Single: "─", Border: lipgloss.DoubleBorder()},
+	"plain":  {Name: "plain", Double: "=", Single: "-", Border: lipgloss.Border{Top: "=", Bottom: "="}},
+}
+
+var currentTheme = themes[DefaultTheme]
+
+// SetTheme selects the theme used by all renderers.
+// An empty name selects the default theme.
+func SetTheme(name string) error {
+	if name == "" {
+		name = DefaultTheme
+	}
+	t, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("unknown theme '%s' (available: %s)", name, strings.Join(ThemeNames(), ", "))
+	}
+	currentTheme = t
+	return nil
+}
+
+// CurrentTheme returns the theme selected with SetTheme.
+func CurrentTheme() Theme {
+	return currentTheme
+}
+
+// ThemeNames returns the names of the built-in themes, sorted.
+func ThemeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithIcon prefixes text with icon and a space, or returns text unchanged
+// when the theme has no icon or ASCII icons are in use.
+func WithIcon(icon, text string) string {
+	if icon == "" || asciiMode {
+		return text
+	}
+	return icon + " " + text
+}
@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useTheme selects a theme for the duration of the test
+func useTheme(t *testing.T, name string) {
+	t.Helper()
+	require.NoError(t, SetTheme(name))
+	t.Cleanup(func() { SetTheme(DefaultTheme) })
+}
+
+func TestRenderIterationHeader_Themes(t *testing.T) {
+	cfg := IterationConfig{
+		Number:       2,
+		MaxIteration: 5,
+		Timestamp:    time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		CLI:          "claude",
+	}
+
+	t.Run("rocket", func(t *testing.T) {
+		useTheme(t, "rocket")
+		out := RenderIterationHeader(cfg)
+		assert.Contains(t, out, "🚀 ITERATION 2 of 5")
+		assert.Contains(t, out, "═")
+		assert.NotContains(t, out, "🚂")
+	})
+
+	t.Run("plain", func(t *testing.T) {
+		useTheme(t, "plain")
+		out := RenderIterationHeader(cfg)
+		assert.Contains(t, out, "  ITERATION 2 of 5")
+		assert.Contains(t, out, strings.Repeat("=", 38))
+		assert.NotContains(t, out, "🚂")
+		assert.NotContains(t, out, "═")
+
+		summary := RenderIterationSummary(cfg)
+		assert.Contains(t, summary, strings.Repeat("-", 38))
+		assert.NotContains(t, summary, "─")
+	})
+}
+
+func TestRenderRunSummary_Themes(t *testing.T) {
+	cfg := SummaryConfig{Agent: "claude", ExitCode: ExitSuccess}
+
+	assert.Contains(t, RenderRunSummary(cfg), "🍩 RUN COMPLETE 🍩")
+
+	useTheme(t, "rocket")
+	assert.Contains(t, RenderRunSummary(cfg), "✨ RUN COMPLETE ✨")
+}
+
+func TestSetTheme(t *testing.T) {
+	t.Cleanup(func() { SetTheme(DefaultTheme) })
+
+	require.NoError(t, SetTheme(""))
+	assert.Equal(t, DefaultTheme, CurrentTheme().Name)
+
+	err := SetTheme("disco")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "available: plain, rocket, train")
+	assert.Equal(t, DefaultTheme, CurrentTheme().Name, "an unknown theme leaves the current one selected")
+}
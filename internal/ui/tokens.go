@@ -0,0 +1,21 @@
+package ui
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// EstimateTokens returns a rough token count for s, at about four
+// characters per token. It's meant for anticipating context usage, not
+// for billing; real tokenizers vary by model and language.
+func EstimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + 3) / 4
+}
+
+// FormatTokens formats an estimated token count like "~850" or "~12.3k"
+func FormatTokens(n int) string {
+	if n < 1000 {
+		return fmt.Sprintf("~%d", n)
+	}
+	return fmt.Sprintf("~%.1fk", float64(n)/1000)
+}
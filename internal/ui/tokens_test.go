@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"", 0},
+		{"a", 1},
+		{"abcd", 1},
+		{"abcde", 2},
+		{"Fix the failing tests in internal/cli", 10},
+		{strings.Repeat("x", 4000), 1000},
+		{"日本語のテキスト", 2}, // counted in characters, not bytes
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, EstimateTokens(tt.input), "%q", tt.input)
+	}
+}
+
+func TestFormatTokens(t *testing.T) {
+	assert.Equal(t, "~0", FormatTokens(0))
+	assert.Equal(t, "~850", FormatTokens(850))
+	assert.Equal(t, "~1.0k", FormatTokens(1000))
+	assert.Equal(t, "~12.3k", FormatTokens(12345))
+}
@@ -4,9 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -17,10 +19,13 @@ var ErrWizardCancelled = errors.New("wizard cancelled by user")
 
 // WizardConfig holds the configuration values collected by the wizard
 type WizardConfig struct {
-	CLI           string
-	Model         string
-	Verify        string
-	CreatePrompt  bool
+	CLI            string
+	Model          string
+	Verify         string
+	AutoPush       bool
+	StuckThreshold int
+	CreatePrompt   bool
+	Task           string // Task section for PROMPT.md (empty keeps the template's)
 }
 
 // wizardStep represents the current step in the wizard
@@ -30,7 +35,10 @@ const (
 	stepAgent wizardStep = iota
 	stepModel
 	stepVerify
+	stepAutoPush
+	stepStuck
 	stepPrompt
+	stepTask
 	stepDone
 )
 
@@ -42,10 +50,18 @@ type wizardModel struct {
 	modelIndex      int
 	models          []modelOption
 	modelList       list.Model // Fuzzy searchable list for model selection
+	modelSource     ModelSource // Where the model list is fetched from
+	loadingModels   bool       // true while the model list is being fetched
+	modelsOffline   bool       // true if the fetch failed and fallback models are shown
 	customModelMode bool       // true when user selected "Custom..." and is typing
 	modelInput      textinput.Model
 	verifyInput     textinput.Model
+	autoPush        bool
+	stuckInput      textinput.Model
+	stuckDefault    int    // Used when the stuck threshold is left blank
+	stuckError      string // Shown under the stuck threshold input when it isn't a number
 	createPrompt    bool
+	taskInput       textarea.Model // Multiline task entry for PROMPT.md
 	config          WizardConfig
 	cancelled       bool // true if user cancelled with Escape/Ctrl+C
 	width           int
@@ -114,15 +130,35 @@ var availableAgents = []agentOption{
 	{ID: "ollama", Name: "Ollama", Description: "Ollama (local LLM runtime)"},
 }
 
+// fetchModels fetches the model list for an agent (a var so tests can
+// simulate slow or failing fetches)
+var fetchModels = fetchModelsFromAPI
+
+// modelsLoadedMsg delivers the model list fetched for the model step
+type modelsLoadedMsg struct {
+	models  []modelOption
+	offline bool
+}
+
+// loadModels fetches the models for an agent in the background
+func loadModels(agentID string, source ModelSource) tea.Cmd {
+	return func() tea.Msg {
+		models, offline := modelsForAgent(agentID, source)
+		return modelsLoadedMsg{models: models, offline: offline}
+	}
+}
+
 // modelsForAgent returns the available models for a given agent.
 // It fetches from the models.dev API, falling back to hardcoded models if fetch fails.
-func modelsForAgent(agentID string) []modelOption {
+// offline reports whether the fallback list was used.
+func modelsForAgent(agentID string, source ModelSource) (models []modelOption, offline bool) {
 	// Try to fetch from API first
-	models := fetchModelsFromAPI(agentID)
+	models = fetchModels(agentID, source)
 
 	// Fall back to hardcoded models if fetch failed or returned empty
 	if len(models) == 0 {
 		models = fallbackModels(agentID)
+		offline = true
 	}
 
 	// Always add (default) and Custom... options at the end
@@ -131,12 +167,14 @@ func modelsForAgent(agentID string) []modelOption {
 		modelOption{ID: "", Name: "Custom...", Desc: "Enter a custom model name", IsCustom: true},
 	)
 
-	return models
+	return models, offline
 }
 
-// RunWizard launches the interactive setup wizard
+// RunWizard launches the interactive setup wizard. defaults supplies the
+// starting auto-push choice and stuck threshold; source says where the
+// model lists come from.
 // Returns the collected configuration values or an error
-func RunWizard() (*WizardConfig, error) {
+func RunWizard(defaults WizardConfig, source ModelSource) (*WizardConfig, error) {
 	// Create model input
 	modelInput := textinput.New()
 	modelInput.Placeholder = "leave blank for agent default"
@@ -155,6 +193,11 @@ func RunWizard() (*WizardConfig, error) {
 		agents:      availableAgents,
 		modelInput:  modelInput,
 		verifyInput: verifyInput,
+		autoPush:     defaults.AutoPush,
+		stuckInput:   newStuckInput(defaults.StuckThreshold),
+		stuckDefault: defaults.StuckThreshold,
+		modelSource:  source,
+		taskInput:   newTaskInput(),
 		createPrompt: true, // Default to yes
 	}
 
@@ -175,6 +218,29 @@ func RunWizard() (*WizardConfig, error) {
 	return &result.config, nil
 }
 
+// newStuckInput creates the stuck threshold input, showing the default as
+// its placeholder
+func newStuckInput(defaultThreshold int) textinput.Model {
+	stuckInput := textinput.New()
+	stuckInput.Placeholder = strconv.Itoa(defaultThreshold)
+	stuckInput.CharLimit = 4
+	stuckInput.Width = 10
+	return stuckInput
+}
+
+// newTaskInput creates the multiline input for the PROMPT.md task.
+// Pasted text arrives as a single bracketed paste, so newlines in it
+// are kept instead of being read as Enter.
+func newTaskInput() textarea.Model {
+	taskInput := textarea.New()
+	taskInput.Placeholder = "Describe what you want the agent to do"
+	taskInput.CharLimit = 0 // No limit
+	taskInput.ShowLineNumbers = false
+	taskInput.SetWidth(70)
+	taskInput.SetHeight(8)
+	return taskInput
+}
+
 // Init initializes the wizard model
 func (m wizardModel) Init() tea.Cmd {
 	return nil
@@ -184,6 +250,19 @@ func (m wizardModel) Init() tea.Cmd {
 func (m wizardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// The task textarea takes Enter as a newline; Ctrl+D finishes it
+		if m.step == stepTask {
+			switch msg.String() {
+			case "ctrl+d":
+				return m.handleEnter()
+			case "esc":
+				// Go back to the PROMPT.md question, keeping the text
+				m.taskInput.Blur()
+				m.step = stepPrompt
+				return m, nil
+			}
+		}
+
 		switch msg.String() {
 		case "ctrl+c", "esc":
 			// In custom model mode, escape goes back to list
@@ -198,11 +277,15 @@ func (m wizardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 
 		case "enter":
-			return m.handleEnter()
+			if m.step != stepTask {
+				return m.handleEnter()
+			}
 
 		case "up", "k":
 			if m.step == stepAgent && m.agentIndex > 0 {
 				m.agentIndex--
+			} else if m.step == stepAutoPush {
+				m.autoPush = !m.autoPush
 			} else if m.step == stepPrompt {
 				m.createPrompt = !m.createPrompt
 			}
@@ -211,18 +294,24 @@ func (m wizardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "down", "j":
 			if m.step == stepAgent && m.agentIndex < len(m.agents)-1 {
 				m.agentIndex++
+			} else if m.step == stepAutoPush {
+				m.autoPush = !m.autoPush
 			} else if m.step == stepPrompt {
 				m.createPrompt = !m.createPrompt
 			}
 			// Note: model step navigation is handled by the list component
 
 		case "y", "Y":
-			if m.step == stepPrompt {
+			if m.step == stepAutoPush {
+				m.autoPush = true
+			} else if m.step == stepPrompt {
 				m.createPrompt = true
 			}
 
 		case "n", "N":
-			if m.step == stepPrompt {
+			if m.step == stepAutoPush {
+				m.autoPush = false
+			} else if m.step == stepPrompt {
 				m.createPrompt = false
 			}
 		}
@@ -230,6 +319,12 @@ func (m wizardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+
+	case modelsLoadedMsg:
+		m.setModels(msg.models)
+		m.modelsOffline = msg.offline
+		m.loadingModels = false
+		return m, nil
 	}
 
 	// Update text inputs and lists if they're active
@@ -238,51 +333,62 @@ func (m wizardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case stepModel:
 		if m.customModelMode {
 			m.modelInput, cmd = m.modelInput.Update(msg)
-		} else {
+		} else if !m.loadingModels {
 			// Pass messages to the list for filtering/navigation
 			m.modelList, cmd = m.modelList.Update(msg)
 		}
 	case stepVerify:
 		m.verifyInput, cmd = m.verifyInput.Update(msg)
+	case stepStuck:
+		m.stuckInput, cmd = m.stuckInput.Update(msg)
+	case stepTask:
+		m.taskInput, cmd = m.taskInput.Update(msg)
 	}
 
 	return m, cmd
 }
 
+// setModels fills the fuzzy searchable model list
+func (m *wizardModel) setModels(models []modelOption) {
+	m.models = models
+	items := make([]list.Item, len(m.models))
+	for i, model := range m.models {
+		items[i] = model
+	}
+
+	// Create list with custom delegate
+	delegate := modelItemDelegate{}
+	m.modelList = list.New(items, delegate, 60, 10)
+	m.modelList.Title = ""
+	m.modelList.SetShowTitle(false)
+	m.modelList.SetShowStatusBar(false)
+	m.modelList.SetShowHelp(false)
+	m.modelList.SetFilteringEnabled(true)
+	m.modelList.Styles.Title = lipgloss.NewStyle()
+	m.modelList.Styles.PaginationStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	m.modelList.Styles.HelpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	m.modelList.FilterInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+	m.modelList.FilterInput.TextStyle = lipgloss.NewStyle()
+}
+
 // handleEnter processes the Enter key based on current step
 func (m wizardModel) handleEnter() (tea.Model, tea.Cmd) {
 	switch m.step {
 	case stepAgent:
-		// Store selected agent and load models for it
+		// Store selected agent and fetch models for it in the background
 		m.config.CLI = m.agents[m.agentIndex].ID
-		m.models = modelsForAgent(m.config.CLI)
+		m.models = nil
 		m.modelIndex = 0
 		m.customModelMode = false
-
-		// Initialize the fuzzy searchable list
-		items := make([]list.Item, len(m.models))
-		for i, model := range m.models {
-			items[i] = model
-		}
-
-		// Create list with custom delegate
-		delegate := modelItemDelegate{}
-		m.modelList = list.New(items, delegate, 60, 10)
-		m.modelList.Title = ""
-		m.modelList.SetShowTitle(false)
-		m.modelList.SetShowStatusBar(false)
-		m.modelList.SetShowHelp(false)
-		m.modelList.SetFilteringEnabled(true)
-		m.modelList.Styles.Title = lipgloss.NewStyle()
-		m.modelList.Styles.PaginationStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
-		m.modelList.Styles.HelpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
-		m.modelList.FilterInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
-		m.modelList.FilterInput.TextStyle = lipgloss.NewStyle()
-
+		m.loadingModels = true
+		m.modelsOffline = false
 		m.step = stepModel
-		return m, nil
+		return m, loadModels(m.config.CLI, m.modelSource)
 
 	case stepModel:
+		if m.loadingModels {
+			return m, nil
+		}
 		if m.customModelMode {
 			// Store custom model from text input
 			m.config.Model = strings.TrimSpace(m.modelInput.Value())
@@ -314,13 +420,47 @@ func (m wizardModel) handleEnter() (tea.Model, tea.Cmd) {
 	case stepVerify:
 		// Store verify command (can be empty)
 		m.config.Verify = strings.TrimSpace(m.verifyInput.Value())
-		m.step = stepPrompt
+		m.step = stepAutoPush
 		m.verifyInput.Blur()
 		return m, nil
 
+	case stepAutoPush:
+		m.config.AutoPush = m.autoPush
+		m.step = stepStuck
+		return m, m.stuckInput.Focus()
+
+	case stepStuck:
+		// Blank keeps the default; anything else must be a positive number
+		threshold := m.stuckDefault
+		if value := strings.TrimSpace(m.stuckInput.Value()); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				m.stuckError = fmt.Sprintf("%q isn't a positive whole number", value)
+				return m, nil
+			}
+			threshold = n
+		}
+		m.config.StuckThreshold = threshold
+		m.stuckError = ""
+		m.stuckInput.Blur()
+		m.step = stepPrompt
+		return m, nil
+
 	case stepPrompt:
 		// Store createPrompt choice
 		m.config.CreatePrompt = m.createPrompt
+		if m.createPrompt {
+			// Ask for the task to put in PROMPT.md
+			m.step = stepTask
+			return m, m.taskInput.Focus()
+		}
+		m.step = stepDone
+		return m, tea.Quit
+
+	case stepTask:
+		// Store the task (can be empty to keep the template's placeholder)
+		m.config.Task = strings.TrimSpace(m.taskInput.Value())
+		m.taskInput.Blur()
 		m.step = stepDone
 		return m, tea.Quit
 	}
@@ -352,8 +492,14 @@ func (m wizardModel) View() string {
 		s.WriteString(m.renderModelStep())
 	case stepVerify:
 		s.WriteString(m.renderVerifyStep())
+	case stepAutoPush:
+		s.WriteString(m.renderAutoPushStep())
+	case stepStuck:
+		s.WriteString(m.renderStuckStep())
 	case stepPrompt:
 		s.WriteString(m.renderPromptStep())
+	case stepTask:
+		s.WriteString(m.renderTaskStep())
 	}
 
 	// Footer
@@ -361,7 +507,11 @@ func (m wizardModel) View() string {
 	helpStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("241")). // Gray
 		Italic(true)
-	s.WriteString(helpStyle.Render("↑/↓: navigate • enter: confirm • esc: cancel"))
+	if m.step == stepTask {
+		s.WriteString(helpStyle.Render("enter: new line • ctrl+d: done • esc: back"))
+	} else {
+		s.WriteString(helpStyle.Render("↑/↓: navigate • enter: confirm • esc: cancel"))
+	}
 
 	return s.String()
 }
@@ -414,6 +564,13 @@ func (m wizardModel) renderModelStep() string {
 		return s.String()
 	}
 
+	// Show a status until the model list arrives
+	if m.loadingModels {
+		s.WriteString("\n\n")
+		s.WriteString(hintStyle.Render("Fetching models…"))
+		return s.String()
+	}
+
 	// Show hint for filtering
 	s.WriteString(hintStyle.Render("(type to filter)"))
 	s.WriteString("\n\n")
@@ -421,6 +578,14 @@ func (m wizardModel) renderModelStep() string {
 	// Show the fuzzy-searchable list
 	s.WriteString(m.modelList.View())
 
+	// Explain why the list may look dated
+	if m.modelsOffline {
+		mutedStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241")) // Gray
+		s.WriteString("\n")
+		s.WriteString(mutedStyle.Render("Couldn't fetch models.dev, using offline model list"))
+	}
+
 	return s.String()
 }
 
@@ -448,11 +613,18 @@ func (m wizardModel) renderPromptStep() string {
 	questionStyle := lipgloss.NewStyle().Bold(true)
 	s.WriteString(questionStyle.Render("? Create PROMPT.md template?"))
 	s.WriteString("\n\n")
+	s.WriteString(renderYesNo(m.createPrompt))
 
+	return s.String()
+}
+
+// renderYesNo renders a Yes/No choice with the cursor on yes or no
+func renderYesNo(yes bool) string {
+	var s strings.Builder
 	yesStyle := lipgloss.NewStyle()
 	noStyle := lipgloss.NewStyle()
 
-	if m.createPrompt {
+	if yes {
 		yesStyle = yesStyle.Foreground(lipgloss.Color("39")) // Blue
 		s.WriteString(fmt.Sprintf("> %s\n", yesStyle.Render("Yes")))
 		s.WriteString(fmt.Sprintf("  %s\n", noStyle.Render("No")))
@@ -471,3 +643,57 @@ func (m wizardModel) renderPromptStep() string {
 
 	return s.String()
 }
+
+// renderAutoPushStep renders the auto-push toggle step
+func (m wizardModel) renderAutoPushStep() string {
+	var s strings.Builder
+	questionStyle := lipgloss.NewStyle().Bold(true)
+	s.WriteString(questionStyle.Render("? Push commits after each iteration?"))
+	s.WriteString("\n\n")
+	s.WriteString(renderYesNo(m.autoPush))
+
+	return s.String()
+}
+
+// renderStuckStep renders the stuck threshold input step
+func (m wizardModel) renderStuckStep() string {
+	var s strings.Builder
+	questionStyle := lipgloss.NewStyle().Bold(true)
+	s.WriteString(questionStyle.Render("? Stop after how many iterations without a commit?"))
+	s.WriteString(" ")
+
+	hintStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("241")). // Gray
+		Italic(true)
+	s.WriteString(hintStyle.Render(fmt.Sprintf("(leave blank for %d)", m.stuckDefault)))
+	s.WriteString("\n\n")
+
+	s.WriteString(m.stuckInput.View())
+
+	if m.stuckError != "" {
+		errorStyle := lipgloss.NewStyle().
+			Foreground(ColorError)
+		s.WriteString("\n\n")
+		s.WriteString(errorStyle.Render(m.stuckError))
+	}
+
+	return s.String()
+}
+
+// renderTaskStep renders the multiline task entry for PROMPT.md
+func (m wizardModel) renderTaskStep() string {
+	var s strings.Builder
+	questionStyle := lipgloss.NewStyle().Bold(true)
+	s.WriteString(questionStyle.Render("? What should the agent do?"))
+	s.WriteString(" ")
+
+	hintStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("241")). // Gray
+		Italic(true)
+	s.WriteString(hintStyle.Render("(leave empty to fill in PROMPT.md later)"))
+	s.WriteString("\n\n")
+
+	s.WriteString(m.taskInput.View())
+
+	return s.String()
+}
@@ -6,8 +6,28 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// finishLoadingModels runs the model fetch started by selecting an agent
+// and delivers its result to the wizard
+func finishLoadingModels(t *testing.T, m wizardModel, cmd tea.Cmd) wizardModel {
+	t.Helper()
+	require.NotNil(t, cmd)
+	assert.True(t, m.loadingModels)
+	newModel, _ := m.Update(cmd())
+	m = newModel.(wizardModel)
+	assert.False(t, m.loadingModels)
+	return m
+}
+
+// stubFetchModels replaces the model fetch for the rest of the test
+func stubFetchModels(t *testing.T, fetch func(agentID string, source ModelSource) []modelOption) {
+	orig := fetchModels
+	fetchModels = fetch
+	t.Cleanup(func() { fetchModels = orig })
+}
+
 // TestWizardModelInit tests the initial state of the wizard
 func TestWizardModelInit(t *testing.T) {
 	m := wizardModel{
@@ -73,13 +93,17 @@ func TestWizardStepProgression(t *testing.T) {
 		agents:       availableAgents,
 		modelInput:   modelInput,
 		verifyInput:  verifyInput,
+		autoPush:     true,
+		stuckInput:   newStuckInput(3),
+		stuckDefault: 3,
+		taskInput:    newTaskInput(),
 		createPrompt: true,
 	}
 
 	// Step 1: Select agent (claude at index 0)
 	assert.Equal(t, stepAgent, m.step)
-	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
-	m = newModel.(wizardModel)
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = finishLoadingModels(t, newModel.(wizardModel), cmd)
 	assert.Equal(t, stepModel, m.step)
 	assert.Equal(t, "claude", m.config.CLI)
 
@@ -102,15 +126,82 @@ func TestWizardStepProgression(t *testing.T) {
 	// Step 3: Enter verify command (leave blank)
 	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
 	m = newModel.(wizardModel)
-	assert.Equal(t, stepPrompt, m.step)
+	assert.Equal(t, stepAutoPush, m.step)
 	assert.Equal(t, "", m.config.Verify)
 
-	// Step 4: Create PROMPT.md (default yes)
+	// Step 4: Auto-push (default yes)
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(wizardModel)
+	assert.Equal(t, stepStuck, m.step)
+	assert.True(t, m.config.AutoPush)
+
+	// Step 5: Stuck threshold (leave blank for the default)
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(wizardModel)
+	assert.Equal(t, stepPrompt, m.step)
+	assert.Equal(t, 3, m.config.StuckThreshold)
+
+	// Step 6: Create PROMPT.md (default yes)
 	assert.True(t, m.createPrompt)
 	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
 	m = newModel.(wizardModel)
-	assert.Equal(t, stepDone, m.step)
+	assert.Equal(t, stepTask, m.step)
 	assert.True(t, m.config.CreatePrompt)
+
+	// Step 7: Leave the task empty
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	m = newModel.(wizardModel)
+	assert.Equal(t, stepDone, m.step)
+	assert.Equal(t, "", m.config.Task)
+}
+
+// TestWizardTaskInput tests typing and pasting a multiline task
+func TestWizardTaskInput(t *testing.T) {
+	m := wizardModel{
+		step:         stepPrompt,
+		taskInput:    newTaskInput(),
+		createPrompt: true,
+	}
+
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(wizardModel)
+	assert.Equal(t, stepTask, m.step)
+	assert.Contains(t, m.View(), "What should the agent do?")
+
+	// Typed text, Enter for a newline, then a bracketed paste with its own
+	// newlines and a "y" that must not be read as a key binding
+	for _, msg := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("Fix the login form")},
+		{Type: tea.KeyEnter},
+		{Type: tea.KeyRunes, Runes: []rune("- validate email\n- validate password\ny"), Paste: true},
+	} {
+		newModel, _ = m.Update(msg)
+		m = newModel.(wizardModel)
+	}
+	assert.Equal(t, stepTask, m.step, "Enter and pasted newlines should not leave the step")
+
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	m = newModel.(wizardModel)
+	assert.NotNil(t, cmd)
+	assert.Equal(t, stepDone, m.step)
+	assert.Equal(t, "Fix the login form\n- validate email\n- validate password\ny", m.config.Task)
+}
+
+// TestWizardTaskEscape tests that Escape in the task step goes back
+func TestWizardTaskEscape(t *testing.T) {
+	m := wizardModel{
+		step:         stepPrompt,
+		taskInput:    newTaskInput(),
+		createPrompt: true,
+	}
+
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(wizardModel)
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = newModel.(wizardModel)
+
+	assert.Equal(t, stepPrompt, m.step)
+	assert.False(t, m.cancelled)
 }
 
 // TestWizardPromptToggle tests toggling PROMPT.md creation
@@ -140,6 +231,63 @@ func TestWizardPromptToggle(t *testing.T) {
 	assert.True(t, m.createPrompt)
 }
 
+// TestWizardAutoPushToggle tests toggling auto-push
+func TestWizardAutoPushToggle(t *testing.T) {
+	m := wizardModel{
+		step:       stepAutoPush,
+		autoPush:   true,
+		stuckInput: newStuckInput(3),
+	}
+	assert.Contains(t, m.View(), "Push commits after each iteration?")
+
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	m = newModel.(wizardModel)
+	assert.False(t, m.autoPush)
+
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = newModel.(wizardModel)
+	assert.True(t, m.autoPush)
+
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	m = newModel.(wizardModel)
+	assert.False(t, m.autoPush)
+
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(wizardModel)
+	assert.Equal(t, stepStuck, m.step)
+	assert.False(t, m.config.AutoPush)
+}
+
+// TestWizardStuckThreshold tests the stuck threshold input and its validation
+func TestWizardStuckThreshold(t *testing.T) {
+	m := wizardModel{
+		step:         stepStuck,
+		stuckInput:   newStuckInput(3),
+		stuckDefault: 3,
+	}
+	m.stuckInput.Focus()
+	assert.Contains(t, m.View(), "leave blank for 3")
+
+	// Not a positive number: stay on the step and say why
+	for _, value := range []string{"abc", "0", "-2"} {
+		m.stuckInput.SetValue(value)
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m = newModel.(wizardModel)
+		assert.Equal(t, stepStuck, m.step, value)
+		assert.Contains(t, m.View(), "isn't a positive whole number", value)
+	}
+
+	// Typed digits are accepted
+	m.stuckInput.SetValue("")
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("10")})
+	m = newModel.(wizardModel)
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(wizardModel)
+	assert.Equal(t, stepPrompt, m.step)
+	assert.Equal(t, 10, m.config.StuckThreshold)
+	assert.Empty(t, m.stuckError)
+}
+
 // TestAvailableAgents tests that all expected agents are present
 func TestAvailableAgents(t *testing.T) {
 	assert.Equal(t, 6, len(availableAgents))
@@ -159,6 +307,8 @@ func TestWizardView(t *testing.T) {
 		{"Agent step", stepAgent},
 		{"Model step", stepModel},
 		{"Verify step", stepVerify},
+		{"Auto-push step", stepAutoPush},
+		{"Stuck step", stepStuck},
 		{"Prompt step", stepPrompt},
 		{"Done step", stepDone},
 	}
@@ -213,16 +363,18 @@ func TestWizardConfigPersistence(t *testing.T) {
 	verifyInput := textinput.New()
 
 	m := wizardModel{
-		step:        stepAgent,
-		agents:      availableAgents,
-		modelInput:  modelInput,
-		verifyInput: verifyInput,
+		step:         stepAgent,
+		agents:       availableAgents,
+		modelInput:   modelInput,
+		verifyInput:  verifyInput,
+		stuckInput:   newStuckInput(3),
+		stuckDefault: 3,
 	}
 
 	// Select gemini (index 2)
 	m.agentIndex = 2
-	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
-	m = newModel.(wizardModel)
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = finishLoadingModels(t, newModel.(wizardModel), cmd)
 	assert.Equal(t, "gemini", m.config.CLI)
 
 	// Select model - navigate to "(default)" option (second-to-last)
@@ -234,9 +386,17 @@ func TestWizardConfigPersistence(t *testing.T) {
 	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
 	m = newModel.(wizardModel)
 
-	// Skip verify
-	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
-	m = newModel.(wizardModel)
+	// Skip verify, turn auto-push off and set a stuck threshold
+	for _, msg := range []tea.KeyMsg{
+		{Type: tea.KeyEnter},
+		{Type: tea.KeyRunes, Runes: []rune{'n'}},
+		{Type: tea.KeyEnter},
+		{Type: tea.KeyRunes, Runes: []rune("5")},
+		{Type: tea.KeyEnter},
+	} {
+		newModel, _ = m.Update(msg)
+		m = newModel.(wizardModel)
+	}
 
 	// Set createPrompt to false
 	m.createPrompt = false
@@ -247,6 +407,8 @@ func TestWizardConfigPersistence(t *testing.T) {
 	assert.Equal(t, "gemini", m.config.CLI)
 	assert.Equal(t, "", m.config.Model)
 	assert.Equal(t, "", m.config.Verify)
+	assert.False(t, m.config.AutoPush)
+	assert.Equal(t, 5, m.config.StuckThreshold)
 	assert.False(t, m.config.CreatePrompt)
 }
 
@@ -276,3 +438,44 @@ func TestWizardWindowSize(t *testing.T) {
 	assert.Equal(t, 100, m.width)
 	assert.Equal(t, 50, m.height)
 }
+
+// TestWizardModelsFetchFailure tests the loading status and offline note
+func TestWizardModelsFetchFailure(t *testing.T) {
+	stubFetchModels(t, func(agentID string, source ModelSource) []modelOption { return nil })
+
+	m := wizardModel{step: stepAgent, agents: availableAgents}
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(wizardModel)
+
+	// Enter is ignored while the list is loading
+	assert.Contains(t, m.View(), "Fetching models…")
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, stepModel, newModel.(wizardModel).step)
+
+	m = finishLoadingModels(t, m, cmd)
+	assert.True(t, m.modelsOffline)
+	assert.Len(t, m.models, len(fallbackModels("claude"))+2)
+
+	view := m.View()
+	assert.NotContains(t, view, "Fetching models…")
+	assert.Contains(t, view, "using offline model list")
+}
+
+// TestWizardModelsFetchSuccess tests that fetched models show without the offline note
+func TestWizardModelsFetchSuccess(t *testing.T) {
+	var got ModelSource
+	stubFetchModels(t, func(agentID string, source ModelSource) []modelOption {
+		got = source
+		return []modelOption{{ID: "claude-sonnet-4-5", Name: "Claude Sonnet 4.5"}}
+	})
+
+	source := ModelSource{File: "/tmp/models.json"}
+	m := wizardModel{step: stepAgent, agents: availableAgents, modelSource: source}
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = finishLoadingModels(t, newModel.(wizardModel), cmd)
+
+	assert.Equal(t, source, got, "the wizard's model source should reach the fetch")
+	assert.False(t, m.modelsOffline)
+	assert.Equal(t, "claude-sonnet-4-5", m.models[0].ID)
+	assert.NotContains(t, m.View(), "offline model list")
+}
@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// WrapText wraps s to lines of at most width characters, breaking only at
+// spaces. Existing newlines are kept, and a word longer than width (such as
+// a URL) is put on its own line rather than split. A width below 1 returns s
+// unchanged.
+func WrapText(s string, width int) string {
+	if width < 1 {
+		return s
+	}
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = wrapLine(line, width)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wrapLine wraps a single line without newlines
+func wrapLine(line string, width int) string {
+	words := strings.Fields(line)
+	if len(words) == 0 || utf8.RuneCountInString(line) <= width {
+		return line
+	}
+
+	var sb strings.Builder
+	lineLen := 0
+	for _, word := range words {
+		wordLen := utf8.RuneCountInString(word)
+		if lineLen > 0 && lineLen+1+wordLen > width {
+			sb.WriteString("\n")
+			lineLen = 0
+		}
+		if lineLen > 0 {
+			sb.WriteString(" ")
+			lineLen++
+		}
+		sb.WriteString(word)
+		lineLen += wordLen
+	}
+	return sb.String()
+}
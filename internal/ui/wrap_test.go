@@ -0,0 +1,57 @@
+package ui
+
+import "testing"
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		width int
+		want  string
+	}{
+		{
+			name:  "short line unchanged",
+			input: "Fix the tests",
+			width: 20,
+			want:  "Fix the tests",
+		},
+		{
+			name:  "wraps at spaces",
+			input: "Add input validation to the login form",
+			width: 16,
+			want:  "Add input\nvalidation to\nthe login form",
+		},
+		{
+			name:  "preserves existing newlines",
+			input: "# Task\n\nMigrate the utils module to TypeScript",
+			width: 20,
+			want:  "# Task\n\nMigrate the utils\nmodule to TypeScript",
+		},
+		{
+			name:  "does not break inside URLs",
+			input: "See https://github.com/adriancodes/gumloop/issues/42 for details",
+			width: 20,
+			want:  "See\nhttps://github.com/adriancodes/gumloop/issues/42\nfor details",
+		},
+		{
+			name:  "zero width disables wrapping",
+			input: "Add input validation to the login form",
+			width: 0,
+			want:  "Add input validation to the login form",
+		},
+		{
+			name:  "counts characters, not bytes",
+			input: "héllo wörld ünïcode",
+			width: 11,
+			want:  "héllo wörld\nünïcode",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WrapText(tt.input, tt.width); got != tt.want {
+				t.Errorf("WrapText(%q, %d) = %q, want %q", tt.input, tt.width, got, tt.want)
+			}
+		})
+	}
+}
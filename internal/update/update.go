@@ -1,7 +1,7 @@
 package update
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,14 +11,21 @@ import (
 	"strings"
 	"time"
 
+	"github.com/adriancodes/gumloop/internal/github"
 	"github.com/adriancodes/gumloop/internal/ui"
 )
 
 const (
-	githubAPIURL = "https://api.github.com/repos/adriancodes/gumloop/releases/latest"
-	httpTimeout  = 30 * time.Second
+	latestReleasePath = "/repos/adriancodes/gumloop/releases/latest"
+
+	// downloadAttempts is how many times a download is tried (resuming
+	// where the previous attempt stopped) before giving up
+	downloadAttempts = 5
 )
 
+// downloadRetryDelay is the pause before retrying an interrupted download
+var downloadRetryDelay = 2 * time.Second
+
 // Release represents a GitHub release
 type Release struct {
 	TagName string `json:"tag_name"`
@@ -75,7 +82,7 @@ func Update(currentVersion string) error {
 		formatBytes(asset.Size))
 
 	// Download binary
-	tmpFile, err := downloadBinary(asset.BrowserDownloadURL)
+	tmpFile, err := downloadBinary(asset.BrowserDownloadURL, asset.Size)
 	if err != nil {
 		return fmt.Errorf("failed to download binary: %w", err)
 	}
@@ -104,68 +111,124 @@ func Update(currentVersion string) error {
 
 // fetchLatestRelease fetches the latest release from GitHub
 func fetchLatestRelease() (*Release, error) {
-	client := &http.Client{Timeout: httpTimeout}
-
-	req, err := http.NewRequest("GET", githubAPIURL, nil)
-	if err != nil {
+	var release Release
+	if err := github.GetJSON(latestReleasePath, &release); err != nil {
 		return nil, err
 	}
+	return &release, nil
+}
 
-	// Set User-Agent (GitHub API requires it)
-	req.Header.Set("User-Agent", "gumloop-updater")
-
-	resp, err := client.Do(req)
+// downloadBinary downloads a file to a temporary location.
+// If the connection drops, the download is retried and resumed with an HTTP
+// range request; servers that ignore ranges are downloaded again from the
+// start. A size above zero is checked against the downloaded length.
+func downloadBinary(url string, size int64) (string, error) {
+	tmpFile, err := os.CreateTemp("", "gumloop-update-*")
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	defer resp.Body.Close()
+	defer tmpFile.Close()
+
+	client := &http.Client{Timeout: 5 * time.Minute}
+	for attempt := 1; ; attempt++ {
+		err = downloadChunk(client, url, tmpFile)
+		if err == nil {
+			err = checkDownloadSize(tmpFile, size)
+		}
+		if err == nil {
+			break
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+		var permanent *permanentError
+		if errors.As(err, &permanent) || attempt == downloadAttempts {
+			os.Remove(tmpFile.Name())
+			return "", err
+		}
+		fmt.Println(ui.MutedStyle.Render(fmt.Sprintf("Download interrupted (%v), retrying...", err)))
+		time.Sleep(downloadRetryDelay)
 	}
 
-	var release Release
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return nil, err
+	// Make executable
+	if err := os.Chmod(tmpFile.Name(), 0755); err != nil {
+		os.Remove(tmpFile.Name())
+		return "", err
 	}
 
-	return &release, nil
+	return tmpFile.Name(), nil
 }
 
-// downloadBinary downloads a file to a temporary location
-func downloadBinary(url string) (string, error) {
-	client := &http.Client{Timeout: 5 * time.Minute}
+// permanentError is a download failure that retrying won't fix
+type permanentError struct {
+	err error
+}
 
-	resp, err := client.Get(url)
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// downloadChunk fetches url into file, resuming after the bytes already in
+// the file when the server supports range requests
+func downloadChunk(client *http.Client, url string, file *os.File) error {
+	offset, err := file.Seek(0, io.SeekEnd)
 	if err != nil {
-		return "", err
+		return &permanentError{err}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("download failed with status %d", resp.StatusCode)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return &permanentError{err}
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
 	}
 
-	// Create temp file
-	tmpFile, err := os.CreateTemp("", "gumloop-update-*")
+	resp, err := client.Do(req)
 	if err != nil {
-		return "", err
+		return err
 	}
-	defer tmpFile.Close()
+	defer resp.Body.Close()
 
-	// Copy response body to temp file
-	if _, err := io.Copy(tmpFile, resp.Body); err != nil {
-		os.Remove(tmpFile.Name())
-		return "", err
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		// Resuming: append to what we have
+	case resp.StatusCode == http.StatusOK:
+		// Fresh download, or the server ignored the range: start over
+		if err := file.Truncate(0); err != nil {
+			return &permanentError{err}
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return &permanentError{err}
+		}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// Nothing left to fetch; the size check decides if that's right
+		return nil
+	case resp.StatusCode >= 500:
+		return fmt.Errorf("download failed with status %d", resp.StatusCode)
+	default:
+		return &permanentError{fmt.Errorf("download failed with status %d", resp.StatusCode)}
 	}
 
-	// Make executable
-	if err := os.Chmod(tmpFile.Name(), 0755); err != nil {
-		os.Remove(tmpFile.Name())
-		return "", err
-	}
+	_, err = io.Copy(file, resp.Body)
+	return err
+}
 
-	return tmpFile.Name(), nil
+// checkDownloadSize compares the downloaded length with the asset size.
+// A short file can be resumed; a long one is corrupt and is discarded.
+func checkDownloadSize(file *os.File, size int64) error {
+	if size <= 0 {
+		return nil
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return &permanentError{err}
+	}
+	switch {
+	case info.Size() < size:
+		return fmt.Errorf("download incomplete: got %d of %d bytes", info.Size(), size)
+	case info.Size() > size:
+		file.Truncate(0)
+		return fmt.Errorf("download is larger than expected: got %d of %d bytes", info.Size(), size)
+	}
+	return nil
 }
 
 // replaceBinary replaces the current binary with the new one
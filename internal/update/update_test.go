@@ -0,0 +1,126 @@
+package update
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyServer serves payload, dropping the connection halfway through the
+// first response. Later requests honour Range headers only if ranges is set.
+func flakyServer(t *testing.T, payload []byte, ranges bool) (*httptest.Server, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var rangeHeaders []string
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		first := requests == 1
+		rangeHeaders = append(rangeHeaders, r.Header.Get("Range"))
+		mu.Unlock()
+
+		if first {
+			// Promise the whole file, send half, then hang up
+			w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+			w.WriteHeader(http.StatusOK)
+			w.Write(payload[:len(payload)/2])
+			w.(http.Flusher).Flush()
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			conn.Close()
+			return
+		}
+
+		if ranges {
+			http.ServeContent(w, r, "gumloop", time.Time{}, bytes.NewReader(payload))
+			return
+		}
+		w.Write(payload)
+	}))
+	t.Cleanup(server.Close)
+	return server, &rangeHeaders
+}
+
+func testPayload() []byte {
+	payload := make([]byte, 256*1024)
+	for i := range payload {
+		payload[i] = byte(i % 251)
+	}
+	return payload
+}
+
+func withNoRetryDelay(t *testing.T) {
+	orig := downloadRetryDelay
+	downloadRetryDelay = 0
+	t.Cleanup(func() { downloadRetryDelay = orig })
+}
+
+func TestDownloadBinary_ResumesWithRange(t *testing.T) {
+	withNoRetryDelay(t)
+	payload := testPayload()
+	server, rangeHeaders := flakyServer(t, payload, true)
+
+	path, err := downloadBinary(server.URL, int64(len(payload)))
+	require.NoError(t, err)
+	defer os.Remove(path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, payload, data)
+
+	// The retry asked only for the missing bytes
+	require.Len(t, *rangeHeaders, 2)
+	assert.Empty(t, (*rangeHeaders)[0])
+	assert.Equal(t, fmt.Sprintf("bytes=%d-", len(payload)/2), (*rangeHeaders)[1])
+}
+
+func TestDownloadBinary_RestartsWithoutRangeSupport(t *testing.T) {
+	withNoRetryDelay(t)
+	payload := testPayload()
+	server, rangeHeaders := flakyServer(t, payload, false)
+
+	path, err := downloadBinary(server.URL, int64(len(payload)))
+	require.NoError(t, err)
+	defer os.Remove(path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, payload, data)
+	assert.Len(t, *rangeHeaders, 2)
+}
+
+func TestDownloadBinary_SizeMismatch(t *testing.T) {
+	withNoRetryDelay(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("too long"))
+	}))
+	defer server.Close()
+
+	_, err := downloadBinary(server.URL, 3)
+	assert.ErrorContains(t, err, "larger than expected")
+}
+
+func TestDownloadBinary_NotFound(t *testing.T) {
+	withNoRetryDelay(t)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	_, err := downloadBinary(server.URL, 0)
+	assert.ErrorContains(t, err, "status 404")
+	assert.Equal(t, 1, requests, "a 404 should not be retried")
+}
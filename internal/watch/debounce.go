@@ -0,0 +1,62 @@
+package watch
+
+import (
+	"context"
+	"time"
+)
+
+// debounce waits for a change on ch, then keeps collecting changes until
+// none has arrived for delay. It returns the distinct paths in the order
+// they first changed, or nil if ctx is done first. An error on errs is
+// returned as soon as it arrives.
+func debounce(ctx context.Context, ch <-chan string, errs <-chan error, delay time.Duration) ([]string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	select {
+	case path := <-ch:
+		add(path)
+	case err := <-errs:
+		return nil, err
+	case <-ctx.Done():
+		return nil, nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case path := <-ch:
+			add(path)
+			timer.Reset(delay)
+		case err := <-errs:
+			return nil, err
+		case <-timer.C:
+			return paths, nil
+		case <-ctx.Done():
+			return nil, nil
+		}
+	}
+}
+
+// drain discards changes until none has arrived for delay
+func drain(ctx context.Context, ch <-chan string, delay time.Duration) {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ch:
+			timer.Reset(delay)
+		case <-timer.C:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
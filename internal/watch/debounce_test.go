@@ -0,0 +1,85 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebounce_CoalescesBurst(t *testing.T) {
+	ch := make(chan string, 10)
+	ch <- "a.go"
+	ch <- "b.go"
+	ch <- "a.go"
+
+	paths, err := debounce(context.Background(), ch, nil, 20*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.go", "b.go"}, paths)
+}
+
+func TestDebounce_WaitsForQuiet(t *testing.T) {
+	ch := make(chan string)
+	go func() {
+		// Keep changing for longer than the delay, never pausing long enough
+		for _, path := range []string{"a.go", "b.go", "c.go", "d.go"} {
+			ch <- path
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+
+	paths, err := debounce(context.Background(), ch, nil, 100*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.go", "b.go", "c.go", "d.go"}, paths)
+}
+
+func TestDebounce_SeparateBursts(t *testing.T) {
+	ch := make(chan string, 10)
+	ch <- "a.go"
+	first, _ := debounce(context.Background(), ch, nil, 10*time.Millisecond)
+
+	ch <- "b.go"
+	second, _ := debounce(context.Background(), ch, nil, 10*time.Millisecond)
+
+	assert.Equal(t, []string{"a.go"}, first)
+	assert.Equal(t, []string{"b.go"}, second)
+}
+
+func TestDebounce_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	paths, err := debounce(ctx, make(chan string), nil, time.Second)
+	assert.Nil(t, paths)
+	assert.NoError(t, err)
+}
+
+func TestDebounce_Error(t *testing.T) {
+	// An error ends the wait for a change...
+	errs := make(chan error, 1)
+	errs <- errors.New("watch limit reached")
+	_, err := debounce(context.Background(), make(chan string), errs, time.Second)
+	assert.EqualError(t, err, "watch limit reached")
+
+	// ...and a burst in progress
+	ch := make(chan string, 1)
+	ch <- "a.go"
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		errs <- errors.New("watch limit reached")
+	}()
+	paths, err := debounce(context.Background(), ch, errs, time.Second)
+	assert.Nil(t, paths)
+	assert.EqualError(t, err, "watch limit reached")
+}
+
+func TestDrain(t *testing.T) {
+	ch := make(chan string, 10)
+	ch <- "a.go"
+	ch <- "b.go"
+
+	drain(context.Background(), ch, 10*time.Millisecond)
+	assert.Empty(t, ch)
+}
@@ -0,0 +1,42 @@
+package watch
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// skipPath reports whether a change to rel (relative to the watched root)
+// never triggers a run: git's own files, files gumloop writes during a run,
+// and editor swap and backup files
+func skipPath(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	first, _, _ := strings.Cut(rel, "/")
+	switch first {
+	case ".git", ".gumloop", ".gumloop-memory.yaml", ".gumloop-memory.prev.yaml":
+		return true
+	}
+
+	base := filepath.Base(rel)
+	return strings.HasSuffix(base, "~") ||
+		strings.HasSuffix(base, ".swp") ||
+		strings.HasSuffix(base, ".swx") ||
+		strings.HasPrefix(base, ".#")
+}
+
+// removePaths returns paths without any of the paths in remove
+func removePaths(paths, remove []string) []string {
+	if len(remove) == 0 {
+		return paths
+	}
+	drop := make(map[string]bool, len(remove))
+	for _, path := range remove {
+		drop[path] = true
+	}
+	var kept []string
+	for _, path := range paths {
+		if !drop[path] {
+			kept = append(kept, path)
+		}
+	}
+	return kept
+}
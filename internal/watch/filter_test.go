@@ -0,0 +1,76 @@
+package watch
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkipPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"main.go", false},
+		{"internal/cli/run.go", false},
+		{".gitignore", false},
+		{".github/workflows/ci.yml", false},
+		{".git", true},
+		{".git/index.lock", true},
+		{".gumloop/prompt-cache/abc", true},
+		{".gumloop-memory.yaml", true},
+		{".gumloop-memory.prev.yaml", true},
+		{".gumloop.yaml", false},
+		{"src/.main.go.swp", true},
+		{"main.go~", true},
+		{"src/.#main.go", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, skipPath(tt.path), tt.path)
+	}
+}
+
+func TestWatcherFilter(t *testing.T) {
+	root := filepath.FromSlash("/repo")
+	abs := func(rel string) string { return filepath.Join(root, filepath.FromSlash(rel)) }
+
+	var asked []string
+	w := &Watcher{
+		Root: root,
+		Ignored: func(paths []string) ([]string, error) {
+			asked = paths
+			return []string{abs("debug.log")}, nil
+		},
+	}
+
+	kept, err := w.filter([]string{abs("main.go"), abs("debug.log"), abs(".git/HEAD"), abs("pkg/util.go")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.go", filepath.FromSlash("pkg/util.go")}, kept)
+
+	// Skipped paths never reach git
+	assert.Equal(t, []string{abs("main.go"), abs("debug.log"), abs("pkg/util.go")}, asked)
+}
+
+func TestWatcherFilter_NoIgnored(t *testing.T) {
+	w := &Watcher{Root: "/repo"}
+	kept, err := w.filter([]string{"/repo/main.go"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.go"}, kept)
+}
+
+func TestWatcherFilter_Error(t *testing.T) {
+	w := &Watcher{
+		Root:    "/repo",
+		Ignored: func([]string) ([]string, error) { return nil, errors.New("git failed") },
+	}
+	_, err := w.filter([]string{"/repo/main.go"})
+	assert.EqualError(t, err, "git failed")
+}
+
+func TestRemovePaths(t *testing.T) {
+	assert.Equal(t, []string{"a", "c"}, removePaths([]string{"a", "b", "c"}, []string{"b"}))
+	assert.Equal(t, []string{"a"}, removePaths([]string{"a"}, nil))
+}
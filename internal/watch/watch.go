@@ -0,0 +1,177 @@
+// Package watch runs a callback when files in a repository change.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher calls OnChange with the files that changed under Root, once no
+// further change has arrived for Delay. Changes made while OnChange runs
+// (usually by the agent itself) are dropped.
+type Watcher struct {
+	Root  string
+	Delay time.Duration
+
+	// Ignored returns which of the given absolute paths should not trigger
+	// OnChange, e.g. those matched by .gitignore (nil to keep every path)
+	Ignored func(paths []string) ([]string, error)
+
+	// OnChange receives the changed paths, relative to Root
+	OnChange func(paths []string)
+}
+
+// Run watches Root until ctx is done
+func (w *Watcher) Run(ctx context.Context) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start file watcher: %w", err)
+	}
+	defer fsw.Close()
+
+	if err := w.addTree(fsw, w.Root); err != nil {
+		return err
+	}
+
+	changes := make(chan string, 256)
+	errs := make(chan error, 1)
+	go w.forward(ctx, fsw, changes, errs)
+
+	return w.loop(ctx, changes, errs)
+}
+
+// loop calls OnChange for each burst of changes until ctx is done, or
+// returns the first error from errs, even while waiting for a change
+func (w *Watcher) loop(ctx context.Context, changes <-chan string, errs <-chan error) error {
+	for {
+		paths, err := debounce(ctx, changes, errs, w.Delay)
+		if err != nil {
+			return err
+		}
+		if paths == nil {
+			return nil
+		}
+
+		paths, err = w.filter(paths)
+		if err != nil {
+			return err
+		}
+		if len(paths) == 0 {
+			continue
+		}
+
+		w.OnChange(paths)
+		drain(ctx, changes, w.Delay)
+	}
+}
+
+// forward sends the paths of fsnotify events to changes, watching new
+// directories as they're created
+func (w *Watcher) forward(ctx context.Context, fsw *fsnotify.Watcher, changes chan<- string, errs chan<- error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return
+			}
+			errs <- fmt.Errorf("file watcher failed: %w", err)
+			return
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return
+			}
+			if skipPath(w.rel(event.Name)) {
+				continue
+			}
+			// A new directory isn't a change in itself, but the files
+			// created in it are
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					// A failure here only means changes inside are missed
+					_ = w.addTree(fsw, event.Name)
+					continue
+				}
+			}
+			select {
+			case changes <- event.Name:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// addTree watches dir and every directory below it that isn't ignored.
+// fsnotify isn't recursive, so each directory needs its own watch.
+func (w *Watcher) addTree(fsw *fsnotify.Watcher, dir string) error {
+	// Check one level at a time, so ignored trees like node_modules are
+	// never walked
+	level := []string{dir}
+	for len(level) > 0 {
+		kept, err := w.filter(level)
+		if err != nil {
+			return err
+		}
+
+		var next []string
+		for _, rel := range kept {
+			path := filepath.Join(w.Root, rel)
+			if err := fsw.Add(path); err != nil {
+				return fmt.Errorf("failed to watch %s: %w", path, err)
+			}
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				continue // Removed since it was listed
+			}
+			for _, entry := range entries {
+				if entry.Type()&fs.ModeDir != 0 {
+					next = append(next, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+		level = next
+	}
+	return nil
+}
+
+// filter drops skipped and ignored paths and returns the rest relative to
+// Root
+func (w *Watcher) filter(paths []string) ([]string, error) {
+	var candidates []string
+	for _, path := range paths {
+		if !skipPath(w.rel(path)) {
+			candidates = append(candidates, path)
+		}
+	}
+
+	var ignored []string
+	if w.Ignored != nil && len(candidates) > 0 {
+		var err error
+		if ignored, err = w.Ignored(candidates); err != nil {
+			return nil, err
+		}
+	}
+
+	kept := make([]string, 0, len(candidates))
+	for _, path := range removePaths(candidates, ignored) {
+		kept = append(kept, w.rel(path))
+	}
+	return kept, nil
+}
+
+// rel returns path relative to Root, or path itself if it isn't below Root
+func (w *Watcher) rel(path string) string {
+	rel, err := filepath.Rel(w.Root, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
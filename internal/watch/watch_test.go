@@ -0,0 +1,29 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatcherLoop_Error(t *testing.T) {
+	var calls int
+	w := &Watcher{Root: t.TempDir(), Delay: time.Second, OnChange: func([]string) { calls++ }}
+
+	errs := make(chan error, 1)
+	errs <- errors.New("file watcher failed: queue overflow")
+
+	done := make(chan error, 1)
+	go func() { done <- w.loop(context.Background(), make(chan string), errs) }()
+
+	select {
+	case err := <-done:
+		assert.EqualError(t, err, "file watcher failed: queue overflow")
+	case <-time.After(time.Second):
+		t.Fatal("loop kept waiting for changes after a watcher error")
+	}
+	assert.Zero(t, calls)
+}